@@ -88,6 +88,10 @@ type ReqRes struct {
 	mtx  tmsync.Mutex
 	done bool                  // Gets set to true once *after* WaitGroup.Done().
 	cb   func(*types.Response) // A single callback that may be set.
+
+	// Set by the clients wrapping another one (see SetOnEnd).
+	ended bool
+	onEnd func(*types.Response)
 }
 
 func NewReqRes(req *types.Request) *ReqRes {
@@ -131,6 +135,51 @@ func (reqRes *ReqRes) SetDone() {
 	reqRes.mtx.Unlock()
 }
 
+// IsDone returns whether the response was received. The requests failing when
+// the client stops are never done, but WaitGroup.Wait returns for them.
+func (reqRes *ReqRes) IsDone() bool {
+	reqRes.mtx.Lock()
+	defer reqRes.mtx.Unlock()
+	return reqRes.done
+}
+
+// SetOnEnd sets f to be called once the response is received, or with a nil
+// response once the request fails, for the clients wrapping another one, as
+// the callback is set by the users of the clients. The functions set by
+// several wrapping clients are called in order. If the request has already
+// ended, f is called immediately.
+func (reqRes *ReqRes) SetOnEnd(f func(*types.Response)) {
+	reqRes.mtx.Lock()
+	if reqRes.done || reqRes.ended {
+		reqRes.mtx.Unlock()
+		f(reqRes.Response)
+		return
+	}
+	if prev := reqRes.onEnd; prev != nil {
+		reqRes.onEnd = func(res *types.Response) {
+			prev(res)
+			f(res)
+		}
+	} else {
+		reqRes.onEnd = f
+	}
+	reqRes.mtx.Unlock()
+}
+
+// end calls the functions set with SetOnEnd, once the response is received or
+// the request fails.
+func (reqRes *ReqRes) end() {
+	reqRes.mtx.Lock()
+	reqRes.ended = true
+	f := reqRes.onEnd
+	reqRes.onEnd = nil
+	reqRes.mtx.Unlock()
+
+	if f != nil {
+		f(reqRes.Response)
+	}
+}
+
 func waitGroup1() (wg *sync.WaitGroup) {
 	wg = &sync.WaitGroup{}
 	wg.Add(1)
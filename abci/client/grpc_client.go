@@ -3,6 +3,7 @@ package abcicli
 import (
 	"fmt"
 	"net"
+	"path"
 	"time"
 
 	"golang.org/x/net/context"
@@ -12,9 +13,11 @@ import (
 	tmnet "github.com/tendermint/tendermint/libs/net"
 	"github.com/tendermint/tendermint/libs/service"
 	tmsync "github.com/tendermint/tendermint/libs/sync"
+	"github.com/tendermint/tendermint/libs/trace"
 )
 
 var _ Client = (*grpcClient)(nil)
+var _ TraceParentSetter = (*grpcClient)(nil)
 
 // A stripped copy of the remoteClient that makes
 // synchronous calls using grpc
@@ -29,6 +32,11 @@ type grpcClient struct {
 	addr  string
 	err   error
 	resCb func(*types.Request, *types.Response) // listens to all callbacks
+
+	tracer *trace.Tracer // set by NewTracingClient
+
+	traceMtx    tmsync.Mutex
+	traceParent trace.SpanContext // see SetTraceParent
 }
 
 func NewGRPCClient(addr string, mustConnect bool) Client {
@@ -50,7 +58,11 @@ func (cli *grpcClient) OnStart() error {
 	}
 RETRY_LOOP:
 	for {
-		conn, err := grpc.Dial(cli.addr, grpc.WithInsecure(), grpc.WithContextDialer(dialerFunc))
+		opts := []grpc.DialOption{grpc.WithInsecure(), grpc.WithContextDialer(dialerFunc)}
+		if cli.tracer.Enabled() {
+			opts = append(opts, grpc.WithUnaryInterceptor(cli.traceCall))
+		}
+		conn, err := grpc.Dial(cli.addr, opts...)
 		if err != nil {
 			if cli.mustConnect {
 				return err
@@ -79,6 +91,34 @@ RETRY_LOOP:
 	}
 }
 
+// traceCall traces the calls like tracingClient, and propagates the trace
+// context to the server.
+func (cli *grpcClient) traceCall(ctx context.Context, method string, req, reply interface{},
+	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	reqType := path.Base(method)
+	if reqType == "Echo" || reqType == "Flush" {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		cli.traceMtx.Lock()
+		ctx = trace.ContextWithSpanContext(ctx, cli.traceParent)
+		cli.traceMtx.Unlock()
+	}
+	ctx, span := cli.tracer.StartSpanFromContext(ctx, requestSpanName(reqType), "abci.request", reqType)
+	err := invoker(trace.OutgoingGRPCContext(ctx), method, req, reply, cc, opts...)
+	span.SetError(err)
+	span.End()
+	return err
+}
+
+// SetTraceParent implements TraceParentSetter.
+func (cli *grpcClient) SetTraceParent(sc trace.SpanContext) {
+	cli.traceMtx.Lock()
+	cli.traceParent = sc
+	cli.traceMtx.Unlock()
+}
+
 func (cli *grpcClient) OnStop() {
 	cli.BaseService.OnStop()
 
@@ -10,6 +10,9 @@ import (
 	"reflect"
 	"time"
 
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/discard"
+
 	"github.com/tendermint/tendermint/abci/types"
 	tmnet "github.com/tendermint/tendermint/libs/net"
 	"github.com/tendermint/tendermint/libs/service"
@@ -32,6 +35,7 @@ type socketClient struct {
 	conn        net.Conn
 
 	reqQueue   chan *ReqRes
+	queueSize  metrics.Gauge // set to the number of queued requests
 	flushTimer *timer.ThrottleTimer
 
 	mtx     tmsync.Mutex
@@ -48,6 +52,7 @@ var _ Client = (*socketClient)(nil)
 func NewSocketClient(addr string, mustConnect bool) Client {
 	cli := &socketClient{
 		reqQueue:    make(chan *ReqRes, reqQueueSize),
+		queueSize:   discard.NewGauge(),
 		flushTimer:  timer.NewThrottleTimer("socketClient", flushThrottleMS),
 		mustConnect: mustConnect,
 
@@ -59,6 +64,18 @@ func NewSocketClient(addr string, mustConnect bool) Client {
 	return cli
 }
 
+// SetQueueSizeGauge sets the gauge set to the number of requests queued by a
+// socket client, waiting to be written to the connection. It has no effect on
+// the other clients. It must be called before the client is started.
+func SetQueueSizeGauge(client Client, gauge metrics.Gauge) {
+	switch cli := client.(type) {
+	case *socketClient:
+		cli.queueSize = gauge
+	case *tracingClient:
+		SetQueueSizeGauge(cli.Client, gauge)
+	}
+}
+
 // OnStart implements Service by connecting to the server and spawning reading
 // and writing goroutines.
 func (cli *socketClient) OnStart() error {
@@ -121,6 +138,7 @@ func (cli *socketClient) sendRequestsRoutine(conn io.Writer) {
 	for {
 		select {
 		case reqres := <-cli.reqQueue:
+			cli.queueSize.Set(float64(len(cli.reqQueue)))
 			// cli.Logger.Debug("Sent request", "requestType", reflect.TypeOf(reqres.Request), "request", reqres.Request)
 
 			cli.willSendReq(reqres)
@@ -201,6 +219,7 @@ func (cli *socketClient) didRecvResponse(res *types.Response) error {
 
 	reqres.Response = res
 	reqres.Done()            // release waiters
+	reqres.end()             // notify the wrapping clients
 	cli.reqSent.Remove(next) // pop first item from linked list
 
 	// Notify client listener if set (global callback).
@@ -426,6 +445,7 @@ func (cli *socketClient) queueRequest(req *types.Request) *ReqRes {
 
 	// TODO: set cli.err if reqQueue times out
 	cli.reqQueue <- reqres
+	cli.queueSize.Set(float64(len(cli.reqQueue)))
 
 	// Maybe auto-flush, or unset auto-flush
 	switch req.Value.(type) {
@@ -439,13 +459,14 @@ func (cli *socketClient) queueRequest(req *types.Request) *ReqRes {
 }
 
 func (cli *socketClient) flushQueue() {
+	var failed []*ReqRes
 	cli.mtx.Lock()
-	defer cli.mtx.Unlock()
 
 	// mark all in-flight messages as resolved (they will get cli.Error())
 	for req := cli.reqSent.Front(); req != nil; req = req.Next() {
 		reqres := req.Value.(*ReqRes)
 		reqres.Done()
+		failed = append(failed, reqres)
 	}
 
 	// mark all queued messages as resolved
@@ -454,10 +475,19 @@ LOOP:
 		select {
 		case reqres := <-cli.reqQueue:
 			reqres.Done()
+			failed = append(failed, reqres)
 		default:
 			break LOOP
 		}
 	}
+
+	cli.mtx.Unlock()
+
+	// The wrapping clients are notified without the lock, as they may need
+	// cli.Error().
+	for _, reqres := range failed {
+		reqres.end()
+	}
 }
 
 //----------------------------------------
@@ -92,6 +92,42 @@ func TestHangingSyncCalls(t *testing.T) {
 	}
 }
 
+func TestReqResSetOnEnd(t *testing.T) {
+	s, c := setupClientServer(t, types.NewBaseApplication())
+	t.Cleanup(func() {
+		if err := s.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Cleanup(func() {
+		if err := c.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	// The functions of the wrapping clients are called in order, along with
+	// the callback set by the user.
+	var ended []string
+	reqres := c.CheckTxAsync(types.RequestCheckTx{})
+	reqres.SetOnEnd(func(res *types.Response) {
+		assert.NotNil(t, res.GetCheckTx())
+		ended = append(ended, "first")
+	})
+	reqres.SetOnEnd(func(res *types.Response) {
+		assert.NotNil(t, res.GetCheckTx())
+		ended = append(ended, "second")
+	})
+	reqres.SetCallback(func(*types.Response) {})
+	require.NoError(t, c.FlushSync())
+	assert.Equal(t, []string{"first", "second"}, ended)
+
+	// Once the request ended, the function is called immediately.
+	reqres.SetOnEnd(func(res *types.Response) {
+		ended = append(ended, "third")
+	})
+	assert.Equal(t, []string{"first", "second", "third"}, ended)
+}
+
 func setupClientServer(t *testing.T, app types.Application) (
 	service.Service, abcicli.Client) {
 	// some port between 20k and 30k
@@ -0,0 +1,272 @@
+package abcicli
+
+import (
+	"github.com/tendermint/tendermint/abci/types"
+	tmsync "github.com/tendermint/tendermint/libs/sync"
+	"github.com/tendermint/tendermint/libs/trace"
+)
+
+// TraceParentSetter is implemented by the clients tracing their requests (see
+// NewTracingClient), and by the clients wrapping them.
+type TraceParentSetter interface {
+	// SetTraceParent sets the parent of the spans of the following requests,
+	// e.g. the span of the block they are made for. An invalid span context
+	// unsets it.
+	SetTraceParent(sc trace.SpanContext)
+}
+
+// NewTracingClient returns a client tracing the requests of client with
+// tracer, except flushes and echoes. Each request is traced with a span named
+// after its type, e.g. abci.CheckTx, ending when the response is received, and
+// whose parent can be set with SetTraceParent (see TraceParentSetter).
+//
+// gRPC clients trace their calls themselves, and propagate the trace context
+// to the application in the metadata of the calls (see
+// server.NewGRPCServerWithTracer). They must not be started yet.
+func NewTracingClient(client Client, tracer *trace.Tracer) Client {
+	if !tracer.Enabled() {
+		return client
+	}
+	if cli, ok := client.(*grpcClient); ok {
+		cli.tracer = tracer
+		return cli
+	}
+	return &tracingClient{Client: client, tracer: tracer}
+}
+
+type tracingClient struct {
+	Client
+	tracer *trace.Tracer
+
+	mtx    tmsync.Mutex
+	parent trace.SpanContext
+}
+
+var _ Client = (*tracingClient)(nil)
+var _ TraceParentSetter = (*tracingClient)(nil)
+
+// requestSpanName returns the name of the span of an ABCI request of the
+// type, e.g. CheckTx.
+func requestSpanName(reqType string) string {
+	return "abci." + reqType
+}
+
+// SetTraceParent implements TraceParentSetter.
+func (cli *tracingClient) SetTraceParent(sc trace.SpanContext) {
+	cli.mtx.Lock()
+	cli.parent = sc
+	cli.mtx.Unlock()
+}
+
+func (cli *tracingClient) startSpan(reqType string) *trace.Span {
+	cli.mtx.Lock()
+	parent := cli.parent
+	cli.mtx.Unlock()
+	return cli.tracer.StartSpan(requestSpanName(reqType), parent, "abci.request", reqType)
+}
+
+// endSpanOnResponse ends the span once the response to the request is
+// received, or the request fails.
+func (cli *tracingClient) endSpanOnResponse(span *trace.Span, reqres *ReqRes) {
+	reqres.SetOnEnd(func(res *types.Response) {
+		if res == nil {
+			span.SetError(cli.Error())
+		}
+		span.End()
+	})
+}
+
+func (cli *tracingClient) InfoAsync(req types.RequestInfo) *ReqRes {
+	span := cli.startSpan("Info")
+	reqres := cli.Client.InfoAsync(req)
+	cli.endSpanOnResponse(span, reqres)
+	return reqres
+}
+
+func (cli *tracingClient) SetOptionAsync(req types.RequestSetOption) *ReqRes {
+	span := cli.startSpan("SetOption")
+	reqres := cli.Client.SetOptionAsync(req)
+	cli.endSpanOnResponse(span, reqres)
+	return reqres
+}
+
+func (cli *tracingClient) DeliverTxAsync(req types.RequestDeliverTx) *ReqRes {
+	span := cli.startSpan("DeliverTx")
+	reqres := cli.Client.DeliverTxAsync(req)
+	cli.endSpanOnResponse(span, reqres)
+	return reqres
+}
+
+func (cli *tracingClient) CheckTxAsync(req types.RequestCheckTx) *ReqRes {
+	span := cli.startSpan("CheckTx")
+	reqres := cli.Client.CheckTxAsync(req)
+	cli.endSpanOnResponse(span, reqres)
+	return reqres
+}
+
+func (cli *tracingClient) QueryAsync(req types.RequestQuery) *ReqRes {
+	span := cli.startSpan("Query")
+	reqres := cli.Client.QueryAsync(req)
+	cli.endSpanOnResponse(span, reqres)
+	return reqres
+}
+
+func (cli *tracingClient) CommitAsync() *ReqRes {
+	span := cli.startSpan("Commit")
+	reqres := cli.Client.CommitAsync()
+	cli.endSpanOnResponse(span, reqres)
+	return reqres
+}
+
+func (cli *tracingClient) InitChainAsync(req types.RequestInitChain) *ReqRes {
+	span := cli.startSpan("InitChain")
+	reqres := cli.Client.InitChainAsync(req)
+	cli.endSpanOnResponse(span, reqres)
+	return reqres
+}
+
+func (cli *tracingClient) BeginBlockAsync(req types.RequestBeginBlock) *ReqRes {
+	span := cli.startSpan("BeginBlock")
+	reqres := cli.Client.BeginBlockAsync(req)
+	cli.endSpanOnResponse(span, reqres)
+	return reqres
+}
+
+func (cli *tracingClient) EndBlockAsync(req types.RequestEndBlock) *ReqRes {
+	span := cli.startSpan("EndBlock")
+	reqres := cli.Client.EndBlockAsync(req)
+	cli.endSpanOnResponse(span, reqres)
+	return reqres
+}
+
+func (cli *tracingClient) ListSnapshotsAsync(req types.RequestListSnapshots) *ReqRes {
+	span := cli.startSpan("ListSnapshots")
+	reqres := cli.Client.ListSnapshotsAsync(req)
+	cli.endSpanOnResponse(span, reqres)
+	return reqres
+}
+
+func (cli *tracingClient) OfferSnapshotAsync(req types.RequestOfferSnapshot) *ReqRes {
+	span := cli.startSpan("OfferSnapshot")
+	reqres := cli.Client.OfferSnapshotAsync(req)
+	cli.endSpanOnResponse(span, reqres)
+	return reqres
+}
+
+func (cli *tracingClient) LoadSnapshotChunkAsync(req types.RequestLoadSnapshotChunk) *ReqRes {
+	span := cli.startSpan("LoadSnapshotChunk")
+	reqres := cli.Client.LoadSnapshotChunkAsync(req)
+	cli.endSpanOnResponse(span, reqres)
+	return reqres
+}
+
+func (cli *tracingClient) ApplySnapshotChunkAsync(req types.RequestApplySnapshotChunk) *ReqRes {
+	span := cli.startSpan("ApplySnapshotChunk")
+	reqres := cli.Client.ApplySnapshotChunkAsync(req)
+	cli.endSpanOnResponse(span, reqres)
+	return reqres
+}
+
+func (cli *tracingClient) InfoSync(req types.RequestInfo) (*types.ResponseInfo, error) {
+	span := cli.startSpan("Info")
+	res, err := cli.Client.InfoSync(req)
+	span.SetError(err)
+	span.End()
+	return res, err
+}
+
+func (cli *tracingClient) SetOptionSync(req types.RequestSetOption) (*types.ResponseSetOption, error) {
+	span := cli.startSpan("SetOption")
+	res, err := cli.Client.SetOptionSync(req)
+	span.SetError(err)
+	span.End()
+	return res, err
+}
+
+func (cli *tracingClient) DeliverTxSync(req types.RequestDeliverTx) (*types.ResponseDeliverTx, error) {
+	span := cli.startSpan("DeliverTx")
+	res, err := cli.Client.DeliverTxSync(req)
+	span.SetError(err)
+	span.End()
+	return res, err
+}
+
+func (cli *tracingClient) CheckTxSync(req types.RequestCheckTx) (*types.ResponseCheckTx, error) {
+	span := cli.startSpan("CheckTx")
+	res, err := cli.Client.CheckTxSync(req)
+	span.SetError(err)
+	span.End()
+	return res, err
+}
+
+func (cli *tracingClient) QuerySync(req types.RequestQuery) (*types.ResponseQuery, error) {
+	span := cli.startSpan("Query")
+	res, err := cli.Client.QuerySync(req)
+	span.SetError(err)
+	span.End()
+	return res, err
+}
+
+func (cli *tracingClient) CommitSync() (*types.ResponseCommit, error) {
+	span := cli.startSpan("Commit")
+	res, err := cli.Client.CommitSync()
+	span.SetError(err)
+	span.End()
+	return res, err
+}
+
+func (cli *tracingClient) InitChainSync(req types.RequestInitChain) (*types.ResponseInitChain, error) {
+	span := cli.startSpan("InitChain")
+	res, err := cli.Client.InitChainSync(req)
+	span.SetError(err)
+	span.End()
+	return res, err
+}
+
+func (cli *tracingClient) BeginBlockSync(req types.RequestBeginBlock) (*types.ResponseBeginBlock, error) {
+	span := cli.startSpan("BeginBlock")
+	res, err := cli.Client.BeginBlockSync(req)
+	span.SetError(err)
+	span.End()
+	return res, err
+}
+
+func (cli *tracingClient) EndBlockSync(req types.RequestEndBlock) (*types.ResponseEndBlock, error) {
+	span := cli.startSpan("EndBlock")
+	res, err := cli.Client.EndBlockSync(req)
+	span.SetError(err)
+	span.End()
+	return res, err
+}
+
+func (cli *tracingClient) ListSnapshotsSync(req types.RequestListSnapshots) (*types.ResponseListSnapshots, error) {
+	span := cli.startSpan("ListSnapshots")
+	res, err := cli.Client.ListSnapshotsSync(req)
+	span.SetError(err)
+	span.End()
+	return res, err
+}
+
+func (cli *tracingClient) OfferSnapshotSync(req types.RequestOfferSnapshot) (*types.ResponseOfferSnapshot, error) {
+	span := cli.startSpan("OfferSnapshot")
+	res, err := cli.Client.OfferSnapshotSync(req)
+	span.SetError(err)
+	span.End()
+	return res, err
+}
+
+func (cli *tracingClient) LoadSnapshotChunkSync(req types.RequestLoadSnapshotChunk) (*types.ResponseLoadSnapshotChunk, error) {
+	span := cli.startSpan("LoadSnapshotChunk")
+	res, err := cli.Client.LoadSnapshotChunkSync(req)
+	span.SetError(err)
+	span.End()
+	return res, err
+}
+
+func (cli *tracingClient) ApplySnapshotChunkSync(req types.RequestApplySnapshotChunk) (*types.ResponseApplySnapshotChunk, error) {
+	span := cli.startSpan("ApplySnapshotChunk")
+	res, err := cli.Client.ApplySnapshotChunkSync(req)
+	span.SetError(err)
+	span.End()
+	return res, err
+}
@@ -0,0 +1,108 @@
+package abcicli_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	abcicli "github.com/tendermint/tendermint/abci/client"
+	"github.com/tendermint/tendermint/abci/example/kvstore"
+	"github.com/tendermint/tendermint/abci/server"
+	"github.com/tendermint/tendermint/abci/types"
+	tmrand "github.com/tendermint/tendermint/libs/rand"
+	tmsync "github.com/tendermint/tendermint/libs/sync"
+	"github.com/tendermint/tendermint/libs/trace"
+)
+
+// spanRecorder records the exported spans.
+type spanRecorder struct {
+	mtx   tmsync.Mutex
+	spans map[string][]trace.SpanData
+}
+
+func (r *spanRecorder) ExportSpans(spans []trace.SpanData) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	for _, s := range spans {
+		r.spans[s.Name] = append(r.spans[s.Name], s)
+	}
+	return nil
+}
+
+func (r *spanRecorder) get(name string) []trace.SpanData {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.spans[name]
+}
+
+func TestTracingClient(t *testing.T) {
+	testCases := []struct {
+		name      string
+		newClient func(t *testing.T, app types.Application, tracer *trace.Tracer) abcicli.Client
+	}{
+		{"local", func(t *testing.T, app types.Application, tracer *trace.Tracer) abcicli.Client {
+			return abcicli.NewLocalClient(new(tmsync.Mutex), app)
+		}},
+		{"socket", func(t *testing.T, app types.Application, tracer *trace.Tracer) abcicli.Client {
+			addr := fmt.Sprintf("unix:///tmp/abci-%s.sock", tmrand.Str(6))
+			s := server.NewSocketServer(addr, app)
+			require.NoError(t, s.Start())
+			t.Cleanup(func() { s.Stop() }) // nolint:errcheck // ignore for tests
+			return abcicli.NewSocketClient(addr, true)
+		}},
+		{"grpc", func(t *testing.T, app types.Application, tracer *trace.Tracer) abcicli.Client {
+			addr := fmt.Sprintf("unix:///tmp/abci-%s.sock", tmrand.Str(6))
+			s := server.NewGRPCServerWithTracer(addr, types.NewGRPCApplication(app), tracer)
+			require.NoError(t, s.Start())
+			t.Cleanup(func() { s.Stop() }) // nolint:errcheck // ignore for tests
+			return abcicli.NewGRPCClient(addr, true)
+		}},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := &spanRecorder{spans: make(map[string][]trace.SpanData)}
+			tracer := trace.NewTracer(recorder)
+			require.NoError(t, tracer.Start())
+			t.Cleanup(func() { tracer.Stop() }) // nolint:errcheck // ignore for tests
+
+			c := abcicli.NewTracingClient(tc.newClient(t, kvstore.NewApplication(), tracer), tracer)
+			c.SetResponseCallback(func(*types.Request, *types.Response) {})
+			require.NoError(t, c.Start())
+			t.Cleanup(func() { c.Stop() }) // nolint:errcheck // ignore for tests
+
+			parent := tracer.StartSpan("parent", trace.SpanContext{})
+			c.(abcicli.TraceParentSetter).SetTraceParent(parent.Context())
+			_, err := c.InfoSync(types.RequestInfo{})
+			require.NoError(t, err)
+			c.(abcicli.TraceParentSetter).SetTraceParent(trace.SpanContext{})
+			// Setting the callback of the request doesn't prevent its span from ending.
+			c.CheckTxAsync(types.RequestCheckTx{Tx: []byte("key=value")}).SetCallback(func(*types.Response) {})
+			require.NoError(t, c.FlushSync())
+
+			assert.Eventually(t, func() bool {
+				return len(recorder.get("abci.Info")) == 1 && len(recorder.get("abci.CheckTx")) == 1
+			}, 5*time.Second, 10*time.Millisecond)
+			assert.Empty(t, recorder.get("abci.Flush"))
+			assert.Empty(t, recorder.get("abci.Echo"))
+			assert.Equal(t, "CheckTx", recorder.get("abci.CheckTx")[0].Attributes[0].Value)
+			assert.Equal(t, parent.Context().SpanID, recorder.get("abci.Info")[0].ParentSpanID)
+			assert.False(t, recorder.get("abci.CheckTx")[0].ParentSpanID.IsValid())
+
+			if tc.name == "grpc" {
+				// The trace context is propagated to the server.
+				require.Len(t, recorder.get("abci.server.CheckTx"), 1)
+				client, server := recorder.get("abci.CheckTx")[0], recorder.get("abci.server.CheckTx")[0]
+				assert.Equal(t, client.TraceID, server.TraceID)
+				assert.Equal(t, client.SpanID, server.ParentSpanID)
+			}
+		})
+	}
+
+	// Clients aren't wrapped without tracing.
+	c := abcicli.NewLocalClient(new(tmsync.Mutex), kvstore.NewApplication())
+	assert.Equal(t, c, abcicli.NewTracingClient(c, trace.NopTracer()))
+}
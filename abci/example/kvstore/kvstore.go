@@ -87,13 +87,7 @@ func (app *Application) Info(req types.RequestInfo) (resInfo types.ResponseInfo)
 
 // tx is either "key=value" or just arbitrary bytes
 func (app *Application) DeliverTx(req types.RequestDeliverTx) types.ResponseDeliverTx {
-	var key, value []byte
-	parts := bytes.Split(req.Tx, []byte("="))
-	if len(parts) == 2 {
-		key, value = parts[0], parts[1]
-	} else {
-		key, value = req.Tx, req.Tx
-	}
+	key, value := parseTx(req.Tx)
 
 	err := app.state.db.Set(prefixKey(key), value)
 	if err != nil {
@@ -116,6 +110,15 @@ func (app *Application) DeliverTx(req types.RequestDeliverTx) types.ResponseDeli
 	return types.ResponseDeliverTx{Code: code.CodeTypeOK, Events: events}
 }
 
+// parseTx returns the key and value set by a tx.
+func parseTx(tx []byte) (key, value []byte) {
+	parts := bytes.Split(tx, []byte("="))
+	if len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	return tx, tx
+}
+
 func (app *Application) CheckTx(req types.RequestCheckTx) types.ResponseCheckTx {
 	return types.ResponseCheckTx{Code: code.CodeTypeOK, GasWanted: 1}
 }
@@ -124,6 +127,11 @@ func (app *Application) Commit() types.ResponseCommit {
 	// Using a memdb - just return the big endian size of the db
 	appHash := make([]byte, 8)
 	binary.PutVarint(appHash, app.state.Size)
+	return app.commit(appHash)
+}
+
+// commit saves the state of the next height with the given app hash.
+func (app *Application) commit(appHash []byte) types.ResponseCommit {
 	app.state.AppHash = appHash
 	app.state.Height++
 	saveState(app.state)
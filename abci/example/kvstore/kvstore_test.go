@@ -1,13 +1,19 @@
 package kvstore
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"sort"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/tendermint/tendermint/crypto/merkle"
 	"github.com/tendermint/tendermint/libs/log"
 	"github.com/tendermint/tendermint/libs/service"
 
@@ -184,6 +190,112 @@ func TestValUpdates(t *testing.T) {
 
 }
 
+func TestPersistentKVStoreSnapshots(t *testing.T) {
+	dir, err := ioutil.TempDir("", "abci-kvstore-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	kvstore := NewPersistentKVStoreApplication(filepath.Join(dir, "source"))
+	vals := RandVals(3)
+	kvstore.InitChain(types.RequestInitChain{Validators: vals})
+	for h := 1; h <= int(SnapshotInterval); h++ {
+		makeApplyBlock(t, kvstore, h, nil, []byte(fmt.Sprintf("key%v=value%v", h, h)))
+	}
+
+	resList := kvstore.ListSnapshots(types.RequestListSnapshots{})
+	require.Len(t, resList.Snapshots, 1)
+	snapshot := resList.Snapshots[0]
+	require.EqualValues(t, SnapshotInterval, snapshot.Height)
+
+	// restore the snapshot into an app with some other state
+	restored := NewPersistentKVStoreApplication(filepath.Join(dir, "restored"))
+	restored.InitChain(types.RequestInitChain{Validators: RandVals(1)})
+	makeApplyBlock(t, restored, 1, nil, []byte("other=state"))
+
+	resOffer := restored.OfferSnapshot(types.RequestOfferSnapshot{Snapshot: snapshot})
+	require.Equal(t, types.ResponseOfferSnapshot_ACCEPT, resOffer.Result)
+	for i := uint32(0); i < snapshot.Chunks; i++ {
+		resChunk := kvstore.LoadSnapshotChunk(types.RequestLoadSnapshotChunk{
+			Height: snapshot.Height, Format: snapshot.Format, Chunk: i})
+		resApply := restored.ApplySnapshotChunk(types.RequestApplySnapshotChunk{Index: i, Chunk: resChunk.Chunk})
+		require.Equal(t, types.ResponseApplySnapshotChunk_ACCEPT, resApply.Result)
+	}
+
+	require.Equal(t, kvstore.Info(types.RequestInfo{}), restored.Info(types.RequestInfo{}))
+	valsEqual(t, vals, restored.Validators())
+	require.Len(t, restored.valAddrToPubKeyMap, len(vals))
+	resQuery := restored.Query(types.RequestQuery{Data: []byte("key3")})
+	require.Equal(t, []byte("value3"), resQuery.Value)
+	resQuery = restored.Query(types.RequestQuery{Data: []byte("other")})
+	require.Nil(t, resQuery.Value)
+}
+
+func TestPersistentKVStoreQueryProof(t *testing.T) {
+	dir, err := ioutil.TempDir("", "abci-kvstore-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	kvstore := NewPersistentKVStoreApplication(dir)
+	kvstore.InitChain(types.RequestInitChain{Validators: RandVals(1)})
+	makeApplyBlock(t, kvstore, 1, nil, []byte("b=1"), []byte("d=1"))
+	appHash1 := kvstore.Info(types.RequestInfo{}).LastBlockAppHash
+	makeApplyBlock(t, kvstore, 2, nil, []byte("b=2"), []byte("f=2"))
+	appHash2 := kvstore.Info(types.RequestInfo{}).LastBlockAppHash
+	require.NotEqual(t, appHash1, appHash2)
+
+	prt := merkle.DefaultProofRuntime()
+	testCases := []struct {
+		height  int64
+		key     string
+		value   string
+		appHash []byte
+	}{
+		{0, "b", "2", appHash2},
+		{2, "f", "2", appHash2},
+		{2, "c", "", appHash2},
+		{1, "b", "1", appHash1},
+		{1, "a", "", appHash1},
+		{1, "f", "", appHash1},
+	}
+	for i, tc := range testCases {
+		resQuery := kvstore.Query(types.RequestQuery{Data: []byte(tc.key), Height: tc.height, Prove: true})
+		require.EqualValues(t, code.CodeTypeOK, resQuery.Code, i)
+		keyPath := "/" + StoreName + "/" + tc.key
+		if tc.value == "" {
+			require.Nil(t, resQuery.Value, i)
+			require.NoError(t, prt.VerifyAbsence(resQuery.ProofOps, tc.appHash, keyPath), i)
+		} else {
+			require.Equal(t, []byte(tc.value), resQuery.Value, i)
+			require.NoError(t, prt.VerifyValue(resQuery.ProofOps, tc.appHash, keyPath, resQuery.Value), i)
+		}
+	}
+
+	resQuery := kvstore.Query(types.RequestQuery{Data: []byte("b"), Height: 3, Prove: true})
+	require.EqualValues(t, code.CodeTypeUnknownError, resQuery.Code)
+}
+
+func TestPersistentKVStoreRestoreCorrupted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "abci-kvstore-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	kvstore := NewPersistentKVStoreApplication(dir)
+
+	// a length prefix of 2^63 bytes
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, 1<<63)
+	corrupted := append(buf[:n], []byte("key")...)
+	err = kvstore.Restore(1, snapshotFormat, bytes.NewReader(corrupted))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "exceeds the maximum")
+
+	// a length prefix longer than the snapshot
+	n = binary.PutUvarint(buf, 1024)
+	truncated := append(buf[:n], []byte("key")...)
+	err = kvstore.Restore(1, snapshotFormat, bytes.NewReader(truncated))
+	require.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
 func makeApplyBlock(
 	t *testing.T,
 	kvstore types.Application,
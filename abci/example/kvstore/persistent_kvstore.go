@@ -1,9 +1,15 @@
 package kvstore
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/base64"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -12,14 +18,38 @@ import (
 	"github.com/tendermint/tendermint/abci/example/code"
 	"github.com/tendermint/tendermint/abci/types"
 	cryptoenc "github.com/tendermint/tendermint/crypto/encoding"
+	"github.com/tendermint/tendermint/crypto/merkle"
 	"github.com/tendermint/tendermint/libs/log"
 	pc "github.com/tendermint/tendermint/proto/tendermint/crypto"
+	"github.com/tendermint/tendermint/statesync/snapshots"
 )
 
 const (
 	ValidatorSetChangePrefix string = "val:"
+
+	// StoreName is the name of the store of the key/value pairs in the
+	// multistore committed by the app hash. The pairs are proven at the key
+	// path "/<StoreName>/<key>", i.e. for the query path "/store/kvstore/key".
+	StoreName = "kvstore"
+
+	// SnapshotInterval is the height interval at which state sync snapshots are taken.
+	SnapshotInterval uint64 = 10
+	// SnapshotKeepRecent is the number of recent snapshots kept, so that they
+	// remain available while state syncing nodes discover and fetch them.
+	SnapshotKeepRecent uint32 = 10
+
+	// snapshotFormat is the format of the state sync snapshots: the database
+	// key/value pairs, each prefixed by its uvarint length.
+	snapshotFormat uint32 = 1
+	// maxSnapshotBytesSize is the maximum length of a key or value of a
+	// snapshot, the maximum block size, as they are set by txs.
+	maxSnapshotBytesSize = 100 * 1024 * 1024
 )
 
+// kvPairVersionPrefixKey prefixes the value set to a key at a height, by which
+// the pairs of any committed height are proven.
+var kvPairVersionPrefixKey = []byte("kvPairVersionKey:")
+
 //-----------------------------------------
 
 var _ types.Application = (*PersistentKVStoreApplication)(nil)
@@ -32,6 +62,8 @@ type PersistentKVStoreApplication struct {
 
 	valAddrToPubKeyMap map[string]pc.PublicKey
 
+	snapshots *snapshots.Manager
+
 	logger log.Logger
 }
 
@@ -44,15 +76,31 @@ func NewPersistentKVStoreApplication(dbDir string) *PersistentKVStoreApplication
 
 	state := loadState(db)
 
-	return &PersistentKVStoreApplication{
+	app := &PersistentKVStoreApplication{
 		app:                &Application{state: state},
 		valAddrToPubKeyMap: make(map[string]pc.PublicKey),
 		logger:             log.NewNopLogger(),
 	}
+
+	// rebuild the address lookup of the persisted validators, so that a
+	// restarted app punishes the same validators as the others
+	app.loadValAddrToPubKeyMap()
+
+	snapshotStore, err := snapshots.NewStore(filepath.Join(dbDir, "snapshots"))
+	if err != nil {
+		panic(err)
+	}
+	app.snapshots = snapshots.NewManager(snapshotStore, app, snapshots.Options{
+		Interval:   SnapshotInterval,
+		KeepRecent: SnapshotKeepRecent,
+	})
+
+	return app
 }
 
 func (app *PersistentKVStoreApplication) SetLogger(l log.Logger) {
 	app.logger = l
+	app.snapshots.SetLogger(l)
 }
 
 func (app *PersistentKVStoreApplication) Info(req types.RequestInfo) types.ResponseInfo {
@@ -77,7 +125,12 @@ func (app *PersistentKVStoreApplication) DeliverTx(req types.RequestDeliverTx) t
 	}
 
 	// otherwise, update the key-value store
-	return app.app.DeliverTx(req)
+	res := app.app.DeliverTx(req)
+	key, value := parseTx(req.Tx)
+	if err := app.app.state.db.Set(versionKey(app.app.state.Height+1, key), value); err != nil {
+		panic(err)
+	}
+	return res
 }
 
 func (app *PersistentKVStoreApplication) CheckTx(req types.RequestCheckTx) types.ResponseCheckTx {
@@ -86,14 +139,35 @@ func (app *PersistentKVStoreApplication) CheckTx(req types.RequestCheckTx) types
 
 // Commit will panic if InitChain was not called
 func (app *PersistentKVStoreApplication) Commit() types.ResponseCommit {
-	return app.app.Commit()
+	itr, err := dbm.IteratePrefix(app.app.state.db, kvPairPrefixKey)
+	if err != nil {
+		panic(err)
+	}
+	var keys, values [][]byte
+	for ; itr.Valid(); itr.Next() {
+		keys = append(keys, itr.Key()[len(kvPairPrefixKey):])
+		values = append(values, itr.Value())
+	}
+	if err := itr.Error(); err != nil {
+		panic(err)
+	}
+	itr.Close()
+	storeRoot, _ := merkle.SimpleExistenceProofs(keys, values)
+	appHash, _ := multistoreProof(storeRoot)
+
+	res := app.app.commit(appHash)
+	if err := app.snapshots.Commit(uint64(app.app.state.Height)); err != nil {
+		app.logger.Error("Failed to create snapshot", "height", app.app.state.Height, "err", err)
+	}
+	return res
 }
 
 // When path=/val and data={validator address}, returns the validator update (types.ValidatorUpdate) varint encoded.
-// For any other path, returns an associated value or nil if missing.
+// For any other path, returns an associated value or nil if missing, with the
+// proof of its existence or absence at the requested height if prove is set.
 func (app *PersistentKVStoreApplication) Query(reqQuery types.RequestQuery) (resQuery types.ResponseQuery) {
-	switch reqQuery.Path {
-	case "/val":
+	switch {
+	case reqQuery.Path == "/val":
 		key := []byte("val:" + string(reqQuery.Data))
 		value, err := app.app.state.db.Get(key)
 		if err != nil {
@@ -103,13 +177,108 @@ func (app *PersistentKVStoreApplication) Query(reqQuery types.RequestQuery) (res
 		resQuery.Key = reqQuery.Data
 		resQuery.Value = value
 		return
+	case reqQuery.Prove:
+		return app.proveQuery(reqQuery)
 	default:
 		return app.app.Query(reqQuery)
 	}
 }
 
+// proveQuery returns the value of the key at the requested height, or the last
+// committed one, with the ICS23 proofs of its existence or absence in the
+// store and of the store in the multistore.
+func (app *PersistentKVStoreApplication) proveQuery(reqQuery types.RequestQuery) (resQuery types.ResponseQuery) {
+	height := reqQuery.Height
+	if height == 0 {
+		height = app.app.state.Height
+	}
+	if height < 1 || height > app.app.state.Height {
+		resQuery.Code = code.CodeTypeUnknownError
+		resQuery.Log = fmt.Sprintf("can't prove height %v, the last committed height is %v",
+			height, app.app.state.Height)
+		return
+	}
+
+	keys, values := app.pairsAt(height)
+	storeRoot, proofs := merkle.SimpleExistenceProofs(keys, values)
+	_, storeProof := multistoreProof(storeRoot)
+
+	key := reqQuery.Data
+	proof := &pc.CommitmentProof{}
+	i := sort.Search(len(keys), func(i int) bool { return bytes.Compare(keys[i], key) >= 0 })
+	if i < len(keys) && bytes.Equal(keys[i], key) {
+		resQuery.Value = values[i]
+		resQuery.Log = "exists"
+		proof.Proof = &pc.CommitmentProof_Exist{Exist: proofs[i]}
+	} else {
+		resQuery.Log = "does not exist"
+		nonexist := &pc.NonExistenceProof{Key: key}
+		if i > 0 {
+			nonexist.Left = proofs[i-1]
+		}
+		if i < len(keys) {
+			nonexist.Right = proofs[i]
+		}
+		proof.Proof = &pc.CommitmentProof_Nonexist{Nonexist: nonexist}
+	}
+	resQuery.Key = key
+	resQuery.Height = height
+	resQuery.ProofOps = &pc.ProofOps{Ops: []pc.ProofOp{
+		merkle.NewSimpleCommitmentOp(key, proof).ProofOp(),
+		merkle.NewSimpleCommitmentOp([]byte(StoreName), &pc.CommitmentProof{
+			Proof: &pc.CommitmentProof_Exist{Exist: storeProof},
+		}).ProofOp(),
+	}}
+	return
+}
+
+// pairsAt returns the key/value pairs of a committed height, sorted by key.
+func (app *PersistentKVStoreApplication) pairsAt(height int64) (keys, values [][]byte) {
+	itr, err := app.app.state.db.Iterator(versionKey(0, nil), versionKey(height+1, nil))
+	if err != nil {
+		panic(err)
+	}
+	defer itr.Close()
+	// the versions are iterated by height, so the last one set wins
+	pairs := make(map[string][]byte)
+	for ; itr.Valid(); itr.Next() {
+		pairs[string(itr.Key()[len(kvPairVersionPrefixKey)+8:])] = itr.Value()
+	}
+	if err := itr.Error(); err != nil {
+		panic(err)
+	}
+
+	for k := range pairs {
+		keys = append(keys, []byte(k))
+	}
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+	for _, k := range keys {
+		values = append(values, pairs[string(k)])
+	}
+	return keys, values
+}
+
+// versionKey returns the key of the value set to a key at a height.
+func versionKey(height int64, key []byte) []byte {
+	bz := make([]byte, len(kvPairVersionPrefixKey)+8, len(kvPairVersionPrefixKey)+8+len(key))
+	copy(bz, kvPairVersionPrefixKey)
+	binary.BigEndian.PutUint64(bz[len(kvPairVersionPrefixKey):], uint64(height))
+	return append(bz, key...)
+}
+
+// multistoreProof returns the app hash of the multistore with the single store
+// of the pairs, and the proof of the store's root.
+func multistoreProof(storeRoot []byte) ([]byte, *pc.ExistenceProof) {
+	appHash, proofs := merkle.SimpleExistenceProofs([][]byte{[]byte(StoreName)}, [][]byte{storeRoot})
+	return appHash, proofs[0]
+}
+
 // Save the validators in the merkle tree
 func (app *PersistentKVStoreApplication) InitChain(req types.RequestInitChain) types.ResponseInitChain {
+	// the first committed block must have the chain's initial height
+	if req.InitialHeight > 1 {
+		app.app.state.Height = req.InitialHeight - 1
+	}
 	for _, v := range req.Validators {
 		r := app.updateValidator(v)
 		if r.IsErr() {
@@ -152,27 +321,168 @@ func (app *PersistentKVStoreApplication) EndBlock(req types.RequestEndBlock) typ
 
 func (app *PersistentKVStoreApplication) ListSnapshots(
 	req types.RequestListSnapshots) types.ResponseListSnapshots {
-	return types.ResponseListSnapshots{}
+	return app.snapshots.ListSnapshots(req)
 }
 
 func (app *PersistentKVStoreApplication) LoadSnapshotChunk(
 	req types.RequestLoadSnapshotChunk) types.ResponseLoadSnapshotChunk {
-	return types.ResponseLoadSnapshotChunk{}
+	return app.snapshots.LoadSnapshotChunk(req)
 }
 
 func (app *PersistentKVStoreApplication) OfferSnapshot(
 	req types.RequestOfferSnapshot) types.ResponseOfferSnapshot {
-	return types.ResponseOfferSnapshot{Result: types.ResponseOfferSnapshot_ABORT}
+	return app.snapshots.OfferSnapshot(req)
 }
 
 func (app *PersistentKVStoreApplication) ApplySnapshotChunk(
 	req types.RequestApplySnapshotChunk) types.ResponseApplySnapshotChunk {
-	return types.ResponseApplySnapshotChunk{Result: types.ResponseApplySnapshotChunk_ABORT}
+	return app.snapshots.ApplySnapshotChunk(req)
+}
+
+//---------------------------------------------
+// state sync snapshots
+
+// SnapshotFormat implements snapshots.Snapshotter.
+func (app *PersistentKVStoreApplication) SnapshotFormat() uint32 {
+	return snapshotFormat
+}
+
+// Snapshot implements snapshots.Snapshotter. It writes all the key/value pairs
+// of the database.
+func (app *PersistentKVStoreApplication) Snapshot(height uint64, w io.Writer) error {
+	if height != uint64(app.app.state.Height) {
+		return fmt.Errorf("can't snapshot height %v, the last committed height is %v",
+			height, app.app.state.Height)
+	}
+	itr, err := app.app.state.db.Iterator(nil, nil)
+	if err != nil {
+		return err
+	}
+	defer itr.Close()
+
+	bw := bufio.NewWriter(w)
+	for ; itr.Valid(); itr.Next() {
+		if err := writeSnapshotBytes(bw, itr.Key()); err != nil {
+			return err
+		}
+		if err := writeSnapshotBytes(bw, itr.Value()); err != nil {
+			return err
+		}
+	}
+	if err := itr.Error(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Restore implements snapshots.Snapshotter. It replaces the database contents
+// with the key/value pairs of the snapshot.
+func (app *PersistentKVStoreApplication) Restore(height uint64, format uint32, r io.Reader) error {
+	if format != snapshotFormat {
+		return fmt.Errorf("unknown snapshot format %v", format)
+	}
+	db := app.app.state.db
+
+	// discard the current state, which may be a partially restored snapshot
+	keys := [][]byte{}
+	itr, err := db.Iterator(nil, nil)
+	if err != nil {
+		return err
+	}
+	for ; itr.Valid(); itr.Next() {
+		keys = append(keys, itr.Key())
+	}
+	if err := itr.Error(); err != nil {
+		itr.Close()
+		return err
+	}
+	itr.Close()
+	batch := db.NewBatch()
+	defer batch.Close()
+	for _, key := range keys {
+		if err := batch.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	br := bufio.NewReader(r)
+	for {
+		key, err := readSnapshotBytes(br)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		value, err := readSnapshotBytes(br)
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		} else if err != nil {
+			return err
+		}
+		if err := batch.Set(key, value); err != nil {
+			return err
+		}
+	}
+	if err := batch.WriteSync(); err != nil {
+		return err
+	}
+
+	state := loadState(db)
+	if uint64(state.Height) != height {
+		return fmt.Errorf("restored height %v, expected %v", state.Height, height)
+	}
+	app.app.state = state
+	app.valAddrToPubKeyMap = make(map[string]pc.PublicKey)
+	app.loadValAddrToPubKeyMap()
+	return nil
+}
+
+func writeSnapshotBytes(w *bufio.Writer, bz []byte) error {
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, uint64(len(bz)))
+	if _, err := w.Write(buf[:n]); err != nil {
+		return err
+	}
+	_, err := w.Write(bz)
+	return err
+}
+
+// readSnapshotBytes reads length-prefixed bytes, returning io.EOF only if there
+// is nothing left to read. The snapshot comes from peers, so the bytes are
+// only allocated as they are read.
+func readSnapshotBytes(r *bufio.Reader) ([]byte, error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if size > maxSnapshotBytesSize {
+		return nil, fmt.Errorf("snapshot key or value of %v bytes exceeds the maximum of %v bytes",
+			size, maxSnapshotBytesSize)
+	}
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, r, int64(size)); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
 //---------------------------------------------
 // update validators
 
+// loadValAddrToPubKeyMap adds the persisted validators to the address lookup.
+func (app *PersistentKVStoreApplication) loadValAddrToPubKeyMap() {
+	for _, v := range app.Validators() {
+		pubKey, err := cryptoenc.PubKeyFromProto(v.PubKey)
+		if err != nil {
+			panic(fmt.Errorf("can't decode public key: %w", err))
+		}
+		app.valAddrToPubKeyMap[string(pubKey.Address())] = v.PubKey
+	}
+}
+
 func (app *PersistentKVStoreApplication) Validators() (validators []types.ValidatorUpdate) {
 	itr, err := app.app.state.db.Iterator(nil, nil)
 	if err != nil {
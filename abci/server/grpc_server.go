@@ -1,13 +1,16 @@
 package server
 
 import (
+	"context"
 	"net"
+	"path"
 
 	"google.golang.org/grpc"
 
 	"github.com/tendermint/tendermint/abci/types"
 	tmnet "github.com/tendermint/tendermint/libs/net"
 	"github.com/tendermint/tendermint/libs/service"
+	"github.com/tendermint/tendermint/libs/trace"
 )
 
 type GRPCServer struct {
@@ -18,7 +21,8 @@ type GRPCServer struct {
 	listener net.Listener
 	server   *grpc.Server
 
-	app types.ABCIApplicationServer
+	app    types.ABCIApplicationServer
+	tracer *trace.Tracer
 }
 
 // NewGRPCServer returns a new gRPC ABCI server
@@ -34,6 +38,15 @@ func NewGRPCServer(protoAddr string, app types.ABCIApplicationServer) service.Se
 	return s
 }
 
+// NewGRPCServerWithTracer returns a new gRPC ABCI server tracing the calls
+// with tracer, as children of the spans of the clients propagated in their
+// metadata (see abcicli.NewTracingClient).
+func NewGRPCServerWithTracer(protoAddr string, app types.ABCIApplicationServer, tracer *trace.Tracer) service.Service {
+	s := NewGRPCServer(protoAddr, app).(*GRPCServer)
+	s.tracer = tracer
+	return s
+}
+
 // OnStart starts the gRPC service.
 func (s *GRPCServer) OnStart() error {
 
@@ -43,7 +56,11 @@ func (s *GRPCServer) OnStart() error {
 	}
 
 	s.listener = ln
-	s.server = grpc.NewServer()
+	var opts []grpc.ServerOption
+	if s.tracer.Enabled() {
+		opts = append(opts, grpc.UnaryInterceptor(s.traceCall))
+	}
+	s.server = grpc.NewServer(opts...)
 	types.RegisterABCIApplicationServer(s.server, s.app)
 
 	s.Logger.Info("Listening", "proto", s.proto, "addr", s.addr)
@@ -59,3 +76,16 @@ func (s *GRPCServer) OnStart() error {
 func (s *GRPCServer) OnStop() {
 	s.server.Stop()
 }
+
+// traceCall traces the call with a span named after the request type, e.g.
+// abci.server.CheckTx.
+func (s *GRPCServer) traceCall(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	reqType := path.Base(info.FullMethod)
+	ctx, span := s.tracer.StartSpanFromContext(trace.IncomingGRPCContext(ctx), "abci.server."+reqType,
+		"abci.request", reqType)
+	res, err := handler(ctx, req)
+	span.SetError(err)
+	span.End()
+	return res, err
+}
@@ -0,0 +1,90 @@
+package blockchain
+
+import (
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/discard"
+	"github.com/go-kit/kit/metrics/prometheus"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// MetricsSubsystem is a subsystem shared by all metrics exposed by this
+	// package.
+	MetricsSubsystem = "blockchain"
+)
+
+// Metrics contains metrics exposed by this package.
+type Metrics struct {
+	// Height of the last block synced with fast sync.
+	SyncHeight metrics.Gauge
+	// Height of the last header verified ahead of the synced blocks.
+	VerifiedHeaderHeight metrics.Gauge
+	// Highest height reported by the peers.
+	MaxPeerHeight metrics.Gauge
+	// Number of blocks synced per second.
+	SyncRate metrics.Gauge
+	// Estimated number of seconds until fast sync catches up.
+	SyncETA metrics.Gauge
+	// Number of peers disconnected for serving invalid data.
+	BannedPeers metrics.Counter
+}
+
+// PrometheusMetrics returns Metrics build using Prometheus client library.
+// Optionally, labels can be provided along with their values ("foo",
+// "fooValue").
+func PrometheusMetrics(namespace string, labelsAndValues ...string) *Metrics {
+	labels := []string{}
+	for i := 0; i < len(labelsAndValues); i += 2 {
+		labels = append(labels, labelsAndValues[i])
+	}
+	return &Metrics{
+		SyncHeight: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "sync_height",
+			Help:      "Height of the last block synced with fast sync.",
+		}, labels).With(labelsAndValues...),
+		VerifiedHeaderHeight: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "verified_header_height",
+			Help:      "Height of the last header verified ahead of the synced blocks.",
+		}, labels).With(labelsAndValues...),
+		MaxPeerHeight: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "max_peer_height",
+			Help:      "Highest height reported by the peers.",
+		}, labels).With(labelsAndValues...),
+		SyncRate: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "sync_rate",
+			Help:      "Number of blocks synced per second.",
+		}, labels).With(labelsAndValues...),
+		SyncETA: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "sync_eta_seconds",
+			Help:      "Estimated number of seconds until fast sync catches up.",
+		}, labels).With(labelsAndValues...),
+		BannedPeers: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "banned_peers",
+			Help:      "Number of peers disconnected for serving invalid data.",
+		}, labels).With(labelsAndValues...),
+	}
+}
+
+// NopMetrics returns no-op Metrics.
+func NopMetrics() *Metrics {
+	return &Metrics{
+		SyncHeight:           discard.NewGauge(),
+		VerifiedHeaderHeight: discard.NewGauge(),
+		MaxPeerHeight:        discard.NewGauge(),
+		SyncRate:             discard.NewGauge(),
+		SyncETA:              discard.NewGauge(),
+		BannedPeers:          discard.NewCounter(),
+	}
+}
@@ -34,6 +34,12 @@ func EncodeMsg(pb proto.Message) ([]byte, error) {
 		msg.Sum = &bcproto.Message_StatusRequest{StatusRequest: pb}
 	case *bcproto.StatusResponse:
 		msg.Sum = &bcproto.Message_StatusResponse{StatusResponse: pb}
+	case *bcproto.LightBlockRequest:
+		msg.Sum = &bcproto.Message_LightBlockRequest{LightBlockRequest: pb}
+	case *bcproto.NoLightBlockResponse:
+		msg.Sum = &bcproto.Message_NoLightBlockResponse{NoLightBlockResponse: pb}
+	case *bcproto.LightBlockResponse:
+		msg.Sum = &bcproto.Message_LightBlockResponse{LightBlockResponse: pb}
 	default:
 		return nil, fmt.Errorf("unknown message type %T", pb)
 	}
@@ -66,6 +72,12 @@ func DecodeMsg(bz []byte) (proto.Message, error) {
 		return msg.StatusRequest, nil
 	case *bcproto.Message_StatusResponse:
 		return msg.StatusResponse, nil
+	case *bcproto.Message_LightBlockRequest:
+		return msg.LightBlockRequest, nil
+	case *bcproto.Message_NoLightBlockResponse:
+		return msg.NoLightBlockResponse, nil
+	case *bcproto.Message_LightBlockResponse:
+		return msg.LightBlockResponse, nil
 	default:
 		return nil, fmt.Errorf("unknown message type %T", msg)
 	}
@@ -103,6 +115,22 @@ func ValidateMsg(pb proto.Message) error {
 		}
 	case *bcproto.StatusRequest:
 		return nil
+	case *bcproto.LightBlockRequest:
+		if msg.Height < 0 {
+			return errors.New("negative Height")
+		}
+	case *bcproto.NoLightBlockResponse:
+		if msg.Height < 0 {
+			return errors.New("negative Height")
+		}
+	case *bcproto.LightBlockResponse:
+		lb, err := types.LightBlockFromProto(msg.LightBlock)
+		if err != nil {
+			return err
+		}
+		if lb.SignedHeader == nil || lb.ValidatorSet == nil {
+			return errors.New("incomplete light block")
+		}
 	default:
 		return fmt.Errorf("unknown message type %T", msg)
 	}
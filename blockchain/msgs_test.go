@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	bcproto "github.com/tendermint/tendermint/proto/tendermint/blockchain"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
 	"github.com/tendermint/tendermint/types"
 )
 
@@ -53,6 +54,15 @@ func TestBcNoBlockResponseMessageValidateBasic(t *testing.T) {
 	}
 }
 
+func TestBcLightBlockMessagesValidateBasic(t *testing.T) {
+	assert.NoError(t, ValidateMsg(&bcproto.LightBlockRequest{Height: 1}))
+	assert.Error(t, ValidateMsg(&bcproto.LightBlockRequest{Height: -1}))
+	assert.NoError(t, ValidateMsg(&bcproto.NoLightBlockResponse{Height: 1}))
+	assert.Error(t, ValidateMsg(&bcproto.NoLightBlockResponse{Height: -1}))
+	assert.Error(t, ValidateMsg(&bcproto.LightBlockResponse{}))
+	assert.Error(t, ValidateMsg(&bcproto.LightBlockResponse{LightBlock: &tmproto.LightBlock{}}))
+}
+
 func TestBcStatusRequestMessageValidateBasic(t *testing.T) {
 	request := bcproto.StatusRequest{}
 	assert.NoError(t, ValidateMsg(&request))
@@ -112,6 +122,10 @@ func TestBlockchainMessageVectors(t *testing.T) {
 		{"StatusResponseMessage", &bcproto.Message{Sum: &bcproto.Message_StatusResponse{
 			StatusResponse: &bcproto.StatusResponse{Height: math.MaxInt64, Base: math.MaxInt64}}},
 			"2a1408ffffffffffffffff7f10ffffffffffffffff7f"},
+		{"LightBlockRequestMessage", &bcproto.Message{Sum: &bcproto.Message_LightBlockRequest{
+			LightBlockRequest: &bcproto.LightBlockRequest{Height: 1}}}, "32020801"},
+		{"NoLightBlockResponseMessage", &bcproto.Message{Sum: &bcproto.Message_NoLightBlockResponse{
+			NoLightBlockResponse: &bcproto.NoLightBlockResponse{Height: 1}}}, "3a020801"},
 	}
 
 	for _, tc := range testCases {
@@ -0,0 +1,280 @@
+package v0
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/tendermint/tendermint/p2p"
+	sm "github.com/tendermint/tendermint/state"
+	"github.com/tendermint/tendermint/types"
+)
+
+/*
+	Light blocks (header, commit and validator set) are much smaller than
+	blocks, so the pool fetches them far ahead of the blocks from all the
+	peers that serve them, and verifies them as a chain starting from the last
+	synced block: each header must link to the previous one, be signed by +2/3
+	of its validator set and that validator set must be the one the previous
+	header committed to.
+
+	A block can then be checked against its verified header as soon as it
+	arrives, instead of waiting for the commit in the next block, so peers
+	serving invalid blocks are dropped right away, and blocks no longer have
+	to be verified one after the other.
+*/
+
+const (
+	// maximum number of light blocks verified ahead of the pool height
+	maxLightBlocksAhead = 2 * maxTotalRequesters
+	// maximum number of light block requests pending with a peer
+	maxPendingLightBlocksPerPeer = 50
+	// interval between light block requests rounds
+	lightBlockRequestIntervalMS = 10
+)
+
+var lightBlockTimeout = 10 * time.Second // not const so we can override with tests
+
+type lightBlockRequest struct {
+	peerID p2p.ID // empty if not assigned
+	sentAt time.Time
+	tried  map[p2p.ID]bool // peers that didn't respond or didn't have it
+}
+
+type lightBlockResponse struct {
+	lightBlock *types.LightBlock
+	peerID     p2p.ID
+}
+
+// lightBlockChain keeps track of the light block requests and responses, and
+// the last verified light block.
+type lightBlockChain struct {
+	// disabled until the trusted state is set
+	chainID string
+
+	// last verified light block, or trusted state
+	height             int64
+	blockID            types.BlockID
+	nextValidatorsHash []byte
+
+	requests map[int64]*lightBlockRequest
+	received map[int64]lightBlockResponse
+	verified map[int64]*types.LightBlock
+}
+
+func newLightBlockChain() *lightBlockChain {
+	return &lightBlockChain{
+		requests: make(map[int64]*lightBlockRequest),
+		received: make(map[int64]lightBlockResponse),
+		verified: make(map[int64]*types.LightBlock),
+	}
+}
+
+// verify verifies that the light block follows the last verified one.
+func (c *lightBlockChain) verify(lb *types.LightBlock) error {
+	if lb.Height != c.height+1 {
+		return fmt.Errorf("expected light block at height %d, got %d", c.height+1, lb.Height)
+	}
+	if err := lb.ValidateBasic(c.chainID); err != nil {
+		return err
+	}
+	if !bytes.Equal(lb.ValidatorsHash, c.nextValidatorsHash) {
+		return fmt.Errorf("expected validators hash %X, got %X", c.nextValidatorsHash, lb.ValidatorsHash)
+	}
+	if !lb.LastBlockID.Equals(c.blockID) {
+		return fmt.Errorf("expected last block ID %v, got %v", c.blockID, lb.LastBlockID)
+	}
+	return lb.ValidatorSet.VerifyCommitLight(c.chainID, lb.Commit.BlockID, lb.Height, lb.Commit)
+}
+
+// SetTrustedState enables fetching light blocks ahead of the blocks,
+// verifying them from the last block of the given state on.
+func (pool *BlockPool) SetTrustedState(state sm.State) {
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+
+	c := newLightBlockChain()
+	c.chainID = state.ChainID
+	c.height = state.LastBlockHeight
+	c.blockID = state.LastBlockID
+	c.nextValidatorsHash = state.Validators.Hash()
+	pool.lightBlocks = c
+}
+
+// SetPeerServesLightBlocks marks a peer added with SetPeerRange as serving
+// light blocks.
+func (pool *BlockPool) SetPeerServesLightBlocks(peerID p2p.ID) {
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+
+	if peer := pool.peers[peerID]; peer != nil {
+		peer.servesLightBlocks = true
+	}
+}
+
+// VerifiedLightBlock returns the verified light block at the height, or nil
+// if it hasn't been verified (yet).
+func (pool *BlockPool) VerifiedLightBlock(height int64) *types.LightBlock {
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+
+	return pool.lightBlocks.verified[height]
+}
+
+// AddLightBlock adds a light block requested from the peer, and verifies as
+// many light blocks as possible. Peers serving invalid light blocks are
+// banned.
+func (pool *BlockPool) AddLightBlock(peerID p2p.ID, lb *types.LightBlock) {
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+
+	c := pool.lightBlocks
+	request := c.requests[lb.Height]
+	if request == nil || request.peerID != peerID {
+		// possibly a response to a request that timed out
+		pool.Logger.Debug("Peer sent us a light block we didn't expect", "peer", peerID, "height", lb.Height)
+		return
+	}
+	delete(c.requests, lb.Height)
+	if peer := pool.peers[peerID]; peer != nil {
+		peer.numPendingLightBlocks--
+	}
+	c.received[lb.Height] = lightBlockResponse{lightBlock: lb, peerID: peerID}
+
+	for {
+		response, ok := c.received[c.height+1]
+		if !ok {
+			return
+		}
+		delete(c.received, c.height+1)
+		if err := c.verify(response.lightBlock); err != nil {
+			pool.banPeer(fmt.Errorf("invalid light block: %w", err), response.peerID)
+			continue
+		}
+		c.height = response.lightBlock.Height
+		c.blockID = response.lightBlock.Commit.BlockID
+		c.nextValidatorsHash = response.lightBlock.NextValidatorsHash
+		c.verified[c.height] = response.lightBlock
+		pool.metrics.VerifiedHeaderHeight.Set(float64(c.height))
+	}
+}
+
+// NoLightBlock records that the peer doesn't have the light block at the
+// height, so that it's requested from another peer.
+func (pool *BlockPool) NoLightBlock(peerID p2p.ID, height int64) {
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+
+	request := pool.lightBlocks.requests[height]
+	if request == nil || request.peerID != peerID {
+		return
+	}
+	request.peerID = ""
+	request.tried[peerID] = true
+	if peer := pool.peers[peerID]; peer != nil {
+		peer.numPendingLightBlocks--
+	}
+}
+
+// requests light blocks ahead of the pool height
+func (pool *BlockPool) makeLightBlockRequestsRoutine() {
+	ticker := time.NewTicker(lightBlockRequestIntervalMS * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-pool.Quit():
+			return
+		case <-ticker.C:
+			pool.makeLightBlockRequests()
+		}
+	}
+}
+
+func (pool *BlockPool) makeLightBlockRequests() {
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+
+	c := pool.lightBlocks
+	if c.chainID == "" {
+		return
+	}
+
+	// re-assign requests that timed out
+	for _, request := range c.requests {
+		if request.peerID != "" && time.Since(request.sentAt) > lightBlockTimeout {
+			if peer := pool.peers[request.peerID]; peer != nil {
+				peer.numPendingLightBlocks--
+			}
+			request.tried[request.peerID] = true
+			request.peerID = ""
+		}
+	}
+
+	// peers only serve light blocks below their height, since the commit of
+	// their last block isn't final yet
+	maxHeight := pool.height + maxLightBlocksAhead
+	if pool.maxPeerHeight-1 < maxHeight {
+		maxHeight = pool.maxPeerHeight - 1
+	}
+	for height := c.height + 1; height <= maxHeight; height++ {
+		if _, ok := c.received[height]; ok {
+			continue
+		}
+		request := c.requests[height]
+		if request == nil {
+			request = &lightBlockRequest{tried: make(map[p2p.ID]bool)}
+			c.requests[height] = request
+		}
+		if request.peerID != "" {
+			continue
+		}
+		peer := pool.pickLightBlockPeer(height, request.tried)
+		if peer == nil && len(request.tried) > 0 {
+			// all peers were tried, try them again
+			request.tried = make(map[p2p.ID]bool)
+			peer = pool.pickLightBlockPeer(height, request.tried)
+		}
+		if peer == nil {
+			continue
+		}
+		peer.numPendingLightBlocks++
+		request.peerID = peer.id
+		request.sentAt = time.Now()
+		pool.sendLightBlockRequest(height, peer.id)
+	}
+}
+
+// pickLightBlockPeer picks the least busy peer that serves light blocks at
+// the height, and hasn't been tried yet.
+// CONTRACT: pool.mtx must be locked.
+func (pool *BlockPool) pickLightBlockPeer(height int64, tried map[p2p.ID]bool) *bpPeer {
+	var best *bpPeer
+	for _, peer := range pool.peers {
+		if !peer.servesLightBlocks || peer.didTimeout || tried[peer.id] {
+			continue
+		}
+		if peer.numPendingLightBlocks >= maxPendingLightBlocksPerPeer {
+			continue
+		}
+		if height < peer.base || height >= peer.height {
+			continue
+		}
+		if best == nil || peer.numPendingLightBlocks < best.numPendingLightBlocks {
+			best = peer
+		}
+	}
+	return best
+}
+
+func (pool *BlockPool) sendLightBlockRequest(height int64, peerID p2p.ID) {
+	if !pool.IsRunning() {
+		return
+	}
+	select {
+	case pool.requestsCh <- BlockRequest{Height: height, PeerID: peerID, LightBlock: true}:
+	default:
+		// the request will time out and be retried
+		pool.Logger.Debug("Request queue is full, drop light block request", "peer", peerID, "height", height)
+	}
+}
@@ -0,0 +1,100 @@
+package v0
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/crypto/tmhash"
+	"github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/p2p"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	tmversion "github.com/tendermint/tendermint/proto/tendermint/version"
+	sm "github.com/tendermint/tendermint/state"
+	"github.com/tendermint/tendermint/types"
+	"github.com/tendermint/tendermint/version"
+)
+
+const lightBlocksTestChainID = "light-blocks-test"
+
+func makeLightBlock(t *testing.T, height int64, lastBlockID types.BlockID,
+	vals *types.ValidatorSet, privVals []types.PrivValidator) *types.LightBlock {
+	header := &types.Header{
+		Version:            tmversion.Consensus{Block: version.BlockProtocol},
+		ChainID:            lightBlocksTestChainID,
+		Height:             height,
+		Time:               time.Now(),
+		LastBlockID:        lastBlockID,
+		ValidatorsHash:     vals.Hash(),
+		NextValidatorsHash: vals.Hash(),
+		ProposerAddress:    vals.Proposer.Address,
+	}
+	blockID := types.BlockID{
+		Hash:          header.Hash(),
+		PartSetHeader: types.PartSetHeader{Total: 1, Hash: tmhash.Sum([]byte("parts"))},
+	}
+	voteSet := types.NewVoteSet(lightBlocksTestChainID, height, 0, tmproto.PrecommitType, vals)
+	commit, err := types.MakeCommit(blockID, height, 0, voteSet, privVals, time.Now())
+	require.NoError(t, err)
+	return &types.LightBlock{
+		SignedHeader: &types.SignedHeader{Header: header, Commit: commit},
+		ValidatorSet: vals,
+	}
+}
+
+func TestBlockPoolLightBlocks(t *testing.T) {
+	vals, privVals := types.RandValidatorSet(4, 10)
+	otherVals, otherPrivVals := types.RandValidatorSet(4, 10)
+
+	pool := NewBlockPool(1, make(chan BlockRequest), make(chan peerError))
+	pool.SetLogger(log.TestingLogger())
+	pool.SetTrustedState(sm.State{ChainID: lightBlocksTestChainID, Validators: vals})
+
+	pool.SetPeerRange("good", 1, 10)
+	pool.SetPeerServesLightBlocks("good")
+	pool.SetPeerRange("bad", 1, 10)
+	pool.SetPeerServesLightBlocks("bad")
+
+	pool.makeLightBlockRequests()
+	// peers only serve light blocks below their height
+	require.Len(t, pool.lightBlocks.requests, 9)
+	for _, request := range pool.lightBlocks.requests {
+		require.NotEmpty(t, request.peerID)
+		request.peerID = "good"
+	}
+
+	// light blocks can arrive out of order
+	lb1 := makeLightBlock(t, 1, types.BlockID{}, vals, privVals)
+	lb2 := makeLightBlock(t, 2, lb1.Commit.BlockID, vals, privVals)
+	pool.AddLightBlock("good", lb2)
+	assert.Nil(t, pool.VerifiedLightBlock(2))
+	pool.AddLightBlock("good", lb1)
+	assert.Equal(t, lb1, pool.VerifiedLightBlock(1))
+	assert.Equal(t, lb2, pool.VerifiedLightBlock(2))
+
+	// unsolicited light blocks are ignored
+	lb3 := makeLightBlock(t, 3, lb2.Commit.BlockID, vals, privVals)
+	pool.AddLightBlock("bad", lb3)
+	assert.Nil(t, pool.VerifiedLightBlock(3))
+
+	// a light block signed by another validator set bans the peer
+	pool.lightBlocks.requests[3].peerID = "bad"
+	pool.AddLightBlock("bad", makeLightBlock(t, 3, lb2.Commit.BlockID, otherVals, otherPrivVals))
+	assert.Nil(t, pool.VerifiedLightBlock(3))
+	assert.NotContains(t, pool.peers, p2p.ID("bad"))
+
+	// the light block is requested again
+	pool.makeLightBlockRequests()
+	require.Contains(t, pool.lightBlocks.requests, int64(3))
+	assert.EqualValues(t, "good", pool.lightBlocks.requests[3].peerID)
+	pool.AddLightBlock("good", lb3)
+	assert.Equal(t, lb3, pool.VerifiedLightBlock(3))
+
+	// a block that doesn't match its verified header bans the peer
+	pool.requesters[1] = newBPRequester(pool, 1)
+	pool.requesters[1].peerID = "good"
+	pool.AddBlock("good", &types.Block{Header: types.Header{Height: 1}}, 100)
+	assert.NotContains(t, pool.peers, p2p.ID("good"))
+}
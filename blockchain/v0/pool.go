@@ -1,12 +1,14 @@
 package v0
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"math"
 	"sync/atomic"
 	"time"
 
+	bc "github.com/tendermint/tendermint/blockchain"
 	flow "github.com/tendermint/tendermint/libs/flowrate"
 	"github.com/tendermint/tendermint/libs/log"
 	"github.com/tendermint/tendermint/libs/service"
@@ -74,8 +76,13 @@ type BlockPool struct {
 	// atomic
 	numPending int32 // number of requests pending assignment or block response
 
+	// light blocks, fetched and verified ahead of the blocks
+	lightBlocks *lightBlockChain
+
 	requestsCh chan<- BlockRequest
 	errorsCh   chan<- peerError
+
+	metrics *bc.Metrics
 }
 
 // NewBlockPool returns a new BlockPool with the height equal to start. Block
@@ -88,8 +95,12 @@ func NewBlockPool(start int64, requestsCh chan<- BlockRequest, errorsCh chan<- p
 		height:     start,
 		numPending: 0,
 
+		lightBlocks: newLightBlockChain(),
+
 		requestsCh: requestsCh,
 		errorsCh:   errorsCh,
+
+		metrics: bc.NopMetrics(),
 	}
 	bp.BaseService = *service.NewBaseService(nil, "BlockPool", bp)
 	return bp
@@ -99,6 +110,7 @@ func NewBlockPool(start int64, requestsCh chan<- BlockRequest, errorsCh chan<- p
 // pool's start time.
 func (pool *BlockPool) OnStart() error {
 	go pool.makeRequestersRoutine()
+	go pool.makeLightBlockRequestsRoutine()
 	pool.startTime = time.Now()
 	return nil
 }
@@ -218,6 +230,7 @@ func (pool *BlockPool) PopRequest() {
 			pool.Logger.Error("Error stopping requester", "err", err)
 		}
 		delete(pool.requesters, pool.height)
+		delete(pool.lightBlocks.verified, pool.height)
 		pool.height++
 	} else {
 		panic(fmt.Sprintf("Expected requester to pop, got nothing at height %v", pool.height))
@@ -266,6 +279,12 @@ func (pool *BlockPool) AddBlock(peerID p2p.ID, block *types.Block, blockSize int
 		return
 	}
 
+	if lb := pool.lightBlocks.verified[block.Height]; lb != nil && requester.getPeerID() == peerID &&
+		!bytes.Equal(block.Hash(), lb.Hash()) {
+		pool.banPeer(fmt.Errorf("block %d does not match the verified header", block.Height), peerID)
+		return
+	}
+
 	if requester.setBlock(block, peerID) {
 		atomic.AddInt32(&pool.numPending, -1)
 		peer := pool.peers[peerID]
@@ -302,6 +321,7 @@ func (pool *BlockPool) SetPeerRange(peerID p2p.ID, base int64, height int64) {
 
 	if height > pool.maxPeerHeight {
 		pool.maxPeerHeight = height
+		pool.metrics.MaxPeerHeight.Set(float64(height))
 	}
 }
 
@@ -320,6 +340,11 @@ func (pool *BlockPool) removePeer(peerID p2p.ID) {
 			requester.redo(peerID)
 		}
 	}
+	for _, request := range pool.lightBlocks.requests {
+		if request.peerID == peerID {
+			request.peerID = ""
+		}
+	}
 
 	peer, ok := pool.peers[peerID]
 	if ok {
@@ -348,12 +373,17 @@ func (pool *BlockPool) updateMaxPeerHeight() {
 	pool.maxPeerHeight = max
 }
 
-// Pick an available peer with the given height available.
-// If no peers are available, returns nil.
+// Pick an available peer with the given height available, preferring the
+// peer that is expected to deliver the block first given its pending
+// requests and receive rate. If no peers are available, returns nil.
 func (pool *BlockPool) pickIncrAvailablePeer(height int64) *bpPeer {
 	pool.mtx.Lock()
 	defer pool.mtx.Unlock()
 
+	var (
+		best      *bpPeer
+		bestScore float64
+	)
 	for _, peer := range pool.peers {
 		if peer.didTimeout {
 			pool.removePeer(peer.id)
@@ -365,10 +395,14 @@ func (pool *BlockPool) pickIncrAvailablePeer(height int64) *bpPeer {
 		if height < peer.base || height > peer.height {
 			continue
 		}
-		peer.incrPending()
-		return peer
+		if score := float64(peer.numPending+1) / peer.recvRate(); best == nil || score < bestScore {
+			best, bestScore = peer, score
+		}
 	}
-	return nil
+	if best != nil {
+		best.incrPending()
+	}
+	return best
 }
 
 func (pool *BlockPool) makeNextRequester() {
@@ -399,7 +433,7 @@ func (pool *BlockPool) sendRequest(height int64, peerID p2p.ID) {
 	if !pool.IsRunning() {
 		return
 	}
-	pool.requestsCh <- BlockRequest{height, peerID}
+	pool.requestsCh <- BlockRequest{Height: height, PeerID: peerID}
 }
 
 func (pool *BlockPool) sendError(err error, peerID p2p.ID) {
@@ -409,6 +443,16 @@ func (pool *BlockPool) sendError(err error, peerID p2p.ID) {
 	pool.errorsCh <- peerError{err, peerID}
 }
 
+// banPeer removes a peer that served invalid data from the pool, and has the
+// reactor disconnect it.
+// CONTRACT: pool.mtx must be locked.
+func (pool *BlockPool) banPeer(err error, peerID p2p.ID) {
+	pool.Logger.Error("Peer served invalid data", "peer", peerID, "err", err)
+	pool.metrics.BannedPeers.Add(1)
+	pool.removePeer(peerID)
+	pool.sendError(err, peerID)
+}
+
 // for debugging purposes
 //nolint:unused
 func (pool *BlockPool) debug() string {
@@ -438,6 +482,12 @@ type bpPeer struct {
 	pool        *BlockPool
 	id          p2p.ID
 	recvMonitor *flow.Monitor
+	lastRate    int64 // last measured receive rate, in bytes/s
+
+	// whether the peer serves light blocks, and the number of light block
+	// requests pending with it
+	servesLightBlocks     bool
+	numPendingLightBlocks int32
 
 	timeout *time.Timer
 
@@ -490,6 +540,18 @@ func (peer *bpPeer) decrPending(recvSize int) {
 		peer.recvMonitor.Update(recvSize)
 		peer.resetTimeout()
 	}
+	if rate := peer.recvMonitor.Status().CurRate; rate > 0 {
+		peer.lastRate = rate
+	}
+}
+
+// recvRate returns the last measured receive rate of the peer, or the
+// initial estimate used for the timeout check if there is none yet.
+func (peer *bpPeer) recvRate() float64 {
+	if peer.lastRate > 0 {
+		return float64(peer.lastRate)
+	}
+	return float64(minRecvRate) * math.E
 }
 
 func (peer *bpPeer) onTimeout() {
@@ -644,4 +706,7 @@ OUTER_LOOP:
 type BlockRequest struct {
 	Height int64
 	PeerID p2p.ID
+	// LightBlock requests the header, commit and validator set at the
+	// height instead of the block.
+	LightBlock bool
 }
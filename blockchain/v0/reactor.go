@@ -1,10 +1,13 @@
 package v0
 
 import (
+	"bytes"
 	"fmt"
 	"reflect"
 	"time"
 
+	"github.com/gogo/protobuf/proto"
+
 	bc "github.com/tendermint/tendermint/blockchain"
 	"github.com/tendermint/tendermint/libs/log"
 	"github.com/tendermint/tendermint/p2p"
@@ -17,6 +20,9 @@ import (
 const (
 	// BlockchainChannel is a channel for blocks and status updates (`BlockStore` height)
 	BlockchainChannel = byte(0x40)
+	// LightBlockChannel is a channel for the light blocks fetched ahead of
+	// the blocks. Peers that don't have it are only used for blocks.
+	LightBlockChannel = byte(0x41)
 
 	trySyncIntervalMS = 10
 
@@ -59,11 +65,24 @@ type BlockchainReactor struct {
 
 	requestsCh <-chan BlockRequest
 	errorsCh   <-chan peerError
+
+	metrics *bc.Metrics
+}
+
+// ReactorOption sets an optional parameter on the BlockchainReactor.
+type ReactorOption func(*BlockchainReactor)
+
+// ReactorMetrics sets the metrics.
+func ReactorMetrics(metrics *bc.Metrics) ReactorOption {
+	return func(bcR *BlockchainReactor) {
+		bcR.metrics = metrics
+		bcR.pool.metrics = metrics
+	}
 }
 
 // NewBlockchainReactor returns new reactor instance.
 func NewBlockchainReactor(state sm.State, blockExec *sm.BlockExecutor, store *store.BlockStore,
-	fastSync bool) *BlockchainReactor {
+	fastSync bool, options ...ReactorOption) *BlockchainReactor {
 
 	if state.LastBlockHeight != store.Height() {
 		panic(fmt.Sprintf("state (%v) and store (%v) height mismatch", state.LastBlockHeight,
@@ -80,6 +99,7 @@ func NewBlockchainReactor(state sm.State, blockExec *sm.BlockExecutor, store *st
 		startHeight = state.InitialHeight
 	}
 	pool := NewBlockPool(startHeight, requestsCh, errorsCh)
+	pool.SetTrustedState(state)
 
 	bcR := &BlockchainReactor{
 		initialState: state,
@@ -89,8 +109,12 @@ func NewBlockchainReactor(state sm.State, blockExec *sm.BlockExecutor, store *st
 		fastSync:     fastSync,
 		requestsCh:   requestsCh,
 		errorsCh:     errorsCh,
+		metrics:      bc.NopMetrics(),
 	}
 	bcR.BaseReactor = *p2p.NewBaseReactor("BlockchainReactor", bcR)
+	for _, option := range options {
+		option(bcR)
+	}
 	return bcR
 }
 
@@ -118,6 +142,7 @@ func (bcR *BlockchainReactor) SwitchToFastSync(state sm.State) error {
 	bcR.initialState = state
 
 	bcR.pool.height = state.LastBlockHeight + 1
+	bcR.pool.SetTrustedState(state)
 	err := bcR.pool.Start()
 	if err != nil {
 		return err
@@ -145,6 +170,13 @@ func (bcR *BlockchainReactor) GetChannels() []*p2p.ChannelDescriptor {
 			RecvBufferCapacity:  50 * 4096,
 			RecvMessageCapacity: bc.MaxMsgSize,
 		},
+		{
+			ID:                  LightBlockChannel,
+			Priority:            10,
+			SendQueueCapacity:   1000,
+			RecvBufferCapacity:  50 * 4096,
+			RecvMessageCapacity: bc.MaxMsgSize,
+		},
 	}
 }
 
@@ -203,7 +235,63 @@ func (bcR *BlockchainReactor) respondToPeer(msg *bcproto.BlockRequest,
 	return src.TrySend(BlockchainChannel, msgBytes)
 }
 
-// Receive implements Reactor by handling 4 types of messages (look below).
+// respondLightBlockToPeer loads a light block and sends it to the requesting
+// peer, if we have it. Otherwise, we'll respond saying we don't have it.
+func (bcR *BlockchainReactor) respondLightBlockToPeer(msg *bcproto.LightBlockRequest,
+	src p2p.Peer) (queued bool) {
+
+	var pb proto.Message = &bcproto.NoLightBlockResponse{Height: msg.Height}
+	if lb := bcR.loadLightBlock(msg.Height); lb != nil {
+		lbpb, err := lb.ToProto()
+		if err != nil {
+			bcR.Logger.Error("could not convert msg to protobuf", "err", err)
+			return false
+		}
+		pb = &bcproto.LightBlockResponse{LightBlock: lbpb}
+	} else {
+		bcR.Logger.Debug("Peer asking for a light block we don't have", "src", src, "height", msg.Height)
+	}
+
+	msgBytes, err := bc.EncodeMsg(pb)
+	if err != nil {
+		bcR.Logger.Error("could not marshal msg", "err", err)
+		return false
+	}
+	return src.TrySend(LightBlockChannel, msgBytes)
+}
+
+// loadLightBlock loads the light block at the height, or returns nil if we
+// don't have it. The commit of our last block isn't final until the next
+// block is committed, so we only serve light blocks below it.
+func (bcR *BlockchainReactor) loadLightBlock(height int64) *types.LightBlock {
+	if height >= bcR.store.Height() {
+		return nil
+	}
+	meta := bcR.store.LoadBlockMeta(height)
+	if meta == nil {
+		return nil
+	}
+	commit := bcR.store.LoadBlockCommit(height)
+	if commit == nil {
+		return nil
+	}
+	vals, err := bcR.blockExec.Store().LoadValidators(height)
+	if err != nil {
+		return nil
+	}
+	return &types.LightBlock{
+		SignedHeader: &types.SignedHeader{Header: &meta.Header, Commit: commit},
+		ValidatorSet: vals,
+	}
+}
+
+// servesLightBlocks returns true if the peer has the light block channel.
+func servesLightBlocks(peer p2p.Peer) bool {
+	nodeInfo, ok := peer.NodeInfo().(p2p.DefaultNodeInfo)
+	return ok && bytes.Contains(nodeInfo.Channels, []byte{LightBlockChannel})
+}
+
+// Receive implements Reactor by handling 7 types of messages (look below).
 func (bcR *BlockchainReactor) Receive(chID byte, src p2p.Peer, msgBytes []byte) {
 	msg, err := bc.DecodeMsg(msgBytes)
 	if err != nil {
@@ -244,8 +332,23 @@ func (bcR *BlockchainReactor) Receive(chID byte, src p2p.Peer, msgBytes []byte)
 	case *bcproto.StatusResponse:
 		// Got a peer status. Unverified.
 		bcR.pool.SetPeerRange(src.ID(), msg.Base, msg.Height)
+		if servesLightBlocks(src) {
+			bcR.pool.SetPeerServesLightBlocks(src.ID())
+		}
 	case *bcproto.NoBlockResponse:
 		bcR.Logger.Debug("Peer does not have requested block", "peer", src, "height", msg.Height)
+	case *bcproto.LightBlockRequest:
+		bcR.respondLightBlockToPeer(msg, src)
+	case *bcproto.LightBlockResponse:
+		lb, err := types.LightBlockFromProto(msg.LightBlock)
+		if err != nil {
+			bcR.Logger.Error("Light block content is invalid", "err", err)
+			return
+		}
+		bcR.pool.AddLightBlock(src.ID(), lb)
+	case *bcproto.NoLightBlockResponse:
+		bcR.Logger.Debug("Peer does not have requested light block", "peer", src, "height", msg.Height)
+		bcR.pool.NoLightBlock(src.ID(), msg.Height)
 	default:
 		bcR.Logger.Error(fmt.Sprintf("Unknown message type %v", reflect.TypeOf(msg)))
 	}
@@ -281,15 +384,23 @@ func (bcR *BlockchainReactor) poolRoutine(stateSynced bool) {
 				if peer == nil {
 					continue
 				}
-				msgBytes, err := bc.EncodeMsg(&bcproto.BlockRequest{Height: request.Height})
+				var (
+					pb   proto.Message = &bcproto.BlockRequest{Height: request.Height}
+					chID               = BlockchainChannel
+				)
+				if request.LightBlock {
+					pb, chID = &bcproto.LightBlockRequest{Height: request.Height}, LightBlockChannel
+				}
+				msgBytes, err := bc.EncodeMsg(pb)
 				if err != nil {
 					bcR.Logger.Error("could not convert msg to proto", "err", err)
 					continue
 				}
 
-				queued := peer.TrySend(BlockchainChannel, msgBytes)
+				queued := peer.TrySend(chID, msgBytes)
 				if !queued {
-					bcR.Logger.Debug("Send queue is full, drop block request", "peer", peer.ID(), "height", request.Height)
+					bcR.Logger.Debug("Send queue is full, drop request", "peer", peer.ID(), "height", request.Height,
+						"lightBlock", request.LightBlock)
 				}
 			case err := <-bcR.errorsCh:
 				peer := bcR.Switch.Peers().Get(err.peerID)
@@ -347,25 +458,44 @@ FOR_LOOP:
 			// See if there are any blocks to sync.
 			first, second := bcR.pool.PeekTwoBlocks()
 			//bcR.Logger.Info("TrySync peeked", "first", first, "second", second)
-			if first == nil || second == nil {
-				// We need both to sync the first block.
+			if first == nil {
+				continue FOR_LOOP
+			}
+
+			// If the first block's light block has been verified, the block
+			// only has to match it. Otherwise, we need the second block to
+			// verify the first block with its commit.
+			lightBlock := bcR.pool.VerifiedLightBlock(first.Height)
+			if lightBlock == nil && second == nil {
 				continue FOR_LOOP
-			} else {
-				// Try again quickly next loop.
-				didProcessCh <- struct{}{}
 			}
+			// Try again quickly next loop.
+			didProcessCh <- struct{}{}
 
 			firstParts := first.MakePartSet(types.BlockPartSizeBytes)
 			firstPartSetHeader := firstParts.Header()
 			firstID := types.BlockID{Hash: first.Hash(), PartSetHeader: firstPartSetHeader}
-			// Finally, verify the first block using the second's commit
+			// Finally, verify the first block using the verified light block
+			// or the second's commit.
 			// NOTE: we can probably make this more efficient, but note that calling
 			// first.Hash() doesn't verify the tx contents, so MakePartSet() is
 			// currently necessary.
-			err := state.Validators.VerifyCommitLight(
-				chainID, firstID, first.Height, second.LastCommit)
+			var (
+				commit *types.Commit
+				err    error
+			)
+			if lightBlock != nil {
+				commit = lightBlock.Commit
+				if !firstID.Equals(commit.BlockID) {
+					err = fmt.Errorf("block ID %v does not match the verified light block %v", firstID, commit.BlockID)
+				}
+			} else {
+				commit = second.LastCommit
+				err = state.Validators.VerifyCommitLight(chainID, firstID, first.Height, commit)
+			}
 			if err != nil {
 				bcR.Logger.Error("Error in validation", "err", err)
+				bcR.metrics.BannedPeers.Add(1)
 				peerID := bcR.pool.RedoRequest(first.Height)
 				peer := bcR.Switch.Peers().Get(peerID)
 				if peer != nil {
@@ -373,6 +503,10 @@ FOR_LOOP:
 					// still need to clean up the rest.
 					bcR.Switch.StopPeerForError(peer, fmt.Errorf("blockchainReactor validation error: %v", err))
 				}
+				if lightBlock != nil {
+					// the second block wasn't involved
+					continue FOR_LOOP
+				}
 				peerID2 := bcR.pool.RedoRequest(second.Height)
 				peer2 := bcR.Switch.Peers().Get(peerID2)
 				if peer2 != nil && peer2 != peer {
@@ -385,7 +519,7 @@ FOR_LOOP:
 				bcR.pool.PopRequest()
 
 				// TODO: batch saves so we dont persist to disk every block
-				bcR.store.SaveBlock(first, firstParts, second.LastCommit)
+				bcR.store.SaveBlock(first, firstParts, commit)
 
 				// TODO: same thing for app - but we would need a way to
 				// get the hash without persisting the state
@@ -396,11 +530,17 @@ FOR_LOOP:
 					panic(fmt.Sprintf("Failed to process committed block (%d:%X): %v", first.Height, first.Hash(), err))
 				}
 				blocksSynced++
+				bcR.metrics.SyncHeight.Set(float64(first.Height))
 
 				if blocksSynced%100 == 0 {
 					lastRate = 0.9*lastRate + 0.1*(100/time.Since(lastHundred).Seconds())
+					maxPeerHeight := bcR.pool.MaxPeerHeight()
 					bcR.Logger.Info("Fast Sync Rate", "height", bcR.pool.height,
-						"max_peer_height", bcR.pool.MaxPeerHeight(), "blocks/s", lastRate)
+						"max_peer_height", maxPeerHeight, "blocks/s", lastRate)
+					bcR.metrics.SyncRate.Set(lastRate)
+					if lastRate > 0 && maxPeerHeight > first.Height {
+						bcR.metrics.SyncETA.Set(float64(maxPeerHeight-first.Height) / lastRate)
+					}
 					lastHundred = time.Now()
 				}
 			}
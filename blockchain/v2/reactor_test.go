@@ -150,7 +150,7 @@ func newTestReactor(p testReactorParams) *BlockchainReactor {
 	} else {
 		app := &testApp{}
 		cc := proxy.NewLocalClientCreator(app)
-		proxyApp := proxy.NewAppConns(cc)
+		proxyApp := proxy.NewAppConns(cc, proxy.NopMetrics())
 		err := proxyApp.Start()
 		if err != nil {
 			panic(fmt.Errorf("error start app: %w", err))
@@ -493,7 +493,7 @@ func newReactorStore(
 	}
 	app := &testApp{}
 	cc := proxy.NewLocalClientCreator(app)
-	proxyApp := proxy.NewAppConns(cc)
+	proxyApp := proxy.NewAppConns(cc, proxy.NopMetrics())
 	err := proxyApp.Start()
 	if err != nil {
 		panic(fmt.Errorf("error start app: %w", err))
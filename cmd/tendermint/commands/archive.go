@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	nm "github.com/tendermint/tendermint/node"
+	sm "github.com/tendermint/tendermint/state"
+	"github.com/tendermint/tendermint/store"
+	"github.com/tendermint/tendermint/store/archive"
+	"github.com/tendermint/tendermint/types"
+)
+
+var (
+	exportFrom   int64
+	exportTo     int64
+	exportOutput string
+)
+
+// ExportCmd exports a range of heights of a stopped node into a portable
+// archive.
+var ExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the blocks and state of a stopped node into an archive",
+	Long: `Export the blocks, commits, validator sets, consensus params and ABCI
+responses of a range of heights, and the state after it, from the databases of
+the stopped node in the home directory into a portable archive, which can be
+imported into a node using any database backend with "tendermint import".`,
+	Args: cobra.NoArgs,
+	RunE: runExport,
+}
+
+// ImportCmd imports an archive written by ExportCmd into a stopped node.
+var ImportCmd = &cobra.Command{
+	Use:   "import [archive]",
+	Short: "Import an archive into the databases of a stopped node",
+	Long: `Verify an archive written by "tendermint export" and import it into the
+databases of the stopped node in the home directory, reading it from stdin if
+no file is given. The archive must start at the initial height if the node has
+no blocks, or right after the node's last block otherwise.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runImport,
+}
+
+func init() {
+	ExportCmd.Flags().Int64Var(&exportFrom, "from", 0, "First height to export (default: the block store base)")
+	ExportCmd.Flags().Int64Var(&exportTo, "to", 0, "Last height to export (default: the latest height)")
+	ExportCmd.Flags().StringVar(&exportOutput, "output", "", "Write the archive to this file instead of stdout")
+	ImportCmd.Flags().String("db_backend", config.DBBackend,
+		"Database backend: goleveldb | cleveldb | boltdb | rocksdb | badgerdb")
+	ImportCmd.Flags().String("db_dir", config.DBPath, "Database directory")
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	blockStore, bdb, err := openBlockStore()
+	if err != nil {
+		return err
+	}
+	defer bdb.Close()
+	stateStore, sdb, err := openStateStore()
+	if err != nil {
+		return err
+	}
+	defer sdb.Close()
+
+	from, to := exportFrom, exportTo
+	if from == 0 {
+		from = blockStore.Base()
+	}
+	if to == 0 {
+		state, err := stateStore.Load()
+		if err != nil {
+			return err
+		}
+		to = state.LastBlockHeight
+	}
+
+	var out io.Writer = os.Stdout
+	if exportOutput != "" {
+		f, err := os.Create(exportOutput)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	bw := bufio.NewWriter(out)
+	if err := archive.Export(bw, blockStore, stateStore, from, to); err != nil {
+		return fmt.Errorf("failed to export: %w", err)
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	logger.Info("Exported archive", "from", from, "to", to)
+	return nil
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	genDoc, err := types.GenesisDocFromFile(config.GenesisFile())
+	if err != nil {
+		return err
+	}
+
+	var in io.Reader = os.Stdin
+	if len(args) > 0 && args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	bdb, err := nm.DefaultDBProvider(&nm.DBContext{ID: "blockstore", Config: config})
+	if err != nil {
+		return err
+	}
+	defer bdb.Close()
+	sdb, err := nm.DefaultDBProvider(&nm.DBContext{ID: "state", Config: config})
+	if err != nil {
+		return err
+	}
+	defer sdb.Close()
+
+	height, err := archive.Import(bufio.NewReader(in), genDoc, store.NewBlockStore(bdb), sm.NewStore(sdb))
+	if err != nil {
+		return fmt.Errorf("failed to import after height %v: %w", height, err)
+	}
+	logger.Info("Imported archive", "height", height)
+	return nil
+}
@@ -0,0 +1,370 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	dbm "github.com/tendermint/tm-db"
+
+	tmjson "github.com/tendermint/tendermint/libs/json"
+	nm "github.com/tendermint/tendermint/node"
+	sm "github.com/tendermint/tendermint/state"
+	"github.com/tendermint/tendermint/store"
+)
+
+// dbNames are the databases of a node which can be inspected and compacted.
+var dbNames = []string{"blockstore", "state", "tx_index"}
+
+// DBCmd groups the commands which inspect, verify and compact the databases
+// of a stopped node.
+var DBCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Inspect, verify and compact the databases of a stopped node",
+	Long: `Inspect, verify and compact the block store, state and tx index databases
+of the node in the home directory. The node must be stopped, since most
+database backends can only be opened by one process at a time.`,
+}
+
+var dbInfoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Print the base, height and size of the block store and the latest state",
+	Args:  cobra.NoArgs,
+	RunE:  dbInfo,
+}
+
+var dbBlockCmd = &cobra.Command{
+	Use:   "block [height]",
+	Short: "Print the block at the given height as JSON",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return dumpFromBlockStore(args[0], func(bs *store.BlockStore, h int64) (interface{}, bool) {
+			block := bs.LoadBlock(h)
+			return block, block != nil
+		})
+	},
+}
+
+var dbCommitCmd = &cobra.Command{
+	Use:   "commit [height]",
+	Short: "Print the commit of the block at the given height as JSON",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return dumpFromBlockStore(args[0], func(bs *store.BlockStore, h int64) (interface{}, bool) {
+			// The canonical commit of a block is stored with the next block,
+			// fall back to the commit this node saw for the latest block.
+			commit := bs.LoadBlockCommit(h)
+			if commit == nil {
+				commit = bs.LoadSeenCommit(h)
+			}
+			return commit, commit != nil
+		})
+	},
+}
+
+var dbABCIResponsesCmd = &cobra.Command{
+	Use:   "abci_responses [height]",
+	Short: "Print the ABCI responses of the block at the given height as JSON",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return dumpFromStateStore(args[0], func(ss sm.Store, h int64) (interface{}, error) {
+			return ss.LoadABCIResponses(h)
+		})
+	},
+}
+
+var dbValidatorsCmd = &cobra.Command{
+	Use:   "validators [height]",
+	Short: "Print the validator set at the given height as JSON",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return dumpFromStateStore(args[0], func(ss sm.Store, h int64) (interface{}, error) {
+			return ss.LoadValidators(h)
+		})
+	},
+}
+
+var dbVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify the hash-linking of the stored chain and detect gaps",
+	Long: `Verify that the header of every stored block links to the previous block
+through its LastBlockID, LastCommitHash and LastResultsHash, and report the
+heights between the block store base and height which are missing. The
+LastResultsHash is only checked where the previous ABCI responses are stored.`,
+	Args: cobra.NoArgs,
+	RunE: dbVerify,
+}
+
+var dbCompactCmd = &cobra.Command{
+	Use:   "compact [database...]",
+	Short: "Compact the given databases, or all of them",
+	Long: `Compact the given databases (blockstore, state or tx_index), or all of them,
+to reclaim the disk space of pruned data. Only the goleveldb backend is
+supported.`,
+	RunE: dbCompact,
+}
+
+func init() {
+	DBCmd.AddCommand(
+		dbInfoCmd,
+		dbBlockCmd,
+		dbCommitCmd,
+		dbABCIResponsesCmd,
+		dbValidatorsCmd,
+		dbVerifyCmd,
+		dbCompactCmd,
+	)
+}
+
+// openDB opens an existing database of the node. Unlike the node, it refuses
+// to create a missing database, which would mean a wrong home directory.
+func openDB(name string) (dbm.DB, error) {
+	if _, err := os.Stat(filepath.Join(config.DBDir(), name+".db")); err != nil {
+		return nil, fmt.Errorf("database %q not found in %v: %w", name, config.DBDir(), err)
+	}
+	return nm.DefaultDBProvider(&nm.DBContext{ID: name, Config: config})
+}
+
+func openBlockStore() (*store.BlockStore, dbm.DB, error) {
+	db, err := openDB("blockstore")
+	if err != nil {
+		return nil, nil, err
+	}
+	return store.NewBlockStore(db), db, nil
+}
+
+func openStateStore() (sm.Store, dbm.DB, error) {
+	db, err := openDB("state")
+	if err != nil {
+		return nil, nil, err
+	}
+	return sm.NewStore(db), db, nil
+}
+
+func parseHeight(arg string) (int64, error) {
+	height, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || height <= 0 {
+		return 0, fmt.Errorf("invalid height %q", arg)
+	}
+	return height, nil
+}
+
+func printJSON(v interface{}) error {
+	bz, err := tmjson.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(bz))
+	return nil
+}
+
+func dumpFromBlockStore(arg string, load func(*store.BlockStore, int64) (interface{}, bool)) error {
+	height, err := parseHeight(arg)
+	if err != nil {
+		return err
+	}
+	blockStore, db, err := openBlockStore()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	v, ok := load(blockStore, height)
+	if !ok {
+		return fmt.Errorf("height %d not found, the block store has heights %d to %d",
+			height, blockStore.Base(), blockStore.Height())
+	}
+	return printJSON(v)
+}
+
+func dumpFromStateStore(arg string, load func(sm.Store, int64) (interface{}, error)) error {
+	height, err := parseHeight(arg)
+	if err != nil {
+		return err
+	}
+	stateStore, db, err := openStateStore()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	v, err := load(stateStore, height)
+	if err != nil {
+		return err
+	}
+	return printJSON(v)
+}
+
+func dbInfo(cmd *cobra.Command, args []string) error {
+	blockStore, bdb, err := openBlockStore()
+	if err != nil {
+		return err
+	}
+	defer bdb.Close()
+	stateStore, sdb, err := openStateStore()
+	if err != nil {
+		return err
+	}
+	defer sdb.Close()
+
+	state, err := stateStore.Load()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Block store base:   %d\n", blockStore.Base())
+	fmt.Printf("Block store height: %d\n", blockStore.Height())
+	fmt.Printf("Block store size:   %d\n", blockStore.Size())
+	if !state.IsEmpty() {
+		fmt.Printf("Chain ID:           %s\n", state.ChainID)
+		fmt.Printf("State height:       %d\n", state.LastBlockHeight)
+		fmt.Printf("State app hash:     %X\n", state.AppHash)
+	}
+	for _, name := range dbNames {
+		size, err := dirSize(filepath.Join(config.DBDir(), name+".db"))
+		if err != nil {
+			continue
+		}
+		fmt.Printf("%-19s %d bytes\n", name+":", size)
+	}
+	return nil
+}
+
+func dbVerify(cmd *cobra.Command, args []string) error {
+	blockStore, bdb, err := openBlockStore()
+	if err != nil {
+		return err
+	}
+	defer bdb.Close()
+	stateStore, sdb, err := openStateStore()
+	if err != nil {
+		return err
+	}
+	defer sdb.Close()
+
+	problems := verifyChain(blockStore, stateStore, os.Stdout)
+	if problems > 0 {
+		return fmt.Errorf("found %d problem(s) in heights %d to %d", problems, blockStore.Base(), blockStore.Height())
+	}
+	fmt.Printf("Verified heights %d to %d\n", blockStore.Base(), blockStore.Height())
+	return nil
+}
+
+// verifyChain checks the hash-linking of every stored block to the previous
+// one and reports the missing heights to out. It returns the number of
+// problems found.
+func verifyChain(blockStore *store.BlockStore, stateStore sm.Store, out io.Writer) int {
+	problems := 0
+	report := func(height int64, format string, args ...interface{}) {
+		problems++
+		fmt.Fprintf(out, "height %d: %s\n", height, fmt.Sprintf(format, args...))
+	}
+
+	var prev, gapStart int64
+	for h := blockStore.Base(); h > 0 && h <= blockStore.Height(); h++ {
+		meta := blockStore.LoadBlockMeta(h)
+		if meta == nil {
+			if gapStart == 0 {
+				gapStart = h
+			}
+			continue
+		}
+		if gapStart > 0 {
+			report(gapStart, "missing blocks up to height %d", h-1)
+			gapStart = 0
+		}
+		if meta.Header.Height != h {
+			report(h, "header has height %d", meta.Header.Height)
+		}
+		if !bytes.Equal(meta.BlockID.Hash, meta.Header.Hash()) {
+			report(h, "block ID hash %X does not match header hash %X", meta.BlockID.Hash, meta.Header.Hash())
+		}
+
+		// The first block, and blocks after a gap, can't be linked.
+		if prev > 0 && prev == h-1 {
+			prevMeta := blockStore.LoadBlockMeta(prev)
+			if !meta.Header.LastBlockID.Equals(prevMeta.BlockID) {
+				report(h, "last block ID %v does not match block ID %v of height %d",
+					meta.Header.LastBlockID, prevMeta.BlockID, prev)
+			}
+			if commit := blockStore.LoadBlockCommit(prev); commit == nil {
+				report(h, "missing commit of height %d", prev)
+			} else if !bytes.Equal(meta.Header.LastCommitHash, commit.Hash()) {
+				report(h, "last commit hash %X does not match commit hash %X of height %d",
+					meta.Header.LastCommitHash, commit.Hash(), prev)
+			}
+			abciResponses, err := stateStore.LoadABCIResponses(prev)
+			switch {
+			case errors.As(err, &sm.ErrNoABCIResponsesForHeight{}):
+			case err != nil:
+				report(h, "failed to load ABCI responses of height %d: %v", prev, err)
+			case !bytes.Equal(meta.Header.LastResultsHash, sm.ABCIResponsesResultsHash(abciResponses)):
+				report(h, "last results hash %X does not match results hash %X of height %d",
+					meta.Header.LastResultsHash, sm.ABCIResponsesResultsHash(abciResponses), prev)
+			}
+		}
+		prev = h
+	}
+	return problems
+}
+
+func dbCompact(cmd *cobra.Command, args []string) error {
+	names := args
+	if len(names) == 0 {
+		names = dbNames
+	}
+	for _, name := range names {
+		if err := compactDB(name); err != nil {
+			return fmt.Errorf("failed to compact %v: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func compactDB(name string) error {
+	db, err := openDB(name)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ldb, ok := db.(*dbm.GoLevelDB)
+	if !ok {
+		return fmt.Errorf("compaction is not supported by the %v backend", config.DBBackend)
+	}
+	dir := filepath.Join(config.DBDir(), name+".db")
+	before, err := dirSize(dir)
+	if err != nil {
+		return err
+	}
+	logger.Info("Compacting database", "db", name, "size", before)
+	if err := ldb.DB().CompactRange(util.Range{}); err != nil {
+		return err
+	}
+	after, err := dirSize(dir)
+	if err != nil {
+		return err
+	}
+	logger.Info("Compacted database", "db", name, "size", after, "reclaimed", before-after)
+	return nil
+}
+
+// dirSize returns the total size of the files in dir.
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
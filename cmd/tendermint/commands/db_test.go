@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	dbm "github.com/tendermint/tm-db"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto"
+	tmstate "github.com/tendermint/tendermint/proto/tendermint/state"
+	sm "github.com/tendermint/tendermint/state"
+	"github.com/tendermint/tendermint/store"
+	"github.com/tendermint/tendermint/types"
+)
+
+// makeVerifyStores makes a hash-linked chain of the given number of blocks
+// with their ABCI responses, and returns the stores and the block store DB.
+func makeVerifyStores(t *testing.T, height int64) (*store.BlockStore, sm.Store, dbm.DB) {
+	blockDB := dbm.NewMemDB()
+	blockStore := store.NewBlockStore(blockDB)
+	stateStore := sm.NewStore(dbm.NewMemDB())
+
+	var (
+		lastCommit    = types.NewCommit(0, 0, types.BlockID{}, nil)
+		lastBlockID   types.BlockID
+		lastResponses *tmstate.ABCIResponses
+	)
+	for h := int64(1); h <= height; h++ {
+		block := types.MakeBlock(h, []types.Tx{{byte(h)}}, lastCommit, nil)
+		block.LastBlockID = lastBlockID
+		block.ProposerAddress = make([]byte, crypto.AddressSize)
+		if lastResponses != nil {
+			block.LastResultsHash = sm.ABCIResponsesResultsHash(lastResponses)
+		}
+		parts := block.MakePartSet(types.BlockPartSizeBytes)
+		blockID := types.BlockID{Hash: block.Hash(), PartSetHeader: parts.Header()}
+		commit := types.NewCommit(h, 0, blockID, []types.CommitSig{types.NewCommitSigAbsent()})
+		blockStore.SaveBlock(block, parts, commit)
+
+		lastResponses = &tmstate.ABCIResponses{
+			DeliverTxs: []*abci.ResponseDeliverTx{{Data: []byte{byte(h)}}},
+		}
+		require.NoError(t, stateStore.SaveABCIResponses(h, lastResponses))
+		lastCommit, lastBlockID = commit, blockID
+	}
+	return blockStore, stateStore, blockDB
+}
+
+func TestVerifyChain(t *testing.T) {
+	blockStore, stateStore, _ := makeVerifyStores(t, 10)
+	out := &bytes.Buffer{}
+	assert.Zero(t, verifyChain(blockStore, stateStore, out), out.String())
+}
+
+func TestVerifyChain_Gap(t *testing.T) {
+	blockStore, stateStore, blockDB := makeVerifyStores(t, 10)
+	require.NoError(t, blockDB.Delete([]byte("H:4")))
+	require.NoError(t, blockDB.Delete([]byte("H:5")))
+
+	out := &bytes.Buffer{}
+	assert.Equal(t, 1, verifyChain(blockStore, stateStore, out))
+	assert.Contains(t, out.String(), "height 4: missing blocks up to height 5")
+}
+
+func TestVerifyChain_LastResultsHash(t *testing.T) {
+	blockStore, stateStore, _ := makeVerifyStores(t, 10)
+	require.NoError(t, stateStore.SaveABCIResponses(6, &tmstate.ABCIResponses{
+		DeliverTxs: []*abci.ResponseDeliverTx{{Data: []byte("bad")}},
+	}))
+
+	out := &bytes.Buffer{}
+	assert.Equal(t, 1, verifyChain(blockStore, stateStore, out))
+	assert.Contains(t, out.String(), "height 7: last results hash")
+}
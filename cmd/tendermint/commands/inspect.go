@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tendermint/tendermint/inspect"
+	tmos "github.com/tendermint/tendermint/libs/os"
+)
+
+// InspectCmd serves the read-only RPC routes from the data of a stopped node.
+var InspectCmd = &cobra.Command{
+	Use:   "inspect",
+	Short: "Serve the read-only RPC routes from the data of a stopped node",
+	Long: `Serve the read-only RPC routes (blockchain, block, block_by_hash,
+block_results, commit, validators, tx, tx_search, consensus_params and genesis)
+from the block store, state store and tx index of the node in the home
+directory, without starting consensus, p2p or the ABCI application. This helps
+to debug a node which can't be started, e.g. after an app hash mismatch.
+
+The node must be stopped. The goleveldb databases are opened read-only.`,
+	Args: cobra.NoArgs,
+	RunE: runInspect,
+}
+
+func init() {
+	InspectCmd.Flags().String("rpc.laddr", config.RPC.ListenAddress, "RPC listen address. Port required")
+	InspectCmd.Flags().String("db_backend", config.DBBackend,
+		"Database backend: goleveldb | cleveldb | boltdb | rocksdb | badgerdb")
+	InspectCmd.Flags().String("db_dir", config.DBPath, "Database directory")
+}
+
+func runInspect(cmd *cobra.Command, args []string) error {
+	ins, err := inspect.NewFromConfig(config, logger.With("module", "inspect"))
+	if err != nil {
+		return fmt.Errorf("failed to create inspector: %w", err)
+	}
+	if err := ins.Start(); err != nil {
+		return fmt.Errorf("failed to start inspector: %w", err)
+	}
+
+	// Stop upon receiving SIGTERM or CTRL-C.
+	tmos.TrapSignal(logger, func() {
+		if err := ins.Stop(); err != nil {
+			logger.Error("unable to stop the inspector", "error", err)
+		}
+	})
+
+	// Run forever.
+	select {}
+}
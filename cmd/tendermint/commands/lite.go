@@ -16,6 +16,8 @@ import (
 	tmmath "github.com/tendermint/tendermint/libs/math"
 	tmos "github.com/tendermint/tendermint/libs/os"
 	"github.com/tendermint/tendermint/light"
+	"github.com/tendermint/tendermint/light/provider"
+	httpp "github.com/tendermint/tendermint/light/provider/http"
 	lproxy "github.com/tendermint/tendermint/light/proxy"
 	lrpc "github.com/tendermint/tendermint/light/rpc"
 	dbs "github.com/tendermint/tendermint/light/store/db"
@@ -38,6 +40,13 @@ need a primary RPC address, a trusted hash and height and witness RPC addresses
 (if not using sequential verification). To restart the node, thereafter
 only the chainID is required. 
 
+With --update-period, the light client follows the head of the chain in the
+background instead of only verifying the heights requested through the proxy,
+and warns when the trusting period of its latest trusted header is about to
+end. Spare witnesses (--spare-witnesses) replace the witnesses which are
+removed for sending invalid headers or promoted to primary. The state of the
+light client is served by the light_status route of the proxy.
+
 `,
 	RunE: runProxy,
 	Args: cobra.ExactArgs(1),
@@ -49,6 +58,7 @@ var (
 	listenAddr         string
 	primaryAddr        string
 	witnessAddrsJoined string
+	spareAddrsJoined   string
 	chainID            string
 	home               string
 	maxOpenConnections int
@@ -59,6 +69,9 @@ var (
 	trustedHash    []byte
 	trustLevelStr  string
 
+	updatePeriod      time.Duration
+	expirationWarning time.Duration
+
 	verbose bool
 
 	primaryKey   = []byte("primary")
@@ -72,6 +85,8 @@ func init() {
 		"Connect to a Tendermint node at this address")
 	LightCmd.Flags().StringVarP(&witnessAddrsJoined, "witnesses", "w", "",
 		"Tendermint nodes to cross-check the primary node, comma-separated")
+	LightCmd.Flags().StringVar(&spareAddrsJoined, "spare-witnesses", "",
+		"Tendermint nodes to replace the witnesses which are removed or promoted to primary, comma-separated")
 	LightCmd.Flags().StringVar(&home, "home-dir", ".tendermint-light", "Specify the home directory")
 	LightCmd.Flags().IntVar(
 		&maxOpenConnections,
@@ -89,6 +104,11 @@ func init() {
 	LightCmd.Flags().BoolVar(&sequential, "sequential", false,
 		"Sequential Verification. Verify all headers sequentially as opposed to using skipping verification",
 	)
+	LightCmd.Flags().DurationVar(&updatePeriod, "update-period", 0,
+		"Follow the head of the chain, verifying the latest header at this interval (0 - only verify requested headers)")
+	LightCmd.Flags().DurationVar(&expirationWarning, "expiration-warning", 0,
+		"Warn when the trusting period of the latest trusted header ends within this duration"+
+			" (0 - a third of the trusting period)")
 }
 
 func runProxy(cmd *cobra.Command, args []string) error {
@@ -137,7 +157,21 @@ func runProxy(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("can't parse trust level: %w", err)
 	}
 
-	options := []light.Option{light.Logger(logger)}
+	options := []light.Option{light.Logger(logger), light.UpdatePeriod(updatePeriod)}
+	if expirationWarning > 0 {
+		options = append(options, light.ExpirationWarning(expirationWarning))
+	}
+	if spareAddrsJoined != "" {
+		spares := make([]provider.Provider, 0)
+		for _, addr := range strings.Split(spareAddrsJoined, ",") {
+			p, err := httpp.New(chainID, addr)
+			if err != nil {
+				return fmt.Errorf("spare witness %s: %w", addr, err)
+			}
+			spares = append(spares, p)
+		}
+		options = append(options, light.WitnessPool(spares))
+	}
 
 	if sequential {
 		options = append(options, light.SequentialVerification())
@@ -145,6 +179,11 @@ func runProxy(cmd *cobra.Command, args []string) error {
 		options = append(options, light.SkippingVerification(trustLevel))
 	}
 
+	lightStore, err := dbs.New(db, chainID)
+	if err != nil {
+		return fmt.Errorf("can't open the light store: %w", err)
+	}
+
 	var c *light.Client
 	if trustedHeight > 0 && len(trustedHash) > 0 { // fresh installation
 		c, err = light.NewHTTPClient(
@@ -156,7 +195,7 @@ func runProxy(cmd *cobra.Command, args []string) error {
 			},
 			primaryAddr,
 			witnessesAddrs,
-			dbs.New(db, chainID),
+			lightStore,
 			options...,
 		)
 	} else { // continue from latest state
@@ -165,13 +204,17 @@ func runProxy(cmd *cobra.Command, args []string) error {
 			trustingPeriod,
 			primaryAddr,
 			witnessesAddrs,
-			dbs.New(db, chainID),
+			lightStore,
 			options...,
 		)
 	}
 	if err != nil {
 		return err
 	}
+	if err := c.Start(); err != nil {
+		return fmt.Errorf("can't start light client: %w", err)
+	}
+	defer c.Stop()
 
 	rpcClient, err := rpchttp.New(primaryAddr, "/websocket")
 	if err != nil {
@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tendermint/tendermint/consensus"
+	"github.com/tendermint/tendermint/proxy"
+	"github.com/tendermint/tendermint/types"
+)
+
+var (
+	replayAppFrom int64
+	replayAppTo   int64
+)
+
+// ReplayAppCmd re-executes the blocks of a stopped node against its
+// application.
+var ReplayAppCmd = &cobra.Command{
+	Use:     "replay_app",
+	Aliases: []string{"replay-app"},
+	Short:   "Re-execute stored blocks against the application",
+	Long: `Re-execute the blocks stored by the stopped node in the home directory
+against its application, and compare the app hash and results of each block with
+the recorded ones, stopping at the first mismatch. The node's databases aren't
+modified.
+
+The application isn't reset: ABCI has no request for it. It must either be
+fresh, e.g. wiped beforehand, to replay from the initial height, or be at the
+height before --from. The command fails otherwise.
+
+This can be used to reproduce non-determinism bugs of the application, or to
+rebuild its state after a schema migration without syncing from the network.`,
+	Args: cobra.NoArgs,
+	RunE: runReplayApp,
+}
+
+func init() {
+	ReplayAppCmd.Flags().Int64Var(&replayAppFrom, "from", 0,
+		"First height to replay (default: the height after the application's last block)")
+	ReplayAppCmd.Flags().Int64Var(&replayAppTo, "to", 0, "Last height to replay (default: the latest height)")
+	ReplayAppCmd.Flags().String("proxy_app", config.ProxyApp,
+		"Proxy app address, or one of: 'kvstore', 'persistent_kvstore', 'counter', 'counter_serial' or 'noop'")
+}
+
+func runReplayApp(cmd *cobra.Command, args []string) error {
+	genDoc, err := types.GenesisDocFromFile(config.GenesisFile())
+	if err != nil {
+		return err
+	}
+	blockStore, bdb, err := openBlockStore()
+	if err != nil {
+		return err
+	}
+	defer bdb.Close()
+	stateStore, sdb, err := openStateStore()
+	if err != nil {
+		return err
+	}
+	defer sdb.Close()
+
+	proxyApp := proxy.NewAppConns(proxy.DefaultClientCreator(config.ProxyApp, config.ABCI, config.DBDir()),
+		proxy.NopMetrics())
+	proxyApp.SetLogger(logger.With("module", "proxy"))
+	if err := proxyApp.Start(); err != nil {
+		return fmt.Errorf("error starting proxy app connections: %v", err)
+	}
+	defer func() {
+		if err := proxyApp.Stop(); err != nil {
+			logger.Error("Error stopping proxy app connections", "err", err)
+		}
+	}()
+
+	from, to := replayAppFrom, replayAppTo
+	if from == 0 {
+		res, err := proxyApp.Query().InfoSync(proxy.RequestInfo)
+		if err != nil {
+			return fmt.Errorf("error calling Info: %v", err)
+		}
+		from = res.LastBlockHeight + 1
+		if res.LastBlockHeight == 0 {
+			from = genDoc.InitialHeight
+		}
+	}
+	if to == 0 {
+		to = blockStore.Height()
+	}
+
+	if err := consensus.ReplayApp(stateStore, blockStore, genDoc, proxyApp, from, to, logger); err != nil {
+		return fmt.Errorf("failed to replay: %w", err)
+	}
+	logger.Info("Replayed blocks", "from", from, "to", to)
+	return nil
+}
@@ -6,10 +6,15 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
 	cfg "github.com/tendermint/tendermint/config"
+	tmflags "github.com/tendermint/tendermint/libs/cli/flags"
+	"github.com/tendermint/tendermint/libs/log"
 	tmos "github.com/tendermint/tendermint/libs/os"
 	nm "github.com/tendermint/tendermint/node"
 )
@@ -117,6 +122,9 @@ func NewRunNodeCmd(nodeProvider nm.Provider) *cobra.Command {
 
 			logger.Info("Started node", "nodeInfo", n.Switch().NodeInfo())
 
+			// Reload the log level from the config file upon receiving SIGHUP.
+			trapLogLevelReload(logger)
+
 			// Stop upon receiving SIGTERM or CTRL-C.
 			tmos.TrapSignal(logger, func() {
 				if n.IsRunning() {
@@ -135,6 +143,29 @@ func NewRunNodeCmd(nodeProvider nm.Provider) *cobra.Command {
 	return cmd
 }
 
+// trapLogLevelReload re-reads the config file and sets the log level of the
+// logger to its log_level every time the process receives SIGHUP. A log level
+// set with the --log_level flag or the TM_LOG_LEVEL environment variable takes
+// precedence over the config file.
+func trapLogLevelReload(logger log.Logger) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+	go func() {
+		for range c {
+			if err := viper.ReadInConfig(); err != nil {
+				logger.Error("Failed to reload the config file", "err", err)
+				continue
+			}
+			level := viper.GetString("log_level")
+			if err := tmflags.SetLogLevel(level, logger, cfg.DefaultLogLevel()); err != nil {
+				logger.Error("Failed to set the log level", "level", level, "err", err)
+				continue
+			}
+			logger.Info("Reloaded the log level", "level", level)
+		}
+	}()
+}
+
 func checkGenesisHash(config *cfg.Config) error {
 	if len(genesisHash) == 0 || config.Genesis == "" {
 		return nil
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -11,7 +12,9 @@ import (
 	"github.com/spf13/viper"
 
 	cfg "github.com/tendermint/tendermint/config"
+	"github.com/tendermint/tendermint/crypto/ed25519"
 	"github.com/tendermint/tendermint/libs/bytes"
+	tmos "github.com/tendermint/tendermint/libs/os"
 	tmrand "github.com/tendermint/tendermint/libs/rand"
 	"github.com/tendermint/tendermint/p2p"
 	"github.com/tendermint/tendermint/privval"
@@ -34,10 +37,20 @@ var (
 	hostnames               []string
 	p2pPort                 int
 	randomMonikers          bool
+
+	validatorPowers      []int
+	sentriesPerValidator int
+	proxyApp             string
+	localnet             string
+	dockerImage          string
+	portOffset           int
 )
 
 const (
 	nodeDirPerm = 0755
+
+	localnetDocker  = "docker"
+	localnetProcess = "process"
 )
 
 func init() {
@@ -74,6 +87,22 @@ func init() {
 		"P2P Port")
 	TestnetFilesCmd.Flags().BoolVar(&randomMonikers, "random-monikers", false,
 		"Randomize the moniker for each generated node")
+
+	TestnetFilesCmd.Flags().IntSliceVar(&validatorPowers, "power", []int{},
+		"Voting power of the validators, either one value for all of them or one value per validator")
+	TestnetFilesCmd.Flags().IntVar(&sentriesPerValidator, "sentries", 0,
+		"Number of sentry nodes to put in front of each validator")
+	TestnetFilesCmd.Flags().StringVar(&proxyApp, "proxy-app", "",
+		"Proxy app of every node: the name of a built-in app (e.g. kvstore) or the address of an external ABCI app"+
+			" (with --localnet process, each node defaults to its own app address on localhost)")
+	TestnetFilesCmd.Flags().StringVar(&localnet, "localnet", "",
+		"Also generate a runnable local network: \"docker\" writes a docker-compose.yml,"+
+			" \"process\" writes a start.sh running every node on localhost with distinct ports")
+	TestnetFilesCmd.Flags().StringVar(&dockerImage, "docker-image", "tendermint/tendermint",
+		"Docker image used by the generated docker-compose.yml")
+	TestnetFilesCmd.Flags().IntVar(&portOffset, "port-offset", 10,
+		"Difference between the ports of two consecutive nodes (process localnet only)."+
+			" Each node uses 4 ports: p2p, rpc, prometheus and abci")
 }
 
 // TestnetFilesCmd allows initialisation of files for a Tendermint testnet.
@@ -87,20 +116,33 @@ Note, strict routability for addresses is turned off in the config file.
 
 Optionally, it will fill in persistent_peers list in config file using either hostnames or IPs.
 
+With --sentries, each validator only connects to its own sentry nodes, which
+keep the validator's ID private. Non-validators connect to the sentries.
+
+With --localnet, it also writes a definition of a runnable local network:
+either a docker-compose.yml or a start.sh that runs every node as a process on
+localhost with distinct ports.
+
 Example:
 
 	tendermint testnet --v 4 --o ./output --populate-persistent-peers --starting-ip-address 192.168.10.2
+	tendermint testnet --v 4 --power 10,10,5,1 --proxy-app kvstore --localnet process
 	`,
 	RunE: testnetFiles,
 }
 
 func testnetFiles(cmd *cobra.Command, args []string) error {
-	if len(hostnames) > 0 && len(hostnames) != (nValidators+nNonValidators) {
+	nSentries := nValidators * sentriesPerValidator
+	nNodes := nValidators + nNonValidators + nSentries
+	if len(hostnames) > 0 && len(hostnames) != nNodes {
 		return fmt.Errorf(
-			"testnet needs precisely %d hostnames (number of validators plus non-validators) if --hostname parameter is used",
-			nValidators+nNonValidators,
+			"testnet needs precisely %d hostnames (number of validators, non-validators and sentries) if --hostname parameter is used",
+			nNodes,
 		)
 	}
+	if err := validateTestnetFlags(); err != nil {
+		return err
+	}
 
 	config := cfg.DefaultConfig()
 
@@ -136,12 +178,16 @@ func testnetFiles(cmd *cobra.Command, args []string) error {
 			return err
 		}
 
+		pvKeyFile := filepath.Join(nodeDir, config.BaseConfig.PrivValidatorKey)
+		pvStateFile := filepath.Join(nodeDir, config.BaseConfig.PrivValidatorState)
+		if !tmos.FileExists(pvKeyFile) {
+			privval.NewFilePV(ed25519.GenPrivKey(), pvKeyFile, pvStateFile).Save()
+		}
+
 		if err := initFilesWithConfig(config); err != nil {
 			return err
 		}
 
-		pvKeyFile := filepath.Join(nodeDir, config.BaseConfig.PrivValidatorKey)
-		pvStateFile := filepath.Join(nodeDir, config.BaseConfig.PrivValidatorState)
 		pv := privval.LoadFilePV(pvKeyFile, pvStateFile)
 
 		pubKey, err := pv.GetPubKey()
@@ -151,13 +197,13 @@ func testnetFiles(cmd *cobra.Command, args []string) error {
 		genVals[i] = types.GenesisValidator{
 			Address: pubKey.Address(),
 			PubKey:  pubKey,
-			Power:   1,
+			Power:   validatorPower(i),
 			Name:    nodeDirName,
 		}
 	}
 
-	for i := 0; i < nNonValidators; i++ {
-		nodeDir := filepath.Join(outputDir, fmt.Sprintf("%s%d", nodeDirPrefix, i+nValidators))
+	for i := nValidators; i < nNodes; i++ {
+		nodeDir := filepath.Join(outputDir, fmt.Sprintf("%s%d", nodeDirPrefix, i))
 		config.SetRoot(nodeDir)
 
 		err := os.MkdirAll(filepath.Join(nodeDir, "config"), nodeDirPerm)
@@ -187,7 +233,7 @@ func testnetFiles(cmd *cobra.Command, args []string) error {
 	}
 
 	// Write genesis file.
-	for i := 0; i < nValidators+nNonValidators; i++ {
+	for i := 0; i < nNodes; i++ {
 		nodeDir := filepath.Join(outputDir, fmt.Sprintf("%s%d", nodeDirPrefix, i))
 		if err := genDoc.SaveAs(filepath.Join(nodeDir, config.BaseConfig.Genesis)); err != nil {
 			_ = os.RemoveAll(outputDir)
@@ -195,38 +241,141 @@ func testnetFiles(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Gather persistent peer addresses.
-	var (
-		persistentPeers string
-		err             error
-	)
-	if populatePersistentPeers {
-		persistentPeers, err = persistentPeersString(config)
-		if err != nil {
-			_ = os.RemoveAll(outputDir)
-			return err
-		}
+	// Gather peer IDs and addresses.
+	ids, addrs, err := nodeAddresses(config, nNodes)
+	if err != nil {
+		_ = os.RemoveAll(outputDir)
+		return err
 	}
 
 	// Overwrite default config.
-	for i := 0; i < nValidators+nNonValidators; i++ {
+	pex, privatePeerIDs := config.P2P.PexReactor, config.P2P.PrivatePeerIDs
+	for i := 0; i < nNodes; i++ {
 		nodeDir := filepath.Join(outputDir, fmt.Sprintf("%s%d", nodeDirPrefix, i))
 		config.SetRoot(nodeDir)
 		config.P2P.AddrBookStrict = false
 		config.P2P.AllowDuplicateIP = true
 		if populatePersistentPeers {
-			config.P2P.PersistentPeers = persistentPeers
+			config.P2P.PersistentPeers = strings.Join(persistentPeers(i, addrs), ",")
+		}
+		config.P2P.PexReactor = pex
+		config.P2P.PrivatePeerIDs = privatePeerIDs
+		if sentriesPerValidator > 0 {
+			if i < nValidators {
+				// validators only talk to their sentries
+				config.P2P.PexReactor = false
+			} else if v, ok := sentryOf(i); ok {
+				config.P2P.PrivatePeerIDs = string(ids[v])
+			}
+		}
+		if proxyApp != "" {
+			config.ProxyApp = proxyApp
+		}
+		switch localnet {
+		case localnetDocker:
+			config.RPC.ListenAddress = "tcp://0.0.0.0:26657"
+		case localnetProcess:
+			config.P2P.ListenAddress = fmt.Sprintf("tcp://127.0.0.1:%d", nodeP2PPort(i))
+			config.RPC.ListenAddress = fmt.Sprintf("tcp://127.0.0.1:%d", nodeP2PPort(i)+1)
+			config.Instrumentation.PrometheusListenAddr = fmt.Sprintf("127.0.0.1:%d", nodeP2PPort(i)+2)
+			if proxyApp == "" {
+				// every node runs against its own app, not the shared default address
+				config.ProxyApp = fmt.Sprintf("tcp://127.0.0.1:%d", nodeP2PPort(i)+3)
+			}
 		}
 		config.Moniker = moniker(i)
 
 		cfg.WriteConfigFile(filepath.Join(nodeDir, "config", "config.toml"), config)
 	}
 
-	fmt.Printf("Successfully initialized %v node directories\n", nValidators+nNonValidators)
+	switch localnet {
+	case localnetDocker:
+		if err := writeDockerCompose(nNodes); err != nil {
+			return err
+		}
+	case localnetProcess:
+		if err := writeStartScript(nNodes); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Successfully initialized %v node directories\n", nNodes)
 	return nil
 }
 
+func validateTestnetFlags() error {
+	if len(validatorPowers) > 1 && len(validatorPowers) != nValidators {
+		return fmt.Errorf("--power needs either one value or precisely %d values (number of validators)", nValidators)
+	}
+	for _, p := range validatorPowers {
+		if p <= 0 {
+			return fmt.Errorf("validator power must be positive, got %d", p)
+		}
+	}
+	if sentriesPerValidator < 0 {
+		return errors.New("--sentries can't be negative")
+	}
+	switch localnet {
+	case "", localnetDocker:
+	case localnetProcess:
+		if portOffset < 4 {
+			return errors.New("--port-offset must be at least 4 (p2p, rpc, prometheus and abci ports)")
+		}
+	default:
+		return fmt.Errorf("unknown localnet %q, must be %q or %q", localnet, localnetDocker, localnetProcess)
+	}
+	return nil
+}
+
+func validatorPower(i int) int64 {
+	switch len(validatorPowers) {
+	case 0:
+		return 1
+	case 1:
+		return int64(validatorPowers[0])
+	}
+	return int64(validatorPowers[i])
+}
+
+// sentryOf returns the index of the validator the i-th node is a sentry of.
+// Sentries are placed after the validators and non-validators.
+func sentryOf(i int) (int, bool) {
+	first := nValidators + nNonValidators
+	if sentriesPerValidator == 0 || i < first {
+		return 0, false
+	}
+	return (i - first) / sentriesPerValidator, true
+}
+
+// persistentPeers returns the addresses the i-th node should connect to.
+// Without sentries every node connects to every node. With sentries,
+// validators only connect to their own sentries, sentries connect to their
+// validator and to each other, and non-validators connect to the sentries.
+func persistentPeers(i int, addrs []string) []string {
+	if sentriesPerValidator == 0 {
+		return addrs
+	}
+	first := nValidators + nNonValidators
+	sentries := addrs[first:]
+	switch v, isSentry := sentryOf(i); {
+	case i < nValidators:
+		return sentries[i*sentriesPerValidator : (i+1)*sentriesPerValidator]
+	case isSentry:
+		peers := []string{addrs[v]}
+		for j, addr := range sentries {
+			if first+j != i {
+				peers = append(peers, addr)
+			}
+		}
+		return peers
+	}
+	return sentries
+}
+
 func hostnameOrIP(i int) string {
+	if localnet == localnetProcess {
+		return "127.0.0.1"
+	}
 	if len(hostnames) > 0 && i < len(hostnames) {
 		return hostnames[i]
 	}
@@ -246,18 +395,27 @@ func hostnameOrIP(i int) string {
 	return ip.String()
 }
 
-func persistentPeersString(config *cfg.Config) (string, error) {
-	persistentPeers := make([]string, nValidators+nNonValidators)
-	for i := 0; i < nValidators+nNonValidators; i++ {
+func nodeP2PPort(i int) int {
+	if localnet == localnetProcess {
+		return p2pPort + i*portOffset
+	}
+	return p2pPort
+}
+
+func nodeAddresses(config *cfg.Config, nNodes int) ([]p2p.ID, []string, error) {
+	ids := make([]p2p.ID, nNodes)
+	addrs := make([]string, nNodes)
+	for i := 0; i < nNodes; i++ {
 		nodeDir := filepath.Join(outputDir, fmt.Sprintf("%s%d", nodeDirPrefix, i))
 		config.SetRoot(nodeDir)
 		nodeKey, err := p2p.LoadNodeKey(config.NodeKeyFile())
 		if err != nil {
-			return "", err
+			return nil, nil, err
 		}
-		persistentPeers[i] = p2p.IDAddressString(nodeKey.ID(), fmt.Sprintf("%s:%d", hostnameOrIP(i), p2pPort))
+		ids[i] = nodeKey.ID()
+		addrs[i] = p2p.IDAddressString(nodeKey.ID(), fmt.Sprintf("%s:%d", hostnameOrIP(i), nodeP2PPort(i)))
 	}
-	return strings.Join(persistentPeers, ","), nil
+	return ids, addrs, nil
 }
 
 func moniker(i int) string {
@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+const (
+	dockerComposeFile = "docker-compose.yml"
+	startScriptFile   = "start.sh"
+)
+
+type localnetNode struct {
+	Name    string
+	Dir     string
+	IP      string
+	RPCPort int
+	// ABCI address the node connects to, if it's not a built-in app
+	ProxyApp string
+}
+
+var dockerComposeTemplate = template.Must(template.New("docker-compose").Parse(`version: '3'
+
+services:
+{{- range .Nodes}}
+  {{.Name}}:
+    container_name: {{.Name}}
+    image: "{{$.Image}}"
+    command: node
+    ports:
+      - "{{.RPCPort}}:26657"
+    volumes:
+      - ./{{.Dir}}:/tendermint:Z
+    networks:
+      localnet:{{if .IP}}
+        ipv4_address: {{.IP}}{{end}}
+{{- end}}
+
+networks:
+  localnet:
+    driver: bridge
+{{- if .Subnet}}
+    ipam:
+      driver: default
+      config:
+      -
+        subnet: {{.Subnet}}
+{{- end}}
+`))
+
+var startScriptTemplate = template.Must(template.New("start").Parse(`#!/usr/bin/env sh
+# Starts every node of the local testnet and stops them all on exit.
+# Set BINARY to use another tendermint binary than the one in PATH.
+BINARY=${BINARY:-tendermint}
+DIR=$(cd "$(dirname "$0")" && pwd)
+
+trap 'kill $(jobs -p) 2>/dev/null' INT TERM EXIT
+{{range .Nodes}}
+echo "starting {{.Name}} (rpc on 127.0.0.1:{{.RPCPort}}{{if .ProxyApp}}, app expected on {{.ProxyApp}}{{end}})"
+"$BINARY" node --home "$DIR/{{.Dir}}" > "$DIR/{{.Dir}}/tendermint.log" 2>&1 &
+{{- end}}
+
+wait
+`))
+
+// writeDockerCompose writes a docker-compose.yml running every node in its
+// own container. The RPC port of the i-th node is published on 26657+i.
+func writeDockerCompose(nNodes int) error {
+	nodes := make([]localnetNode, nNodes)
+	subnet := ""
+	for i := 0; i < nNodes; i++ {
+		nodes[i] = localnetNode{
+			Name:    hostnameOrIP(i),
+			Dir:     fmt.Sprintf("%s%d", nodeDirPrefix, i),
+			RPCPort: 26657 + i,
+		}
+		if startingIPAddress != "" {
+			nodes[i].Name = nodes[i].Dir
+			nodes[i].IP = hostnameOrIP(i)
+		}
+	}
+	if startingIPAddress != "" {
+		ip := net.ParseIP(startingIPAddress).To4()
+		subnet = (&net.IPNet{IP: ip.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+
+	return writeTemplate(filepath.Join(outputDir, dockerComposeFile), 0644, dockerComposeTemplate, map[string]interface{}{
+		"Image":  dockerImage,
+		"Nodes":  nodes,
+		"Subnet": subnet,
+	})
+}
+
+// writeStartScript writes a script running every node as a local process.
+func writeStartScript(nNodes int) error {
+	nodes := make([]localnetNode, nNodes)
+	for i := 0; i < nNodes; i++ {
+		nodes[i] = localnetNode{
+			Name:    moniker(i),
+			Dir:     fmt.Sprintf("%s%d", nodeDirPrefix, i),
+			RPCPort: nodeP2PPort(i) + 1,
+		}
+		if proxyApp == "" {
+			nodes[i].ProxyApp = fmt.Sprintf("tcp://127.0.0.1:%d", nodeP2PPort(i)+3)
+		}
+	}
+
+	return writeTemplate(filepath.Join(outputDir, startScriptFile), 0755, startScriptTemplate, map[string]interface{}{
+		"Nodes": nodes,
+	})
+}
+
+func writeTemplate(path string, perm os.FileMode, tmpl *template.Template, data interface{}) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return tmpl.Execute(f, data)
+}
@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	cfg "github.com/tendermint/tendermint/config"
+	"github.com/tendermint/tendermint/types"
+)
+
+func runTestnet(t *testing.T, args ...string) string {
+	dir, err := ioutil.TempDir("", "testnet")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	resetPowerFlag()
+	require.NoError(t, TestnetFilesCmd.Flags().Parse(append([]string{"--o", dir}, args...)))
+	require.NoError(t, testnetFiles(TestnetFilesCmd, nil))
+	return dir
+}
+
+// resetPowerFlag resets --power, since slice flags append to the values of
+// a previous Parse instead of replacing them.
+func resetPowerFlag() {
+	fresh := &cobra.Command{}
+	fresh.Flags().IntSliceVar(&validatorPowers, "power", []int{}, "")
+	TestnetFilesCmd.Flags().Lookup("power").Value = fresh.Flags().Lookup("power").Value
+}
+
+func loadTestnetConfig(t *testing.T, dir string, i int) *cfg.Config {
+	v := viper.New()
+	v.SetConfigFile(filepath.Join(dir, fmt.Sprintf("node%d", i), "config", "config.toml"))
+	require.NoError(t, v.ReadInConfig())
+	conf := cfg.DefaultConfig()
+	require.NoError(t, v.Unmarshal(conf))
+	return conf
+}
+
+func TestTestnetProcessLocalnetWithSentries(t *testing.T) {
+	dir := runTestnet(t, "--v", "2", "--n", "0", "--sentries", "2", "--power", "10,5",
+		"--proxy-app", "kvstore", "--localnet", "process")
+
+	genDoc, err := types.GenesisDocFromFile(filepath.Join(dir, "node0", "config", "genesis.json"))
+	require.NoError(t, err)
+	require.Len(t, genDoc.Validators, 2)
+	assert.EqualValues(t, 10, genDoc.Validators[0].Power)
+	assert.EqualValues(t, 5, genDoc.Validators[1].Power)
+
+	// node0 is a validator with sentries node2 and node3
+	val := loadTestnetConfig(t, dir, 0)
+	assert.False(t, val.P2P.PexReactor)
+	assert.Equal(t, "kvstore", val.ProxyApp)
+	peers := strings.Split(val.P2P.PersistentPeers, ",")
+	require.Len(t, peers, 2)
+	assert.Contains(t, peers[0], "127.0.0.1:26676")
+	assert.Contains(t, peers[1], "127.0.0.1:26686")
+
+	sentry := loadTestnetConfig(t, dir, 2)
+	assert.True(t, sentry.P2P.PexReactor)
+	assert.NotEmpty(t, sentry.P2P.PrivatePeerIDs)
+	assert.Contains(t, sentry.P2P.PersistentPeers, sentry.P2P.PrivatePeerIDs+"@127.0.0.1:26656")
+	assert.Len(t, strings.Split(sentry.P2P.PersistentPeers, ","), 4)
+
+	script, err := ioutil.ReadFile(filepath.Join(dir, startScriptFile))
+	require.NoError(t, err)
+	for _, node := range []string{"node0", "node1", "node2", "node3", "node4", "node5"} {
+		assert.Contains(t, string(script), `--home "$DIR/`+node+`"`)
+	}
+}
+
+func TestTestnetProcessLocalnetOwnApps(t *testing.T) {
+	dir := runTestnet(t, "--v", "2", "--n", "1", "--sentries", "0", "--power", "1",
+		"--proxy-app", "", "--localnet", "process")
+
+	// without --proxy-app, every node gets its own app address
+	for i, addr := range []string{"tcp://127.0.0.1:26659", "tcp://127.0.0.1:26669", "tcp://127.0.0.1:26679"} {
+		assert.Equal(t, addr, loadTestnetConfig(t, dir, i).ProxyApp)
+	}
+
+	script, err := ioutil.ReadFile(filepath.Join(dir, startScriptFile))
+	require.NoError(t, err)
+	assert.Contains(t, string(script), "app expected on tcp://127.0.0.1:26679")
+}
+
+func TestTestnetDockerLocalnet(t *testing.T) {
+	dir := runTestnet(t, "--v", "3", "--n", "1", "--sentries", "0", "--power", "1",
+		"--localnet", "docker", "--starting-ip-address", "192.167.10.2")
+
+	compose, err := ioutil.ReadFile(filepath.Join(dir, dockerComposeFile))
+	require.NoError(t, err)
+	assert.Contains(t, string(compose), "ipv4_address: 192.167.10.5")
+	assert.Contains(t, string(compose), "subnet: 192.167.10.0/24")
+	assert.Contains(t, string(compose), "./node3:/tendermint:Z")
+
+	peers := strings.Split(loadTestnetConfig(t, dir, 3).P2P.PersistentPeers, ",")
+	assert.Len(t, peers, 4)
+}
+
+func TestTestnetInvalidFlags(t *testing.T) {
+	resetPowerFlag()
+	require.NoError(t, TestnetFilesCmd.Flags().Parse([]string{"--v", "3", "--power", "1,2"}))
+	assert.Error(t, testnetFiles(TestnetFilesCmd, nil))
+	require.NoError(t, TestnetFilesCmd.Flags().Parse([]string{"--power", "1", "--localnet", "kubernetes"}))
+	assert.Error(t, testnetFiles(TestnetFilesCmd, nil))
+	require.NoError(t, TestnetFilesCmd.Flags().Parse([]string{"--localnet", "process", "--port-offset", "3"}))
+	assert.Error(t, testnetFiles(TestnetFilesCmd, nil))
+	require.NoError(t, TestnetFilesCmd.Flags().Parse([]string{"--localnet", "", "--port-offset", "10"}))
+}
@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tendermint/tendermint/consensus"
+	"github.com/tendermint/tendermint/p2p"
+)
+
+var (
+	walInspectFile     string
+	walInspectHeight   int64
+	walInspectRound    int32
+	walInspectTypes    []string
+	walInspectPeer     string
+	walInspectFormat   string
+	walInspectOutput   string
+	walInspectTimeline bool
+)
+
+// WALInspectCmd decodes the consensus WAL and prints its messages or a per
+// round timeline of each height.
+var WALInspectCmd = &cobra.Command{
+	Use:   "wal_inspect",
+	Short: "Inspect the consensus WAL without replaying it",
+	Long: `Decode every message of the consensus WAL, including the files rotated out
+of its head, optionally filtered by height, round, message type or peer, and
+print them. With --timeline, print for each
+height the step transitions, proposal, vote bit arrays and timeouts of every
+round, which helps to explain why a height took many rounds.
+
+Message types: round_state, proposal, block_part, vote, timeout, end_height.`,
+	RunE: walInspect,
+}
+
+func init() {
+	WALInspectCmd.Flags().StringVar(&walInspectFile, "wal", "",
+		"Head of the WAL to inspect, read with its rotated files (default: the node's WAL)")
+	WALInspectCmd.Flags().Int64Var(&walInspectHeight, "height", 0, "Only show this height")
+	WALInspectCmd.Flags().Int32Var(&walInspectRound, "round", -1, "Only show this round")
+	WALInspectCmd.Flags().StringSliceVar(&walInspectTypes, "type", nil, "Only show these message types")
+	WALInspectCmd.Flags().StringVar(&walInspectPeer, "peer", "", "Only show messages received from this peer")
+	WALInspectCmd.Flags().StringVar(&walInspectFormat, "format", "text", "Output format: text, json or html (html implies --timeline)")
+	WALInspectCmd.Flags().StringVar(&walInspectOutput, "output", "", "Write to this file instead of stdout")
+	WALInspectCmd.Flags().BoolVar(&walInspectTimeline, "timeline", false, "Print a per round timeline of each height")
+}
+
+func walInspect(cmd *cobra.Command, args []string) error {
+	file := walInspectFile
+	if file == "" {
+		file = config.Consensus.WalFile()
+	}
+	wi, err := consensus.OpenWALInspector(file)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if walInspectOutput != "" {
+		of, err := os.Create(walInspectOutput)
+		if err != nil {
+			return err
+		}
+		defer of.Close()
+		out = of
+	}
+
+	if walInspectTimeline || walInspectFormat == "html" {
+		var timelines []*consensus.WALHeightTimeline
+		if walInspectHeight > 0 {
+			timelines = append(timelines, wi.Timeline(walInspectHeight))
+		} else {
+			timelines = wi.Timelines()
+		}
+		switch walInspectFormat {
+		case "json":
+			return consensus.WriteTimelineJSON(out, timelines)
+		case "html":
+			return consensus.WriteTimelineHTML(out, timelines)
+		case "text":
+			printWALTimelines(out, timelines)
+			return nil
+		}
+		return fmt.Errorf("unknown format %q", walInspectFormat)
+	}
+
+	entries := wi.Entries(consensus.WALFilter{
+		Height: walInspectHeight,
+		Round:  walInspectRound,
+		Types:  walInspectTypes,
+		PeerID: p2p.ID(walInspectPeer),
+	})
+	switch walInspectFormat {
+	case "json":
+		return consensus.WriteWALEntriesJSON(out, entries)
+	case "text":
+		for _, e := range entries {
+			fmt.Fprintln(out, e)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown format %q", walInspectFormat)
+}
+
+func printWALTimelines(out io.Writer, timelines []*consensus.WALHeightTimeline) {
+	for _, ht := range timelines {
+		status := "complete"
+		if !ht.Complete {
+			status = "incomplete"
+		}
+		fmt.Fprintf(out, "Height %d: %d round(s) in %v (%s)\n", ht.Height, len(ht.Rounds), ht.Duration, status)
+		for _, rt := range ht.Rounds {
+			fmt.Fprintf(out, "  Round %d: %s\n", rt.Round, rt.Outcome)
+			for _, st := range rt.Steps {
+				fmt.Fprintf(out, "    +%-12v %s\n", st.Time.Sub(ht.Start), st.Step)
+			}
+			if rt.Proposal != nil {
+				fmt.Fprintf(out, "    proposal    %v (+%v, %d parts)\n",
+					rt.Proposal.BlockID, rt.ProposalTime.Sub(ht.Start), rt.BlockParts)
+			}
+			fmt.Fprintf(out, "    prevotes    block %v nil %v\n", rt.Prevotes, rt.PrevotesNil)
+			fmt.Fprintf(out, "    precommits  block %v nil %v\n", rt.Precommits, rt.PrecommitsNil)
+		}
+	}
+}
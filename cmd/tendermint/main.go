@@ -20,6 +20,12 @@ func main() {
 		cmd.LightCmd,
 		cmd.ReplayCmd,
 		cmd.ReplayConsoleCmd,
+		cmd.WALInspectCmd,
+		cmd.DBCmd,
+		cmd.InspectCmd,
+		cmd.ExportCmd,
+		cmd.ImportCmd,
+		cmd.ReplayAppCmd,
 		cmd.ResetAllCmd,
 		cmd.ResetPrivValidatorCmd,
 		cmd.ShowValidatorCmd,
@@ -20,6 +20,11 @@ const (
 	LogFormatPlain = "plain"
 	// LogFormatJSON is a format for json output
 	LogFormatJSON = "json"
+
+	// TracingExporterOTLP exports the traces to an OpenTelemetry collector
+	TracingExporterOTLP = "otlp"
+	// TracingExporterFile exports the traces to a file
+	TracingExporterFile = "file"
 )
 
 // NOTE: Most of the structs & relevant comments + the
@@ -64,6 +69,7 @@ type Config struct {
 	FastSync        *FastSyncConfig        `mapstructure:"fastsync"`
 	Consensus       *ConsensusConfig       `mapstructure:"consensus"`
 	TxIndex         *TxIndexConfig         `mapstructure:"tx_index"`
+	Pruning         *PruningConfig         `mapstructure:"pruning"`
 	Instrumentation *InstrumentationConfig `mapstructure:"instrumentation"`
 }
 
@@ -78,6 +84,7 @@ func DefaultConfig() *Config {
 		FastSync:        DefaultFastSyncConfig(),
 		Consensus:       DefaultConsensusConfig(),
 		TxIndex:         DefaultTxIndexConfig(),
+		Pruning:         DefaultPruningConfig(),
 		Instrumentation: DefaultInstrumentationConfig(),
 	}
 }
@@ -93,6 +100,7 @@ func TestConfig() *Config {
 		FastSync:        TestFastSyncConfig(),
 		Consensus:       TestConsensusConfig(),
 		TxIndex:         TestTxIndexConfig(),
+		Pruning:         TestPruningConfig(),
 		Instrumentation: TestInstrumentationConfig(),
 	}
 }
@@ -104,6 +112,7 @@ func (cfg *Config) SetRoot(root string) *Config {
 	cfg.P2P.RootDir = root
 	cfg.Mempool.RootDir = root
 	cfg.Consensus.RootDir = root
+	cfg.Instrumentation.RootDir = root
 	return cfg
 }
 
@@ -131,6 +140,9 @@ func (cfg *Config) ValidateBasic() error {
 	if err := cfg.Consensus.ValidateBasic(); err != nil {
 		return fmt.Errorf("error in [consensus] section: %w", err)
 	}
+	if err := cfg.Pruning.ValidateBasic(); err != nil {
+		return fmt.Errorf("error in [pruning] section: %w", err)
+	}
 	if err := cfg.Instrumentation.ValidateBasic(); err != nil {
 		return fmt.Errorf("error in [instrumentation] section: %w", err)
 	}
@@ -191,6 +203,11 @@ type BaseConfig struct { //nolint: maligned
 	// Output format: 'plain' (colored text) or 'json'
 	LogFormat string `mapstructure:"log_format"`
 
+	// Maximum number of debug log events of a message logged per second by the
+	// p2p connections and the mempool. Above it, they're dropped (0 -
+	// unlimited)
+	LogDebugRateLimit int `mapstructure:"log_debug_rate_limit"`
+
 	// Path to the JSON file containing the initial validator set and other meta data
 	Genesis string `mapstructure:"genesis_file"`
 
@@ -281,6 +298,9 @@ func (cfg BaseConfig) ValidateBasic() error {
 	default:
 		return errors.New("unknown log_format (must be 'plain' or 'json')")
 	}
+	if cfg.LogDebugRateLimit < 0 {
+		return errors.New("log_debug_rate_limit can't be negative")
+	}
 	return nil
 }
 
@@ -382,6 +402,15 @@ type RPCConfig struct {
 
 	// pprof listen address (https://golang.org/pkg/net/http/pprof)
 	PprofListenAddress string `mapstructure:"pprof_laddr"`
+
+	// Minimum number of peers for the node to be ready (see /health/ready).
+	// 0 disables the check, e.g. for a single node network.
+	HealthMinPeers int `mapstructure:"health_min_peers"`
+
+	// Maximum time since the last block for the node to be ready (see
+	// /health/ready), as a multiple of the expected block time (see
+	// ConsensusConfig.ExpectedBlockTime). 0 disables the check.
+	HealthMaxBlockDelay int `mapstructure:"health_max_block_delay"`
 }
 
 // DefaultRPCConfig returns a default configuration for the RPC server
@@ -406,6 +435,9 @@ func DefaultRPCConfig() *RPCConfig {
 
 		TLSCertFile: "",
 		TLSKeyFile:  "",
+
+		HealthMinPeers:      1,
+		HealthMaxBlockDelay: 10,
 	}
 }
 
@@ -415,6 +447,7 @@ func TestRPCConfig() *RPCConfig {
 	cfg.ListenAddress = "tcp://127.0.0.1:36657"
 	cfg.GRPCListenAddress = "tcp://127.0.0.1:36658"
 	cfg.Unsafe = true
+	cfg.HealthMinPeers = 0
 	return cfg
 }
 
@@ -442,6 +475,12 @@ func (cfg *RPCConfig) ValidateBasic() error {
 	if cfg.MaxHeaderBytes < 0 {
 		return errors.New("max_header_bytes can't be negative")
 	}
+	if cfg.HealthMinPeers < 0 {
+		return errors.New("health_min_peers can't be negative")
+	}
+	if cfg.HealthMaxBlockDelay < 0 {
+		return errors.New("health_max_block_delay can't be negative")
+	}
 	return nil
 }
 
@@ -711,6 +750,7 @@ func (cfg *MempoolConfig) ValidateBasic() error {
 type StateSyncConfig struct {
 	Enable      bool          `mapstructure:"enable"`
 	TempDir     string        `mapstructure:"temp_dir"`
+	UseP2P      bool          `mapstructure:"use_p2p"`
 	RPCServers  []string      `mapstructure:"rpc_servers"`
 	TrustPeriod time.Duration `mapstructure:"trust_period"`
 	TrustHeight int64         `mapstructure:"trust_height"`
@@ -741,15 +781,17 @@ func TestStateSyncConfig() *StateSyncConfig {
 // ValidateBasic performs basic validation.
 func (cfg *StateSyncConfig) ValidateBasic() error {
 	if cfg.Enable {
-		if len(cfg.RPCServers) == 0 {
-			return errors.New("rpc_servers is required")
-		}
-		if len(cfg.RPCServers) < 2 {
-			return errors.New("at least two rpc_servers entries is required")
-		}
-		for _, server := range cfg.RPCServers {
-			if len(server) == 0 {
-				return errors.New("found empty rpc_servers entry")
+		if !cfg.UseP2P {
+			if len(cfg.RPCServers) == 0 {
+				return errors.New("rpc_servers is required")
+			}
+			if len(cfg.RPCServers) < 2 {
+				return errors.New("at least two rpc_servers entries is required")
+			}
+			for _, server := range cfg.RPCServers {
+				if len(server) == 0 {
+					return errors.New("found empty rpc_servers entry")
+				}
 			}
 		}
 		if cfg.TrustPeriod <= 0 {
@@ -877,6 +919,17 @@ func (cfg *ConsensusConfig) WaitForTxs() bool {
 	return !cfg.CreateEmptyBlocks || cfg.CreateEmptyBlocksInterval > 0
 }
 
+// ExpectedBlockTime returns the maximum time a block is expected to take to be
+// made in a single round: timeout_commit, plus create_empty_blocks_interval
+// when waiting for txs, plus timeout_propose. It returns 0 if blocks are only
+// made with txs, so there's no expected block time.
+func (cfg *ConsensusConfig) ExpectedBlockTime() time.Duration {
+	if !cfg.CreateEmptyBlocks {
+		return 0
+	}
+	return cfg.TimeoutCommit + cfg.CreateEmptyBlocksInterval + cfg.TimeoutPropose
+}
+
 // Propose returns the amount of time to wait for a proposal
 func (cfg *ConsensusConfig) Propose(round int32) time.Duration {
 	return time.Duration(
@@ -989,11 +1042,85 @@ func TestTxIndexConfig() *TxIndexConfig {
 	return DefaultTxIndexConfig()
 }
 
+//-----------------------------------------------------------------------------
+// PruningConfig
+
+// PruningConfig defines the retention policy of the background pruner, which
+// prunes the blocks, states, ABCI responses and indexed transactions the
+// operator doesn't want to keep. The pruner never prunes blocks above the
+// retain height last returned by the application on Commit, unless it's 0 (the
+// application leaves the pruning to the operator), nor blocks which are still
+// needed to verify evidence. When enabled, the pruner also does the pruning requested by
+// the application, instead of consensus on commit.
+type PruningConfig struct {
+	// How often the pruner runs
+	Interval time.Duration `mapstructure:"interval"`
+
+	// Number of most recent blocks to keep (0 keeps all blocks, unless
+	// KeepDuration is set)
+	KeepRecent int64 `mapstructure:"keep_recent"`
+
+	// Keep the blocks more recent than this duration (0 keeps all blocks,
+	// unless KeepRecent is set). If both are set, a block is kept as long as
+	// either of them keeps it.
+	KeepDuration time.Duration `mapstructure:"keep_duration"`
+
+	// Keep every KeepEvery-th block in the block store, e.g. as checkpoints,
+	// but not its state, ABCI responses or indexed transactions (0 disables it)
+	KeepEvery int64 `mapstructure:"keep_every"`
+
+	// Number of most recent heights to keep the ABCI responses of (0 keeps
+	// them as long as the blocks). They can't be kept longer than the blocks.
+	ABCIResponsesKeepRecent int64 `mapstructure:"abci_responses_keep_recent"`
+}
+
+// DefaultPruningConfig returns a default configuration for the pruner, which
+// keeps all blocks.
+func DefaultPruningConfig() *PruningConfig {
+	return &PruningConfig{
+		Interval: 10 * time.Second,
+	}
+}
+
+// TestPruningConfig returns a configuration for testing the pruner.
+func TestPruningConfig() *PruningConfig {
+	cfg := DefaultPruningConfig()
+	cfg.Interval = 100 * time.Millisecond
+	return cfg
+}
+
+// Enabled returns true if the retention policy prunes anything.
+func (cfg *PruningConfig) Enabled() bool {
+	return cfg.KeepRecent > 0 || cfg.KeepDuration > 0 || cfg.ABCIResponsesKeepRecent > 0
+}
+
+// ValidateBasic performs basic validation.
+func (cfg *PruningConfig) ValidateBasic() error {
+	if cfg.Interval <= 0 {
+		return errors.New("interval must be positive")
+	}
+	if cfg.KeepRecent < 0 {
+		return errors.New("keep_recent can't be negative")
+	}
+	if cfg.KeepDuration < 0 {
+		return errors.New("keep_duration can't be negative")
+	}
+	if cfg.KeepEvery < 0 {
+		return errors.New("keep_every can't be negative")
+	}
+	if cfg.ABCIResponsesKeepRecent < 0 {
+		return errors.New("abci_responses_keep_recent can't be negative")
+	}
+	return nil
+}
+
 //-----------------------------------------------------------------------------
 // InstrumentationConfig
 
 // InstrumentationConfig defines the configuration for metrics reporting.
 type InstrumentationConfig struct {
+	RootDir string `mapstructure:"home"`
+
 	// When true, Prometheus metrics are served under /metrics on
 	// PrometheusListenAddr.
 	// Check out the documentation for the list of available metrics.
@@ -1010,6 +1137,26 @@ type InstrumentationConfig struct {
 
 	// Instrumentation namespace.
 	Namespace string `mapstructure:"namespace"`
+
+	// Exporter of the traces of the consensus steps, ABCI requests, block
+	// executions and RPC calls: "" (tracing disabled), "otlp" (to an
+	// OpenTelemetry collector at TracingEndpoint) or "file" (to TracingFile).
+	TracingExporter string `mapstructure:"tracing_exporter"`
+
+	// URL of the OTLP/HTTP traces endpoint of the OpenTelemetry collector.
+	TracingEndpoint string `mapstructure:"tracing_endpoint"`
+
+	// File the traces are appended to, as lines of OTLP JSON.
+	TracingFile string `mapstructure:"tracing_file"`
+
+	// Number of the last blocks the uptime of the validators is tracked
+	// over, from the commits of the blocks. 0 disables the tracking.
+	ValidatorUptimeWindow int64 `mapstructure:"validator_uptime_window"`
+
+	// Number of blocks the local validator must miss in a row to fire a
+	// ValidatorMissedBlocks event, up to ValidatorUptimeWindow. 0 disables
+	// the event.
+	ValidatorMissedBlocksThreshold int64 `mapstructure:"validator_missed_blocks_threshold"`
 }
 
 // DefaultInstrumentationConfig returns a default configuration for metrics
@@ -1020,6 +1167,12 @@ func DefaultInstrumentationConfig() *InstrumentationConfig {
 		PrometheusListenAddr: ":26660",
 		MaxOpenConnections:   3,
 		Namespace:            "tendermint",
+		TracingExporter:      "",
+		TracingEndpoint:      "http://localhost:4318/v1/traces",
+		TracingFile:          "data/traces.json",
+
+		ValidatorUptimeWindow:          1000,
+		ValidatorMissedBlocksThreshold: 10,
 	}
 }
 
@@ -1029,12 +1182,38 @@ func TestInstrumentationConfig() *InstrumentationConfig {
 	return DefaultInstrumentationConfig()
 }
 
+// TracingFilePath returns the full path to the file the traces are exported to.
+func (cfg *InstrumentationConfig) TracingFilePath() string {
+	return rootify(cfg.TracingFile, cfg.RootDir)
+}
+
 // ValidateBasic performs basic validation (checking param bounds, etc.) and
 // returns an error if any check fails.
 func (cfg *InstrumentationConfig) ValidateBasic() error {
 	if cfg.MaxOpenConnections < 0 {
 		return errors.New("max_open_connections can't be negative")
 	}
+	switch cfg.TracingExporter {
+	case "", TracingExporterOTLP, TracingExporterFile:
+	default:
+		return fmt.Errorf("unknown tracing_exporter %q (must be '', 'otlp' or 'file')", cfg.TracingExporter)
+	}
+	if cfg.TracingExporter == TracingExporterOTLP && cfg.TracingEndpoint == "" {
+		return errors.New("tracing_endpoint can't be empty with the otlp exporter")
+	}
+	if cfg.TracingExporter == TracingExporterFile && cfg.TracingFile == "" {
+		return errors.New("tracing_file can't be empty with the file exporter")
+	}
+	if cfg.ValidatorUptimeWindow < 0 {
+		return errors.New("validator_uptime_window can't be negative")
+	}
+	if cfg.ValidatorMissedBlocksThreshold < 0 {
+		return errors.New("validator_missed_blocks_threshold can't be negative")
+	}
+	if cfg.ValidatorUptimeWindow > 0 && cfg.ValidatorMissedBlocksThreshold > cfg.ValidatorUptimeWindow {
+		return fmt.Errorf("validator_missed_blocks_threshold (%d) can't be greater than validator_uptime_window (%d)",
+			cfg.ValidatorMissedBlocksThreshold, cfg.ValidatorUptimeWindow)
+	}
 	return nil
 }
 
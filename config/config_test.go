@@ -76,6 +76,8 @@ func TestRPCConfigValidateBasic(t *testing.T) {
 		"TimeoutBroadcastTxCommit",
 		"MaxBodyBytes",
 		"MaxHeaderBytes",
+		"HealthMinPeers",
+		"HealthMaxBlockDelay",
 	}
 
 	for _, fieldName := range fieldsToTest {
@@ -126,6 +128,14 @@ func TestMempoolConfigValidateBasic(t *testing.T) {
 func TestStateSyncConfigValidateBasic(t *testing.T) {
 	cfg := TestStateSyncConfig()
 	require.NoError(t, cfg.ValidateBasic())
+
+	// rpc_servers are only required without use_p2p
+	cfg.Enable = true
+	cfg.TrustHeight = 1
+	cfg.TrustHash = "0123456789ABCDEF"
+	assert.Error(t, cfg.ValidateBasic())
+	cfg.UseP2P = true
+	assert.NoError(t, cfg.ValidateBasic())
 }
 
 func TestFastSyncConfigValidateBasic(t *testing.T) {
@@ -189,4 +199,29 @@ func TestInstrumentationConfigValidateBasic(t *testing.T) {
 	// tamper with maximum open connections
 	cfg.MaxOpenConnections = -1
 	assert.Error(t, cfg.ValidateBasic())
+
+	// tamper with the tracing exporter
+	cfg = TestInstrumentationConfig()
+	cfg.TracingExporter = "jaeger"
+	assert.Error(t, cfg.ValidateBasic())
+	cfg.TracingExporter = TracingExporterOTLP
+	assert.NoError(t, cfg.ValidateBasic())
+	cfg.TracingEndpoint = ""
+	assert.Error(t, cfg.ValidateBasic())
+	cfg.TracingExporter = TracingExporterFile
+	assert.NoError(t, cfg.ValidateBasic())
+	cfg.TracingFile = ""
+	assert.Error(t, cfg.ValidateBasic())
+
+	// tamper with the validator uptime
+	cfg = TestInstrumentationConfig()
+	cfg.ValidatorUptimeWindow = -1
+	assert.Error(t, cfg.ValidateBasic())
+	cfg = TestInstrumentationConfig()
+	cfg.ValidatorMissedBlocksThreshold = -1
+	assert.Error(t, cfg.ValidateBasic())
+	cfg.ValidatorMissedBlocksThreshold = cfg.ValidatorUptimeWindow + 1
+	assert.Error(t, cfg.ValidateBasic())
+	cfg.ValidatorUptimeWindow = 0
+	assert.NoError(t, cfg.ValidateBasic())
 }
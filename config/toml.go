@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"strings"
 	"text/template"
 
 	tmos "github.com/tendermint/tendermint/libs/os"
@@ -17,7 +18,10 @@ var configTemplate *template.Template
 
 func init() {
 	var err error
-	if configTemplate, err = template.New("configFileTemplate").Parse(defaultConfigTemplate); err != nil {
+	tmpl := template.New("configFileTemplate").Funcs(template.FuncMap{
+		"StringsJoin": strings.Join,
+	})
+	if configTemplate, err = tmpl.Parse(defaultConfigTemplate); err != nil {
 		panic(err)
 	}
 }
@@ -118,6 +122,11 @@ log_level = "{{ .BaseConfig.LogLevel }}"
 # Output format: 'plain' (colored text) or 'json'
 log_format = "{{ .BaseConfig.LogFormat }}"
 
+# Maximum number of debug log events of a message logged per second by the
+# p2p connections and the mempool. Above it, they're dropped, and the number
+# of dropped events is logged with the next one (0 - unlimited)
+log_debug_rate_limit = {{ .BaseConfig.LogDebugRateLimit }}
+
 ##### additional base config options #####
 
 # Path to the JSON file containing the initial validator set and other meta data
@@ -232,6 +241,17 @@ tls_key_file = "{{ .RPC.TLSKeyFile }}"
 # pprof listen address (https://golang.org/pkg/net/http/pprof)
 pprof_laddr = "{{ .RPC.PprofListenAddress }}"
 
+# Minimum number of peers for the node to be ready (see /health/ready).
+# 0 disables the check, e.g. for a single node network.
+health_min_peers = {{ .RPC.HealthMinPeers }}
+
+# Maximum time since the last block for the node to be ready (see
+# /health/ready), as a multiple of the expected block time: timeout_commit plus
+# create_empty_blocks_interval plus timeout_propose. The check is disabled if
+# create_empty_blocks is false.
+# 0 disables the check.
+health_max_block_delay = {{ .RPC.HealthMaxBlockDelay }}
+
 #######################################################
 ###           P2P Configuration Options             ###
 #######################################################
@@ -346,13 +366,19 @@ enable = {{ .StateSync.Enable }}
 #
 # For Cosmos SDK-based chains, trust_period should usually be about 2/3 of the unbonding time (~2
 # weeks) during which they can be financially punished (slashed) for misbehavior.
-rpc_servers = ""
+rpc_servers = "{{ StringsJoin .StateSync.RPCServers "," }}"
+
+# Use the connected peers (e.g. persistent_peers) instead of rpc_servers for light client
+# verification and retrieval of state data. Requires at least two peers serving light blocks.
+use_p2p = {{ .StateSync.UseP2P }}
+
 trust_height = {{ .StateSync.TrustHeight }}
 trust_hash = "{{ .StateSync.TrustHash }}"
 trust_period = "{{ .StateSync.TrustPeriod }}"
 
 # Temporary directory for state sync snapshot chunks, defaults to the OS tempdir (typically /tmp).
-# Will create a new, randomly named directory within, and remove it when done.
+# Will create a new, randomly named directory within, and remove it when done. If set, the
+# directory is named after the snapshot instead, so that a restarted node resumes the download.
 temp_dir = "{{ .StateSync.TempDir }}"
 
 #######################################################
@@ -414,6 +440,36 @@ peer_query_maj23_sleep_duration = "{{ .Consensus.PeerQueryMaj23SleepDuration }}"
 # 		- When "kv" is chosen "tx.height" and "tx.hash" will always be indexed.
 indexer = "{{ .TxIndex.Indexer }}"
 
+#######################################################
+###         Pruning Configuration Options           ###
+#######################################################
+[pruning]
+
+# The pruner prunes the blocks, states, ABCI responses and indexed transactions the node
+# doesn't need to keep, in the background. It never prunes blocks above the retain height
+# last returned by the application on Commit, unless it's 0 (the application leaves the
+# pruning to the operator), nor blocks still needed to verify evidence. When enabled, the pruner also does the
+# pruning requested by the application, instead of consensus on commit.
+# By default, all blocks are kept.
+
+# How often the pruner runs
+interval = "{{ .Pruning.Interval }}"
+
+# Number of most recent blocks to keep (0 keeps all blocks, unless keep_duration is set)
+keep_recent = {{ .Pruning.KeepRecent }}
+
+# Keep the blocks more recent than this duration (0 keeps all blocks, unless keep_recent is set).
+# If both are set, a block is kept as long as either of them keeps it.
+keep_duration = "{{ .Pruning.KeepDuration }}"
+
+# Keep every keep_every-th block in the block store, e.g. as checkpoints, but not its state,
+# ABCI responses or indexed transactions (0 disables it)
+keep_every = {{ .Pruning.KeepEvery }}
+
+# Number of most recent heights to keep the ABCI responses of (0 keeps them as long as the
+# blocks). ABCI responses are used by the /block_results RPC endpoint.
+abci_responses_keep_recent = {{ .Pruning.ABCIResponsesKeepRecent }}
+
 #######################################################
 ###       Instrumentation Configuration Options     ###
 #######################################################
@@ -435,6 +491,30 @@ max_open_connections = {{ .Instrumentation.MaxOpenConnections }}
 
 # Instrumentation namespace
 namespace = "{{ .Instrumentation.Namespace }}"
+
+# Exporter of the traces of the consensus steps, ABCI requests, block
+# executions and RPC calls:
+#   1) "" (default) - tracing is disabled.
+#   2) "otlp" - the traces are sent to an OpenTelemetry collector at
+#   tracing_endpoint, with the OTLP/HTTP protocol in JSON encoding.
+#   3) "file" - the traces are appended to tracing_file, as lines of OTLP JSON.
+tracing_exporter = "{{ .Instrumentation.TracingExporter }}"
+
+# URL of the OTLP/HTTP traces endpoint of the OpenTelemetry collector
+tracing_endpoint = "{{ .Instrumentation.TracingEndpoint }}"
+
+# File the traces are appended to, relative to the home directory
+tracing_file = "{{ js .Instrumentation.TracingFile }}"
+
+# Number of the last blocks the uptime of the validators is tracked over, from
+# the commits of the blocks. It's served by the /validator_uptime RPC endpoint.
+# 0 disables the tracking.
+validator_uptime_window = {{ .Instrumentation.ValidatorUptimeWindow }}
+
+# Number of blocks the local validator must miss in a row to fire a
+# ValidatorMissedBlocks event, up to validator_uptime_window. 0 disables the
+# event.
+validator_missed_blocks_threshold = {{ .Instrumentation.ValidatorMissedBlocksThreshold }}
 `
 
 /****** these are for test settings ***********/
@@ -64,6 +64,21 @@ func TestEnsureTestRoot(t *testing.T) {
 	ensureFiles(t, rootDir, defaultDataDir, baseConfig.Genesis, baseConfig.PrivValidatorKey, baseConfig.PrivValidatorState)
 }
 
+func TestWriteConfigFileStateSync(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "config-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	cfg := DefaultConfig()
+	cfg.StateSync.RPCServers = []string{"tcp://127.0.0.1:26657", "tcp://127.0.0.1:36657"}
+	configFile := filepath.Join(tmpDir, "config.toml")
+	WriteConfigFile(configFile, cfg)
+
+	data, err := ioutil.ReadFile(configFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `rpc_servers = "tcp://127.0.0.1:26657,tcp://127.0.0.1:36657"`)
+}
+
 func checkConfig(configFile string) bool {
 	var valid bool
 
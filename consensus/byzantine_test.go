@@ -33,6 +33,15 @@ import (
 
 // Byzantine node sends two different prevotes (nil and blockID) to the same validator
 func TestByzantinePrevoteEquivocation(t *testing.T) {
+	testByzantineEquivocation(t, MisbehaviorDoublePrevote)
+}
+
+// Byzantine node sends two different precommits (nil and blockID) to the same validator
+func TestByzantinePrecommitEquivocation(t *testing.T) {
+	testByzantineEquivocation(t, MisbehaviorDoublePrecommit)
+}
+
+func testByzantineEquivocation(t *testing.T, misbehavior string) {
 	const nValidators = 4
 	const byzantineNode = 0
 	testName := "consensus_byzantine_test"
@@ -41,6 +50,7 @@ func TestByzantinePrevoteEquivocation(t *testing.T) {
 
 	genDoc, privVals := randGenesisDoc(nValidators, false, 30)
 	css := make([]*State, nValidators)
+	evpools := make([]*evidence.Pool, nValidators)
 
 	for i := 0; i < nValidators; i++ {
 		logger := consensusLogger().With("test", "byzantine", "validator", i)
@@ -74,6 +84,7 @@ func TestByzantinePrevoteEquivocation(t *testing.T) {
 		evpool, err := evidence.NewPool(evidenceDB, stateStore, blockStore)
 		require.NoError(t, err)
 		evpool.SetLogger(logger.With("module", "evidence"))
+		evpools[i] = evpool
 
 		// Make State
 		blockExec := sm.NewBlockExecutor(stateStore, log.TestingLogger(), proxyAppConnCon, mempool, evpool)
@@ -116,9 +127,14 @@ func TestByzantinePrevoteEquivocation(t *testing.T) {
 			require.NoError(t, err)
 		}
 	}
-	// make connected switches and start all reactors
+	// make connected switches and start all reactors. The evidence is
+	// gossiped, so that whichever validator proposes next includes it, even if
+	// it moved on to the next height before receiving the conflicting vote.
 	p2p.MakeConnectedSwitches(config.P2P, nValidators, func(i int, s *p2p.Switch) *p2p.Switch {
 		s.AddReactor("CONSENSUS", reactors[i])
+		evR := evidence.NewReactor(evpools[i])
+		evR.SetLogger(reactors[i].conS.Logger.With("module", "evidence"))
+		s.AddReactor("EVIDENCE", evR)
 		s.SetLogger(reactors[i].conS.Logger.With("module", "p2p"))
 		return s
 	}, p2p.Connect2Switches)
@@ -126,32 +142,9 @@ func TestByzantinePrevoteEquivocation(t *testing.T) {
 	// create byzantine validator
 	bcs := css[byzantineNode]
 
-	// alter prevote so that the byzantine node double votes when height is 2
-	bcs.doPrevote = func(height int64, round int32) {
-		// allow first height to happen normally so that byzantine validator is no longer proposer
-		if height == 2 {
-			bcs.Logger.Info("Sending two votes")
-			prevote1, err := bcs.signVote(tmproto.PrevoteType, bcs.ProposalBlock.Hash(), bcs.ProposalBlockParts.Header())
-			require.NoError(t, err)
-			prevote2, err := bcs.signVote(tmproto.PrevoteType, nil, types.PartSetHeader{})
-			require.NoError(t, err)
-			peerList := reactors[byzantineNode].Switch.Peers().List()
-			bcs.Logger.Info("Getting peer list", "peers", peerList)
-			// send two votes to all peers (1st to one half, 2nd to another half)
-			for i, peer := range peerList {
-				if i < len(peerList)/2 {
-					bcs.Logger.Info("Signed and pushed vote", "vote", prevote1, "peer", peer)
-					peer.Send(VoteChannel, MustEncode(&VoteMessage{prevote1}))
-				} else {
-					bcs.Logger.Info("Signed and pushed vote", "vote", prevote2, "peer", peer)
-					peer.Send(VoteChannel, MustEncode(&VoteMessage{prevote2}))
-				}
-			}
-		} else {
-			bcs.Logger.Info("Behaving normally")
-			bcs.defaultDoPrevote(height, round)
-		}
-	}
+	// double sign at height 2 and behave normally otherwise. The first height
+	// happens normally so that the byzantine validator is no longer proposer.
+	reactors[byzantineNode].SetMisbehaviors(map[int64]Misbehavior{2: Misbehaviors[misbehavior]})
 
 	// start the consensus reactors
 	for i := 0; i < nValidators; i++ {
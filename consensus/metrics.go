@@ -60,6 +60,25 @@ type Metrics struct {
 
 	// Number of blockparts transmitted by peer.
 	BlockParts metrics.Counter
+
+	// Time spent in a step.
+	StepDurationSeconds metrics.Histogram
+	// Time between the start of a round and the reception of the proposal.
+	ProposalDelaySeconds metrics.Histogram
+	// Time between the start of a round and the reception of the last part of
+	// the proposal block.
+	BlockPartsDelaySeconds metrics.Histogram
+	// Time between the start of a round and the reception of +2/3 votes of a
+	// type, for anything.
+	QuorumDelaySeconds metrics.Histogram
+	// Number of votes of the validators not received by the end of their
+	// round, by type. See the consensus timeline for the validators.
+	MissedVotes metrics.Counter
+	// Number of missed votes of the validators received after the end of
+	// their round, by type.
+	LateVotes metrics.Counter
+	// Number of rounds which ended without a commit, by reason.
+	FailedRounds metrics.Counter
 }
 
 // PrometheusMetrics returns Metrics build using Prometheus client library.
@@ -186,6 +205,48 @@ func PrometheusMetrics(namespace string, labelsAndValues ...string) *Metrics {
 			Name:      "block_parts",
 			Help:      "Number of blockparts transmitted by peer.",
 		}, append(labels, "peer_id")).With(labelsAndValues...),
+		StepDurationSeconds: prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "step_duration_seconds",
+			Help:      "Time spent in a step.",
+		}, append(labels, "step")).With(labelsAndValues...),
+		ProposalDelaySeconds: prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "proposal_delay_seconds",
+			Help:      "Time between the start of a round and the reception of the proposal.",
+		}, labels).With(labelsAndValues...),
+		BlockPartsDelaySeconds: prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "block_parts_delay_seconds",
+			Help:      "Time between the start of a round and the reception of the last part of the proposal block.",
+		}, labels).With(labelsAndValues...),
+		QuorumDelaySeconds: prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "quorum_delay_seconds",
+			Help:      "Time between the start of a round and the reception of +2/3 votes of a type.",
+		}, append(labels, "vote_type")).With(labelsAndValues...),
+		MissedVotes: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "missed_votes",
+			Help:      "Number of votes of the validators not received by the end of their round.",
+		}, append(labels, "vote_type")).With(labelsAndValues...),
+		LateVotes: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "late_votes",
+			Help:      "Number of missed votes of the validators received after the end of their round.",
+		}, append(labels, "vote_type")).With(labelsAndValues...),
+		FailedRounds: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "failed_rounds",
+			Help:      "Number of rounds which ended without a commit, by reason.",
+		}, append(labels, "reason")).With(labelsAndValues...),
 	}
 }
 
@@ -216,5 +277,13 @@ func NopMetrics() *Metrics {
 		FastSyncing:     discard.NewGauge(),
 		StateSyncing:    discard.NewGauge(),
 		BlockParts:      discard.NewCounter(),
+
+		StepDurationSeconds:    discard.NewHistogram(),
+		ProposalDelaySeconds:   discard.NewHistogram(),
+		BlockPartsDelaySeconds: discard.NewHistogram(),
+		QuorumDelaySeconds:     discard.NewHistogram(),
+		MissedVotes:            discard.NewCounter(),
+		LateVotes:              discard.NewCounter(),
+		FailedRounds:           discard.NewCounter(),
 	}
 }
@@ -0,0 +1,259 @@
+package consensus
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tendermint/tendermint/crypto/tmhash"
+	tmrand "github.com/tendermint/tendermint/libs/rand"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	sm "github.com/tendermint/tendermint/state"
+	"github.com/tendermint/tendermint/types"
+)
+
+// Names of the built-in misbehaviors.
+const (
+	MisbehaviorDoublePrevote   = "double-prevote"
+	MisbehaviorDoublePrecommit = "double-precommit"
+	MisbehaviorAmnesia         = "amnesia"
+	MisbehaviorLunatic         = "lunatic-proposal"
+	MisbehaviorWithholding     = "withholding"
+)
+
+// Misbehavior is a byzantine behaviour a validator can be made to exhibit at
+// a given height. Each hook replaces the corresponding step of the consensus
+// state machine; nil hooks keep the default behaviour.
+//
+// Misbehaviors exist to test evidence handling and app punishment on real
+// networks (see test/maverick). They must never be enabled on a production
+// validator: most of them require a signer without double signing protection
+// and get the validator slashed.
+type Misbehavior struct {
+	Name string
+
+	DecideProposal func(conR *Reactor, height int64, round int32)
+	DoPrevote      func(conR *Reactor, height int64, round int32)
+	DoPrecommit    func(conR *Reactor, height int64, round int32, blockID types.BlockID)
+}
+
+// Misbehaviors holds the built-in misbehaviors by name.
+var Misbehaviors = map[string]Misbehavior{
+	// send both a prevote for the proposal block and a nil prevote.
+	MisbehaviorDoublePrevote: {
+		Name:      MisbehaviorDoublePrevote,
+		DoPrevote: doublePrevote,
+	},
+	// send both a precommit for the polka block and a nil precommit.
+	MisbehaviorDoublePrecommit: {
+		Name:        MisbehaviorDoublePrecommit,
+		DoPrecommit: doublePrecommit,
+	},
+	// forget the lock and prevote the current proposal anyway.
+	MisbehaviorAmnesia: {
+		Name:      MisbehaviorAmnesia,
+		DoPrevote: amnesiaPrevote,
+	},
+	// propose a block with a bogus app hash.
+	MisbehaviorLunatic: {
+		Name:           MisbehaviorLunatic,
+		DecideProposal: lunaticProposal,
+	},
+	// neither propose nor vote.
+	MisbehaviorWithholding: {
+		Name:           MisbehaviorWithholding,
+		DecideProposal: func(*Reactor, int64, int32) {},
+		DoPrevote:      func(*Reactor, int64, int32) {},
+		DoPrecommit:    func(*Reactor, int64, int32, types.BlockID) {},
+	},
+}
+
+// ParseMisbehaviors parses a comma-separated list of height:name pairs, e.g.
+// "10:double-prevote,15:amnesia", into misbehaviors by height.
+func ParseMisbehaviors(s string) (map[int64]Misbehavior, error) {
+	misbehaviors := make(map[int64]Misbehavior)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid misbehavior %q, expected height:name", item)
+		}
+		height, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil || height <= 0 {
+			return nil, fmt.Errorf("invalid misbehavior height %q", parts[0])
+		}
+		misbehavior, ok := Misbehaviors[parts[1]]
+		if !ok {
+			return nil, fmt.Errorf("unknown misbehavior %q, expected one of %v", parts[1], MisbehaviorNames())
+		}
+		if _, ok := misbehaviors[height]; ok {
+			return nil, fmt.Errorf("duplicate misbehavior at height %d", height)
+		}
+		misbehaviors[height] = misbehavior
+	}
+	return misbehaviors, nil
+}
+
+// MisbehaviorNames returns the sorted names of the built-in misbehaviors.
+func MisbehaviorNames() []string {
+	names := make([]string, 0, len(Misbehaviors))
+	for name := range Misbehaviors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetMisbehaviors makes the validator misbehave at the given heights and
+// behave normally at all others. It must be called before the reactor is
+// started.
+func (conR *Reactor) SetMisbehaviors(misbehaviors map[int64]Misbehavior) {
+	cs := conR.conS
+	cs.decideProposal = func(height int64, round int32) {
+		conR.submitEquivocations(height)
+		if m, ok := misbehaviors[height]; ok && m.DecideProposal != nil {
+			cs.Logger.Info("Misbehaving", "misbehavior", m.Name, "step", "propose", "height", height, "round", round)
+			m.DecideProposal(conR, height, round)
+			return
+		}
+		cs.defaultDecideProposal(height, round)
+	}
+	cs.doPrevote = func(height int64, round int32) {
+		conR.submitEquivocations(height)
+		if m, ok := misbehaviors[height]; ok && m.DoPrevote != nil {
+			cs.Logger.Info("Misbehaving", "misbehavior", m.Name, "step", "prevote", "height", height, "round", round)
+			m.DoPrevote(conR, height, round)
+			return
+		}
+		cs.defaultDoPrevote(height, round)
+	}
+	cs.doPrecommit = func(height int64, round int32, blockID types.BlockID) {
+		if m, ok := misbehaviors[height]; ok && m.DoPrecommit != nil {
+			cs.Logger.Info("Misbehaving", "misbehavior", m.Name, "step", "precommit", "height", height, "round", round)
+			m.DoPrecommit(conR, height, round, blockID)
+			return
+		}
+		cs.defaultDoPrecommit(height, round, blockID)
+	}
+}
+
+func doublePrevote(conR *Reactor, height int64, round int32) {
+	cs := conR.conS
+	if cs.ProposalBlock == nil {
+		cs.defaultDoPrevote(height, round)
+		return
+	}
+	blockID := types.BlockID{Hash: cs.ProposalBlock.Hash(), PartSetHeader: cs.ProposalBlockParts.Header()}
+	conR.equivocate(tmproto.PrevoteType, blockID)
+}
+
+func doublePrecommit(conR *Reactor, height int64, round int32, blockID types.BlockID) {
+	if len(blockID.Hash) == 0 {
+		conR.conS.defaultDoPrecommit(height, round, blockID)
+		return
+	}
+	conR.equivocate(tmproto.PrecommitType, blockID)
+}
+
+// equivocate signs a vote for the block and a conflicting nil vote, and sends
+// both to every peer. Peers count the first one and report the second as
+// duplicate vote evidence. The nil vote is sent first, so that peers can't
+// reach a majority for the block and move on to the next height with it before
+// receiving the conflicting vote. As the peers which do still ignore it, the
+// evidence is also added to the evidence pool of the validator once the height
+// is committed, for the evidence reactor to gossip it.
+func (conR *Reactor) equivocate(msgType tmproto.SignedMsgType, blockID types.BlockID) {
+	cs := conR.conS
+	if cs.privValidator == nil || cs.privValidatorPubKey == nil ||
+		!cs.Validators.HasAddress(cs.privValidatorPubKey.Address()) {
+		return
+	}
+
+	blockVote, err := cs.signVote(msgType, blockID.Hash, blockID.PartSetHeader)
+	if err != nil {
+		cs.Logger.Error("Error signing vote", "err", err)
+		return
+	}
+	nilVote, err := cs.signVote(msgType, nil, types.PartSetHeader{})
+	if err != nil {
+		cs.Logger.Error("Error signing conflicting vote", "err", err)
+		cs.sendInternalMessage(msgInfo{&VoteMessage{blockVote}, ""})
+		return
+	}
+
+	for _, peer := range conR.Switch.Peers().List() {
+		peer.Send(VoteChannel, MustEncode(&VoteMessage{nilVote}))
+		peer.Send(VoteChannel, MustEncode(&VoteMessage{blockVote}))
+	}
+	cs.sendInternalMessage(msgInfo{&VoteMessage{blockVote}, ""})
+	cs.Logger.Info("Signed and sent conflicting votes", "block", blockVote, "nil", nilVote)
+
+	// Timestamped like the evidence reported by the peers.
+	timestamp := cs.state.LastBlockTime
+	if cs.Height != cs.state.InitialHeight {
+		timestamp = sm.MedianTime(cs.LastCommit.MakeCommit(), cs.LastValidators)
+	}
+	conR.equivocations = append(conR.equivocations, types.NewDuplicateVoteEvidence(blockVote, nilVote, timestamp))
+}
+
+// submitEquivocations adds the evidence of the equivocations of the heights
+// before height, which are committed, to the evidence pool of the validator.
+func (conR *Reactor) submitEquivocations(height int64) {
+	cs := conR.conS
+	pending := conR.equivocations[:0]
+	for _, ev := range conR.equivocations {
+		if ev.Height() >= height {
+			pending = append(pending, ev)
+			continue
+		}
+		if err := cs.evpool.AddEvidence(ev); err != nil {
+			cs.Logger.Error("Failed to add the evidence of the equivocation", "evidence", ev, "err", err)
+		}
+	}
+	conR.equivocations = pending
+}
+
+func amnesiaPrevote(conR *Reactor, height int64, round int32) {
+	cs := conR.conS
+	if cs.LockedBlock != nil {
+		cs.Logger.Info("Forgetting lock", "lockedRound", cs.LockedRound, "lockedBlock", cs.LockedBlock.Hash())
+		cs.LockedRound = -1
+		cs.LockedBlock = nil
+		cs.LockedBlockParts = nil
+	}
+	cs.defaultDoPrevote(height, round)
+}
+
+func lunaticProposal(conR *Reactor, height int64, round int32) {
+	cs := conR.conS
+	block, _ := cs.createProposalBlock()
+	if block == nil {
+		return
+	}
+	block.AppHash = tmrand.Bytes(tmhash.Size)
+	blockParts := block.MakePartSet(types.BlockPartSizeBytes)
+
+	if err := cs.wal.FlushAndSync(); err != nil {
+		cs.Logger.Error("Error flushing to disk")
+	}
+
+	propBlockID := types.BlockID{Hash: block.Hash(), PartSetHeader: blockParts.Header()}
+	proposal := types.NewProposal(height, round, -1, propBlockID)
+	p := proposal.ToProto()
+	if err := cs.privValidator.SignProposal(cs.state.ChainID, p); err != nil {
+		cs.Logger.Error("Error signing lunatic proposal", "err", err)
+		return
+	}
+	proposal.Signature = p.Signature
+
+	cs.sendInternalMessage(msgInfo{&ProposalMessage{proposal}, ""})
+	for i := 0; i < int(blockParts.Total()); i++ {
+		part := blockParts.GetPart(i)
+		cs.sendInternalMessage(msgInfo{&BlockPartMessage{cs.Height, cs.Round, part}, ""})
+	}
+	cs.Logger.Info("Signed lunatic proposal", "height", height, "round", round, "proposal", proposal)
+}
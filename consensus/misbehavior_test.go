@@ -0,0 +1,25 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMisbehaviors(t *testing.T) {
+	misbehaviors, err := ParseMisbehaviors("10:double-prevote, 12:amnesia,")
+	require.NoError(t, err)
+	require.Len(t, misbehaviors, 2)
+	assert.Equal(t, MisbehaviorDoublePrevote, misbehaviors[10].Name)
+	assert.Equal(t, MisbehaviorAmnesia, misbehaviors[12].Name)
+
+	misbehaviors, err = ParseMisbehaviors("")
+	require.NoError(t, err)
+	assert.Empty(t, misbehaviors)
+
+	for _, s := range []string{"double-prevote", "x:amnesia", "0:amnesia", "10:explode", "10:amnesia,10:withholding"} {
+		_, err := ParseMisbehaviors(s)
+		assert.Error(t, err, s)
+	}
+}
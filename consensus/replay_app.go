@@ -0,0 +1,174 @@
+package consensus
+
+import (
+	"bytes"
+	"fmt"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/log"
+	tmstate "github.com/tendermint/tendermint/proto/tendermint/state"
+	"github.com/tendermint/tendermint/proxy"
+	sm "github.com/tendermint/tendermint/state"
+	"github.com/tendermint/tendermint/types"
+)
+
+// ErrAppHashMismatch is returned by ReplayApp when the application returns a
+// different app hash or results than recorded for a block.
+type ErrAppHashMismatch struct {
+	Height int64
+	Field  string
+	Got    []byte
+	Want   []byte
+	// Index of the first DeliverTx response which differs, or -1 if unknown.
+	TxIndex int
+}
+
+func (e ErrAppHashMismatch) Error() string {
+	msg := fmt.Sprintf("%v mismatch at height %v: got %X, expected %X", e.Field, e.Height, e.Got, e.Want)
+	if e.TxIndex >= 0 {
+		msg += fmt.Sprintf(" (first differing DeliverTx response at index %v)", e.TxIndex)
+	}
+	return msg
+}
+
+// replayAppStore is the node's state store, from which the replay reads the
+// validator sets, but which discards the states and ABCI responses of the
+// replayed blocks, keeping the last responses for comparison.
+type replayAppStore struct {
+	sm.Store
+	abciResponses *tmstate.ABCIResponses
+}
+
+func (s *replayAppStore) Save(sm.State) error                     { return nil }
+func (s *replayAppStore) SaveApplicationRetainHeight(int64) error { return nil }
+func (s *replayAppStore) SaveABCIResponses(_ int64, abciResponses *tmstate.ABCIResponses) error {
+	s.abciResponses = abciResponses
+	return nil
+}
+
+// ReplayApp re-executes the stored blocks from the given heights (inclusive)
+// against the application through a BlockExecutor, and compares the app hash
+// and results of each block with the ones recorded in the next header or in
+// the state. The application must be at the height before from, or be fresh
+// if from is the initial height, in which case InitChain is called. The
+// stores aren't modified.
+//
+// It's used to reproduce non-determinism bugs of the application, and to
+// rebuild the application state, e.g. after a schema migration, without
+// syncing from the network.
+func ReplayApp(
+	stateStore sm.Store,
+	blockStore sm.BlockStore,
+	genDoc *types.GenesisDoc,
+	proxyApp proxy.AppConns,
+	from, to int64,
+	logger log.Logger,
+) error {
+	if from < blockStore.Base() || to > blockStore.Height() || from > to {
+		return fmt.Errorf("invalid height range %v-%v, heights %v-%v are stored",
+			from, to, blockStore.Base(), blockStore.Height())
+	}
+	latest, err := stateStore.Load()
+	if err != nil {
+		return err
+	}
+	state, err := sm.RebuildState(stateStore, blockStore, from-1)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild the state at height %v: %w", from-1, err)
+	}
+
+	res, err := proxyApp.Query().InfoSync(proxy.RequestInfo)
+	if err != nil {
+		return fmt.Errorf("error calling Info: %v", err)
+	}
+	switch {
+	case res.LastBlockHeight == 0 && from == state.InitialHeight:
+		if err := replayAppInitChain(proxyApp, genDoc, state); err != nil {
+			return err
+		}
+	case res.LastBlockHeight != from-1:
+		return fmt.Errorf("app is at height %v, but replaying from height %v needs it at height %v",
+			res.LastBlockHeight, from, from-1)
+	case !bytes.Equal(res.LastBlockAppHash, state.AppHash):
+		return ErrAppHashMismatch{Height: from - 1, Field: "app hash", Got: res.LastBlockAppHash,
+			Want: state.AppHash, TxIndex: -1}
+	}
+
+	store := &replayAppStore{Store: stateStore}
+	blockExec := sm.NewBlockExecutor(store, logger, proxyApp.Consensus(), emptyMempool{}, emptyEvidencePool{})
+	for height := from; height <= to; height++ {
+		block := blockStore.LoadBlock(height)
+		if block == nil {
+			return fmt.Errorf("block at height %v not found", height)
+		}
+		blockID := blockStore.LoadBlockMeta(height).BlockID
+		state, _, err = blockExec.ApplyBlock(state, blockID, block)
+		if err != nil {
+			return fmt.Errorf("failed to apply block at height %v: %w", height, err)
+		}
+
+		// The results of a block are recorded in the next header, or in the
+		// state for the latest block.
+		var wantAppHash, wantResultsHash []byte
+		if nextMeta := blockStore.LoadBlockMeta(height + 1); nextMeta != nil {
+			wantAppHash, wantResultsHash = nextMeta.Header.AppHash, nextMeta.Header.LastResultsHash
+		} else if latest.LastBlockHeight == height {
+			wantAppHash, wantResultsHash = latest.AppHash, latest.LastResultsHash
+		} else {
+			logger.Info("No recorded results to compare with", "height", height)
+			continue
+		}
+		if !bytes.Equal(state.LastResultsHash, wantResultsHash) {
+			return ErrAppHashMismatch{Height: height, Field: "results hash", Got: state.LastResultsHash,
+				Want: wantResultsHash, TxIndex: firstDifferingTx(stateStore, height, store.abciResponses)}
+		}
+		if !bytes.Equal(state.AppHash, wantAppHash) {
+			return ErrAppHashMismatch{Height: height, Field: "app hash", Got: state.AppHash,
+				Want: wantAppHash, TxIndex: -1}
+		}
+		logger.Info("Replayed block", "height", height, "appHash", state.AppHash)
+	}
+	return nil
+}
+
+// replayAppInitChain initializes a fresh application like the Handshaker.
+func replayAppInitChain(proxyApp proxy.AppConns, genDoc *types.GenesisDoc, state sm.State) error {
+	validators := make([]*types.Validator, len(genDoc.Validators))
+	for i, val := range genDoc.Validators {
+		validators[i] = types.NewValidator(val.PubKey, val.Power)
+	}
+	res, err := proxyApp.Consensus().InitChainSync(abci.RequestInitChain{
+		Time:            genDoc.GenesisTime,
+		ChainId:         genDoc.ChainID,
+		InitialHeight:   genDoc.InitialHeight,
+		ConsensusParams: types.TM2PB.ConsensusParams(genDoc.ConsensusParams),
+		Validators:      types.TM2PB.ValidatorUpdates(types.NewValidatorSet(validators)),
+		AppStateBytes:   genDoc.AppState,
+	})
+	if err != nil {
+		return err
+	}
+	if len(res.AppHash) > 0 && !bytes.Equal(res.AppHash, state.AppHash) {
+		return ErrAppHashMismatch{Height: 0, Field: "app hash", Got: res.AppHash, Want: state.AppHash, TxIndex: -1}
+	}
+	return nil
+}
+
+// firstDifferingTx returns the index of the first DeliverTx response which
+// differs from the stored one, or -1 if the stored responses were pruned.
+func firstDifferingTx(stateStore sm.Store, height int64, abciResponses *tmstate.ABCIResponses) int {
+	stored, err := stateStore.LoadABCIResponses(height)
+	if err != nil || abciResponses == nil {
+		return -1
+	}
+	results, storedResults := types.NewResults(abciResponses.DeliverTxs), types.NewResults(stored.DeliverTxs)
+	for i := range results {
+		if i >= len(storedResults) || !bytes.Equal(results[i:i+1].Hash(), storedResults[i:i+1].Hash()) {
+			return i
+		}
+	}
+	if len(results) != len(storedResults) {
+		return len(results)
+	}
+	return -1
+}
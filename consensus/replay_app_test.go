@@ -0,0 +1,104 @@
+package consensus
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	dbm "github.com/tendermint/tm-db"
+
+	"github.com/tendermint/tendermint/abci/example/kvstore"
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/proxy"
+	sm "github.com/tendermint/tendermint/state"
+	"github.com/tendermint/tendermint/store"
+	"github.com/tendermint/tendermint/types"
+	tmtime "github.com/tendermint/tendermint/types/time"
+)
+
+// nonDeterministicApp is a kvstore which fails a transaction given by key.
+type nonDeterministicApp struct {
+	*kvstore.Application
+	badTx types.Tx
+}
+
+func (app *nonDeterministicApp) DeliverTx(req abci.RequestDeliverTx) abci.ResponseDeliverTx {
+	if string(req.Tx) == string(app.badTx) {
+		return abci.ResponseDeliverTx{Code: 1}
+	}
+	return app.Application.DeliverTx(req)
+}
+
+func startProxyApp(t *testing.T, app abci.Application) proxy.AppConns {
+	proxyApp := proxy.NewAppConns(proxy.NewLocalClientCreator(app), proxy.NopMetrics())
+	require.NoError(t, proxyApp.Start())
+	t.Cleanup(func() { require.NoError(t, proxyApp.Stop()) })
+	return proxyApp
+}
+
+func TestReplayApp(t *testing.T) {
+	privVal := types.NewMockPV()
+	pubKey, err := privVal.GetPubKey()
+	require.NoError(t, err)
+	genDoc := &types.GenesisDoc{
+		ChainID:     "replay-app-test",
+		GenesisTime: tmtime.Now(),
+		Validators:  []types.GenesisValidator{{PubKey: pubKey, Power: 10}},
+	}
+	require.NoError(t, genDoc.ValidateAndComplete())
+
+	// Execute 10 blocks with a transaction each against the kvstore.
+	blockStore := store.NewBlockStore(dbm.NewMemDB())
+	stateStore := sm.NewStore(dbm.NewMemDB())
+	state, err := stateStore.LoadFromDBOrGenesisDoc(genDoc)
+	require.NoError(t, err)
+	require.NoError(t, stateStore.Save(state))
+	blockExec := sm.NewBlockExecutor(stateStore, log.TestingLogger(),
+		startProxyApp(t, kvstore.NewApplication()).Consensus(), emptyMempool{}, emptyEvidencePool{})
+	lastCommit := types.NewCommit(0, 0, types.BlockID{}, nil)
+	for h := int64(1); h <= 10; h++ {
+		txs := []types.Tx{types.Tx(fmt.Sprintf("key%d=value", h))}
+		block, parts := state.MakeBlock(h, txs, lastCommit, nil, state.Validators.Proposer.Address)
+		blockID := types.BlockID{Hash: block.Hash(), PartSetHeader: parts.Header()}
+		vote, err := types.MakeVote(h, blockID, state.Validators, privVal, genDoc.ChainID, time.Now())
+		require.NoError(t, err)
+		lastCommit = types.NewCommit(h, 0, blockID, []types.CommitSig{vote.CommitSig()})
+		blockStore.SaveBlock(block, parts, lastCommit)
+		state, _, err = blockExec.ApplyBlock(state, blockID, block)
+		require.NoError(t, err)
+	}
+	logger := log.TestingLogger()
+
+	// A fresh application replays the whole chain, in several runs.
+	proxyApp := startProxyApp(t, kvstore.NewApplication())
+	require.NoError(t, ReplayApp(stateStore, blockStore, genDoc, proxyApp, 1, 4, logger))
+	require.NoError(t, ReplayApp(stateStore, blockStore, genDoc, proxyApp, 5, 10, logger))
+	res, err := proxyApp.Query().InfoSync(proxy.RequestInfo)
+	require.NoError(t, err)
+	assert.EqualValues(t, 10, res.LastBlockHeight)
+	assert.EqualValues(t, state.AppHash, res.LastBlockAppHash)
+
+	// The stores aren't modified.
+	stored, err := stateStore.Load()
+	require.NoError(t, err)
+	assert.Equal(t, state.Bytes(), stored.Bytes())
+
+	// The application must be at the height before the first replayed block.
+	err = ReplayApp(stateStore, blockStore, genDoc, startProxyApp(t, kvstore.NewApplication()), 5, 10, logger)
+	require.Error(t, err)
+	err = ReplayApp(stateStore, blockStore, genDoc, startProxyApp(t, kvstore.NewApplication()), 0, 11, logger)
+	require.Error(t, err)
+
+	// A non-deterministic application is caught at the first differing block.
+	app := &nonDeterministicApp{Application: kvstore.NewApplication(), badTx: types.Tx("key6=value")}
+	err = ReplayApp(stateStore, blockStore, genDoc, startProxyApp(t, app), 1, 10, logger)
+	var mismatch ErrAppHashMismatch
+	require.True(t, errors.As(err, &mismatch), err)
+	assert.EqualValues(t, 6, mismatch.Height)
+	assert.Equal(t, "results hash", mismatch.Field)
+	assert.Equal(t, 0, mismatch.TxIndex)
+}
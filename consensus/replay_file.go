@@ -110,6 +110,8 @@ type playback struct {
 	// replays can be reset to beginning
 	fileName     string   // so we can close/reopen the file
 	genesisState sm.State // so the replay session knows where to restart from
+
+	prevRS map[string]string // round state snapshot taken before the last "next"
 }
 
 func newPlayback(fileName string, fp *os.File, cs *State, genState sm.State) *playback {
@@ -200,6 +202,7 @@ func (pb *playback) replayConsoleLoop() int {
 			// "next" -> replay next message
 			// "next N" -> replay next N messages
 
+			pb.prevRS = roundStateFields(&pb.cs.RoundState)
 			if len(tokens) == 1 {
 				return 0
 			}
@@ -248,6 +251,7 @@ func (pb *playback) replayConsoleLoop() int {
 		case "rs":
 			// "rs" -> print entire round state
 			// "rs short" -> print height/round/step
+			// "rs diff" -> print the fields changed by the last "next"
 			// "rs <field>" -> print another field of the round state
 
 			rs := pb.cs.RoundState
@@ -269,6 +273,18 @@ func (pb *playback) replayConsoleLoop() int {
 					fmt.Printf("%v %v\n", rs.LockedBlockParts.StringShort(), rs.LockedBlock.StringShort())
 				case "votes":
 					fmt.Println(rs.Votes.StringIndented("  "))
+				case "diff":
+					if pb.prevRS == nil {
+						fmt.Println("no previous round state, use next first")
+						break
+					}
+					diff := diffRoundStates(pb.prevRS, roundStateFields(&rs))
+					if len(diff) == 0 {
+						fmt.Println("no changes")
+					}
+					for _, d := range diff {
+						fmt.Println(d)
+					}
 
 				default:
 					fmt.Println("Unknown option", tokens[1])
@@ -309,7 +325,7 @@ func newConsensusStateForReplay(config cfg.BaseConfig, csConfig *cfg.ConsensusCo
 
 	// Create proxyAppConn connection (consensus, mempool, query)
 	clientCreator := proxy.DefaultClientCreator(config.ProxyApp, config.ABCI, config.DBDir())
-	proxyApp := proxy.NewAppConns(clientCreator)
+	proxyApp := proxy.NewAppConns(clientCreator, proxy.NopMetrics())
 	err = proxyApp.Start()
 	if err != nil {
 		tmos.Exit(fmt.Sprintf("Error starting proxy app conns: %v", err))
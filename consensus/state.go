@@ -2,12 +2,14 @@ package consensus
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"reflect"
 	"runtime/debug"
+	"strings"
 	"time"
 
 	"github.com/gogo/protobuf/proto"
@@ -23,6 +25,7 @@ import (
 	tmos "github.com/tendermint/tendermint/libs/os"
 	"github.com/tendermint/tendermint/libs/service"
 	tmsync "github.com/tendermint/tendermint/libs/sync"
+	"github.com/tendermint/tendermint/libs/trace"
 	"github.com/tendermint/tendermint/p2p"
 	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
 	sm "github.com/tendermint/tendermint/state"
@@ -134,6 +137,7 @@ type State struct {
 	// some functions can be overwritten for testing
 	decideProposal func(height int64, round int32)
 	doPrevote      func(height int64, round int32)
+	doPrecommit    func(height int64, round int32, blockID types.BlockID)
 	setProposal    func(proposal *types.Proposal) error
 
 	// closed when we finish shutting down
@@ -145,6 +149,18 @@ type State struct {
 
 	// for reporting metrics
 	metrics *Metrics
+
+	// for tracing the heights and their steps
+	tracer     *trace.Tracer
+	heightSpan *trace.Span
+	stepSpan   *trace.Span
+
+	// timeline of the last heights, to diagnose why their rounds failed
+	timeline timeline
+
+	// whether to prune the blocks below the application's retain height on
+	// commit, unless a pruner does it
+	pruneOnCommit bool
 }
 
 // StateOption sets an optional parameter on the State.
@@ -175,10 +191,13 @@ func NewState(
 		evpool:           evpool,
 		evsw:             tmevents.NewEventSwitch(),
 		metrics:          NopMetrics(),
+		tracer:           trace.NopTracer(),
+		pruneOnCommit:    true,
 	}
 	// set function defaults (may be overwritten before calling Start)
 	cs.decideProposal = cs.defaultDecideProposal
 	cs.doPrevote = cs.defaultDoPrevote
+	cs.doPrecommit = cs.defaultDoPrecommit
 	cs.setProposal = cs.defaultSetProposal
 
 	// We have no votes, so reconstruct LastCommit from SeenCommit.
@@ -218,6 +237,19 @@ func StateMetrics(metrics *Metrics) StateOption {
 	return func(cs *State) { cs.metrics = metrics }
 }
 
+// StateWithoutPruning leaves the pruning of the blocks below the retain height
+// returned by the application to a pruner (see sm.Pruner), instead of pruning
+// them on commit.
+func StateWithoutPruning() StateOption {
+	return func(cs *State) { cs.pruneOnCommit = false }
+}
+
+// StateTracer sets the tracer of the heights, their steps and the blocks
+// applied.
+func StateTracer(tracer *trace.Tracer) StateOption {
+	return func(cs *State) { cs.tracer = tracer }
+}
+
 // String returns a string.
 func (cs *State) String() string {
 	// better not to access shared variables
@@ -514,6 +546,8 @@ func (cs *State) updateHeight(height int64) {
 func (cs *State) updateRoundStep(round int32, step cstypes.RoundStepType) {
 	cs.Round = round
 	cs.Step = step
+	cs.recordStep()
+	cs.traceStep()
 }
 
 // enterNewRound(height, 0) at cs.StartTime.
@@ -673,6 +707,22 @@ func (cs *State) newStep() {
 	}
 }
 
+// traceStep ends the span of the previous step, and starts the span of the
+// current one, as a child of the span of the height.
+func (cs *State) traceStep() {
+	if !cs.tracer.Enabled() {
+		return
+	}
+	cs.stepSpan.End()
+	if cs.Step == cstypes.RoundStepNewHeight || cs.heightSpan == nil {
+		cs.heightSpan.End()
+		cs.heightSpan = cs.tracer.StartSpan("consensus.Height", trace.SpanContext{}, "height", cs.Height)
+	}
+	step := strings.TrimPrefix(cs.Step.String(), "RoundStep")
+	cs.stepSpan = cs.tracer.StartSpan("consensus."+step, cs.heightSpan.Context(),
+		"height", cs.Height, "round", cs.Round)
+}
+
 //-----------------------------------------
 // the main go routines
 
@@ -693,6 +743,9 @@ func (cs *State) receiveRoutine(maxSteps int) {
 		}
 		cs.wal.Wait()
 
+		cs.stepSpan.End()
+		cs.heightSpan.End()
+
 		close(cs.done)
 	}
 
@@ -908,13 +961,7 @@ func (cs *State) enterNewRound(height int64, round int32) {
 	logger := cs.Logger.With("height", height, "round", round)
 
 	if cs.Height != height || round < cs.Round || (cs.Round == round && cs.Step != cstypes.RoundStepNewHeight) {
-		logger.Debug(fmt.Sprintf(
-			"enterNewRound(%v/%v): Invalid args. Current step: %v/%v/%v",
-			height,
-			round,
-			cs.Height,
-			cs.Round,
-			cs.Step))
+		logger.Debug("enterNewRound: invalid args", "current", fmt.Sprintf("%v/%v/%v", cs.Height, cs.Round, cs.Step))
 		return
 	}
 
@@ -922,7 +969,7 @@ func (cs *State) enterNewRound(height int64, round int32) {
 		logger.Info("Need to set a buffer and log message here for sanity.", "startTime", cs.StartTime, "now", now)
 	}
 
-	logger.Info(fmt.Sprintf("enterNewRound(%v/%v). Current: %v/%v/%v", height, round, cs.Height, cs.Round, cs.Step))
+	logger.Info("enterNewRound", "current", fmt.Sprintf("%v/%v/%v", cs.Height, cs.Round, cs.Step))
 
 	// Increment validators if necessary
 	validators := cs.Validators
@@ -931,10 +978,16 @@ func (cs *State) enterNewRound(height int64, round int32) {
 		validators.IncrementProposerPriority(tmmath.SafeSubInt32(round, cs.Round))
 	}
 
+	// The current round failed, unless we start the height.
+	if cs.Step != cstypes.RoundStepNewHeight {
+		cs.recordRoundEnd(cs.Round, cs.roundEndReason(round))
+	}
+
 	// Setup new round
 	// we don't fire newStep for this step,
 	// but we fire an event, so update the round step first
 	cs.updateRoundStep(round, cstypes.RoundStepNewRound)
+	cs.recordRoundStart(round)
 	cs.Validators = validators
 	if round == 0 {
 		// We've already reset these upon new height,
@@ -990,16 +1043,10 @@ func (cs *State) enterPropose(height int64, round int32) {
 	logger := cs.Logger.With("height", height, "round", round)
 
 	if cs.Height != height || round < cs.Round || (cs.Round == round && cstypes.RoundStepPropose <= cs.Step) {
-		logger.Debug(fmt.Sprintf(
-			"enterPropose(%v/%v): Invalid args. Current step: %v/%v/%v",
-			height,
-			round,
-			cs.Height,
-			cs.Round,
-			cs.Step))
+		logger.Debug("enterPropose: invalid args", "current", fmt.Sprintf("%v/%v/%v", cs.Height, cs.Round, cs.Step))
 		return
 	}
-	logger.Info(fmt.Sprintf("enterPropose(%v/%v). Current: %v/%v/%v", height, round, cs.Height, cs.Round, cs.Step))
+	logger.Info("enterPropose", "current", fmt.Sprintf("%v/%v/%v", cs.Height, cs.Round, cs.Step))
 
 	defer func() {
 		// Done enterPropose:
@@ -1158,14 +1205,10 @@ func (cs *State) createProposalBlock() (block *types.Block, blockParts *types.Pa
 // Prevote for LockedBlock if we're locked, or ProposalBlock if valid.
 // Otherwise vote nil.
 func (cs *State) enterPrevote(height int64, round int32) {
+	logger := cs.Logger.With("height", height, "round", round)
+
 	if cs.Height != height || round < cs.Round || (cs.Round == round && cstypes.RoundStepPrevote <= cs.Step) {
-		cs.Logger.Debug(fmt.Sprintf(
-			"enterPrevote(%v/%v): Invalid args. Current step: %v/%v/%v",
-			height,
-			round,
-			cs.Height,
-			cs.Round,
-			cs.Step))
+		logger.Debug("enterPrevote: invalid args", "current", fmt.Sprintf("%v/%v/%v", cs.Height, cs.Round, cs.Step))
 		return
 	}
 
@@ -1175,7 +1218,7 @@ func (cs *State) enterPrevote(height int64, round int32) {
 		cs.newStep()
 	}()
 
-	cs.Logger.Info(fmt.Sprintf("enterPrevote(%v/%v). Current: %v/%v/%v", height, round, cs.Height, cs.Round, cs.Step))
+	logger.Info("enterPrevote", "current", fmt.Sprintf("%v/%v/%v", cs.Height, cs.Round, cs.Step))
 
 	// Sign and broadcast vote as necessary
 	cs.doPrevote(height, round)
@@ -1222,19 +1265,13 @@ func (cs *State) enterPrevoteWait(height int64, round int32) {
 	logger := cs.Logger.With("height", height, "round", round)
 
 	if cs.Height != height || round < cs.Round || (cs.Round == round && cstypes.RoundStepPrevoteWait <= cs.Step) {
-		logger.Debug(fmt.Sprintf(
-			"enterPrevoteWait(%v/%v): Invalid args. Current step: %v/%v/%v",
-			height,
-			round,
-			cs.Height,
-			cs.Round,
-			cs.Step))
+		logger.Debug("enterPrevoteWait: invalid args", "current", fmt.Sprintf("%v/%v/%v", cs.Height, cs.Round, cs.Step))
 		return
 	}
 	if !cs.Votes.Prevotes(round).HasTwoThirdsAny() {
 		panic(fmt.Sprintf("enterPrevoteWait(%v/%v), but Prevotes does not have any +2/3 votes", height, round))
 	}
-	logger.Info(fmt.Sprintf("enterPrevoteWait(%v/%v). Current: %v/%v/%v", height, round, cs.Height, cs.Round, cs.Step))
+	logger.Info("enterPrevoteWait", "current", fmt.Sprintf("%v/%v/%v", cs.Height, cs.Round, cs.Step))
 
 	defer func() {
 		// Done enterPrevoteWait:
@@ -1256,17 +1293,11 @@ func (cs *State) enterPrecommit(height int64, round int32) {
 	logger := cs.Logger.With("height", height, "round", round)
 
 	if cs.Height != height || round < cs.Round || (cs.Round == round && cstypes.RoundStepPrecommit <= cs.Step) {
-		logger.Debug(fmt.Sprintf(
-			"enterPrecommit(%v/%v): Invalid args. Current step: %v/%v/%v",
-			height,
-			round,
-			cs.Height,
-			cs.Round,
-			cs.Step))
+		logger.Debug("enterPrecommit: invalid args", "current", fmt.Sprintf("%v/%v/%v", cs.Height, cs.Round, cs.Step))
 		return
 	}
 
-	logger.Info(fmt.Sprintf("enterPrecommit(%v/%v). Current: %v/%v/%v", height, round, cs.Height, cs.Round, cs.Step))
+	logger.Info("enterPrecommit", "current", fmt.Sprintf("%v/%v/%v", cs.Height, cs.Round, cs.Step))
 
 	defer func() {
 		// Done enterPrecommit:
@@ -1284,7 +1315,7 @@ func (cs *State) enterPrecommit(height int64, round int32) {
 		} else {
 			logger.Info("enterPrecommit: No +2/3 prevotes during enterPrecommit. Precommitting nil.")
 		}
-		cs.signAddVote(tmproto.PrecommitType, nil, types.PartSetHeader{})
+		cs.doPrecommit(height, round, types.BlockID{})
 		return
 	}
 
@@ -1312,7 +1343,7 @@ func (cs *State) enterPrecommit(height int64, round int32) {
 				cs.Logger.Error("Error publishing event unlock", "err", err)
 			}
 		}
-		cs.signAddVote(tmproto.PrecommitType, nil, types.PartSetHeader{})
+		cs.doPrecommit(height, round, types.BlockID{})
 		return
 	}
 
@@ -1325,7 +1356,7 @@ func (cs *State) enterPrecommit(height int64, round int32) {
 		if err := cs.eventBus.PublishEventRelock(cs.RoundStateEvent()); err != nil {
 			cs.Logger.Error("Error publishing event relock", "err", err)
 		}
-		cs.signAddVote(tmproto.PrecommitType, blockID.Hash, blockID.PartSetHeader)
+		cs.doPrecommit(height, round, blockID)
 		return
 	}
 
@@ -1342,7 +1373,7 @@ func (cs *State) enterPrecommit(height int64, round int32) {
 		if err := cs.eventBus.PublishEventLock(cs.RoundStateEvent()); err != nil {
 			cs.Logger.Error("Error publishing event lock", "err", err)
 		}
-		cs.signAddVote(tmproto.PrecommitType, blockID.Hash, blockID.PartSetHeader)
+		cs.doPrecommit(height, round, blockID)
 		return
 	}
 
@@ -1360,7 +1391,11 @@ func (cs *State) enterPrecommit(height int64, round int32) {
 	if err := cs.eventBus.PublishEventUnlock(cs.RoundStateEvent()); err != nil {
 		cs.Logger.Error("Error publishing event unlock", "err", err)
 	}
-	cs.signAddVote(tmproto.PrecommitType, nil, types.PartSetHeader{})
+	cs.doPrecommit(height, round, types.BlockID{})
+}
+
+func (cs *State) defaultDoPrecommit(height int64, round int32, blockID types.BlockID) {
+	cs.signAddVote(tmproto.PrecommitType, blockID.Hash, blockID.PartSetHeader)
 }
 
 // Enter: any +2/3 precommits for next round.
@@ -1368,17 +1403,15 @@ func (cs *State) enterPrecommitWait(height int64, round int32) {
 	logger := cs.Logger.With("height", height, "round", round)
 
 	if cs.Height != height || round < cs.Round || (cs.Round == round && cs.TriggeredTimeoutPrecommit) {
-		logger.Debug(
-			fmt.Sprintf(
-				"enterPrecommitWait(%v/%v): Invalid args. "+
-					"Current state is Height/Round: %v/%v/, TriggeredTimeoutPrecommit:%v",
-				height, round, cs.Height, cs.Round, cs.TriggeredTimeoutPrecommit))
+		logger.Debug("enterPrecommitWait: invalid args",
+			"current", fmt.Sprintf("%v/%v", cs.Height, cs.Round),
+			"triggeredTimeoutPrecommit", cs.TriggeredTimeoutPrecommit)
 		return
 	}
 	if !cs.Votes.Precommits(round).HasTwoThirdsAny() {
 		panic(fmt.Sprintf("enterPrecommitWait(%v/%v), but Precommits does not have any +2/3 votes", height, round))
 	}
-	logger.Info(fmt.Sprintf("enterPrecommitWait(%v/%v). Current: %v/%v/%v", height, round, cs.Height, cs.Round, cs.Step))
+	logger.Info("enterPrecommitWait", "current", fmt.Sprintf("%v/%v/%v", cs.Height, cs.Round, cs.Step))
 
 	defer func() {
 		// Done enterPrecommitWait:
@@ -1392,19 +1425,13 @@ func (cs *State) enterPrecommitWait(height int64, round int32) {
 
 // Enter: +2/3 precommits for block
 func (cs *State) enterCommit(height int64, commitRound int32) {
-	logger := cs.Logger.With("height", height, "commitRound", commitRound)
+	logger := cs.Logger.With("height", height, "round", commitRound)
 
 	if cs.Height != height || cstypes.RoundStepCommit <= cs.Step {
-		logger.Debug(fmt.Sprintf(
-			"enterCommit(%v/%v): Invalid args. Current step: %v/%v/%v",
-			height,
-			commitRound,
-			cs.Height,
-			cs.Round,
-			cs.Step))
+		logger.Debug("enterCommit: invalid args", "current", fmt.Sprintf("%v/%v/%v", cs.Height, cs.Round, cs.Step))
 		return
 	}
-	logger.Info(fmt.Sprintf("enterCommit(%v/%v). Current: %v/%v/%v", height, commitRound, cs.Height, cs.Round, cs.Step))
+	logger.Info("enterCommit", "current", fmt.Sprintf("%v/%v/%v", cs.Height, cs.Round, cs.Step))
 
 	defer func() {
 		// Done enterCommit:
@@ -1487,13 +1514,10 @@ func (cs *State) tryFinalizeCommit(height int64) {
 
 // Increment height and goto cstypes.RoundStepNewHeight
 func (cs *State) finalizeCommit(height int64) {
+	logger := cs.Logger.With("height", height)
+
 	if cs.Height != height || cs.Step != cstypes.RoundStepCommit {
-		cs.Logger.Debug(fmt.Sprintf(
-			"finalizeCommit(%v): Invalid args. Current step: %v/%v/%v",
-			height,
-			cs.Height,
-			cs.Round,
-			cs.Step))
+		logger.Debug("finalizeCommit: invalid args", "current", fmt.Sprintf("%v/%v/%v", cs.Height, cs.Round, cs.Step))
 		return
 	}
 
@@ -1512,13 +1536,13 @@ func (cs *State) finalizeCommit(height int64) {
 	if err := cs.blockExec.ValidateBlock(cs.state, block); err != nil {
 		panic(fmt.Errorf("+2/3 committed an invalid block: %w", err))
 	}
+	cs.recordCommit()
 
-	cs.Logger.Info("Finalizing commit of block with N txs",
-		"height", block.Height,
+	logger.Info("Finalizing commit of block with N txs",
 		"hash", block.Hash(),
 		"root", block.AppHash,
 		"N", len(block.Txs))
-	cs.Logger.Info(fmt.Sprintf("%v", block))
+	logger.Info(fmt.Sprintf("%v", block))
 
 	fail.Fail() // XXX
 
@@ -1531,7 +1555,7 @@ func (cs *State) finalizeCommit(height int64) {
 		cs.blockStore.SaveBlock(block, blockParts, seenCommit)
 	} else {
 		// Happens during replay if we already saved the block but didn't commit
-		cs.Logger.Info("Calling finalizeCommit on already stored block", "height", block.Height)
+		logger.Info("Calling finalizeCommit on already stored block", "height", block.Height)
 	}
 
 	fail.Fail() // XXX
@@ -1564,24 +1588,25 @@ func (cs *State) finalizeCommit(height int64) {
 	// NOTE The block.AppHash wont reflect these txs until the next block.
 	var err error
 	var retainHeight int64
-	stateCopy, retainHeight, err = cs.blockExec.ApplyBlock(
+	stateCopy, retainHeight, err = cs.blockExec.ApplyBlockWithContext(
+		trace.ContextWithSpan(context.Background(), cs.stepSpan),
 		stateCopy,
 		types.BlockID{Hash: block.Hash(), PartSetHeader: blockParts.Header()},
 		block)
 	if err != nil {
-		cs.Logger.Error("Error on ApplyBlock", "err", err)
+		logger.Error("Error on ApplyBlock", "err", err)
 		return
 	}
 
 	fail.Fail() // XXX
 
 	// Prune old heights, if requested by ABCI app.
-	if retainHeight > 0 {
+	if retainHeight > 0 && cs.pruneOnCommit {
 		pruned, err := cs.pruneBlocks(retainHeight)
 		if err != nil {
-			cs.Logger.Error("Failed to prune blocks", "retainHeight", retainHeight, "err", err)
+			logger.Error("Failed to prune blocks", "retainHeight", retainHeight, "err", err)
 		} else {
-			cs.Logger.Info("Pruned blocks", "pruned", pruned, "retainHeight", retainHeight)
+			logger.Info("Pruned blocks", "pruned", pruned, "retainHeight", retainHeight)
 		}
 	}
 
@@ -1595,7 +1620,7 @@ func (cs *State) finalizeCommit(height int64) {
 
 	// Private validator might have changed it's key pair => refetch pubkey.
 	if err := cs.updatePrivValidatorPubKey(); err != nil {
-		cs.Logger.Error("Can't get private validator pubkey", "err", err)
+		logger.Error("Can't get private validator pubkey", "err", err)
 	}
 
 	// cs.StartTime is already set.
@@ -1735,6 +1760,7 @@ func (cs *State) defaultSetProposal(proposal *types.Proposal) error {
 
 	proposal.Signature = p.Signature
 	cs.Proposal = proposal
+	cs.recordProposal()
 	// We don't update cs.ProposalBlockParts if it is already set.
 	// This happens if we're already in cstypes.RoundStepCommit or if there is a valid block in the current round.
 	// TODO: We can check if Proposal is for a different block as this is a sign of misbehavior!
@@ -1786,6 +1812,7 @@ func (cs *State) addProposalBlockPart(msg *BlockPartMessage, peerID p2p.ID) (add
 		}
 
 		cs.ProposalBlock = block
+		cs.recordBlockParts()
 		// NOTE: it's possible to receive complete proposal blocks for future rounds without having the proposal
 		cs.Logger.Info("Received complete proposal block", "height", cs.ProposalBlock.Height, "hash", cs.ProposalBlock.Hash())
 		if err := cs.eventBus.PublishEventCompleteProposal(cs.CompleteProposalEvent()); err != nil {
@@ -1904,6 +1931,7 @@ func (cs *State) addVote(
 		if !added {
 			return
 		}
+		cs.recordVote(vote)
 
 		cs.Logger.Info(fmt.Sprintf("Added to lastPrecommits: %v", cs.LastCommit.StringShort()))
 		if err := cs.eventBus.PublishEventVote(types.EventDataVote{Vote: vote}); err != nil {
@@ -1924,7 +1952,7 @@ func (cs *State) addVote(
 	// Height mismatch is ignored.
 	// Not necessarily a bad peer, but not favourable behaviour.
 	if vote.Height != cs.Height {
-		cs.Logger.Info("Vote ignored and not added", "voteHeight", vote.Height, "csHeight", cs.Height, "peerID", peerID)
+		cs.Logger.Info("Vote ignored and not added", "voteHeight", vote.Height, "csHeight", cs.Height, "peer", peerID)
 		return
 	}
 
@@ -1934,6 +1962,7 @@ func (cs *State) addVote(
 		// Either duplicate, or error upon cs.Votes.AddByIndex()
 		return
 	}
+	cs.recordVote(vote)
 
 	if err := cs.eventBus.PublishEventVote(types.EventDataVote{Vote: vote}); err != nil {
 		return added, err
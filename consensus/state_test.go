@@ -3,6 +3,7 @@ package consensus
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
@@ -13,9 +14,11 @@ import (
 	"github.com/tendermint/tendermint/abci/example/counter"
 	cstypes "github.com/tendermint/tendermint/consensus/types"
 	"github.com/tendermint/tendermint/crypto/tmhash"
+	tmjson "github.com/tendermint/tendermint/libs/json"
 	"github.com/tendermint/tendermint/libs/log"
 	tmpubsub "github.com/tendermint/tendermint/libs/pubsub"
 	tmrand "github.com/tendermint/tendermint/libs/rand"
+	"github.com/tendermint/tendermint/libs/trace"
 	p2pmock "github.com/tendermint/tendermint/p2p/mock"
 	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
 	"github.com/tendermint/tendermint/types"
@@ -281,6 +284,157 @@ func TestStateFullRound1(t *testing.T) {
 	validateLastPrecommit(t, cs, vss[0], propBlockHash)
 }
 
+func TestStateTracing(t *testing.T) {
+	cs, _ := randState(1)
+	height, round := cs.Height, cs.Round
+
+	var buf bytes.Buffer
+	tracer := trace.NewTracer(trace.NewFileExporter(&buf, "test"))
+	require.NoError(t, tracer.Start())
+	cs.tracer = tracer
+
+	newRoundCh := subscribe(cs.eventBus, types.EventQueryNewRound)
+	startTestRound(cs, height, round)
+	ensureNewRound(newRoundCh, height, round)
+	ensureNewRound(newRoundCh, height+1, 0)
+	require.NoError(t, tracer.Stop())
+
+	// The steps of the first height are traced as children of the height.
+	type span struct {
+		Name         string `json:"name"`
+		SpanID       string `json:"spanId"`
+		ParentSpanID string `json:"parentSpanId"`
+	}
+	var data struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []span `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &data))
+	spans := make(map[string]span)
+	for _, s := range data.ResourceSpans[0].ScopeSpans[0].Spans {
+		if _, ok := spans[s.Name]; !ok {
+			spans[s.Name] = s
+		}
+	}
+	require.Contains(t, spans, "consensus.Height")
+	for _, step := range []string{"NewRound", "Propose", "Prevote", "Precommit", "Commit"} {
+		require.Contains(t, spans, "consensus."+step)
+		assert.Equal(t, spans["consensus.Height"].SpanID, spans["consensus."+step].ParentSpanID, step)
+	}
+}
+
+// getTimeline returns the timeline of the last heights of the consensus state.
+func getTimeline(t *testing.T, cs *State, heights int) []*cstypes.HeightTimeline {
+	bz, err := cs.GetTimelineJSON(heights)
+	require.NoError(t, err)
+	var timeline []*cstypes.HeightTimeline
+	require.NoError(t, tmjson.Unmarshal(bz, &timeline))
+	return timeline
+}
+
+func TestStateTimeline(t *testing.T) {
+	cs, _ := randState(1)
+	height, round := cs.Height, cs.Round
+
+	newRoundCh := subscribe(cs.eventBus, types.EventQueryNewRound)
+	startTestRound(cs, height, round)
+	ensureNewRound(newRoundCh, height, round)
+	ensureNewRound(newRoundCh, height+1, 0)
+
+	timeline := getTimeline(t, cs, 2)
+	require.Len(t, timeline, 2)
+	ht := timeline[0]
+	assert.Equal(t, height, ht.Height)
+	assert.EqualValues(t, round, ht.CommitRound)
+	assert.False(t, ht.CommitTime.Before(ht.StartTime))
+
+	var steps []string
+	for _, step := range ht.Steps {
+		steps = append(steps, step.Step)
+	}
+	assert.Equal(t, []string{"NewHeight", "NewRound", "Propose", "Prevote", "Precommit", "Commit"}, steps)
+
+	require.Len(t, ht.Rounds, 1)
+	rt := ht.Rounds[0]
+	assert.Equal(t, cstypes.RoundEndCommit, rt.EndReason)
+	assert.Empty(t, rt.MissedVotes)
+	assert.True(t, rt.ProposalDelay <= rt.BlockPartsDelay)
+	assert.True(t, rt.BlockPartsDelay <= rt.PrevoteQuorumDelay)
+	assert.True(t, rt.PrevoteQuorumDelay <= rt.PrecommitQuorumDelay)
+
+	assert.Equal(t, height+1, timeline[1].Height)
+	assert.EqualValues(t, -1, timeline[1].CommitRound)
+	assert.Len(t, getTimeline(t, cs, 1), 1)
+}
+
+// 4 vals, the others don't prevote, and precommit nil.
+// What we want:
+// round 0 ends for +2/3 nil precommits, with the missed prevotes, and the
+// RoundFailed event is published.
+func TestStateTimelineFailedRound(t *testing.T) {
+	cs1, vss := randState(4)
+	vs2, vs3, vs4 := vss[1], vss[2], vss[3]
+	height, round := cs1.Height, cs1.Round
+
+	newRoundCh := subscribe(cs1.eventBus, types.EventQueryNewRound)
+	roundFailedCh := subscribe(cs1.eventBus, types.EventQueryRoundFailed)
+	pv1, err := cs1.privValidator.GetPubKey()
+	require.NoError(t, err)
+	addr := pv1.Address()
+	voteCh := subscribeToVoter(cs1, addr)
+
+	startTestRound(cs1, height, round)
+	ensureNewRound(newRoundCh, height, round)
+	ensurePrevote(voteCh, height, round)
+
+	signAddVotes(cs1, tmproto.PrecommitType, nil, types.PartSetHeader{}, vs2, vs3, vs4)
+	ensureNewRound(newRoundCh, height, round+1)
+
+	var failed types.EventDataRoundFailed
+	select {
+	case msg := <-roundFailedCh:
+		failed = msg.Data().(types.EventDataRoundFailed)
+	case <-time.After(ensureTimeout):
+		t.Fatal("Timeout expired while waiting for RoundFailed event")
+	}
+	assert.Equal(t, height, failed.Height)
+	assert.Equal(t, round, failed.Round)
+	assert.Equal(t, cstypes.RoundEndNilPrecommits, failed.Reason)
+
+	timeline := getTimeline(t, cs1, 1)
+	require.Len(t, timeline, 1)
+	rt := timeline[0].Round(round)
+	require.NotNil(t, rt)
+	assert.Equal(t, cstypes.RoundEndNilPrecommits, rt.EndReason)
+	assert.NotZero(t, rt.PrecommitQuorumDelay)
+	assert.Zero(t, rt.PrevoteQuorumDelay)
+
+	missed := make(map[string]bool)
+	for _, vote := range rt.MissedVotes {
+		missed[vote.Type+" "+vote.ValidatorAddress.String()] = true
+	}
+	for _, vs := range []*validatorStub{vs2, vs3, vs4} {
+		pubKey, err := vs.GetPubKey()
+		require.NoError(t, err)
+		assert.True(t, missed[cstypes.VoteTypePrevote+" "+pubKey.Address().String()])
+		assert.False(t, missed[cstypes.VoteTypePrecommit+" "+pubKey.Address().String()])
+	}
+	assert.False(t, missed[cstypes.VoteTypePrevote+" "+addr.String()])
+
+	require.Len(t, failed.MissedVotes, len(rt.MissedVotes))
+	for i, vote := range failed.MissedVotes {
+		assert.Equal(t, rt.MissedVotes[i].ValidatorAddress, vote.ValidatorAddress)
+		assert.Equal(t, rt.MissedVotes[i].Type, vote.Type)
+	}
+
+	rt = timeline[0].Round(round + 1)
+	require.NotNil(t, rt)
+	assert.False(t, rt.Ended())
+}
+
 // nil is proposed, so prevote and precommit nil
 func TestStateFullRoundNil(t *testing.T) {
 	cs, vss := randState(1)
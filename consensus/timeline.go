@@ -0,0 +1,253 @@
+package consensus
+
+import (
+	"strings"
+	"time"
+
+	cstypes "github.com/tendermint/tendermint/consensus/types"
+	tmjson "github.com/tendermint/tendermint/libs/json"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	"github.com/tendermint/tendermint/types"
+	tmtime "github.com/tendermint/tendermint/types/time"
+)
+
+// Number of heights the timeline is kept for.
+const timelineHeights = 100
+
+// timeline records the timeline of the consensus of the last heights. It's
+// guarded by the mutex of the consensus state.
+type timeline struct {
+	heights   []*cstypes.HeightTimeline // The last one is the current height.
+	stepStart time.Time
+	// Rounds of the current height +2/3 prevotes and precommits were received
+	// for.
+	prevoteQuorums   map[int32]bool
+	precommitQuorums map[int32]bool
+}
+
+// current returns the timeline of the current height.
+func (tl *timeline) current() *cstypes.HeightTimeline {
+	if len(tl.heights) == 0 {
+		return nil
+	}
+	return tl.heights[len(tl.heights)-1]
+}
+
+// previous returns the timeline of the previous height, if any.
+func (tl *timeline) previous() *cstypes.HeightTimeline {
+	if len(tl.heights) < 2 {
+		return nil
+	}
+	return tl.heights[len(tl.heights)-2]
+}
+
+// delay returns the delay of the time since the start of the round, or zero if
+// the round didn't start yet.
+func delay(rt *cstypes.RoundTimeline, t time.Time) time.Duration {
+	if rt == nil || t.Before(rt.StartTime) {
+		return 0
+	}
+	return t.Sub(rt.StartTime)
+}
+
+func voteType(msgType tmproto.SignedMsgType) string {
+	if msgType == tmproto.PrevoteType {
+		return cstypes.VoteTypePrevote
+	}
+	return cstypes.VoteTypePrecommit
+}
+
+// GetTimelineJSON returns the JSON of the timeline of the last heights, up to
+// the last 100, from the oldest to the current one.
+func (cs *State) GetTimelineJSON(heights int) ([]byte, error) {
+	cs.mtx.RLock()
+	defer cs.mtx.RUnlock()
+	timelines := cs.timeline.heights
+	if heights < len(timelines) {
+		timelines = timelines[len(timelines)-heights:]
+	}
+	return tmjson.Marshal(timelines)
+}
+
+// recordStep ends the timeline of the previous step, and starts the one of the
+// current step, starting the timeline of the height if it changed.
+func (cs *State) recordStep() {
+	now := tmtime.Now()
+	tl := &cs.timeline
+	if ht := tl.current(); ht != nil && len(ht.Steps) > 0 {
+		step := &ht.Steps[len(ht.Steps)-1]
+		step.Duration = now.Sub(tl.stepStart)
+		cs.metrics.StepDurationSeconds.With("step", step.Step).Observe(step.Duration.Seconds())
+	}
+
+	if tl.current() == nil || tl.current().Height != cs.Height {
+		if len(tl.heights) == timelineHeights {
+			tl.heights = append(tl.heights[:0], tl.heights[1:]...)
+		}
+		tl.heights = append(tl.heights, &cstypes.HeightTimeline{
+			Height:      cs.Height,
+			StartTime:   now,
+			CommitRound: -1,
+		})
+		tl.prevoteQuorums = make(map[int32]bool)
+		tl.precommitQuorums = make(map[int32]bool)
+	}
+
+	ht := tl.current()
+	ht.Steps = append(ht.Steps, cstypes.StepTimeline{
+		Round: cs.Round,
+		Step:  strings.TrimPrefix(cs.Step.String(), "RoundStep"),
+	})
+	tl.stepStart = now
+}
+
+// recordRoundStart starts the timeline of the round of the current height.
+func (cs *State) recordRoundStart(round int32) {
+	ht := cs.timeline.current()
+	if ht.Round(round) == nil {
+		ht.Rounds = append(ht.Rounds, &cstypes.RoundTimeline{Round: round, StartTime: tmtime.Now()})
+	}
+}
+
+// recordRoundEnd ends the timeline of the round of the current height, for
+// the reason, and records the votes missed in the round.
+func (cs *State) recordRoundEnd(round int32, reason string) {
+	rt := cs.timeline.current().Round(round)
+	if rt == nil || rt.Ended() {
+		return
+	}
+	rt.EndReason = reason
+	if reason != cstypes.RoundEndCommit {
+		cs.metrics.FailedRounds.With("reason", reason).Add(1)
+		cs.Logger.Info("Round failed", "height", cs.Height, "round", round, "reason", reason)
+	}
+
+	voteSets := []struct {
+		voteType string
+		votes    *types.VoteSet
+	}{
+		{cstypes.VoteTypePrevote, cs.Votes.Prevotes(round)},
+		{cstypes.VoteTypePrecommit, cs.Votes.Precommits(round)},
+	}
+	for i, val := range cs.Validators.Validators {
+		for _, vs := range voteSets {
+			if vs.votes.GetByIndex(int32(i)) != nil {
+				continue
+			}
+			rt.MissedVotes = append(rt.MissedVotes, cstypes.VoteTimeline{ValidatorAddress: val.Address, Type: vs.voteType})
+			cs.metrics.MissedVotes.With("vote_type", vs.voteType).Add(1)
+		}
+	}
+
+	if reason == cstypes.RoundEndCommit {
+		return
+	}
+	missed := make([]types.MissedVote, len(rt.MissedVotes))
+	for i, vt := range rt.MissedVotes {
+		missed[i] = types.MissedVote{ValidatorAddress: vt.ValidatorAddress, Type: vt.Type}
+	}
+	if err := cs.eventBus.PublishEventRoundFailed(types.EventDataRoundFailed{
+		Height:      cs.Height,
+		Round:       round,
+		Reason:      reason,
+		MissedVotes: missed,
+	}); err != nil {
+		cs.Logger.Error("Error publishing round failed", "err", err)
+	}
+}
+
+// roundEndReason returns the reason the current round ends for, when entering
+// the round.
+func (cs *State) roundEndReason(round int32) string {
+	if blockID, ok := cs.Votes.Precommits(cs.Round).TwoThirdsMajority(); ok && blockID.IsZero() {
+		return cstypes.RoundEndNilPrecommits
+	}
+	if round > cs.Round+1 ||
+		cs.Votes.Prevotes(round).HasTwoThirdsAny() || cs.Votes.Precommits(round).HasTwoThirdsAny() {
+		return cstypes.RoundEndRoundSkip
+	}
+	return cstypes.RoundEndTimeout
+}
+
+// recordCommit ends the timeline of the commit round of the current height.
+func (cs *State) recordCommit() {
+	ht := cs.timeline.current()
+	cs.recordRoundStart(cs.CommitRound)
+	ht.CommitRound = cs.CommitRound
+	ht.CommitTime = tmtime.Now()
+	cs.recordRoundEnd(cs.CommitRound, cstypes.RoundEndCommit)
+}
+
+// recordProposal records the reception of the proposal of the current round.
+func (cs *State) recordProposal() {
+	rt := cs.timeline.current().Round(cs.Round)
+	if rt == nil || rt.ProposalDelay != 0 {
+		return
+	}
+	rt.ProposalDelay = delay(rt, tmtime.Now())
+	cs.metrics.ProposalDelaySeconds.Observe(rt.ProposalDelay.Seconds())
+}
+
+// recordBlockParts records the reception of the last part of the proposal
+// block of the current round.
+func (cs *State) recordBlockParts() {
+	rt := cs.timeline.current().Round(cs.Round)
+	if rt == nil || rt.BlockPartsDelay != 0 {
+		return
+	}
+	rt.BlockPartsDelay = delay(rt, tmtime.Now())
+	cs.metrics.BlockPartsDelaySeconds.Observe(rt.BlockPartsDelay.Seconds())
+}
+
+// recordVote records the vote added to the votes of the current height, or to
+// the last commit: the quorum it may complete, or its late reception.
+func (cs *State) recordVote(vote *types.Vote) {
+	now := tmtime.Now()
+	tl := &cs.timeline
+
+	ht := tl.current()
+	if vote.Height != cs.Height {
+		ht = tl.previous()
+	}
+	if ht == nil || ht.Height != vote.Height {
+		return
+	}
+	rt := ht.Round(vote.Round)
+
+	if rt != nil && rt.Ended() {
+		vt := cstypes.VoteTimeline{ValidatorAddress: vote.ValidatorAddress, Type: voteType(vote.Type),
+			Delay: delay(rt, now)}
+		rt.LateVotes = append(rt.LateVotes, vt)
+		cs.metrics.LateVotes.With("vote_type", vt.Type).Add(1)
+		return
+	}
+	if vote.Height != cs.Height {
+		return
+	}
+
+	var (
+		quorums map[int32]bool
+		votes   *types.VoteSet
+	)
+	if vote.Type == tmproto.PrevoteType {
+		quorums, votes = tl.prevoteQuorums, cs.Votes.Prevotes(vote.Round)
+	} else {
+		quorums, votes = tl.precommitQuorums, cs.Votes.Precommits(vote.Round)
+	}
+	if quorums[vote.Round] || !votes.HasTwoThirdsAny() {
+		return
+	}
+	quorums[vote.Round] = true
+	// The delay of a quorum received before the start of its round, causing
+	// a round skip, isn't recorded.
+	if rt == nil {
+		return
+	}
+	d := delay(rt, now)
+	if vote.Type == tmproto.PrevoteType {
+		rt.PrevoteQuorumDelay = d
+	} else {
+		rt.PrecommitQuorumDelay = d
+	}
+	cs.metrics.QuorumDelaySeconds.With("vote_type", voteType(vote.Type)).Observe(d.Seconds())
+}
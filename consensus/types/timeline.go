@@ -0,0 +1,91 @@
+package types
+
+import (
+	"time"
+
+	"github.com/tendermint/tendermint/types"
+)
+
+// Reasons for which a round ended.
+const (
+	// The block was committed in the round.
+	RoundEndCommit = "commit"
+	// +2/3 precommits for nil were received.
+	RoundEndNilPrecommits = "nil_precommits"
+	// There was no +2/3 precommits for a block or nil before the precommit
+	// timeout.
+	RoundEndTimeout = "timeout"
+	// +2/3 votes of a later round were received.
+	RoundEndRoundSkip = "round_skip"
+)
+
+// Types of votes, in the timeline.
+const (
+	VoteTypePrevote   = "prevote"
+	VoteTypePrecommit = "precommit"
+)
+
+// HeightTimeline is the timeline of the consensus of a height, to diagnose why
+// its rounds failed.
+// NOTE: Not thread safe. Should only be manipulated by functions downstream
+// of the cs.receiveRoutine
+type HeightTimeline struct {
+	Height    int64     `json:"height"`
+	StartTime time.Time `json:"start_time"` // When the height was entered, before timeout_commit.
+	// Round the block was committed in, or -1 if it's not committed yet.
+	CommitRound int32            `json:"commit_round"`
+	CommitTime  time.Time        `json:"commit_time"`
+	Steps       []StepTimeline   `json:"steps"`
+	Rounds      []*RoundTimeline `json:"rounds"`
+}
+
+// Round returns the timeline of the round, or nil if it wasn't started.
+func (ht *HeightTimeline) Round(round int32) *RoundTimeline {
+	for _, rt := range ht.Rounds {
+		if rt.Round == round {
+			return rt
+		}
+	}
+	return nil
+}
+
+// StepTimeline is the time spent in a step.
+type StepTimeline struct {
+	Round    int32         `json:"round"`
+	Step     string        `json:"step"`     // e.g. Propose
+	Duration time.Duration `json:"duration"` // Zero until the step ends.
+}
+
+// RoundTimeline is the timeline of a round. The delays are relative to the
+// start of the round, and zero if the event didn't happen. The events which
+// happened before the start of the round, e.g. +2/3 prevotes received for a
+// round skipped to, have zero delays too.
+type RoundTimeline struct {
+	Round     int32     `json:"round"`
+	StartTime time.Time `json:"start_time"`
+	// Delay of the reception of the proposal.
+	ProposalDelay time.Duration `json:"proposal_delay"`
+	// Delay of the reception of the last part of the proposal block.
+	BlockPartsDelay time.Duration `json:"block_parts_delay"`
+	// Delays of the reception of +2/3 prevotes and precommits, for anything.
+	PrevoteQuorumDelay   time.Duration `json:"prevote_quorum_delay"`
+	PrecommitQuorumDelay time.Duration `json:"precommit_quorum_delay"`
+	// Votes not received by the end of the round.
+	MissedVotes []VoteTimeline `json:"missed_votes"`
+	// Missed votes received after the end of the round.
+	LateVotes []VoteTimeline `json:"late_votes"`
+	// Reason the round ended for, or empty if it's the current round.
+	EndReason string `json:"end_reason"`
+}
+
+// Ended returns whether the round ended.
+func (rt *RoundTimeline) Ended() bool {
+	return rt.EndReason != ""
+}
+
+// VoteTimeline is a vote of a validator missed in a round, or received late.
+type VoteTimeline struct {
+	ValidatorAddress types.Address `json:"validator_address"`
+	Type             string        `json:"type"`  // prevote or precommit
+	Delay            time.Duration `json:"delay"` // Delay of the reception of late votes.
+}
@@ -0,0 +1,500 @@
+package consensus
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	cstypes "github.com/tendermint/tendermint/consensus/types"
+	auto "github.com/tendermint/tendermint/libs/autofile"
+	"github.com/tendermint/tendermint/libs/bits"
+	tmjson "github.com/tendermint/tendermint/libs/json"
+	"github.com/tendermint/tendermint/p2p"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+// WAL entry types, used to filter the output of a WALInspector.
+const (
+	WALEntryRoundState = "round_state"
+	WALEntryProposal   = "proposal"
+	WALEntryBlockPart  = "block_part"
+	WALEntryVote       = "vote"
+	WALEntryTimeout    = "timeout"
+	WALEntryEndHeight  = "end_height"
+	WALEntryOther      = "other"
+)
+
+//--------------------------------------------------------
+// offline inspection of WAL files
+
+// WALEntry is a decoded WAL message together with the height, round and
+// origin it refers to.
+type WALEntry struct {
+	Index  int        `json:"index"`
+	Time   time.Time  `json:"time"`
+	Height int64      `json:"height"`
+	Round  int32      `json:"round"`
+	Type   string     `json:"type"`
+	PeerID p2p.ID     `json:"peer_id,omitempty"`
+	Msg    WALMessage `json:"-"`
+}
+
+// String returns a one line summary of the entry.
+func (e WALEntry) String() string {
+	peer := e.PeerID
+	if peer == "" {
+		peer = "self"
+	}
+	return fmt.Sprintf("#%d %v %d/%d %-11s %-8s %s",
+		e.Index, e.Time.Format(time.RFC3339Nano), e.Height, e.Round, e.Type, peer, walEntrySummary(e.Msg))
+}
+
+// WALFilter selects a subset of the entries of a WALInspector. Zero values
+// match everything, except for Round, where -1 matches every round.
+type WALFilter struct {
+	Height int64
+	Round  int32
+	Types  []string
+	PeerID p2p.ID
+}
+
+// Match returns true if the entry satisfies the filter.
+func (f WALFilter) Match(e WALEntry) bool {
+	if f.Height > 0 && e.Height != f.Height {
+		return false
+	}
+	if f.Round >= 0 && e.Round != f.Round {
+		return false
+	}
+	if f.PeerID != "" && e.PeerID != f.PeerID {
+		return false
+	}
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, t := range f.Types {
+		if t == e.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// WALStepTransition records the time the state machine entered a step.
+type WALStepTransition struct {
+	Step string    `json:"step"`
+	Time time.Time `json:"time"`
+}
+
+// WALTimeout records a scheduled timeout found in the WAL.
+type WALTimeout struct {
+	Step     string        `json:"step"`
+	Duration time.Duration `json:"duration"`
+	Time     time.Time     `json:"time"`
+}
+
+// WALRoundTimeline summarises everything that happened in a single round.
+type WALRoundTimeline struct {
+	Round         int32               `json:"round"`
+	Steps         []WALStepTransition `json:"steps"`
+	Proposal      *types.Proposal     `json:"proposal,omitempty"`
+	ProposalTime  time.Time           `json:"proposal_time,omitempty"`
+	BlockParts    int                 `json:"block_parts"`
+	Prevotes      *bits.BitArray      `json:"prevotes"`
+	PrevotesNil   *bits.BitArray      `json:"prevotes_nil"`
+	Precommits    *bits.BitArray      `json:"precommits"`
+	PrecommitsNil *bits.BitArray      `json:"precommits_nil"`
+	Timeouts      []WALTimeout        `json:"timeouts,omitempty"`
+	Outcome       string              `json:"outcome"`
+
+	prevotes, prevotesNil, precommits, precommitsNil map[int32]bool
+}
+
+// WALHeightTimeline summarises the rounds it took to decide a height.
+type WALHeightTimeline struct {
+	Height   int64               `json:"height"`
+	Start    time.Time           `json:"start"`
+	End      time.Time           `json:"end"`
+	Duration time.Duration       `json:"duration"`
+	Complete bool                `json:"complete"`
+	Rounds   []*WALRoundTimeline `json:"rounds"`
+}
+
+// WALInspector decodes a WAL and indexes its messages by height and round.
+// It does not replay the messages, so it does not need an application or
+// any of the stores.
+type WALInspector struct {
+	entries []WALEntry
+}
+
+// NewWALInspector decodes every message in r. Decoding stops at the first
+// corrupted or truncated message, in which case the error is returned.
+func NewWALInspector(r io.Reader) (*WALInspector, error) {
+	wi := &WALInspector{}
+	dec := NewWALDecoder(r)
+	var lastHeight int64
+	var lastRound int32
+	for {
+		msg, err := dec.Decode()
+		if err == io.EOF {
+			return wi, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to decode WAL message #%d: %w", len(wi.entries), err)
+		}
+
+		e := newWALEntry(len(wi.entries), msg, lastHeight, lastRound)
+		if e.Type == WALEntryRoundState {
+			lastHeight, lastRound = e.Height, e.Round
+		}
+		wi.entries = append(wi.entries, e)
+	}
+}
+
+// OpenWALInspector decodes every message of the WAL at walFile, including the
+// files rotated out of its head, oldest first (see NewWALInspector).
+func OpenWALInspector(walFile string) (*WALInspector, error) {
+	// OpenGroup creates a missing head.
+	if _, err := os.Stat(walFile); err != nil {
+		return nil, fmt.Errorf("failed to open WAL file: %w", err)
+	}
+	group, err := auto.OpenGroup(walFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open WAL file: %w", err)
+	}
+	defer group.Close()
+
+	gr, err := group.NewReader(group.MinIndex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open WAL file: %w", err)
+	}
+	defer gr.Close()
+
+	return NewWALInspector(gr)
+}
+
+// Entries returns all entries matching the filter, in WAL order.
+func (wi *WALInspector) Entries(filter WALFilter) []WALEntry {
+	entries := make([]WALEntry, 0)
+	for _, e := range wi.entries {
+		if filter.Match(e) {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Heights returns the sorted list of heights present in the WAL.
+func (wi *WALInspector) Heights() []int64 {
+	seen := make(map[int64]bool)
+	heights := make([]int64, 0)
+	for _, e := range wi.entries {
+		if e.Height > 0 && !seen[e.Height] {
+			seen[e.Height] = true
+			heights = append(heights, e.Height)
+		}
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	return heights
+}
+
+// Timeline builds a per round summary of the given height. Vote bit arrays
+// are sized by the highest validator index seen for the height.
+func (wi *WALInspector) Timeline(height int64) *WALHeightTimeline {
+	ht := &WALHeightTimeline{Height: height}
+	rounds := make(map[int32]*WALRoundTimeline)
+	getRound := func(r int32) *WALRoundTimeline {
+		rt, ok := rounds[r]
+		if !ok {
+			rt = &WALRoundTimeline{
+				Round:         r,
+				prevotes:      make(map[int32]bool),
+				prevotesNil:   make(map[int32]bool),
+				precommits:    make(map[int32]bool),
+				precommitsNil: make(map[int32]bool),
+			}
+			rounds[r] = rt
+		}
+		return rt
+	}
+
+	numVals := 0
+	for _, e := range wi.entries {
+		if e.Height != height {
+			continue
+		}
+		if ht.Start.IsZero() {
+			ht.Start = e.Time
+		}
+		ht.End = e.Time
+
+		switch msg := e.Msg.(type) {
+		case types.EventDataRoundState:
+			rt := getRound(msg.Round)
+			rt.Steps = append(rt.Steps, WALStepTransition{Step: msg.Step, Time: e.Time})
+		case timeoutInfo:
+			rt := getRound(msg.Round)
+			rt.Timeouts = append(rt.Timeouts, WALTimeout{Step: msg.Step.String(), Duration: msg.Duration, Time: e.Time})
+		case EndHeightMessage:
+			ht.Complete = true
+		case msgInfo:
+			switch m := msg.Msg.(type) {
+			case *ProposalMessage:
+				rt := getRound(m.Proposal.Round)
+				if rt.Proposal == nil {
+					rt.Proposal = m.Proposal
+					rt.ProposalTime = e.Time
+				}
+			case *BlockPartMessage:
+				getRound(m.Round).BlockParts++
+			case *VoteMessage:
+				v := m.Vote
+				rt := getRound(v.Round)
+				if int(v.ValidatorIndex)+1 > numVals {
+					numVals = int(v.ValidatorIndex) + 1
+				}
+				isNil := len(v.BlockID.Hash) == 0
+				switch {
+				case v.Type == tmproto.PrevoteType && isNil:
+					rt.prevotesNil[v.ValidatorIndex] = true
+				case v.Type == tmproto.PrevoteType:
+					rt.prevotes[v.ValidatorIndex] = true
+				case v.Type == tmproto.PrecommitType && isNil:
+					rt.precommitsNil[v.ValidatorIndex] = true
+				case v.Type == tmproto.PrecommitType:
+					rt.precommits[v.ValidatorIndex] = true
+				}
+			}
+		}
+	}
+	ht.Duration = ht.End.Sub(ht.Start)
+
+	for _, rt := range rounds {
+		rt.Prevotes = indexBitArray(numVals, rt.prevotes)
+		rt.PrevotesNil = indexBitArray(numVals, rt.prevotesNil)
+		rt.Precommits = indexBitArray(numVals, rt.precommits)
+		rt.PrecommitsNil = indexBitArray(numVals, rt.precommitsNil)
+		ht.Rounds = append(ht.Rounds, rt)
+	}
+	sort.Slice(ht.Rounds, func(i, j int) bool { return ht.Rounds[i].Round < ht.Rounds[j].Round })
+	for i, rt := range ht.Rounds {
+		rt.Outcome = roundOutcome(rt, ht.Complete && i == len(ht.Rounds)-1)
+	}
+	return ht
+}
+
+// Timelines returns the timeline of every height in the WAL.
+func (wi *WALInspector) Timelines() []*WALHeightTimeline {
+	heights := wi.Heights()
+	timelines := make([]*WALHeightTimeline, 0, len(heights))
+	for _, h := range heights {
+		timelines = append(timelines, wi.Timeline(h))
+	}
+	return timelines
+}
+
+// WriteTimelineJSON exports the timelines as an indented JSON array.
+func WriteTimelineJSON(w io.Writer, timelines []*WALHeightTimeline) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(timelines)
+}
+
+// WriteWALEntriesJSON exports the entries as JSON lines, one entry per line.
+// Messages are encoded with tmjson, like scripts/wal2json does.
+func WriteWALEntriesJSON(w io.Writer, entries []WALEntry) error {
+	for _, e := range entries {
+		msg, err := tmjson.Marshal(e.Msg)
+		if err != nil {
+			return fmt.Errorf("failed to marshal WAL message #%d: %w", e.Index, err)
+		}
+		bz, err := json.Marshal(struct {
+			WALEntry
+			Msg json.RawMessage `json:"msg"`
+		}{e, msg})
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(append(bz, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteTimelineHTML exports the timelines as a standalone HTML page.
+func WriteTimelineHTML(w io.Writer, timelines []*WALHeightTimeline) error {
+	return walTimelineTemplate.Execute(w, timelines)
+}
+
+var walTimelineTemplate = template.Must(template.New("timeline").Funcs(template.FuncMap{
+	"offset": func(start, t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Sub(start).String()
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Consensus WAL timeline</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+td, th { border: 1px solid #ccc; padding: 4px 8px; vertical-align: top; font-size: 13px; }
+.bits { font-family: monospace; }
+</style>
+</head>
+<body>
+{{range .}}{{$start := .Start}}
+<h2>Height {{.Height}}</h2>
+<p>{{len .Rounds}} round(s), {{.Duration}}{{if not .Complete}}, incomplete{{end}}</p>
+<table>
+<tr><th>Round</th><th>Steps</th><th>Proposal</th><th>Parts</th><th>Prevotes</th><th>Precommits</th><th>Timeouts</th><th>Outcome</th></tr>
+{{range .Rounds}}
+<tr>
+<td>{{.Round}}</td>
+<td>{{range .Steps}}{{.Step}} +{{offset $start .Time}}<br>{{end}}</td>
+<td>{{if .Proposal}}{{.Proposal.BlockID}} +{{offset $start .ProposalTime}}{{else}}none{{end}}</td>
+<td>{{.BlockParts}}</td>
+<td class="bits">block {{.Prevotes}}<br>nil {{.PrevotesNil}}</td>
+<td class="bits">block {{.Precommits}}<br>nil {{.PrecommitsNil}}</td>
+<td>{{range .Timeouts}}{{.Step}} {{.Duration}}<br>{{end}}</td>
+<td>{{.Outcome}}</td>
+</tr>
+{{end}}
+</table>
+{{end}}
+</body>
+</html>
+`))
+
+func newWALEntry(index int, msg *TimedWALMessage, lastHeight int64, lastRound int32) WALEntry {
+	e := WALEntry{
+		Index:  index,
+		Time:   msg.Time,
+		Height: lastHeight,
+		Round:  lastRound,
+		Type:   WALEntryOther,
+		Msg:    msg.Msg,
+	}
+
+	switch m := msg.Msg.(type) {
+	case types.EventDataRoundState:
+		e.Type, e.Height, e.Round = WALEntryRoundState, m.Height, m.Round
+	case timeoutInfo:
+		e.Type, e.Height, e.Round = WALEntryTimeout, m.Height, m.Round
+	case EndHeightMessage:
+		e.Type, e.Height = WALEntryEndHeight, m.Height
+	case msgInfo:
+		e.PeerID = m.PeerID
+		switch mi := m.Msg.(type) {
+		case *ProposalMessage:
+			e.Type, e.Height, e.Round = WALEntryProposal, mi.Proposal.Height, mi.Proposal.Round
+		case *BlockPartMessage:
+			e.Type, e.Height, e.Round = WALEntryBlockPart, mi.Height, mi.Round
+		case *VoteMessage:
+			e.Type, e.Height, e.Round = WALEntryVote, mi.Vote.Height, mi.Vote.Round
+		}
+	}
+	return e
+}
+
+func walEntrySummary(msg WALMessage) string {
+	switch m := msg.(type) {
+	case types.EventDataRoundState:
+		return m.Step
+	case timeoutInfo:
+		return m.String()
+	case EndHeightMessage:
+		return fmt.Sprintf("ENDHEIGHT %d", m.Height)
+	case msgInfo:
+		switch mi := m.Msg.(type) {
+		case *ProposalMessage:
+			return mi.Proposal.String()
+		case *BlockPartMessage:
+			return fmt.Sprintf("part %d", mi.Part.Index)
+		case *VoteMessage:
+			return mi.Vote.String()
+		}
+		return fmt.Sprintf("%v", m.Msg)
+	}
+	return fmt.Sprintf("%v", msg)
+}
+
+func indexBitArray(size int, indexes map[int32]bool) *bits.BitArray {
+	bA := bits.NewBitArray(size)
+	for i := range indexes {
+		bA.SetIndex(int(i), true)
+	}
+	return bA
+}
+
+// roundOutcome gives a best-effort explanation of how the round ended.
+func roundOutcome(rt *WALRoundTimeline, committed bool) string {
+	if committed {
+		return "committed"
+	}
+	var timeouts []string
+	for _, to := range rt.Timeouts {
+		timeouts = append(timeouts, to.Step)
+	}
+	switch {
+	case rt.Proposal == nil && len(timeouts) > 0:
+		return "no proposal; timeouts: " + strings.Join(timeouts, ", ")
+	case rt.Proposal == nil:
+		return "no proposal"
+	case len(timeouts) > 0:
+		return "timeouts: " + strings.Join(timeouts, ", ")
+	}
+	return "advanced"
+}
+
+//--------------------------------------------------------
+// round state diffs for the replay console
+
+// roundStateFieldNames lists the fields compared by diffRoundStates, in
+// display order.
+var roundStateFieldNames = []string{
+	"height/round/step", "proposal", "proposal_block", "locked_round", "locked_block",
+	"valid_round", "valid_block", "votes", "commit_round", "triggered_timeout_precommit",
+}
+
+// roundStateFields snapshots the printable fields of a RoundState. The
+// snapshot is needed since vote sets are mutated in place.
+func roundStateFields(rs *cstypes.RoundState) map[string]string {
+	votes := "nil-HeightVoteSet"
+	if rs.Votes != nil {
+		votes = rs.Votes.StringIndented("  ")
+	}
+	return map[string]string{
+		"height/round/step":           fmt.Sprintf("%v/%v/%v", rs.Height, rs.Round, rs.Step),
+		"proposal":                    fmt.Sprintf("%v", rs.Proposal),
+		"proposal_block":              fmt.Sprintf("%v %v", rs.ProposalBlockParts.StringShort(), rs.ProposalBlock.StringShort()),
+		"locked_round":                fmt.Sprintf("%v", rs.LockedRound),
+		"locked_block":                fmt.Sprintf("%v %v", rs.LockedBlockParts.StringShort(), rs.LockedBlock.StringShort()),
+		"valid_round":                 fmt.Sprintf("%v", rs.ValidRound),
+		"valid_block":                 fmt.Sprintf("%v %v", rs.ValidBlockParts.StringShort(), rs.ValidBlock.StringShort()),
+		"votes":                       votes,
+		"commit_round":                fmt.Sprintf("%v", rs.CommitRound),
+		"triggered_timeout_precommit": fmt.Sprintf("%v", rs.TriggeredTimeoutPrecommit),
+	}
+}
+
+// diffRoundStates returns a line per field that changed between two
+// snapshots taken with roundStateFields.
+func diffRoundStates(before, after map[string]string) []string {
+	diff := make([]string, 0)
+	for _, name := range roundStateFieldNames {
+		if before[name] != after[name] {
+			diff = append(diff, fmt.Sprintf("%s:\n  - %s\n  + %s", name, before[name], after[name]))
+		}
+	}
+	return diff
+}
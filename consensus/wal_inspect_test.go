@@ -0,0 +1,119 @@
+package consensus
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	cstypes "github.com/tendermint/tendermint/consensus/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+func TestWALInspectorTimeline(t *testing.T) {
+	const numBlocks = 3
+	data, err := WALWithNBlocks(t, numBlocks)
+	require.NoError(t, err)
+
+	wi, err := NewWALInspector(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	heights := wi.Heights()
+	require.GreaterOrEqual(t, len(heights), numBlocks)
+	assert.EqualValues(t, 1, heights[0])
+
+	// the generator does not write the end height message of the last block
+	for h := int64(1); h < numBlocks; h++ {
+		ht := wi.Timeline(h)
+		assert.True(t, ht.Complete, "height %d should be complete", h)
+		require.NotEmpty(t, ht.Rounds)
+
+		last := ht.Rounds[len(ht.Rounds)-1]
+		assert.Equal(t, "committed", last.Outcome)
+		assert.NotNil(t, last.Proposal)
+		assert.Greater(t, last.BlockParts, 0)
+		// a single validator signs every block
+		assert.True(t, last.Prevotes.GetIndex(0))
+		assert.True(t, last.Precommits.GetIndex(0))
+		assert.NotEmpty(t, last.Steps)
+	}
+
+	var html, js bytes.Buffer
+	require.NoError(t, WriteTimelineHTML(&html, wi.Timelines()))
+	assert.Contains(t, html.String(), "Height 1")
+	require.NoError(t, WriteTimelineJSON(&js, wi.Timelines()))
+	assert.Contains(t, js.String(), `"outcome": "committed"`)
+}
+
+func TestOpenWALInspectorRotated(t *testing.T) {
+	data, err := WALWithNBlocks(t, 3)
+	require.NoError(t, err)
+	want, err := NewWALInspector(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	// The older half of the messages was rotated out of the head.
+	walFile := filepath.Join(t.TempDir(), "wal")
+	require.NoError(t, ioutil.WriteFile(walFile+".000", data[:len(data)/2], 0600))
+	require.NoError(t, ioutil.WriteFile(walFile, data[len(data)/2:], 0600))
+
+	wi, err := OpenWALInspector(walFile)
+	require.NoError(t, err)
+	assert.Equal(t, want.Heights(), wi.Heights())
+	assert.Equal(t, want.Entries(WALFilter{Round: -1}), wi.Entries(WALFilter{Round: -1}))
+
+	_, err = OpenWALInspector(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+func TestWALInspectorEntries(t *testing.T) {
+	data, err := WALWithNBlocks(t, 2)
+	require.NoError(t, err)
+
+	wi, err := NewWALInspector(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	votes := wi.Entries(WALFilter{Height: 2, Round: -1, Types: []string{WALEntryVote}})
+	require.NotEmpty(t, votes)
+	for _, e := range votes {
+		assert.EqualValues(t, 2, e.Height)
+		assert.Equal(t, WALEntryVote, e.Type)
+	}
+
+	ends := wi.Entries(WALFilter{Round: -1, Types: []string{WALEntryEndHeight}})
+	require.NotEmpty(t, ends)
+	assert.Equal(t, EndHeightMessage{Height: ends[0].Height}, ends[0].Msg)
+
+	assert.Empty(t, wi.Entries(WALFilter{Round: -1, PeerID: "unknown"}))
+
+	var out bytes.Buffer
+	require.NoError(t, WriteWALEntriesJSON(&out, votes))
+	assert.Equal(t, len(votes), strings.Count(out.String(), "\n"))
+}
+
+func TestWALInspectorCorrupted(t *testing.T) {
+	var b bytes.Buffer
+	enc := NewWALEncoder(&b)
+	err := enc.Encode(&TimedWALMessage{Time: time.Now(), Msg: tmtypes.EventDataRoundState{Height: 1}})
+	require.NoError(t, err)
+	data := b.Bytes()
+
+	_, err = NewWALInspector(bytes.NewReader(data[:len(data)-1]))
+	assert.Error(t, err)
+}
+
+func TestDiffRoundStates(t *testing.T) {
+	rs := &cstypes.RoundState{Height: 1, Round: 0, Step: cstypes.RoundStepPropose}
+	before := roundStateFields(rs)
+	assert.Empty(t, diffRoundStates(before, roundStateFields(rs)))
+
+	rs.Step = cstypes.RoundStepPrevote
+	rs.LockedRound = 0
+	diff := diffRoundStates(before, roundStateFields(rs))
+	require.Len(t, diff, 1)
+	assert.True(t, strings.HasPrefix(diff[0], "height/round/step"))
+}
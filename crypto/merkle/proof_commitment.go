@@ -0,0 +1,418 @@
+package merkle
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/tendermint/tendermint/crypto/tmhash"
+	tmcrypto "github.com/tendermint/tendermint/proto/tendermint/crypto"
+)
+
+const (
+	ProofOpIAVLCommitment   = "ics23:iavl"
+	ProofOpSimpleCommitment = "ics23:simple"
+)
+
+// ProofSpec is the layout of a binary tree which commitment proofs must
+// follow, so that they can't prove a value which isn't a leaf, and that the
+// neighbors in a non-existence proof are adjacent.
+type ProofSpec struct {
+	// LeafSpec must match the operation of every leaf, which prefix must
+	// start with LeafSpec.Prefix.
+	LeafSpec tmcrypto.LeafOp
+
+	// The hash of the inner nodes.
+	InnerHash tmcrypto.HashOp
+	// The length of a child hash in a prefix or suffix, with its encoding.
+	ChildSize int
+	// The bounds of the length of the prefix of an inner node, before the
+	// left child hash.
+	MinPrefixLength int
+	MaxPrefixLength int
+}
+
+// IAVLSpec is the layout of IAVL trees.
+var IAVLSpec = &ProofSpec{
+	LeafSpec: tmcrypto.LeafOp{
+		Hash:         tmcrypto.HashOp_HASH_OP_SHA256,
+		PrehashKey:   tmcrypto.HashOp_HASH_OP_NO_HASH,
+		PrehashValue: tmcrypto.HashOp_HASH_OP_SHA256,
+		Length:       tmcrypto.LengthOp_LENGTH_OP_VAR_PROTO,
+		Prefix:       []byte{0},
+	},
+	InnerHash:       tmcrypto.HashOp_HASH_OP_SHA256,
+	ChildSize:       33,
+	MinPrefixLength: 4,
+	MaxPrefixLength: 12,
+}
+
+// SimpleSpec is the layout of the simple merkle trees of key-value pairs
+// built by SimpleExistenceProofs.
+var SimpleSpec = &ProofSpec{
+	LeafSpec: tmcrypto.LeafOp{
+		Hash:         tmcrypto.HashOp_HASH_OP_SHA256,
+		PrehashKey:   tmcrypto.HashOp_HASH_OP_NO_HASH,
+		PrehashValue: tmcrypto.HashOp_HASH_OP_SHA256,
+		Length:       tmcrypto.LengthOp_LENGTH_OP_VAR_PROTO,
+		Prefix:       leafPrefix,
+	},
+	InnerHash:       tmcrypto.HashOp_HASH_OP_SHA256,
+	ChildSize:       tmhash.Size,
+	MinPrefixLength: len(innerPrefix),
+	MaxPrefixLength: len(innerPrefix),
+}
+
+// CommitmentOp proves the existence or non-existence of a key in a tree with
+// an ICS23 commitment proof, and produces the root hash of the tree.
+//
+// It takes a single value as argument to verify an existence proof, and no
+// arguments to verify a non-existence proof.
+type CommitmentOp struct {
+	Type  string
+	Spec  *ProofSpec
+	Key   []byte
+	Proof *tmcrypto.CommitmentProof
+}
+
+var _ ProofOperator = CommitmentOp{}
+
+func NewIAVLCommitmentOp(key []byte, proof *tmcrypto.CommitmentProof) CommitmentOp {
+	return CommitmentOp{Type: ProofOpIAVLCommitment, Spec: IAVLSpec, Key: key, Proof: proof}
+}
+
+func NewSimpleCommitmentOp(key []byte, proof *tmcrypto.CommitmentProof) CommitmentOp {
+	return CommitmentOp{Type: ProofOpSimpleCommitment, Spec: SimpleSpec, Key: key, Proof: proof}
+}
+
+func IAVLCommitmentOpDecoder(pop tmcrypto.ProofOp) (ProofOperator, error) {
+	return decodeCommitmentOp(pop, ProofOpIAVLCommitment, IAVLSpec)
+}
+
+func SimpleCommitmentOpDecoder(pop tmcrypto.ProofOp) (ProofOperator, error) {
+	return decodeCommitmentOp(pop, ProofOpSimpleCommitment, SimpleSpec)
+}
+
+func decodeCommitmentOp(pop tmcrypto.ProofOp, typ string, spec *ProofSpec) (ProofOperator, error) {
+	if pop.Type != typ {
+		return nil, fmt.Errorf("unexpected ProofOp.Type; got %v, want %v", pop.Type, typ)
+	}
+	proof := &tmcrypto.CommitmentProof{}
+	if err := proof.Unmarshal(pop.Data); err != nil {
+		return nil, fmt.Errorf("decoding ProofOp.Data into CommitmentProof: %w", err)
+	}
+	return CommitmentOp{Type: typ, Spec: spec, Key: pop.Key, Proof: proof}, nil
+}
+
+func (op CommitmentOp) ProofOp() tmcrypto.ProofOp {
+	bz, err := op.Proof.Marshal()
+	if err != nil {
+		panic(err)
+	}
+	return tmcrypto.ProofOp{
+		Type: op.Type,
+		Key:  op.Key,
+		Data: bz,
+	}
+}
+
+func (op CommitmentOp) String() string {
+	return fmt.Sprintf("CommitmentOp{%v %v}", op.Type, op.GetKey())
+}
+
+func (op CommitmentOp) Run(args [][]byte) ([][]byte, error) {
+	var (
+		root []byte
+		err  error
+	)
+	switch len(args) {
+	case 0:
+		nonexist := op.Proof.GetNonexist()
+		if nonexist == nil {
+			return nil, errors.New("expected a non-existence proof")
+		}
+		if !bytes.Equal(nonexist.Key, op.Key) {
+			return nil, fmt.Errorf("non-existence proof is for key %X, expected %X", nonexist.Key, op.Key)
+		}
+		root, err = verifyNonExistence(op.Spec, nonexist)
+	case 1:
+		exist := op.Proof.GetExist()
+		if exist == nil {
+			return nil, errors.New("expected an existence proof")
+		}
+		if !bytes.Equal(exist.Key, op.Key) {
+			return nil, fmt.Errorf("existence proof is for key %X, expected %X", exist.Key, op.Key)
+		}
+		if !bytes.Equal(exist.Value, args[0]) {
+			return nil, fmt.Errorf("existence proof is for value %X, expected %X", exist.Value, args[0])
+		}
+		root, err = verifyExistence(op.Spec, exist)
+	default:
+		return nil, fmt.Errorf("expected 0 or 1 args, got %v", len(args))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{root}, nil
+}
+
+func (op CommitmentOp) GetKey() []byte {
+	return op.Key
+}
+
+// SimpleExistenceProofs returns the root hash of the simple merkle tree of the
+// given key-value pairs, which must be sorted by key, and an existence proof
+// of each pair, verifiable with SimpleSpec. The leaves of the tree hash the
+// pairs as ValueOp does.
+func SimpleExistenceProofs(keys, values [][]byte) (rootHash []byte, proofs []*tmcrypto.ExistenceProof) {
+	if len(keys) != len(values) {
+		panic(fmt.Sprintf("got %v keys but %v values", len(keys), len(values)))
+	}
+	items := make([][]byte, len(keys))
+	for i := range keys {
+		bz := new(bytes.Buffer)
+		encodeByteSlice(bz, keys[i])               //nolint: errcheck // does not error
+		encodeByteSlice(bz, tmhash.Sum(values[i])) //nolint: errcheck // does not error
+		items[i] = bz.Bytes()
+	}
+	rootHash, simpleProofs := ProofsFromByteSlices(items)
+	proofs = make([]*tmcrypto.ExistenceProof, len(keys))
+	for i, sp := range simpleProofs {
+		leaf := SimpleSpec.LeafSpec
+		proofs[i] = &tmcrypto.ExistenceProof{
+			Key:   keys[i],
+			Value: values[i],
+			Leaf:  &leaf,
+			Path:  innerOpsFromAunts(sp.Index, sp.Total, sp.Aunts),
+		}
+	}
+	return rootHash, proofs
+}
+
+// innerOpsFromAunts returns the path from a leaf to the root of a simple
+// merkle tree, like computeHashFromAunts.
+func innerOpsFromAunts(index, total int64, aunts [][]byte) []*tmcrypto.InnerOp {
+	if total <= 1 || len(aunts) == 0 {
+		return nil
+	}
+	numLeft := getSplitPoint(total)
+	aunt := aunts[len(aunts)-1]
+	if index < numLeft {
+		return append(innerOpsFromAunts(index, numLeft, aunts[:len(aunts)-1]), &tmcrypto.InnerOp{
+			Hash:   tmcrypto.HashOp_HASH_OP_SHA256,
+			Prefix: innerPrefix,
+			Suffix: aunt,
+		})
+	}
+	return append(innerOpsFromAunts(index-numLeft, total-numLeft, aunts[:len(aunts)-1]), &tmcrypto.InnerOp{
+		Hash:   tmcrypto.HashOp_HASH_OP_SHA256,
+		Prefix: append(append([]byte{}, innerPrefix...), aunt...),
+	})
+}
+
+//----------------------------------------
+// Verification of commitment proofs
+
+// verifyExistence checks the proof against the spec and returns the root hash.
+func verifyExistence(spec *ProofSpec, proof *tmcrypto.ExistenceProof) ([]byte, error) {
+	if err := checkExistenceSpec(spec, proof); err != nil {
+		return nil, err
+	}
+	res, err := applyLeaf(proof.Leaf, proof.Key, proof.Value)
+	if err != nil {
+		return nil, fmt.Errorf("leaf: %w", err)
+	}
+	for i, step := range proof.Path {
+		res, err = applyInner(step, res)
+		if err != nil {
+			return nil, fmt.Errorf("inner op #%d: %w", i, err)
+		}
+	}
+	return res, nil
+}
+
+// verifyNonExistence checks that the neighbors exist in the same tree and are
+// adjacent, and returns the root hash.
+func verifyNonExistence(spec *ProofSpec, proof *tmcrypto.NonExistenceProof) ([]byte, error) {
+	var root []byte
+	if proof.Left != nil {
+		leftRoot, err := verifyExistence(spec, proof.Left)
+		if err != nil {
+			return nil, fmt.Errorf("left neighbor: %w", err)
+		}
+		if bytes.Compare(proof.Left.Key, proof.Key) >= 0 {
+			return nil, errors.New("left neighbor key isn't before the key")
+		}
+		root = leftRoot
+	}
+	if proof.Right != nil {
+		rightRoot, err := verifyExistence(spec, proof.Right)
+		if err != nil {
+			return nil, fmt.Errorf("right neighbor: %w", err)
+		}
+		if bytes.Compare(proof.Right.Key, proof.Key) <= 0 {
+			return nil, errors.New("right neighbor key isn't after the key")
+		}
+		if root != nil && !bytes.Equal(root, rightRoot) {
+			return nil, errors.New("neighbors have different root hashes")
+		}
+		root = rightRoot
+	}
+
+	switch {
+	case proof.Left == nil && proof.Right == nil:
+		return nil, errors.New("neither left nor right neighbor is given")
+	case proof.Left == nil:
+		if !isLeftMost(spec, proof.Right.Path) {
+			return nil, errors.New("right neighbor isn't the leftmost leaf")
+		}
+	case proof.Right == nil:
+		if !isRightMost(spec, proof.Left.Path) {
+			return nil, errors.New("left neighbor isn't the rightmost leaf")
+		}
+	default:
+		if !isLeftNeighbor(spec, proof.Left.Path, proof.Right.Path) {
+			return nil, errors.New("neighbors aren't adjacent")
+		}
+	}
+	return root, nil
+}
+
+func checkExistenceSpec(spec *ProofSpec, proof *tmcrypto.ExistenceProof) error {
+	leaf := proof.Leaf
+	if leaf == nil {
+		return errors.New("missing leaf op")
+	}
+	if leaf.Hash != spec.LeafSpec.Hash || leaf.PrehashKey != spec.LeafSpec.PrehashKey ||
+		leaf.PrehashValue != spec.LeafSpec.PrehashValue || leaf.Length != spec.LeafSpec.Length {
+		return fmt.Errorf("leaf op %v doesn't match the spec", leaf)
+	}
+	if !bytes.HasPrefix(leaf.Prefix, spec.LeafSpec.Prefix) {
+		return fmt.Errorf("leaf prefix %X doesn't start with %X", leaf.Prefix, spec.LeafSpec.Prefix)
+	}
+	for i, step := range proof.Path {
+		if step.Hash != spec.InnerHash {
+			return fmt.Errorf("inner op #%d: unexpected hash %v", i, step.Hash)
+		}
+		// An inner node mustn't be taken for a leaf.
+		if bytes.HasPrefix(step.Prefix, spec.LeafSpec.Prefix) {
+			return fmt.Errorf("inner op #%d: prefix starts with the leaf prefix", i)
+		}
+		if len(step.Prefix) < spec.MinPrefixLength || len(step.Prefix) > spec.MaxPrefixLength+spec.ChildSize {
+			return fmt.Errorf("inner op #%d: invalid prefix length %v", i, len(step.Prefix))
+		}
+		if len(step.Suffix)%spec.ChildSize != 0 {
+			return fmt.Errorf("inner op #%d: invalid suffix length %v", i, len(step.Suffix))
+		}
+	}
+	return nil
+}
+
+func applyLeaf(op *tmcrypto.LeafOp, key, value []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, errors.New("empty key")
+	}
+	if len(value) == 0 {
+		return nil, errors.New("empty value")
+	}
+	pkey, err := prepareLeafData(op.PrehashKey, op.Length, key)
+	if err != nil {
+		return nil, fmt.Errorf("key: %w", err)
+	}
+	pvalue, err := prepareLeafData(op.PrehashValue, op.Length, value)
+	if err != nil {
+		return nil, fmt.Errorf("value: %w", err)
+	}
+	data := append(append(append([]byte{}, op.Prefix...), pkey...), pvalue...)
+	return doHash(op.Hash, data)
+}
+
+func applyInner(op *tmcrypto.InnerOp, child []byte) ([]byte, error) {
+	if len(child) == 0 {
+		return nil, errors.New("empty child hash")
+	}
+	data := append(append(append([]byte{}, op.Prefix...), child...), op.Suffix...)
+	return doHash(op.Hash, data)
+}
+
+func prepareLeafData(hashOp tmcrypto.HashOp, lengthOp tmcrypto.LengthOp, data []byte) ([]byte, error) {
+	if hashOp != tmcrypto.HashOp_HASH_OP_NO_HASH {
+		var err error
+		if data, err = doHash(hashOp, data); err != nil {
+			return nil, err
+		}
+	}
+	switch lengthOp {
+	case tmcrypto.LengthOp_LENGTH_OP_NO_PREFIX:
+		return data, nil
+	case tmcrypto.LengthOp_LENGTH_OP_VAR_PROTO:
+		buf := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(data))
+		n := binary.PutUvarint(buf, uint64(len(data)))
+		return append(buf[:n], data...), nil
+	default:
+		return nil, fmt.Errorf("unsupported length op %v", lengthOp)
+	}
+}
+
+func doHash(hashOp tmcrypto.HashOp, data []byte) ([]byte, error) {
+	switch hashOp {
+	case tmcrypto.HashOp_HASH_OP_SHA256:
+		return tmhash.Sum(data), nil
+	default:
+		return nil, fmt.Errorf("unsupported hash op %v", hashOp)
+	}
+}
+
+// padding returns the bounds of the prefix length and the suffix length of an
+// inner op whose child is the given branch (0 for left, 1 for right).
+func padding(spec *ProofSpec, branch int) (minPrefix, maxPrefix, suffix int) {
+	return spec.MinPrefixLength + branch*spec.ChildSize,
+		spec.MaxPrefixLength + branch*spec.ChildSize,
+		(1 - branch) * spec.ChildSize
+}
+
+func hasPadding(spec *ProofSpec, op *tmcrypto.InnerOp, branch int) bool {
+	minPrefix, maxPrefix, suffix := padding(spec, branch)
+	return len(op.Prefix) >= minPrefix && len(op.Prefix) <= maxPrefix && len(op.Suffix) == suffix
+}
+
+// isLeftMost returns true if the path only goes through left children.
+func isLeftMost(spec *ProofSpec, path []*tmcrypto.InnerOp) bool {
+	for _, step := range path {
+		if !hasPadding(spec, step, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// isRightMost returns true if the path only goes through right children.
+func isRightMost(spec *ProofSpec, path []*tmcrypto.InnerOp) bool {
+	for _, step := range path {
+		if !hasPadding(spec, step, 1) {
+			return false
+		}
+	}
+	return true
+}
+
+// isLeftNeighbor returns true if the paths lead to adjacent leaves, the left
+// one being the rightmost leaf of the left subtree of their lowest common
+// ancestor, and the right one the leftmost leaf of its right subtree.
+func isLeftNeighbor(spec *ProofSpec, left, right []*tmcrypto.InnerOp) bool {
+	// Skip the common ancestors, from the root.
+	l, r := len(left)-1, len(right)-1
+	for l >= 0 && r >= 0 &&
+		bytes.Equal(left[l].Prefix, right[r].Prefix) && bytes.Equal(left[l].Suffix, right[r].Suffix) {
+		l--
+		r--
+	}
+	if l < 0 || r < 0 {
+		return false
+	}
+	if !hasPadding(spec, left[l], 0) || !hasPadding(spec, right[r], 1) {
+		return false
+	}
+	return isRightMost(spec, left[:l]) && isLeftMost(spec, right[:r])
+}
@@ -0,0 +1,225 @@
+package merkle
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/crypto/tmhash"
+	tmcrypto "github.com/tendermint/tendermint/proto/tendermint/crypto"
+)
+
+// iavlVarints encodes the height, size and version of an IAVL node.
+func iavlVarints(height, size, version int64) []byte {
+	var bz []byte
+	for _, v := range []int64{height, size, version} {
+		buf := make([]byte, binary.MaxVarintLen64)
+		bz = append(bz, buf[:binary.PutVarint(buf, v)]...)
+	}
+	return bz
+}
+
+func iavlLeaf(key, value []byte) ([]byte, *tmcrypto.ExistenceProof) {
+	leaf := IAVLSpec.LeafSpec
+	leaf.Prefix = iavlVarints(0, 1, 1)
+	proof := &tmcrypto.ExistenceProof{Key: key, Value: value, Leaf: &leaf}
+	hash, err := applyLeaf(&leaf, key, value)
+	if err != nil {
+		panic(err)
+	}
+	return hash, proof
+}
+
+// iavlInner returns the hash of an IAVL inner node, and adds it to the path of
+// the proofs of its left and right subtrees.
+func iavlInner(height, size int64, left, right []byte, leftProofs, rightProofs []*tmcrypto.ExistenceProof) []byte {
+	prefix := append(iavlVarints(height, size, 1), byte(len(left)))
+	for _, p := range leftProofs {
+		p.Path = append(p.Path, &tmcrypto.InnerOp{
+			Hash:   tmcrypto.HashOp_HASH_OP_SHA256,
+			Prefix: prefix,
+			Suffix: append([]byte{byte(len(right))}, right...),
+		})
+	}
+	for _, p := range rightProofs {
+		p.Path = append(p.Path, &tmcrypto.InnerOp{
+			Hash:   tmcrypto.HashOp_HASH_OP_SHA256,
+			Prefix: append(append(append([]byte{}, prefix...), left...), byte(len(right))),
+		})
+	}
+	return tmhash.Sum(append(append(append(prefix, left...), byte(len(right))), right...))
+}
+
+// makeIAVLTree returns the root hash and the existence proofs of a balanced
+// IAVL tree of the keys a, c, e and g.
+func makeIAVLTree() ([]byte, map[string]*tmcrypto.ExistenceProof) {
+	proofs := make(map[string]*tmcrypto.ExistenceProof)
+	hashes := make(map[string][]byte)
+	for _, k := range []string{"a", "c", "e", "g"} {
+		hashes[k], proofs[k] = iavlLeaf([]byte(k), []byte("value-"+k))
+	}
+	ac := iavlInner(1, 2, hashes["a"], hashes["c"],
+		[]*tmcrypto.ExistenceProof{proofs["a"]}, []*tmcrypto.ExistenceProof{proofs["c"]})
+	eg := iavlInner(1, 2, hashes["e"], hashes["g"],
+		[]*tmcrypto.ExistenceProof{proofs["e"]}, []*tmcrypto.ExistenceProof{proofs["g"]})
+	root := iavlInner(2, 4, ac, eg,
+		[]*tmcrypto.ExistenceProof{proofs["a"], proofs["c"]}, []*tmcrypto.ExistenceProof{proofs["e"], proofs["g"]})
+	return root, proofs
+}
+
+func existOp(op func([]byte, *tmcrypto.CommitmentProof) CommitmentOp, p *tmcrypto.ExistenceProof) CommitmentOp {
+	return op(p.Key, &tmcrypto.CommitmentProof{Proof: &tmcrypto.CommitmentProof_Exist{Exist: p}})
+}
+
+func nonexistOp(op func([]byte, *tmcrypto.CommitmentProof) CommitmentOp,
+	key string, left, right *tmcrypto.ExistenceProof) CommitmentOp {
+	return op([]byte(key), &tmcrypto.CommitmentProof{Proof: &tmcrypto.CommitmentProof_Nonexist{
+		Nonexist: &tmcrypto.NonExistenceProof{Key: []byte(key), Left: left, Right: right},
+	}})
+}
+
+func TestIAVLCommitmentOp(t *testing.T) {
+	root, proofs := makeIAVLTree()
+
+	for k, p := range proofs {
+		res, err := existOp(NewIAVLCommitmentOp, p).Run([][]byte{p.Value})
+		require.NoError(t, err, k)
+		assert.Equal(t, root, res[0], k)
+
+		_, err = existOp(NewIAVLCommitmentOp, p).Run([][]byte{[]byte("other")})
+		assert.Error(t, err, k)
+		_, err = existOp(NewIAVLCommitmentOp, p).Run(nil)
+		assert.Error(t, err, k)
+	}
+
+	testCases := []struct {
+		key         string
+		left, right *tmcrypto.ExistenceProof
+		valid       bool
+	}{
+		{"d", proofs["c"], proofs["e"], true},
+		{"b", proofs["a"], proofs["c"], true},
+		{"0", nil, proofs["a"], true},
+		{"z", proofs["g"], nil, true},
+		{"d", proofs["a"], proofs["e"], false},
+		{"d", proofs["c"], proofs["g"], false},
+		{"b", nil, proofs["c"], false},
+		{"f", proofs["e"], nil, false},
+		{"c", proofs["c"], proofs["e"], false},
+		{"d", nil, nil, false},
+	}
+	for i, tc := range testCases {
+		res, err := nonexistOp(NewIAVLCommitmentOp, tc.key, tc.left, tc.right).Run(nil)
+		if !tc.valid {
+			assert.Error(t, err, i)
+			continue
+		}
+		require.NoError(t, err, i)
+		assert.Equal(t, root, res[0], i)
+	}
+
+	// An inner node can't be proven as a leaf.
+	leaf := *proofs["a"].Leaf
+	leaf.Prefix = []byte{1}
+	bad := *proofs["a"]
+	bad.Leaf = &leaf
+	_, err := existOp(NewIAVLCommitmentOp, &bad).Run([][]byte{bad.Value})
+	assert.Error(t, err)
+}
+
+// The vectors below were produced by github.com/cosmos/iavl v0.17.3, with a
+// tree of the keys apple, banana, cherry, date and fig set to "value-<key>"
+// and saved as version 1.
+const (
+	iavlVectorRoot  = "d60e369eecbecd8238030f3f243c60154e7905501c123bd3bc865e5dbf17050d"
+	iavlVectorExist = "0a7b0a06636865727279120c76616c75652d6368657272791a0b0801180120012a030002" +
+		"02222b08011204040602201a2120a4bade0e7eeb99d3540dd87246b0dbd238ccc11d44ef8689ab8c0df55f6605" +
+		"61222908011225060a02202f5d4c3e9cce349f141b0f73e9786ea4bb38890ec38116e2f464dd6c40738b6c20"
+)
+
+var iavlVectorNonexist = map[string]string{
+	"coconut": "12ab020a07636f636f6e7574127b0a06636865727279120c76616c75652d6368657272791a0b080118" +
+		"0120012a03000202222b08011204040602201a2120a4bade0e7eeb99d3540dd87246b0dbd238ccc11d44ef8689" +
+		"ab8c0df55f660561222908011225060a02202f5d4c3e9cce349f141b0f73e9786ea4bb38890ec38116e2f464dd" +
+		"6c40738b6c201aa2010a0464617465120a76616c75652d646174651a0b0801180120012a03000202222b080112" +
+		"04020402201a21209c1dae5102357bb00859f78e688b46eef04a7b997055f6feeb2806909878f5682229080112" +
+		"2504060220464816cdd6763455c76bf06c76a6ab04d9a2b203fd2c4c23767fb33aa53a5fb420222908011225060a" +
+		"02202f5d4c3e9cce349f141b0f73e9786ea4bb38890ec38116e2f464dd6c40738b6c20",
+	"aardvark": "1287010a08616172647661726b1a7b0a056170706c65120b76616c75652d6170706c651a0b08011801" +
+		"20012a03000202222b08011204020402201a2120c98d7fbdd9e708c6bf4b6b07632cd5f74ab95dc0c8d269a94a" +
+		"fd67f13314ddb5222b08011204060a02201a2120c11c19757dbaac56c0044c4637b611474486314b3fad70832b" +
+		"9ab1c45cb087d6",
+	"zebra": "12a8010a057a65627261129e010a03666967120976616c75652d6669671a0b0801180120012a03000202" +
+		"222908011225020402203c78d86a946cc2e37bfc9b5787d4355e229447f91e287c17a69b7e0e5e660d43202229" +
+		"0801122504060220464816cdd6763455c76bf06c76a6ab04d9a2b203fd2c4c23767fb33aa53a5fb42022290801" +
+		"1225060a02202f5d4c3e9cce349f141b0f73e9786ea4bb38890ec38116e2f464dd6c40738b6c20",
+}
+
+func decodeCommitmentProof(t *testing.T, s string) *tmcrypto.CommitmentProof {
+	bz, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	var proof tmcrypto.CommitmentProof
+	require.NoError(t, proof.Unmarshal(bz))
+	return &proof
+}
+
+func TestIAVLCommitmentOpVectors(t *testing.T) {
+	root, err := hex.DecodeString(iavlVectorRoot)
+	require.NoError(t, err)
+	prt := DefaultProofRuntime()
+
+	exist := NewIAVLCommitmentOp([]byte("cherry"), decodeCommitmentProof(t, iavlVectorExist))
+	ops := &tmcrypto.ProofOps{Ops: []tmcrypto.ProofOp{exist.ProofOp()}}
+	assert.NoError(t, prt.VerifyValue(ops, root, "/cherry", []byte("value-cherry")))
+	assert.Error(t, prt.VerifyValue(ops, root, "/cherry", []byte("value-date")))
+	assert.Error(t, prt.VerifyAbsence(ops, root, "/cherry"))
+
+	for key, vector := range iavlVectorNonexist {
+		nonexist := NewIAVLCommitmentOp([]byte(key), decodeCommitmentProof(t, vector))
+		ops := &tmcrypto.ProofOps{Ops: []tmcrypto.ProofOp{nonexist.ProofOp()}}
+		assert.NoError(t, prt.VerifyAbsence(ops, root, "/"+key), key)
+		assert.Error(t, prt.VerifyAbsence(ops, root, "/cherry"), key)
+	}
+}
+
+func TestSimpleCommitmentOp(t *testing.T) {
+	var keys, values [][]byte
+	for i := 1; i <= 5; i++ {
+		keys = append(keys, []byte(fmt.Sprintf("key%02d", 2*i)))
+		values = append(values, []byte(fmt.Sprintf("value%02d", 2*i)))
+	}
+	root, proofs := SimpleExistenceProofs(keys, values)
+
+	// Every pair can be proven.
+	for i, p := range proofs {
+		res, err := existOp(NewSimpleCommitmentOp, p).Run([][]byte{values[i]})
+		require.NoError(t, err, i)
+		assert.Equal(t, root, res[0], i)
+	}
+
+	// Every gap between the keys can be proven, through their runtime
+	// encoding.
+	prt := DefaultProofRuntime()
+	for i := 0; i <= len(proofs); i++ {
+		var left, right *tmcrypto.ExistenceProof
+		if i > 0 {
+			left = proofs[i-1]
+		}
+		if i < len(proofs) {
+			right = proofs[i]
+		}
+		key := fmt.Sprintf("key%02d", 2*i+1)
+		op := nonexistOp(NewSimpleCommitmentOp, key, left, right).ProofOp()
+		err := prt.VerifyAbsence(&tmcrypto.ProofOps{Ops: []tmcrypto.ProofOp{op}}, root, "/"+key)
+		assert.NoError(t, err, key)
+	}
+
+	_, err := nonexistOp(NewSimpleCommitmentOp, "key05", proofs[0], proofs[2]).Run(nil)
+	assert.Error(t, err)
+	_, err = nonexistOp(NewSimpleCommitmentOp, "key09", proofs[3], nil).Run(nil)
+	assert.Error(t, err)
+}
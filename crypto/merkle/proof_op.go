@@ -129,11 +129,13 @@ func (prt *ProofRuntime) Verify(proof *tmcrypto.ProofOps, root []byte, keypath s
 	return poz.Verify(root, keypath, args)
 }
 
-// DefaultProofRuntime only knows about value proofs.
-// To use e.g. IAVL proofs, register op-decoders as
-// defined in the IAVL package.
+// DefaultProofRuntime knows about value proofs, and the ICS23 commitment
+// proofs of IAVL and simple merkle trees. Other proofs need their op-decoders
+// to be registered.
 func DefaultProofRuntime() (prt *ProofRuntime) {
 	prt = NewProofRuntime()
 	prt.RegisterOpDecoder(ProofOpValue, ValueOpDecoder)
+	prt.RegisterOpDecoder(ProofOpIAVLCommitment, IAVLCommitmentOpDecoder)
+	prt.RegisterOpDecoder(ProofOpSimpleCommitment, SimpleCommitmentOpDecoder)
 	return
 }
@@ -0,0 +1,226 @@
+package inspect
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/cors"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	dbm "github.com/tendermint/tm-db"
+
+	cfg "github.com/tendermint/tendermint/config"
+	"github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/libs/service"
+	tmstrings "github.com/tendermint/tendermint/libs/strings"
+	rpccore "github.com/tendermint/tendermint/rpc/core"
+	rpcserver "github.com/tendermint/tendermint/rpc/jsonrpc/server"
+	sm "github.com/tendermint/tendermint/state"
+	"github.com/tendermint/tendermint/state/txindex"
+	"github.com/tendermint/tendermint/state/txindex/kv"
+	"github.com/tendermint/tendermint/state/txindex/null"
+	"github.com/tendermint/tendermint/store"
+	"github.com/tendermint/tendermint/types"
+)
+
+// routeNames are the read-only routes of rpc/core served by the Inspector,
+// which only need the stores.
+var routeNames = []string{
+	"blockchain",
+	"block",
+	"block_by_hash",
+	"block_results",
+	"commit",
+	"validators",
+	"tx",
+	"tx_search",
+	"consensus_params",
+	"genesis",
+}
+
+// Routes returns the read-only routes served by the Inspector.
+func Routes() map[string]*rpcserver.RPCFunc {
+	routes := make(map[string]*rpcserver.RPCFunc, len(routeNames))
+	for _, name := range routeNames {
+		routes[name] = rpccore.Routes[name]
+	}
+	return routes
+}
+
+// Inspector serves the read-only RPC routes from the block store, state store
+// and tx index of a node, without starting consensus, p2p or the ABCI
+// application. It's used to debug a node which can't be started, e.g. after
+// an app hash mismatch.
+//
+// Since rpc/core has a single environment, only one Inspector or Node can
+// serve RPC in a process.
+type Inspector struct {
+	service.BaseService
+
+	config     *cfg.RPCConfig
+	genDoc     *types.GenesisDoc
+	stateStore sm.Store
+	blockStore sm.BlockStore
+	txIndexer  txindex.TxIndexer
+
+	dbs       []dbm.DB
+	listeners []net.Listener
+}
+
+// New returns an Inspector of the given stores, serving RPC as configured.
+func New(
+	config *cfg.RPCConfig,
+	genDoc *types.GenesisDoc,
+	stateStore sm.Store,
+	blockStore sm.BlockStore,
+	txIndexer txindex.TxIndexer,
+	logger log.Logger,
+) *Inspector {
+	ins := &Inspector{
+		config:     config,
+		genDoc:     genDoc,
+		stateStore: stateStore,
+		blockStore: blockStore,
+		txIndexer:  txIndexer,
+	}
+	ins.BaseService = *service.NewBaseService(logger, "Inspector", ins)
+	return ins
+}
+
+// NewFromConfig returns an Inspector of the databases of the node with the
+// given config, which must be stopped. The goleveldb databases are opened
+// read-only, others are opened as usual but never written to.
+func NewFromConfig(config *cfg.Config, logger log.Logger) (*Inspector, error) {
+	genDoc, err := types.GenesisDocFromFile(config.GenesisFile())
+	if err != nil {
+		return nil, err
+	}
+
+	var dbs []dbm.DB
+	closeDBs := func() {
+		for _, db := range dbs {
+			db.Close()
+		}
+	}
+	open := func(id string) (dbm.DB, error) {
+		db, err := openReadOnlyDB(id, config)
+		if err != nil {
+			closeDBs()
+			return nil, fmt.Errorf("failed to open %v database: %w", id, err)
+		}
+		dbs = append(dbs, db)
+		return db, nil
+	}
+
+	blockStoreDB, err := open("blockstore")
+	if err != nil {
+		return nil, err
+	}
+	stateDB, err := open("state")
+	if err != nil {
+		return nil, err
+	}
+	var txIndexer txindex.TxIndexer = &null.TxIndex{}
+	if config.TxIndex.Indexer == "kv" {
+		txIndexDB, err := open("tx_index")
+		if err != nil {
+			return nil, err
+		}
+		txIndexer = kv.NewTxIndex(txIndexDB)
+	}
+
+	ins := New(config.RPC, genDoc, sm.NewStore(stateDB), store.NewBlockStore(blockStoreDB), txIndexer, logger)
+	ins.dbs = dbs
+	return ins, nil
+}
+
+// openReadOnlyDB opens an existing database of the node.
+func openReadOnlyDB(id string, config *cfg.Config) (dbm.DB, error) {
+	if dbm.BackendType(config.DBBackend) == dbm.GoLevelDBBackend {
+		return dbm.NewGoLevelDBWithOpts(id, config.DBDir(), &opt.Options{
+			ReadOnly:       true,
+			ErrorIfMissing: true,
+		})
+	}
+	if _, err := os.Stat(filepath.Join(config.DBDir(), id+".db")); err != nil {
+		return nil, err
+	}
+	return dbm.NewDB(id, dbm.BackendType(config.DBBackend), config.DBDir())
+}
+
+// OnStart implements service.Service.
+func (ins *Inspector) OnStart() error {
+	rpccore.SetEnvironment(&rpccore.Environment{
+		StateStore: ins.stateStore,
+		BlockStore: ins.blockStore,
+		GenDoc:     ins.genDoc,
+		TxIndexer:  ins.txIndexer,
+		Logger:     ins.Logger.With("module", "rpc"),
+		Config:     *ins.config,
+	})
+
+	config := rpcserver.DefaultConfig()
+	config.MaxBodyBytes = ins.config.MaxBodyBytes
+	config.MaxHeaderBytes = ins.config.MaxHeaderBytes
+	config.MaxOpenConnections = ins.config.MaxOpenConnections
+
+	routes := Routes()
+	rpcLogger := ins.Logger.With("module", "rpc-server")
+	for _, listenAddr := range tmstrings.SplitAndTrim(ins.config.ListenAddress, ",", " ") {
+		if listenAddr == "" {
+			continue
+		}
+		mux := http.NewServeMux()
+		rpcserver.RegisterRPCFuncs(mux, routes, rpcLogger)
+		listener, err := rpcserver.Listen(listenAddr, config)
+		if err != nil {
+			ins.closeListeners()
+			return err
+		}
+		ins.listeners = append(ins.listeners, listener)
+
+		var rootHandler http.Handler = mux
+		if ins.config.IsCorsEnabled() {
+			rootHandler = cors.New(cors.Options{
+				AllowedOrigins: ins.config.CORSAllowedOrigins,
+				AllowedMethods: ins.config.CORSAllowedMethods,
+				AllowedHeaders: ins.config.CORSAllowedHeaders,
+			}).Handler(mux)
+		}
+		go func() {
+			var err error
+			if ins.config.IsTLSEnabled() {
+				err = rpcserver.ServeTLS(listener, rootHandler, ins.config.CertFile(), ins.config.KeyFile(),
+					rpcLogger, config)
+			} else {
+				err = rpcserver.Serve(listener, rootHandler, rpcLogger, config)
+			}
+			if err != nil && ins.IsRunning() {
+				ins.Logger.Error("Error serving server", "err", err)
+			}
+		}()
+		ins.Logger.Info("Serving read-only RPC", "addr", listener.Addr())
+	}
+	return nil
+}
+
+// OnStop implements service.Service.
+func (ins *Inspector) OnStop() {
+	ins.closeListeners()
+	for _, db := range ins.dbs {
+		if err := db.Close(); err != nil {
+			ins.Logger.Error("Error closing database", "err", err)
+		}
+	}
+}
+
+func (ins *Inspector) closeListeners() {
+	for _, l := range ins.listeners {
+		if err := l.Close(); err != nil {
+			ins.Logger.Error("Error closing listener", "listener", l, "err", err)
+		}
+	}
+	ins.listeners = nil
+}
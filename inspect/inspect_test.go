@@ -0,0 +1,99 @@
+package inspect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	dbm "github.com/tendermint/tm-db"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	cfg "github.com/tendermint/tendermint/config"
+	"github.com/tendermint/tendermint/libs/log"
+	tmstate "github.com/tendermint/tendermint/proto/tendermint/state"
+	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
+	sm "github.com/tendermint/tendermint/state"
+	"github.com/tendermint/tendermint/state/txindex/kv"
+	"github.com/tendermint/tendermint/store"
+	"github.com/tendermint/tendermint/types"
+	tmtime "github.com/tendermint/tendermint/types/time"
+)
+
+func TestInspector(t *testing.T) {
+	val, _ := types.RandValidator(false, 10)
+	genDoc := types.GenesisDoc{
+		ChainID:     "inspect-test",
+		GenesisTime: tmtime.Now(),
+		Validators:  []types.GenesisValidator{{PubKey: val.PubKey, Power: val.VotingPower}},
+	}
+	require.NoError(t, genDoc.ValidateAndComplete())
+	state, err := sm.MakeGenesisState(&genDoc)
+	require.NoError(t, err)
+	stateStore := sm.NewStore(dbm.NewMemDB())
+	require.NoError(t, stateStore.Save(state))
+	blockStore := store.NewBlockStore(dbm.NewMemDB())
+	txIndexer := kv.NewTxIndex(dbm.NewMemDB())
+
+	// Store a single block with one transaction, as if the node was halted
+	// right after it.
+	tx := types.Tx("key=value")
+	block, parts := state.MakeBlock(1, []types.Tx{tx}, new(types.Commit), nil, val.Address)
+	blockID := types.BlockID{Hash: block.Hash(), PartSetHeader: parts.Header()}
+	seenCommit := types.NewCommit(1, 0, blockID, []types.CommitSig{types.NewCommitSigAbsent()})
+	blockStore.SaveBlock(block, parts, seenCommit)
+	require.NoError(t, stateStore.SaveABCIResponses(1, &tmstate.ABCIResponses{
+		BeginBlock: &abci.ResponseBeginBlock{},
+		DeliverTxs: []*abci.ResponseDeliverTx{{Code: abci.CodeTypeOK}},
+		EndBlock:   &abci.ResponseEndBlock{},
+	}))
+	require.NoError(t, txIndexer.Index(&abci.TxResult{Height: 1, Tx: tx}))
+
+	config := cfg.TestRPCConfig()
+	config.ListenAddress = "tcp://127.0.0.1:0"
+	ins := New(config, &genDoc, stateStore, blockStore, txIndexer, log.TestingLogger())
+	require.NoError(t, ins.Start())
+	t.Cleanup(func() { require.NoError(t, ins.Stop()) })
+
+	c, err := rpchttp.New("tcp://"+ins.listeners[0].Addr().String(), "/websocket")
+	require.NoError(t, err)
+
+	resBlock, err := c.Block(nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, resBlock.Block.Height)
+	assert.Equal(t, blockID, resBlock.BlockID)
+
+	resCommit, err := c.Commit(nil)
+	require.NoError(t, err)
+	assert.Equal(t, blockID, resCommit.Commit.BlockID)
+
+	resResults, err := c.BlockResults(nil)
+	require.NoError(t, err)
+	assert.Len(t, resResults.TxsResults, 1)
+
+	resVals, err := c.Validators(nil, nil, nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, resVals.BlockHeight)
+	assert.Equal(t, 1, resVals.Total)
+
+	resParams, err := c.ConsensusParams(nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, resParams.BlockHeight)
+
+	resTx, err := c.Tx(tx.Hash(), false)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, resTx.Height)
+
+	resSearch, err := c.TxSearch("tx.height = 1", false, nil, nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, resSearch.TotalCount)
+
+	resGenesis, err := c.Genesis()
+	require.NoError(t, err)
+	assert.Equal(t, genDoc.ChainID, resGenesis.Genesis.ChainID)
+
+	// Routes which need consensus, p2p or the application are not served.
+	_, err = c.Status()
+	assert.Error(t, err)
+	_, err = c.BroadcastTxSync(tx)
+	assert.Error(t, err)
+}
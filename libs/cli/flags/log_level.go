@@ -19,6 +19,36 @@ const (
 // Example:
 //		ParseLogLevel("consensus:debug,mempool:debug,*:error", log.NewTMLogger(os.Stdout), "info")
 func ParseLogLevel(lvl string, logger log.Logger, defaultLogLevelValue string) (log.Logger, error) {
+	options, err := ParseLogLevelOptions(lvl, defaultLogLevelValue)
+	if err != nil {
+		return nil, err
+	}
+
+	return log.NewFilter(logger, options...), nil
+}
+
+// SetLogLevel parses complex log level (see ParseLogLevel) and sets it as the
+// levels of the logger, and of all the loggers sharing them, while they are in
+// use. The logger must be created by ParseLogLevel or log.NewFilter, or be
+// derived from one of them.
+func SetLogLevel(lvl string, logger log.Logger, defaultLogLevelValue string) error {
+	setter, ok := logger.(log.LevelSetter)
+	if !ok {
+		return errors.New("the levels of the logger can't be changed")
+	}
+
+	options, err := ParseLogLevelOptions(lvl, defaultLogLevelValue)
+	if err != nil {
+		return err
+	}
+
+	setter.SetLevels(options...)
+	return nil
+}
+
+// ParseLogLevelOptions parses complex log level (see ParseLogLevel) into
+// filter options.
+func ParseLogLevelOptions(lvl string, defaultLogLevelValue string) ([]log.Option, error) {
 	if lvl == "" {
 		return nil, errors.New("empty log level")
 	}
@@ -85,5 +115,5 @@ func ParseLogLevel(lvl string, logger log.Logger, defaultLogLevelValue string) (
 		options = append(options, option)
 	}
 
-	return log.NewFilter(logger, options...), nil
+	return options, nil
 }
@@ -92,3 +92,37 @@ func TestParseLogLevel(t *testing.T) {
 		}
 	}
 }
+
+func TestSetLogLevel(t *testing.T) {
+	var buf bytes.Buffer
+	jsonLogger := log.NewTMJSONLogger(&buf)
+
+	logger, err := tmflags.ParseLogLevel("*:error", jsonLogger, defaultLogLevelValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger = logger.With("module", "mempool")
+
+	if err := tmflags.SetLogLevel("mempool:debug,*:error", logger, defaultLogLevelValue); err != nil {
+		t.Fatal(err)
+	}
+	logger.Debug("Kingpin")
+	if want, have := `{"_msg":"Kingpin","level":"debug","module":"mempool"}`, strings.TrimSpace(buf.String()); want != have {
+		t.Errorf("\nwant '%s'\nhave '%s'", want, have)
+	}
+
+	buf.Reset()
+
+	// An incorrect level leaves the levels unchanged.
+	if err := tmflags.SetLogLevel("mempool:some", logger, defaultLogLevelValue); err == nil {
+		t.Fatal("Expected mempool:some to produce error")
+	}
+	logger.Debug("Kitty Pryde")
+	if want, have := `{"_msg":"Kitty Pryde","level":"debug","module":"mempool"}`, strings.TrimSpace(buf.String()); want != have {
+		t.Errorf("\nwant '%s'\nhave '%s'", want, have)
+	}
+
+	if err := tmflags.SetLogLevel("info", jsonLogger, defaultLogLevelValue); err == nil {
+		t.Fatal("Expected a logger without filter to produce error")
+	}
+}
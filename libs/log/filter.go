@@ -1,6 +1,11 @@
 package log
 
-import "fmt"
+import (
+	"fmt"
+	"sync/atomic"
+
+	tmsync "github.com/tendermint/tendermint/libs/sync"
+)
 
 type level byte
 
@@ -11,10 +16,22 @@ const (
 )
 
 type filter struct {
-	next             Logger
-	allowed          level            // XOR'd levels for default case
-	initiallyAllowed level            // XOR'd levels for initial case
-	allowedKeyvals   map[keyval]level // When key-value match, use this level
+	next Logger
+	// Levels shared by the filter and all the filters derived from it, which
+	// can be changed with SetLevels.
+	levels *filterLevels
+	// keyvals of the With calls, from the first one.
+	keyvals [][]interface{}
+	// Allowed levels computed for the version of the shared levels:
+	// version<<8 | allowed.
+	cache uint64
+}
+
+type filterLevels struct {
+	mtx            tmsync.RWMutex
+	allowed        level            // XOR'd levels for default case
+	allowedKeyvals map[keyval]level // When key-value match, use this level
+	version        uint64           // incremented when the levels change
 }
 
 type keyval struct {
@@ -22,24 +39,53 @@ type keyval struct {
 	value interface{}
 }
 
+// debugEnabler is implemented by the loggers which can tell whether their
+// debug log events are filtered out, without logging one.
+type debugEnabler interface {
+	debugEnabled() bool
+}
+
+// LevelSetter is implemented by the loggers whose levels can be changed while
+// they are in use.
+type LevelSetter interface {
+	// SetLevels replaces the levels of the logger, and of all the loggers
+	// sharing them, with the given options.
+	SetLevels(options ...Option)
+}
+
 // NewFilter wraps next and implements filtering. See the commentary on the
 // Option functions for a detailed description of how to configure levels. If
 // no options are provided, all leveled log events created with Debug, Info or
 // Error helper methods are squelched.
+//
+// The returned logger, and all the loggers derived from it with With, share
+// their levels, which can be changed at any time with SetLevels (see
+// LevelSetter).
 func NewFilter(next Logger, options ...Option) Logger {
 	l := &filter{
-		next:           next,
-		allowedKeyvals: make(map[keyval]level),
+		next:   next,
+		levels: &filterLevels{version: 1},
 	}
+	l.SetLevels(options...)
+	return l
+}
+
+// SetLevels implements LevelSetter.
+func (l *filter) SetLevels(options ...Option) {
+	levels := &filterLevels{allowedKeyvals: make(map[keyval]level)}
 	for _, option := range options {
-		option(l)
+		option(levels)
 	}
-	l.initiallyAllowed = l.allowed
-	return l
+
+	l.levels.mtx.Lock()
+	l.levels.allowed = levels.allowed
+	l.levels.allowedKeyvals = levels.allowedKeyvals
+	atomic.AddUint64(&l.levels.version, 1)
+	l.levels.mtx.Unlock()
 }
 
 func (l *filter) Info(msg string, keyvals ...interface{}) {
-	levelAllowed := l.allowed&levelInfo != 0
+	levelAllowed := l.allowed()&levelInfo != 0
 	if !levelAllowed {
 		return
 	}
@@ -47,7 +93,7 @@ func (l *filter) Info(msg string, keyvals ...interface{}) {
 }
 
 func (l *filter) Debug(msg string, keyvals ...interface{}) {
-	levelAllowed := l.allowed&levelDebug != 0
+	levelAllowed := l.allowed()&levelDebug != 0
 	if !levelAllowed {
 		return
 	}
@@ -55,7 +101,7 @@ func (l *filter) Debug(msg string, keyvals ...interface{}) {
 }
 
 func (l *filter) Error(msg string, keyvals ...interface{}) {
-	levelAllowed := l.allowed&levelError != 0
+	levelAllowed := l.allowed()&levelError != 0
 	if !levelAllowed {
 		return
 	}
@@ -82,51 +128,77 @@ func (l *filter) Error(msg string, keyvals ...interface{}) {
 // 				log.AllowInfoWith("module", "crypto"), log.AllowNoneWith("user", "Sam"))
 //		 logger.With("user", "Sam").With("module", "crypto").Info("Hello") # produces "I... Hello module=crypto user=Sam"
 func (l *filter) With(keyvals ...interface{}) Logger {
-	keyInAllowedKeyvals := false
-
-	for i := len(keyvals) - 2; i >= 0; i -= 2 {
-		for kv, allowed := range l.allowedKeyvals {
-			if keyvals[i] == kv.key {
-				keyInAllowedKeyvals = true
-				// Example:
-				//		logger = log.NewFilter(logger, log.AllowError(), log.AllowInfoWith("module", "crypto"))
-				//		logger.With("module", "crypto")
-				if keyvals[i+1] == kv.value {
-					return &filter{
-						next:             l.next.With(keyvals...),
-						allowed:          allowed, // set the desired level
-						allowedKeyvals:   l.allowedKeyvals,
-						initiallyAllowed: l.initiallyAllowed,
+	withKeyvals := make([][]interface{}, len(l.keyvals), len(l.keyvals)+1)
+	copy(withKeyvals, l.keyvals)
+	withKeyvals = append(withKeyvals, append([]interface{}{}, keyvals...))
+
+	return &filter{
+		next:    l.next.With(keyvals...),
+		levels:  l.levels,
+		keyvals: withKeyvals,
+	}
+}
+
+func (l *filter) debugEnabled() bool {
+	return l.allowed()&levelDebug != 0
+}
+
+// allowed returns the levels allowed for the keyvals of the filter, which are
+// recomputed when the shared levels change.
+func (l *filter) allowed() level {
+	version := atomic.LoadUint64(&l.levels.version)
+	if cache := atomic.LoadUint64(&l.cache); cache>>8 == version {
+		return level(cache & 0xff)
+	}
+
+	l.levels.mtx.RLock()
+	version = l.levels.version
+	allowed := l.levels.allowedFor(l.keyvals)
+	l.levels.mtx.RUnlock()
+
+	atomic.StoreUint64(&l.cache, version<<8|uint64(allowed))
+	return allowed
+}
+
+// allowedFor returns the levels allowed for the keyvals of the given With
+// calls: the level of the last matching key-value pair, unless a later call
+// has a key with a custom level, but another value.
+//
+// NOTE: requires the mtx locked.
+func (fl *filterLevels) allowedFor(withKeyvals [][]interface{}) level {
+	for i := len(withKeyvals) - 1; i >= 0; i-- {
+		keyvals := withKeyvals[i]
+		keyInAllowedKeyvals := false
+
+		for j := len(keyvals) - 2; j >= 0; j -= 2 {
+			for kv, allowed := range fl.allowedKeyvals {
+				if keyvals[j] == kv.key {
+					keyInAllowedKeyvals = true
+					// Example:
+					//		logger = log.NewFilter(logger, log.AllowError(), log.AllowInfoWith("module", "crypto"))
+					//		logger.With("module", "crypto")
+					if keyvals[j+1] == kv.value {
+						return allowed // set the desired level
 					}
 				}
 			}
 		}
-	}
 
-	// Example:
-	//		logger = log.NewFilter(logger, log.AllowError(), log.AllowInfoWith("module", "crypto"))
-	//		logger.With("module", "main")
-	if keyInAllowedKeyvals {
-		return &filter{
-			next:             l.next.With(keyvals...),
-			allowed:          l.initiallyAllowed, // return back to initially allowed
-			allowedKeyvals:   l.allowedKeyvals,
-			initiallyAllowed: l.initiallyAllowed,
+		// Example:
+		//		logger = log.NewFilter(logger, log.AllowError(), log.AllowInfoWith("module", "crypto"))
+		//		logger.With("module", "main")
+		if keyInAllowedKeyvals {
+			return fl.allowed // return back to initially allowed
 		}
 	}
 
-	return &filter{
-		next:             l.next.With(keyvals...),
-		allowed:          l.allowed, // simply continue with the current level
-		allowedKeyvals:   l.allowedKeyvals,
-		initiallyAllowed: l.initiallyAllowed,
-	}
+	return fl.allowed
 }
 
 //--------------------------------------------------------------------------------
 
 // Option sets a parameter for the filter.
-type Option func(*filter)
+type Option func(*filterLevels)
 
 // AllowLevel returns an option for the given level or error if no option exist
 // for such level.
@@ -171,25 +243,25 @@ func AllowNone() Option {
 }
 
 func allowed(allowed level) Option {
-	return func(l *filter) { l.allowed = allowed }
+	return func(l *filterLevels) { l.allowed = allowed }
 }
 
 // AllowDebugWith allows error, info and debug level log events to pass for a specific key value pair.
 func AllowDebugWith(key interface{}, value interface{}) Option {
-	return func(l *filter) { l.allowedKeyvals[keyval{key, value}] = levelError | levelInfo | levelDebug }
+	return func(l *filterLevels) { l.allowedKeyvals[keyval{key, value}] = levelError | levelInfo | levelDebug }
 }
 
 // AllowInfoWith allows error and info level log events to pass for a specific key value pair.
 func AllowInfoWith(key interface{}, value interface{}) Option {
-	return func(l *filter) { l.allowedKeyvals[keyval{key, value}] = levelError | levelInfo }
+	return func(l *filterLevels) { l.allowedKeyvals[keyval{key, value}] = levelError | levelInfo }
 }
 
 // AllowErrorWith allows only error level log events to pass for a specific key value pair.
 func AllowErrorWith(key interface{}, value interface{}) Option {
-	return func(l *filter) { l.allowedKeyvals[keyval{key, value}] = levelError }
+	return func(l *filterLevels) { l.allowedKeyvals[keyval{key, value}] = levelError }
 }
 
 // AllowNoneWith allows no leveled log events to pass for a specific key value pair.
 func AllowNoneWith(key interface{}, value interface{}) Option {
-	return func(l *filter) { l.allowedKeyvals[keyval{key, value}] = 0 }
+	return func(l *filterLevels) { l.allowedKeyvals[keyval{key, value}] = 0 }
 }
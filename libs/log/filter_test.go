@@ -138,3 +138,34 @@ func TestVariousAllowWith(t *testing.T) {
 		t.Errorf("\nwant '%s'\nhave '%s'", want, have)
 	}
 }
+
+func TestSetLevels(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger := log.NewFilter(log.NewTMJSONLogger(&buf), log.AllowError(), log.AllowInfoWith("module", "p2p"))
+	p2pLogger := logger.With("module", "p2p")
+
+	p2pLogger.Debug("foo")
+	p2pLogger.Info("bar")
+	if want, have := `{"_msg":"bar","level":"info","module":"p2p"}`, strings.TrimSpace(buf.String()); want != have {
+		t.Errorf("\nwant '%s'\nhave '%s'", want, have)
+	}
+
+	buf.Reset()
+
+	// The levels set on a derived logger apply to all the loggers sharing them.
+	p2pLogger.(log.LevelSetter).SetLevels(log.AllowInfo(), log.AllowDebugWith("module", "p2p"))
+
+	p2pLogger.Debug("foo")
+	if want, have := `{"_msg":"foo","level":"debug","module":"p2p"}`, strings.TrimSpace(buf.String()); want != have {
+		t.Errorf("\nwant '%s'\nhave '%s'", want, have)
+	}
+
+	buf.Reset()
+
+	logger.Debug("foo")
+	logger.With("module", "consensus").Info("bar")
+	if want, have := `{"_msg":"bar","level":"info","module":"consensus"}`, strings.TrimSpace(buf.String()); want != have {
+		t.Errorf("\nwant '%s'\nhave '%s'", want, have)
+	}
+}
@@ -0,0 +1,102 @@
+package log
+
+import (
+	"time"
+
+	tmsync "github.com/tendermint/tendermint/libs/sync"
+)
+
+// NewRateLimitedLogger wraps next, and drops the debug log events of a
+// message above limit per second. The number of dropped events is logged
+// with the next event of the message which passes, with the "dropped" key.
+// Info and error log events aren't limited. The debug log events filtered out
+// by next (see NewFilter) are dropped before being counted.
+//
+// The loggers derived from the returned one with With share its limits.
+func NewRateLimitedLogger(next Logger, limit int) Logger {
+	return &rateLimitedLogger{
+		next: next,
+		limiter: &rateLimiter{
+			limit: limit,
+			msgs:  make(map[string]*msgRate),
+		},
+	}
+}
+
+type rateLimitedLogger struct {
+	next    Logger
+	limiter *rateLimiter
+}
+
+type rateLimiter struct {
+	mtx   tmsync.Mutex
+	limit int
+	msgs  map[string]*msgRate
+}
+
+// msgRate counts the log events of a message in the current second.
+type msgRate struct {
+	start   time.Time
+	count   int
+	dropped int
+}
+
+func (l *rateLimitedLogger) Info(msg string, keyvals ...interface{}) {
+	l.next.Info(msg, keyvals...)
+}
+
+func (l *rateLimitedLogger) Debug(msg string, keyvals ...interface{}) {
+	// Don't count the events the wrapped logger would filter out.
+	if e, ok := l.next.(debugEnabler); ok && !e.debugEnabled() {
+		return
+	}
+	allowed, dropped := l.limiter.allow(msg, time.Now())
+	if !allowed {
+		return
+	}
+	if dropped > 0 {
+		keyvals = append(keyvals, "dropped", dropped)
+	}
+	l.next.Debug(msg, keyvals...)
+}
+
+func (l *rateLimitedLogger) Error(msg string, keyvals ...interface{}) {
+	l.next.Error(msg, keyvals...)
+}
+
+func (l *rateLimitedLogger) With(keyvals ...interface{}) Logger {
+	return &rateLimitedLogger{next: l.next.With(keyvals...), limiter: l.limiter}
+}
+
+// SetLevels implements LevelSetter, if the wrapped logger does.
+func (l *rateLimitedLogger) SetLevels(options ...Option) {
+	if setter, ok := l.next.(LevelSetter); ok {
+		setter.SetLevels(options...)
+	}
+}
+
+// allow returns whether a log event of the message is allowed at the given
+// time, and if so, the number of events of the message dropped since the
+// last allowed one.
+func (r *rateLimiter) allow(msg string, now time.Time) (bool, int) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	m, ok := r.msgs[msg]
+	if !ok {
+		m = &msgRate{start: now}
+		r.msgs[msg] = m
+	}
+	if now.Sub(m.start) >= time.Second {
+		m.start = now
+		m.count = 0
+	}
+	if m.count >= r.limit {
+		m.dropped++
+		return false, 0
+	}
+	m.count++
+	dropped := m.dropped
+	m.dropped = 0
+	return true, dropped
+}
@@ -0,0 +1,61 @@
+package log_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestRateLimitedLogger(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger := log.NewRateLimitedLogger(log.NewTMJSONLogger(&buf), 2)
+	peerLogger := logger.With("peer", "abc")
+
+	for i := 0; i < 3; i++ {
+		logger.Debug("foo")
+		peerLogger.Debug("foo")
+		logger.Debug("bar")
+		logger.Info("baz")
+	}
+
+	want := strings.Join([]string{
+		`{"_msg":"foo","level":"debug"}`,
+		`{"_msg":"foo","level":"debug","peer":"abc"}`,
+		`{"_msg":"bar","level":"debug"}`,
+		`{"_msg":"baz","level":"info"}`,
+		`{"_msg":"bar","level":"debug"}`,
+		`{"_msg":"baz","level":"info"}`,
+		`{"_msg":"baz","level":"info"}`,
+	}, "\n")
+	if have := strings.TrimSpace(buf.String()); want != have {
+		t.Errorf("\nwant '%s'\nhave '%s'", want, have)
+	}
+
+	buf.Reset()
+
+	// The number of dropped events is logged once the limit is reset.
+	time.Sleep(time.Second)
+	logger.Debug("foo")
+	if want, have := `{"_msg":"foo","dropped":4,"level":"debug"}`, strings.TrimSpace(buf.String()); want != have {
+		t.Errorf("\nwant '%s'\nhave '%s'", want, have)
+	}
+}
+
+func TestRateLimitedLoggerFiltered(t *testing.T) {
+	var buf bytes.Buffer
+
+	filtered := log.NewFilter(log.NewTMJSONLogger(&buf), log.AllowInfo())
+	logger := log.NewRateLimitedLogger(filtered, 1)
+
+	// The filtered out events don't use up the limit.
+	logger.Debug("foo")
+	filtered.(log.LevelSetter).SetLevels(log.AllowDebug())
+	logger.Debug("foo")
+	if want, have := `{"_msg":"foo","level":"debug"}`, strings.TrimSpace(buf.String()); want != have {
+		t.Errorf("\nwant '%s'\nhave '%s'", want, have)
+	}
+}
@@ -44,6 +44,18 @@ func (l *tracingLogger) With(keyvals ...interface{}) Logger {
 	return &tracingLogger{next: l.next.With(formatErrors(keyvals)...)}
 }
 
+// SetLevels implements LevelSetter, if the wrapped logger does.
+func (l *tracingLogger) SetLevels(options ...Option) {
+	if setter, ok := l.next.(LevelSetter); ok {
+		setter.SetLevels(options...)
+	}
+}
+
+func (l *tracingLogger) debugEnabled() bool {
+	e, ok := l.next.(debugEnabler)
+	return !ok || e.debugEnabled()
+}
+
 func formatErrors(keyvals []interface{}) []interface{} {
 	newKeyvals := make([]interface{}, len(keyvals))
 	copy(newKeyvals, keyvals)
@@ -0,0 +1,201 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	tmsync "github.com/tendermint/tendermint/libs/sync"
+)
+
+const (
+	// Name of the instrumentation scope of the exported spans.
+	scopeName = "github.com/tendermint/tendermint"
+
+	otlpExportTimeout = 10 * time.Second
+
+	// OTLP span kind and status code.
+	otlpSpanKindInternal = 1
+	otlpStatusCodeError  = 2
+)
+
+// Exporter exports ended spans.
+type Exporter interface {
+	ExportSpans(spans []SpanData) error
+}
+
+// NewOTLPExporter returns an exporter posting the spans to an OpenTelemetry
+// collector, with the OTLP/HTTP protocol in JSON encoding, e.g. to
+// http://localhost:4318/v1/traces. The spans are attributed to the service
+// serviceName.
+func NewOTLPExporter(endpoint, serviceName string) Exporter {
+	return &otlpExporter{
+		endpoint:    endpoint,
+		serviceName: serviceName,
+		client:      &http.Client{Timeout: otlpExportTimeout},
+	}
+}
+
+type otlpExporter struct {
+	endpoint    string
+	serviceName string
+	client      *http.Client
+}
+
+func (e *otlpExporter) ExportSpans(spans []SpanData) error {
+	bz, err := json.Marshal(newOTLPTracesData(e.serviceName, spans))
+	if err != nil {
+		return err
+	}
+
+	res, err := e.client.Post(e.endpoint, "application/json", bytes.NewReader(bz))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	body, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("collector responded with status %v: %s", res.Status, body)
+	}
+	return nil
+}
+
+// NewFileExporter returns an exporter writing each batch of spans to w as a
+// line of JSON, in the OTLP JSON encoding, like the file exporter of the
+// OpenTelemetry collector.
+func NewFileExporter(w io.Writer, serviceName string) Exporter {
+	return &fileExporter{w: w, serviceName: serviceName}
+}
+
+type fileExporter struct {
+	mtx         tmsync.Mutex
+	w           io.Writer
+	serviceName string
+}
+
+func (e *fileExporter) ExportSpans(spans []SpanData) error {
+	bz, err := json.Marshal(newOTLPTracesData(e.serviceName, spans))
+	if err != nil {
+		return err
+	}
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	_, err = e.w.Write(append(bz, '\n'))
+	return err
+}
+
+//-----------------------------------------------------------------------------
+// OTLP JSON encoding
+
+type otlpTracesData struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            *otlpStatus    `json:"status,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func newOTLPTracesData(serviceName string, spans []SpanData) otlpTracesData {
+	otlpSpans := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		span := otlpSpan{
+			TraceID:           s.TraceID.String(),
+			SpanID:            s.SpanID.String(),
+			Name:              s.Name,
+			Kind:              otlpSpanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+		}
+		if s.ParentSpanID.IsValid() {
+			span.ParentSpanID = s.ParentSpanID.String()
+		}
+		for _, attr := range s.Attributes {
+			span.Attributes = append(span.Attributes, newOTLPKeyValue(attr.Key, attr.Value))
+		}
+		if s.Error != "" {
+			span.Status = &otlpStatus{Code: otlpStatusCodeError, Message: s.Error}
+		}
+		otlpSpans = append(otlpSpans, span)
+	}
+
+	return otlpTracesData{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: []otlpKeyValue{newOTLPKeyValue("service.name", serviceName)},
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: scopeName},
+				Spans: otlpSpans,
+			}},
+		}},
+	}
+}
+
+func newOTLPKeyValue(key string, value interface{}) otlpKeyValue {
+	var v otlpAnyValue
+	switch value := value.(type) {
+	case bool:
+		v.BoolValue = &value
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		s := fmt.Sprint(value)
+		v.IntValue = &s
+	case float32:
+		f := float64(value)
+		v.DoubleValue = &f
+	case float64:
+		v.DoubleValue = &value
+	default:
+		s := fmt.Sprint(value)
+		v.StringValue = &s
+	}
+	return otlpKeyValue{Key: key, Value: v}
+}
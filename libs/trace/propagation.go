@@ -0,0 +1,99 @@
+package trace
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// TraceParentHeader is the HTTP header and gRPC metadata key propagating the
+// span context, as specified by W3C Trace Context.
+const TraceParentHeader = "traceparent"
+
+const (
+	traceParentVersion = "00"
+	// Sampled trace flag. All the propagated spans are sampled.
+	traceParentFlags = "01"
+)
+
+// TraceParent formats the span context as a traceparent header value, e.g.
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01.
+func TraceParent(sc SpanContext) string {
+	return strings.Join([]string{traceParentVersion, sc.TraceID.String(), sc.SpanID.String(), traceParentFlags}, "-")
+}
+
+// ParseTraceParent parses a traceparent header value.
+func ParseTraceParent(s string) (SpanContext, error) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 {
+		return sc, fmt.Errorf("expected 4 parts in traceparent %q, got %d", s, len(parts))
+	}
+	if len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == traceParentVersion && len(parts) != 4) {
+		return sc, fmt.Errorf("unsupported traceparent version %q", parts[0])
+	}
+	if err := decodeHex(sc.TraceID[:], parts[1]); err != nil {
+		return sc, fmt.Errorf("invalid trace ID: %w", err)
+	}
+	if err := decodeHex(sc.SpanID[:], parts[2]); err != nil {
+		return sc, fmt.Errorf("invalid parent ID: %w", err)
+	}
+	if !sc.IsValid() {
+		return SpanContext{}, errors.New("zero trace or parent ID")
+	}
+	return sc, nil
+}
+
+func decodeHex(dst []byte, s string) error {
+	if len(s) != hex.EncodedLen(len(dst)) {
+		return fmt.Errorf("expected %d hex characters, got %d", hex.EncodedLen(len(dst)), len(s))
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err
+}
+
+// SpanContextFromHTTPRequest returns the span context propagated with the
+// traceparent header of the request, if any.
+func SpanContextFromHTTPRequest(r *http.Request) SpanContext {
+	if r == nil {
+		return SpanContext{}
+	}
+	sc, err := ParseTraceParent(r.Header.Get(TraceParentHeader))
+	if err != nil {
+		return SpanContext{}
+	}
+	return sc
+}
+
+// OutgoingGRPCContext returns a copy of ctx propagating the span context of
+// ctx, if any, in the metadata of the outgoing gRPC calls.
+func OutgoingGRPCContext(ctx context.Context) context.Context {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, TraceParentHeader, TraceParent(sc))
+}
+
+// IncomingGRPCContext returns a copy of ctx carrying the span context
+// propagated in the metadata of an incoming gRPC call, if any.
+func IncomingGRPCContext(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	values := md.Get(TraceParentHeader)
+	if len(values) == 0 {
+		return ctx
+	}
+	sc, err := ParseTraceParent(values[0])
+	if err != nil {
+		return ctx
+	}
+	return ContextWithSpanContext(ctx, sc)
+}
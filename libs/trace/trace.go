@@ -0,0 +1,326 @@
+package trace
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	tmrand "github.com/tendermint/tendermint/libs/rand"
+	"github.com/tendermint/tendermint/libs/service"
+	tmsync "github.com/tendermint/tendermint/libs/sync"
+)
+
+const (
+	// Maximum number of ended spans waiting to be exported. Spans ended when
+	// the queue is full are dropped.
+	spanQueueSize = 2048
+	// Maximum number of spans exported at once.
+	maxExportBatchSize = 512
+	// Interval between exports of the queued spans.
+	exportInterval = time.Second
+)
+
+// TraceID identifies a trace.
+type TraceID [16]byte
+
+// IsValid returns whether the ID is not zero.
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanID identifies a span within a trace.
+type SpanID [8]byte
+
+// IsValid returns whether the ID is not zero.
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanContext identifies a span, and is propagated to its children, including
+// in other processes (see TraceParent).
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+}
+
+// IsValid returns whether both IDs are set.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Attribute is a key-value pair describing a span.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// SpanData is an ended span, as exported.
+type SpanData struct {
+	Name         string
+	TraceID      TraceID
+	SpanID       SpanID
+	ParentSpanID SpanID
+	Start        time.Time
+	End          time.Time
+	Attributes   []Attribute
+	Error        string
+}
+
+// Span is an operation being traced. All the methods of a nil span are no-ops,
+// so the callers don't need to check whether tracing is enabled.
+type Span struct {
+	tracer *Tracer
+
+	mtx   tmsync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// Context returns the context of the span, to start its children. It's zero
+// for a nil span.
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return SpanContext{TraceID: s.data.TraceID, SpanID: s.data.SpanID}
+}
+
+// SetAttributes adds the key-value pairs to the attributes of the span.
+func (s *Span) SetAttributes(keyvals ...interface{}) {
+	if s == nil {
+		return
+	}
+	s.mtx.Lock()
+	s.data.Attributes = appendAttributes(s.data.Attributes, keyvals)
+	s.mtx.Unlock()
+}
+
+// SetError records the error, if any, as the status of the span.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mtx.Lock()
+	s.data.Error = err.Error()
+	s.mtx.Unlock()
+}
+
+// End ends the span and queues it to be exported. Only the first call has an
+// effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mtx.Lock()
+	if s.ended {
+		s.mtx.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mtx.Unlock()
+
+	s.tracer.enqueue(data)
+}
+
+//-----------------------------------------------------------------------------
+
+// Tracer starts spans and exports them in batches once they end. The spans are
+// exported while the tracer is running, and the queued ones when it's
+// stopped.
+type Tracer struct {
+	service.BaseService
+
+	exporter Exporter
+	queue    chan SpanData
+	stop     chan struct{} // closed to stop the export routine
+	exported chan struct{} // closed when the export routine returns
+
+	mtx     tmsync.Mutex
+	dropped int
+}
+
+// NewTracer returns a tracer exporting its spans with the exporter.
+func NewTracer(exporter Exporter) *Tracer {
+	t := &Tracer{
+		exporter: exporter,
+		queue:    make(chan SpanData, spanQueueSize),
+	}
+	t.BaseService = *service.NewBaseService(nil, "Tracer", t)
+	return t
+}
+
+// NopTracer returns a tracer which doesn't start any span.
+func NopTracer() *Tracer {
+	t := &Tracer{}
+	t.BaseService = *service.NewBaseService(nil, "Tracer", t)
+	return t
+}
+
+// Enabled returns whether the tracer starts spans.
+func (t *Tracer) Enabled() bool {
+	return t != nil && t.exporter != nil
+}
+
+// StartSpan starts a span as a child of parent, or of a new trace if parent
+// isn't valid, with the attributes given as key-value pairs. It returns nil if
+// the tracer isn't enabled.
+func (t *Tracer) StartSpan(name string, parent SpanContext, keyvals ...interface{}) *Span {
+	if !t.Enabled() {
+		return nil
+	}
+	s := &Span{
+		tracer: t,
+		data: SpanData{
+			Name:         name,
+			TraceID:      parent.TraceID,
+			ParentSpanID: parent.SpanID,
+			Start:        time.Now(),
+			Attributes:   appendAttributes(nil, keyvals),
+		},
+	}
+	if !parent.IsValid() {
+		copy(s.data.TraceID[:], tmrand.Bytes(len(s.data.TraceID)))
+		s.data.ParentSpanID = SpanID{}
+	}
+	copy(s.data.SpanID[:], tmrand.Bytes(len(s.data.SpanID)))
+	return s
+}
+
+// StartSpanFromContext starts a span as a child of the span of ctx, if any,
+// and returns a copy of ctx carrying the new span.
+func (t *Tracer) StartSpanFromContext(ctx context.Context, name string, keyvals ...interface{}) (context.Context, *Span) {
+	if !t.Enabled() {
+		return ctx, nil
+	}
+	s := t.StartSpan(name, SpanContextFromContext(ctx), keyvals...)
+	return ContextWithSpan(ctx, s), s
+}
+
+// OnStart implements service.Service by exporting the ended spans in the
+// background.
+func (t *Tracer) OnStart() error {
+	if t.exporter != nil {
+		t.stop = make(chan struct{})
+		t.exported = make(chan struct{})
+		go t.exportRoutine()
+	}
+	return nil
+}
+
+// OnStop implements service.Service by waiting for the queued spans to be
+// exported.
+func (t *Tracer) OnStop() {
+	if t.exported != nil {
+		close(t.stop)
+		<-t.exported
+	}
+}
+
+func (t *Tracer) enqueue(data SpanData) {
+	select {
+	case t.queue <- data:
+	default:
+		t.mtx.Lock()
+		t.dropped++
+		t.mtx.Unlock()
+	}
+}
+
+func (t *Tracer) exportRoutine() {
+	defer close(t.exported)
+
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	batch := make([]SpanData, 0, maxExportBatchSize)
+	for {
+		select {
+		case data := <-t.queue:
+			batch = append(batch, data)
+			if len(batch) < maxExportBatchSize {
+				continue
+			}
+		case <-ticker.C:
+		case <-t.stop:
+			// Export the spans queued before stopping.
+			for len(t.queue) > 0 {
+				batch = append(batch, <-t.queue)
+			}
+			t.export(batch)
+			return
+		}
+		batch = t.export(batch)
+	}
+}
+
+// export exports the batch, and returns it emptied.
+func (t *Tracer) export(batch []SpanData) []SpanData {
+	t.mtx.Lock()
+	dropped := t.dropped
+	t.dropped = 0
+	t.mtx.Unlock()
+	if dropped > 0 {
+		t.Logger.Error("Dropped spans, the export queue is full", "dropped", dropped)
+	}
+
+	if len(batch) == 0 {
+		return batch
+	}
+	if err := t.exporter.ExportSpans(batch); err != nil {
+		t.Logger.Error("Failed to export spans", "spans", len(batch), "err", err)
+	}
+	return batch[:0]
+}
+
+//-----------------------------------------------------------------------------
+
+type spanContextKey struct{}
+
+// ContextWithSpan returns a copy of ctx carrying the span, as the parent of the
+// spans started with Tracer.StartSpanFromContext.
+func ContextWithSpan(ctx context.Context, s *Span) context.Context {
+	if s == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, spanContextKey{}, s.Context())
+}
+
+// ContextWithSpanContext returns a copy of ctx carrying the span context, e.g.
+// of a span of another process.
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	if !sc.IsValid() {
+		return ctx
+	}
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context carried by ctx, if any.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if ctx == nil {
+		return SpanContext{}
+	}
+	sc, _ := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc
+}
+
+func appendAttributes(attrs []Attribute, keyvals []interface{}) []Attribute {
+	for i := 0; i < len(keyvals); i += 2 {
+		var value interface{} = "(MISSING)"
+		if i+1 < len(keyvals) {
+			value = keyvals[i+1]
+		}
+		attrs = append(attrs, Attribute{Key: fmt.Sprint(keyvals[i]), Value: value})
+	}
+	return attrs
+}
@@ -0,0 +1,187 @@
+package trace_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/tendermint/tendermint/libs/trace"
+)
+
+type exportedSpan struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+	Attributes   []struct {
+		Key   string            `json:"key"`
+		Value map[string]string `json:"value"`
+	} `json:"attributes"`
+	Status *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"status"`
+}
+
+type exportedTracesData struct {
+	ResourceSpans []struct {
+		ScopeSpans []struct {
+			Spans []exportedSpan `json:"spans"`
+		} `json:"scopeSpans"`
+	} `json:"resourceSpans"`
+}
+
+func readSpans(t *testing.T, bz []byte) map[string]exportedSpan {
+	spans := make(map[string]exportedSpan)
+	scanner := bufio.NewScanner(bytes.NewReader(bz))
+	for scanner.Scan() {
+		var data exportedTracesData
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &data))
+		for _, rs := range data.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				for _, s := range ss.Spans {
+					spans[s.Name] = s
+				}
+			}
+		}
+	}
+	return spans
+}
+
+func TestTracer(t *testing.T) {
+	var buf bytes.Buffer
+	tracer := trace.NewTracer(trace.NewFileExporter(&buf, "test"))
+	require.NoError(t, tracer.Start())
+
+	parent := tracer.StartSpan("parent", trace.SpanContext{}, "height", int64(3))
+	ctx, child := tracer.StartSpanFromContext(trace.ContextWithSpan(context.Background(), parent), "child")
+	_, grandchild := tracer.StartSpanFromContext(ctx, "grandchild")
+	grandchild.SetError(errors.New("failed"))
+	grandchild.End()
+	child.End()
+	parent.End()
+	parent.End()
+
+	// A span ended twice is only exported once.
+	require.NoError(t, tracer.Stop())
+	spans := readSpans(t, buf.Bytes())
+	require.Len(t, spans, 3)
+
+	assert.Empty(t, spans["parent"].ParentSpanID)
+	assert.Equal(t, parent.Context().TraceID.String(), spans["parent"].TraceID)
+	assert.Equal(t, parent.Context().SpanID.String(), spans["parent"].SpanID)
+	require.Len(t, spans["parent"].Attributes, 1)
+	assert.Equal(t, "height", spans["parent"].Attributes[0].Key)
+	assert.Equal(t, map[string]string{"intValue": "3"}, spans["parent"].Attributes[0].Value)
+
+	assert.Equal(t, spans["parent"].TraceID, spans["child"].TraceID)
+	assert.Equal(t, spans["parent"].SpanID, spans["child"].ParentSpanID)
+	assert.Equal(t, spans["parent"].TraceID, spans["grandchild"].TraceID)
+	assert.Equal(t, spans["child"].SpanID, spans["grandchild"].ParentSpanID)
+	assert.Nil(t, spans["child"].Status)
+	require.NotNil(t, spans["grandchild"].Status)
+	assert.Equal(t, "failed", spans["grandchild"].Status.Message)
+}
+
+func TestNopTracer(t *testing.T) {
+	tracer := trace.NopTracer()
+	require.NoError(t, tracer.Start())
+	defer tracer.Stop() // nolint:errcheck // ignore for tests
+
+	assert.False(t, tracer.Enabled())
+	span := tracer.StartSpan("span", trace.SpanContext{})
+	assert.Nil(t, span)
+	ctx, span := tracer.StartSpanFromContext(context.Background(), "span")
+	assert.Nil(t, span)
+	assert.False(t, trace.SpanContextFromContext(ctx).IsValid())
+
+	// The methods of a nil span are no-ops.
+	span.SetAttributes("key", "value")
+	span.SetError(errors.New("failed"))
+	span.End()
+	assert.False(t, span.Context().IsValid())
+}
+
+func TestOTLPExporter(t *testing.T) {
+	bodies := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		bz, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		bodies <- bz
+	}))
+	defer srv.Close()
+
+	tracer := trace.NewTracer(trace.NewOTLPExporter(srv.URL+"/v1/traces", "test"))
+	require.NoError(t, tracer.Start())
+	tracer.StartSpan("span", trace.SpanContext{}).End()
+	require.NoError(t, tracer.Stop())
+
+	spans := readSpans(t, <-bodies)
+	assert.Contains(t, spans, "span")
+
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+	err := trace.NewOTLPExporter(srv.URL+"/v1/traces", "test").ExportSpans([]trace.SpanData{{Name: "span"}})
+	assert.Error(t, err)
+}
+
+func TestTraceParent(t *testing.T) {
+	sc, err := trace.ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", trace.TraceParent(sc))
+
+	for _, s := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-00",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902zz-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+	} {
+		_, err := trace.ParseTraceParent(s)
+		assert.Error(t, err, s)
+	}
+
+	// Future versions may have more parts.
+	_, err = trace.ParseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-00")
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "/status", nil)
+	req.Header.Set(trace.TraceParentHeader, trace.TraceParent(sc))
+	assert.Equal(t, sc, trace.SpanContextFromHTTPRequest(req))
+}
+
+func TestGRPCContext(t *testing.T) {
+	var buf bytes.Buffer
+	tracer := trace.NewTracer(trace.NewFileExporter(&buf, "test"))
+	span := tracer.StartSpan("span", trace.SpanContext{})
+
+	ctx := trace.OutgoingGRPCContext(trace.ContextWithSpan(context.Background(), span))
+	md, ok := metadata.FromOutgoingContext(ctx)
+	require.True(t, ok)
+
+	ctx = trace.IncomingGRPCContext(metadata.NewIncomingContext(context.Background(), md))
+	assert.Equal(t, span.Context(), trace.SpanContextFromContext(ctx))
+
+	// Nothing is propagated without a span.
+	ctx = trace.OutgoingGRPCContext(context.Background())
+	_, ok = metadata.FromOutgoingContext(ctx)
+	assert.False(t, ok)
+}
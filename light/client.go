@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/tendermint/tendermint/libs/log"
@@ -67,12 +69,22 @@ func SkippingVerification(trustLevel tmmath.Fraction) Option {
 // client stores. When Prune() is run, all light blocks that are earlier than
 // the h amount of light blocks will be removed from the store.
 // Default: 1000. A pruning size of 0 will not prune the light client at all.
-func PruningSize(h uint16) Option {
+func PruningSize(h uint64) Option {
 	return func(c *Client) {
 		c.pruningSize = h
 	}
 }
 
+// PruningAge option sets the maximum age of the light blocks that the light
+// client stores, relative to the time of the latest trusted light block.
+// Older light blocks are removed from the store, except the latest trusted
+// one. Default: 0 (light blocks are only pruned by PruningSize).
+func PruningAge(d time.Duration) Option {
+	return func(c *Client) {
+		c.pruningAge = d
+	}
+}
+
 // ConfirmationFunction option can be used to prompt to confirm an action. For
 // example, remove newer headers if the light client is being reset with an
 // older header. No confirmation is required by default!
@@ -105,6 +117,32 @@ func MaxClockDrift(d time.Duration) Option {
 	}
 }
 
+// WitnessPool option sets spare witnesses. When a witness is removed, or
+// promoted to primary, the first spare witness takes its place.
+func WitnessPool(spares []provider.Provider) Option {
+	return func(c *Client) {
+		c.spareWitnesses = spares
+	}
+}
+
+// UpdatePeriod option makes the client, once started with Start, follow the
+// head of the chain by calling Update every d. Default: 0 (the client only
+// verifies the light blocks requested by the caller).
+func UpdatePeriod(d time.Duration) Option {
+	return func(c *Client) {
+		c.updatePeriod = d
+	}
+}
+
+// ExpirationWarning option sets how long before the trusting period of the
+// latest trusted light block ends the client, once started with Start, warns
+// about it. Default: 1/3 of the trusting period.
+func ExpirationWarning(d time.Duration) Option {
+	return func(c *Client) {
+		c.expirationWarning = d
+	}
+}
+
 // Client represents a light client, connected to a single chain, which gets
 // light blocks from a primary provider, verifies them either sequentially or by
 // skipping some and stores them in a trusted store (usually, a local FS).
@@ -124,6 +162,19 @@ type Client struct {
 	primary provider.Provider
 	// See Witnesses option
 	witnesses []provider.Provider
+	// See WitnessPool option
+	spareWitnesses []provider.Provider
+
+	// Mutex for locking the health of the witnesses, which is recorded while
+	// the providerMutex is held. The providers are only changed with both
+	// mutexes held, so that Status can read them without waiting for the
+	// providerMutex, held during the cross-checks with the witnesses.
+	healthMutex tmsync.Mutex
+	health      map[provider.Provider]*WitnessStatus
+
+	// Serializes the verification of new light blocks, requested by the
+	// callers or by the update routine.
+	verificationMutex tmsync.Mutex
 
 	// Where trusted light blocks are stored.
 	trustedStore store.Store
@@ -131,11 +182,19 @@ type Client struct {
 	latestTrustedBlock *types.LightBlock
 
 	// See RemoveNoLongerTrustedHeadersPeriod option
-	pruningSize uint16
+	pruningSize uint64
+	// See PruningAge option
+	pruningAge time.Duration
 	// See ConfirmationFunction option
 	confirmationFn func(action string) bool
 
-	quit chan struct{}
+	// See UpdatePeriod option
+	updatePeriod time.Duration
+	// See ExpirationWarning option
+	expirationWarning time.Duration
+	routinesWaitGroup sync.WaitGroup
+	quit              chan struct{}
+	stopOnce          sync.Once
 
 	logger log.Logger
 }
@@ -196,19 +255,21 @@ func NewClientFromTrustedStore(
 	options ...Option) (*Client, error) {
 
 	c := &Client{
-		chainID:          chainID,
-		trustingPeriod:   trustingPeriod,
-		verificationMode: skipping,
-		trustLevel:       DefaultTrustLevel,
-		maxRetryAttempts: defaultMaxRetryAttempts,
-		maxClockDrift:    defaultMaxClockDrift,
-		primary:          primary,
-		witnesses:        witnesses,
-		trustedStore:     trustedStore,
-		pruningSize:      defaultPruningSize,
-		confirmationFn:   func(action string) bool { return true },
-		quit:             make(chan struct{}),
-		logger:           log.NewNopLogger(),
+		chainID:           chainID,
+		trustingPeriod:    trustingPeriod,
+		verificationMode:  skipping,
+		trustLevel:        DefaultTrustLevel,
+		maxRetryAttempts:  defaultMaxRetryAttempts,
+		maxClockDrift:     defaultMaxClockDrift,
+		primary:           primary,
+		witnesses:         witnesses,
+		health:            make(map[provider.Provider]*WitnessStatus),
+		trustedStore:      trustedStore,
+		pruningSize:       defaultPruningSize,
+		confirmationFn:    func(action string) bool { return true },
+		expirationWarning: trustingPeriod / 3,
+		quit:              make(chan struct{}),
+		logger:            log.NewNopLogger(),
 	}
 
 	for _, o := range options {
@@ -227,6 +288,12 @@ func NewClientFromTrustedStore(
 				i, w, w.ChainID(), chainID)
 		}
 	}
+	for i, w := range c.spareWitnesses {
+		if w.ChainID() != chainID {
+			return nil, fmt.Errorf("spare witness #%d: %v is on another chain %s, expected %s",
+				i, w, w.ChainID(), chainID)
+		}
+	}
 
 	// Validate trust level.
 	if err := ValidateTrustLevel(c.trustLevel); err != nil {
@@ -496,6 +563,9 @@ func (c *Client) VerifyHeader(newHeader *types.Header, now time.Time) error {
 }
 
 func (c *Client) verifyLightBlock(newLightBlock *types.LightBlock, now time.Time) error {
+	c.verificationMutex.Lock()
+	defer c.verificationMutex.Unlock()
+
 	c.logger.Info("VerifyHeader", "height", newLightBlock.Height, "hash", hash2str(newLightBlock.Hash()))
 
 	var (
@@ -851,6 +921,14 @@ func (c *Client) updateTrustedLightBlock(l *types.LightBlock) error {
 		c.latestTrustedBlock = l
 	}
 
+	// Only prune by age when moving forwards, so that light blocks verified
+	// backwards are not removed right after being saved.
+	if c.pruningAge > 0 && l.Height >= c.latestTrustedBlock.Height {
+		if err := c.trustedStore.PruneBefore(c.latestTrustedBlock.Time.Add(-c.pruningAge)); err != nil {
+			return fmt.Errorf("prune before: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -899,6 +977,13 @@ func (c *Client) compareNewHeaderWithWitnesses(l *types.LightBlock, now time.Tim
 	c.providerMutex.Lock()
 	defer c.providerMutex.Unlock()
 
+	return c.compareNewHeaderWithCurrentWitnesses(l, now)
+}
+
+// NOTE: requires a providerMutex locked.
+func (c *Client) compareNewHeaderWithCurrentWitnesses(l *types.LightBlock, now time.Time) error {
+	spares := len(c.spareWitnesses)
+
 	// 1. Make sure AT LEAST ONE witness returns the same header.
 	var headerMatched bool
 
@@ -933,6 +1018,8 @@ func (c *Client) compareNewHeaderWithWitnesses(l *types.LightBlock, now time.Tim
 		}
 	}
 
+	// remove the witnesses from the end, so the indices of the others stay valid
+	sort.Sort(sort.Reverse(sort.IntSlice(witnessesToRemove)))
 	for _, idx := range witnessesToRemove {
 		c.removeWitness(idx)
 	}
@@ -941,6 +1028,11 @@ func (c *Client) compareNewHeaderWithWitnesses(l *types.LightBlock, now time.Tim
 		return nil
 	}
 
+	// the removed witnesses were replaced with spare ones => try again
+	if len(c.spareWitnesses) < spares {
+		return c.compareNewHeaderWithCurrentWitnesses(l, now)
+	}
+
 	return errors.New("awaiting response from all witnesses exceeded dropout time")
 }
 
@@ -948,6 +1040,7 @@ func (c *Client) compareNewHeaderWithWitness(errc chan error, l *types.LightBloc
 	witness provider.Provider, witnessIndex int, now time.Time) {
 
 	altBlock, err := witness.LightBlock(l.Height)
+	c.recordWitnessHealth(witness, err)
 	if err != nil {
 		if _, ok := err.(provider.ErrBadLightBlock); ok {
 			errc <- errBadWitness{Reason: err, Code: invalidLightBlock, WitnessIndex: witnessIndex}
@@ -971,8 +1064,11 @@ func (c *Client) compareNewHeaderWithWitness(errc chan error, l *types.LightBloc
 
 // NOTE: requires a providerMutex locked.
 func (c *Client) removeWitness(idx int) {
+	c.healthMutex.Lock()
+	delete(c.health, c.witnesses[idx])
 	switch len(c.witnesses) {
 	case 0:
+		c.healthMutex.Unlock()
 		panic(fmt.Sprintf("wanted to remove %d element from empty witnesses slice", idx))
 	case 1:
 		c.witnesses = make([]provider.Provider, 0)
@@ -980,6 +1076,24 @@ func (c *Client) removeWitness(idx int) {
 		c.witnesses[idx] = c.witnesses[len(c.witnesses)-1]
 		c.witnesses = c.witnesses[:len(c.witnesses)-1]
 	}
+	c.healthMutex.Unlock()
+
+	c.addSpareWitness()
+}
+
+// addSpareWitness moves the first spare witness, if any, to the witnesses.
+//
+// NOTE: requires a providerMutex locked.
+func (c *Client) addSpareWitness() {
+	if len(c.spareWitnesses) == 0 {
+		return
+	}
+	c.healthMutex.Lock()
+	w := c.spareWitnesses[0]
+	c.spareWitnesses = c.spareWitnesses[1:]
+	c.witnesses = append(c.witnesses, w)
+	c.healthMutex.Unlock()
+	c.logger.Info("Added a spare witness", "witness", w, "spare_witnesses", len(c.spareWitnesses))
 }
 
 // Update attempts to advance the state by downloading the latest light
@@ -1013,18 +1127,85 @@ func (c *Client) Update(now time.Time) (*types.LightBlock, error) {
 	return nil, nil
 }
 
+// Start starts the update routine if UpdatePeriod option is set.
+func (c *Client) Start() error {
+	if c.updatePeriod > 0 {
+		c.routinesWaitGroup.Add(1)
+		go c.autoUpdateRoutine()
+	}
+	return nil
+}
+
+// Stop stops the update routine and waits for it to return. It can be called
+// more than once.
+func (c *Client) Stop() {
+	c.stopOnce.Do(func() {
+		c.logger.Debug("Stopping light client")
+		close(c.quit)
+	})
+	c.routinesWaitGroup.Wait()
+}
+
+// autoUpdateRoutine follows the head of the chain, and warns when the
+// trusting period of the latest trusted light block is about to end, which
+// happens when the client can't advance, e.g. because all the providers are
+// unavailable.
+func (c *Client) autoUpdateRoutine() {
+	defer c.routinesWaitGroup.Done()
+
+	ticker := time.NewTicker(c.updatePeriod)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now()
+		if _, err := c.Update(now); err != nil {
+			c.logger.Error("Error during auto update", "err", err)
+		}
+		c.checkExpiration(now)
+
+		select {
+		case <-ticker.C:
+		case <-c.quit:
+			return
+		}
+	}
+}
+
+func (c *Client) checkExpiration(now time.Time) {
+	status, err := c.Status(now)
+	if err != nil || status.LatestTrustedHeight <= 0 {
+		return
+	}
+	switch remaining := status.TrustingPeriodRemaining; {
+	case remaining <= 0:
+		c.logger.Error("Trusting period of the latest trusted light block has ended."+
+			" The client must be reset with a new trusted header",
+			"height", status.LatestTrustedHeight)
+	case remaining <= c.expirationWarning:
+		c.logger.Error("Trusting period of the latest trusted light block ends soon",
+			"height", status.LatestTrustedHeight, "remaining", remaining)
+	}
+}
+
 // replaceProvider takes the first alternative provider and promotes it as the
 // primary provider.
 func (c *Client) replacePrimaryProvider() error {
 	c.providerMutex.Lock()
 	defer c.providerMutex.Unlock()
 
+	if len(c.witnesses) <= 1 && len(c.spareWitnesses) > 0 {
+		c.addSpareWitness()
+	}
 	if len(c.witnesses) <= 1 {
 		return errNoWitnesses{}
 	}
+	c.healthMutex.Lock()
 	c.primary = c.witnesses[0]
 	c.witnesses = c.witnesses[1:]
+	delete(c.health, c.primary)
+	c.healthMutex.Unlock()
 	c.logger.Info("Replacing primary with the first witness", "new_primary", c.primary)
+	c.addSpareWitness()
 
 	return nil
 }
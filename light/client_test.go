@@ -8,13 +8,10 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	dbm "github.com/tendermint/tm-db"
-
 	"github.com/tendermint/tendermint/libs/log"
 	"github.com/tendermint/tendermint/light"
 	"github.com/tendermint/tendermint/light/provider"
 	mockp "github.com/tendermint/tendermint/light/provider/mock"
-	dbs "github.com/tendermint/tendermint/light/store/db"
 	"github.com/tendermint/tendermint/types"
 )
 
@@ -207,7 +204,7 @@ func TestClient_SequentialVerification(t *testing.T) {
 					tc.otherHeaders,
 					tc.vals,
 				)},
-				dbs.New(dbm.NewMemDB(), chainID),
+				newStore(),
 				light.SequentialVerification(),
 				light.Logger(log.TestingLogger()),
 			)
@@ -331,7 +328,7 @@ func TestClient_SkippingVerification(t *testing.T) {
 					tc.otherHeaders,
 					tc.vals,
 				)},
-				dbs.New(dbm.NewMemDB(), chainID),
+				newStore(),
 				light.SkippingVerification(light.DefaultTrustLevel),
 				light.Logger(log.TestingLogger()),
 			)
@@ -368,7 +365,7 @@ func TestClientLargeBisectionVerification(t *testing.T) {
 		},
 		veryLargeFullNode,
 		[]provider.Provider{veryLargeFullNode},
-		dbs.New(dbm.NewMemDB(), chainID),
+		newStore(),
 		light.SkippingVerification(light.DefaultTrustLevel),
 	)
 	require.NoError(t, err)
@@ -389,7 +386,7 @@ func TestClientBisectionBetweenTrustedHeaders(t *testing.T) {
 		},
 		fullNode,
 		[]provider.Provider{fullNode},
-		dbs.New(dbm.NewMemDB(), chainID),
+		newStore(),
 		light.SkippingVerification(light.DefaultTrustLevel),
 	)
 	require.NoError(t, err)
@@ -412,7 +409,7 @@ func TestClient_Cleanup(t *testing.T) {
 		trustOptions,
 		fullNode,
 		[]provider.Provider{fullNode},
-		dbs.New(dbm.NewMemDB(), chainID),
+		newStore(),
 		light.Logger(log.TestingLogger()),
 	)
 	require.NoError(t, err)
@@ -432,7 +429,7 @@ func TestClient_Cleanup(t *testing.T) {
 func TestClientRestoresTrustedHeaderAfterStartup1(t *testing.T) {
 	// 1. options.Hash == trustedHeader.Hash
 	{
-		trustedStore := dbs.New(dbm.NewMemDB(), chainID)
+		trustedStore := newStore()
 		err := trustedStore.SaveLightBlock(l1)
 		require.NoError(t, err)
 
@@ -455,7 +452,7 @@ func TestClientRestoresTrustedHeaderAfterStartup1(t *testing.T) {
 
 	// 2. options.Hash != trustedHeader.Hash
 	{
-		trustedStore := dbs.New(dbm.NewMemDB(), chainID)
+		trustedStore := newStore()
 		err := trustedStore.SaveLightBlock(l1)
 		require.NoError(t, err)
 
@@ -499,7 +496,7 @@ func TestClientRestoresTrustedHeaderAfterStartup1(t *testing.T) {
 func TestClientRestoresTrustedHeaderAfterStartup2(t *testing.T) {
 	// 1. options.Hash == trustedHeader.Hash
 	{
-		trustedStore := dbs.New(dbm.NewMemDB(), chainID)
+		trustedStore := newStore()
 		err := trustedStore.SaveLightBlock(l1)
 		require.NoError(t, err)
 
@@ -528,7 +525,7 @@ func TestClientRestoresTrustedHeaderAfterStartup2(t *testing.T) {
 	// 2. options.Hash != trustedHeader.Hash
 	// This could happen if previous provider was lying to us.
 	{
-		trustedStore := dbs.New(dbm.NewMemDB(), chainID)
+		trustedStore := newStore()
 		err := trustedStore.SaveLightBlock(l1)
 		require.NoError(t, err)
 
@@ -574,7 +571,7 @@ func TestClientRestoresTrustedHeaderAfterStartup3(t *testing.T) {
 	// 1. options.Hash == trustedHeader.Hash
 	{
 		// load the first three headers into the trusted store
-		trustedStore := dbs.New(dbm.NewMemDB(), chainID)
+		trustedStore := newStore()
 		err := trustedStore.SaveLightBlock(l1)
 		require.NoError(t, err)
 
@@ -611,7 +608,7 @@ func TestClientRestoresTrustedHeaderAfterStartup3(t *testing.T) {
 	// 2. options.Hash != trustedHeader.Hash
 	// This could happen if previous provider was lying to us.
 	{
-		trustedStore := dbs.New(dbm.NewMemDB(), chainID)
+		trustedStore := newStore()
 		err := trustedStore.SaveLightBlock(l1)
 		require.NoError(t, err)
 
@@ -669,7 +666,7 @@ func TestClient_Update(t *testing.T) {
 		trustOptions,
 		fullNode,
 		[]provider.Provider{fullNode},
-		dbs.New(dbm.NewMemDB(), chainID),
+		newStore(),
 		light.Logger(log.TestingLogger()),
 	)
 	require.NoError(t, err)
@@ -689,7 +686,7 @@ func TestClient_Concurrency(t *testing.T) {
 		trustOptions,
 		fullNode,
 		[]provider.Provider{fullNode},
-		dbs.New(dbm.NewMemDB(), chainID),
+		newStore(),
 		light.Logger(log.TestingLogger()),
 	)
 	require.NoError(t, err)
@@ -729,7 +726,7 @@ func TestClientReplacesPrimaryWithWitnessIfPrimaryIsUnavailable(t *testing.T) {
 		trustOptions,
 		deadNode,
 		[]provider.Provider{fullNode, fullNode},
-		dbs.New(dbm.NewMemDB(), chainID),
+		newStore(),
 		light.Logger(log.TestingLogger()),
 		light.MaxRetryAttempts(1),
 	)
@@ -754,7 +751,7 @@ func TestClient_BackwardsVerification(t *testing.T) {
 			},
 			largeFullNode,
 			[]provider.Provider{largeFullNode},
-			dbs.New(dbm.NewMemDB(), chainID),
+			newStore(),
 			light.Logger(log.TestingLogger()),
 		)
 		require.NoError(t, err)
@@ -835,7 +832,7 @@ func TestClient_BackwardsVerification(t *testing.T) {
 				},
 				tc.provider,
 				[]provider.Provider{tc.provider},
-				dbs.New(dbm.NewMemDB(), chainID),
+				newStore(),
 				light.Logger(log.TestingLogger()),
 			)
 			require.NoError(t, err, idx)
@@ -848,7 +845,7 @@ func TestClient_BackwardsVerification(t *testing.T) {
 
 func TestClient_NewClientFromTrustedStore(t *testing.T) {
 	// 1) Initiate DB and fill with a "trusted" header
-	db := dbs.New(dbm.NewMemDB(), chainID)
+	db := newStore()
 	err := db.SaveLightBlock(l1)
 	require.NoError(t, err)
 
@@ -901,7 +898,7 @@ func TestClientRemovesWitnessIfItSendsUsIncorrectHeader(t *testing.T) {
 		trustOptions,
 		fullNode,
 		[]provider.Provider{badProvider1, badProvider2},
-		dbs.New(dbm.NewMemDB(), chainID),
+		newStore(),
 		light.Logger(log.TestingLogger()),
 		light.MaxRetryAttempts(1),
 	)
@@ -950,7 +947,7 @@ func TestClient_TrustedValidatorSet(t *testing.T) {
 		trustOptions,
 		fullNode,
 		[]provider.Provider{badValSetNode, fullNode},
-		dbs.New(dbm.NewMemDB(), chainID),
+		newStore(),
 		light.Logger(log.TestingLogger()),
 	)
 	require.NoError(t, err)
@@ -967,7 +964,7 @@ func TestClientPrunesHeadersAndValidatorSets(t *testing.T) {
 		trustOptions,
 		fullNode,
 		[]provider.Provider{fullNode},
-		dbs.New(dbm.NewMemDB(), chainID),
+		newStore(),
 		light.Logger(log.TestingLogger()),
 		light.PruningSize(1),
 	)
@@ -1039,7 +1036,7 @@ func TestClientEnsureValidHeadersAndValSets(t *testing.T) {
 			trustOptions,
 			badNode,
 			[]provider.Provider{badNode, badNode},
-			dbs.New(dbm.NewMemDB(), chainID),
+			newStore(),
 			light.MaxRetryAttempts(1),
 		)
 		require.NoError(t, err)
@@ -1053,3 +1050,249 @@ func TestClientEnsureValidHeadersAndValSets(t *testing.T) {
 	}
 
 }
+
+func TestClientRefillsWitnessesFromPool(t *testing.T) {
+	// sends a header signed by less than 1/3 of the validators
+	badProvider := mockp.New(
+		chainID,
+		map[int64]*types.SignedHeader{
+			1: h1,
+			2: keys.GenSignedHeaderLastBlockID(chainID, 2, bTime.Add(30*time.Minute), nil, vals, vals,
+				hash("app_hash2"), hash("cons_hash"), hash("results_hash"),
+				len(keys), len(keys), types.BlockID{Hash: h1.Hash()}),
+		},
+		valSet,
+	)
+	spare := mockp.New(chainID, headerSet, valSet)
+
+	c, err := light.NewClient(
+		chainID,
+		trustOptions,
+		fullNode,
+		[]provider.Provider{badProvider},
+		newStore(),
+		light.Logger(log.TestingLogger()),
+		light.WitnessPool([]provider.Provider{spare}),
+	)
+	require.NoError(t, err)
+
+	// the bad witness is replaced by the spare one
+	_, err = c.VerifyLightBlockAtHeight(2, bTime.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []provider.Provider{spare}, c.Witnesses())
+
+	// the spare witness cross-checks the next light block
+	_, err = c.VerifyLightBlockAtHeight(3, bTime.Add(2*time.Hour))
+	require.NoError(t, err)
+
+	status, err := c.Status(bTime.Add(2 * time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, status.SpareWitnesses)
+	require.Len(t, status.Witnesses, 1)
+	assert.False(t, status.Witnesses[0].LastResponse.IsZero())
+	assert.Empty(t, status.Witnesses[0].LastError)
+}
+
+func TestClientReplacesPrimaryWithSpareWitness(t *testing.T) {
+	spare := mockp.New(chainID, headerSet, valSet)
+
+	c, err := light.NewClient(
+		chainID,
+		trustOptions,
+		deadNode,
+		[]provider.Provider{fullNode},
+		newStore(),
+		light.Logger(log.TestingLogger()),
+		light.WitnessPool([]provider.Provider{spare}),
+	)
+	require.NoError(t, err)
+
+	// the witness becomes the primary, and the spare witness takes its place
+	_, err = c.Update(bTime.Add(2 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, fullNode, c.Primary())
+	assert.Equal(t, []provider.Provider{spare}, c.Witnesses())
+
+	_, err = light.NewClient(
+		chainID,
+		trustOptions,
+		fullNode,
+		[]provider.Provider{fullNode},
+		newStore(),
+		light.WitnessPool([]provider.Provider{mockp.New("other", headerSet, valSet)}),
+	)
+	assert.Error(t, err)
+}
+
+func TestClient_Status(t *testing.T) {
+	c, err := light.NewClient(
+		chainID,
+		trustOptions,
+		fullNode,
+		[]provider.Provider{deadNode},
+		newStore(),
+		light.Logger(log.TestingLogger()),
+	)
+	require.NoError(t, err)
+
+	status, err := c.Status(bTime.Add(time.Hour))
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, status.LatestTrustedHeight)
+	assert.Equal(t, h1.Time, status.LatestTrustedTime)
+	assert.Equal(t, trustPeriod-time.Hour, status.TrustingPeriodRemaining)
+	assert.Equal(t, "deadMock", status.Witnesses[0].Address)
+
+	// the witness doesn't respond
+	_, err = c.VerifyLightBlockAtHeight(2, bTime.Add(time.Hour))
+	assert.Error(t, err)
+
+	status, err = c.Status(bTime.Add(5 * time.Hour))
+	require.NoError(t, err)
+	assert.True(t, status.TrustingPeriodRemaining < 0)
+	require.Len(t, status.Witnesses, 1)
+	assert.True(t, status.Witnesses[0].LastResponse.IsZero())
+	assert.NotEmpty(t, status.Witnesses[0].LastError)
+}
+
+// blockingProvider is a provider whose light blocks are only sent once released.
+type blockingProvider struct {
+	provider.Provider
+	requested chan struct{}
+	release   chan struct{}
+}
+
+func (p blockingProvider) LightBlock(height int64) (*types.LightBlock, error) {
+	select {
+	case p.requested <- struct{}{}:
+	default:
+	}
+	<-p.release
+	return p.Provider.LightBlock(height)
+}
+
+func TestClient_StatusDuringCrossCheck(t *testing.T) {
+	witness := blockingProvider{Provider: fullNode, requested: make(chan struct{}, 1), release: make(chan struct{})}
+	c, err := light.NewClient(
+		chainID,
+		trustOptions,
+		fullNode,
+		[]provider.Provider{witness},
+		newStore(),
+		light.Logger(log.TestingLogger()),
+	)
+	require.NoError(t, err)
+
+	// the witness blocks the cross-check of the light block
+	verified := make(chan error)
+	go func() {
+		_, err := c.VerifyLightBlockAtHeight(2, bTime.Add(time.Hour))
+		verified <- err
+	}()
+	<-witness.requested
+
+	statusc := make(chan *light.Status)
+	go func() {
+		status, err := c.Status(bTime.Add(time.Hour))
+		assert.NoError(t, err)
+		statusc <- status
+	}()
+	select {
+	case status := <-statusc:
+		assert.Len(t, status.Witnesses, 1)
+	case <-time.After(time.Second):
+		t.Fatal("status waited for the cross-check")
+	}
+
+	close(witness.release)
+	require.NoError(t, <-verified)
+}
+
+func TestClient_StopTwice(t *testing.T) {
+	c, err := light.NewClient(
+		chainID,
+		trustOptions,
+		fullNode,
+		[]provider.Provider{fullNode},
+		newStore(),
+		light.UpdatePeriod(time.Second),
+	)
+	require.NoError(t, err)
+	require.NoError(t, c.Start())
+
+	c.Stop()
+	assert.NotPanics(t, c.Stop)
+}
+
+func TestClient_AutoUpdate(t *testing.T) {
+	node := mockp.New(GenMockNode(chainID, 5, 3, 0, time.Now().Add(-time.Hour)))
+	first, err := node.LightBlock(1)
+	require.NoError(t, err)
+
+	c, err := light.NewClient(
+		chainID,
+		light.TrustOptions{Period: trustPeriod, Height: 1, Hash: first.Hash()},
+		node,
+		[]provider.Provider{node},
+		newStore(),
+		light.Logger(log.TestingLogger()),
+		light.UpdatePeriod(10*time.Millisecond),
+	)
+	require.NoError(t, err)
+	require.NoError(t, c.Start())
+	defer c.Stop()
+
+	assert.Eventually(t, func() bool {
+		h, err := c.LastTrustedHeight()
+		return err == nil && h == 5
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestClientPrunesLightBlocksByAge(t *testing.T) {
+	c, err := light.NewClient(
+		chainID,
+		trustOptions,
+		fullNode,
+		[]provider.Provider{fullNode},
+		newStore(),
+		light.Logger(log.TestingLogger()),
+		light.PruningAge(45*time.Minute),
+	)
+	require.NoError(t, err)
+
+	// h2 is 30m older than h3
+	_, err = c.VerifyLightBlockAtHeight(2, bTime.Add(2*time.Hour))
+	require.NoError(t, err)
+	_, err = c.VerifyLightBlockAtHeight(3, bTime.Add(2*time.Hour))
+	require.NoError(t, err)
+
+	// h1 is 1h older than h3
+	first, err := c.FirstTrustedHeight()
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, first)
+}
+
+func TestClientDoesNotPruneLightBlocksVerifiedBackwardsByAge(t *testing.T) {
+	c, err := light.NewClient(
+		chainID,
+		trustOptions,
+		fullNode,
+		[]provider.Provider{fullNode},
+		newStore(),
+		light.Logger(log.TestingLogger()),
+		light.PruningAge(45*time.Minute),
+	)
+	require.NoError(t, err)
+
+	_, err = c.VerifyLightBlockAtHeight(3, bTime.Add(2*time.Hour))
+	require.NoError(t, err)
+	first, err := c.FirstTrustedHeight()
+	require.NoError(t, err)
+	require.EqualValues(t, 3, first)
+
+	// h1 is older than the pruning age, but was requested explicitly
+	_, err = c.VerifyLightBlockAtHeight(1, bTime.Add(2*time.Hour))
+	require.NoError(t, err)
+	l, err := c.TrustedLightBlock(1)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, l.Height)
+}
@@ -68,6 +68,11 @@ Example usage:
 			// handle error
 		}
 
+		store, err := dbs.New(db, "")
+		if err != nil {
+			// handle error
+		}
+
 		c, err := NewHTTPClient(
 			chainID,
 			TrustOptions{
@@ -77,7 +82,7 @@ Example usage:
 			},
 			"http://localhost:26657",
 			[]string{"http://witness1:26657"},
-			dbs.New(db, ""),
+			store,
 		)
 		if err != nil {
 			// handle error
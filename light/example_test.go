@@ -49,6 +49,10 @@ func ExampleClient_Update() {
 	if err != nil {
 		stdlog.Fatal(err)
 	}
+	store, err := dbs.New(db, chainID)
+	if err != nil {
+		stdlog.Fatal(err)
+	}
 
 	c, err := light.NewClient(
 		chainID,
@@ -59,7 +63,7 @@ func ExampleClient_Update() {
 		},
 		primary,
 		[]provider.Provider{primary}, // NOTE: primary should not be used here
-		dbs.New(db, chainID),
+		store,
 		light.Logger(log.TestingLogger()),
 	)
 	if err != nil {
@@ -120,6 +124,10 @@ func ExampleClient_VerifyLightBlockAtHeight() {
 	if err != nil {
 		stdlog.Fatal(err)
 	}
+	store, err := dbs.New(db, chainID)
+	if err != nil {
+		stdlog.Fatal(err)
+	}
 
 	c, err := light.NewClient(
 		chainID,
@@ -130,7 +138,7 @@ func ExampleClient_VerifyLightBlockAtHeight() {
 		},
 		primary,
 		[]provider.Provider{primary}, // NOTE: primary should not be used here
-		dbs.New(db, chainID),
+		store,
 		light.Logger(log.TestingLogger()),
 	)
 	if err != nil {
@@ -3,9 +3,13 @@ package light_test
 import (
 	"time"
 
+	dbm "github.com/tendermint/tm-db"
+
 	"github.com/tendermint/tendermint/crypto"
 	"github.com/tendermint/tendermint/crypto/ed25519"
 	"github.com/tendermint/tendermint/crypto/tmhash"
+	"github.com/tendermint/tendermint/light/store"
+	dbs "github.com/tendermint/tendermint/light/store/db"
 	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
 	tmversion "github.com/tendermint/tendermint/proto/tendermint/version"
 	"github.com/tendermint/tendermint/types"
@@ -13,6 +17,15 @@ import (
 	"github.com/tendermint/tendermint/version"
 )
 
+// newStore returns a db store of a new memdb.
+func newStore() store.Store {
+	s, err := dbs.New(dbm.NewMemDB(), chainID)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
 // privKeys is a helper type for testing.
 //
 // It lets us simulate signing with many keys.  The main use case is to create
@@ -2,6 +2,7 @@ package proxy
 
 import (
 	"github.com/tendermint/tendermint/libs/bytes"
+	"github.com/tendermint/tendermint/light"
 	lrpc "github.com/tendermint/tendermint/light/rpc"
 	ctypes "github.com/tendermint/tendermint/rpc/core/types"
 	rpcserver "github.com/tendermint/tendermint/rpc/jsonrpc/server"
@@ -19,6 +20,7 @@ func RPCRoutes(c *lrpc.Client) map[string]*rpcserver.RPCFunc {
 		// info API
 		"health":               rpcserver.NewRPCFunc(makeHealthFunc(c), ""),
 		"status":               rpcserver.NewRPCFunc(makeStatusFunc(c), ""),
+		"light_status":         rpcserver.NewRPCFunc(makeLightStatusFunc(c), ""),
 		"net_info":             rpcserver.NewRPCFunc(makeNetInfoFunc(c), ""),
 		"blockchain":           rpcserver.NewRPCFunc(makeBlockchainInfoFunc(c), "minHeight,maxHeight"),
 		"genesis":              rpcserver.NewRPCFunc(makeGenesisFunc(c), ""),
@@ -31,6 +33,8 @@ func RPCRoutes(c *lrpc.Client) map[string]*rpcserver.RPCFunc {
 		"validators":           rpcserver.NewRPCFunc(makeValidatorsFunc(c), "height,page,per_page"),
 		"dump_consensus_state": rpcserver.NewRPCFunc(makeDumpConsensusStateFunc(c), ""),
 		"consensus_state":      rpcserver.NewRPCFunc(makeConsensusStateFunc(c), ""),
+		"consensus_timeline":   rpcserver.NewRPCFunc(makeConsensusTimelineFunc(c), "heights"),
+		"validator_uptime":     rpcserver.NewRPCFunc(makeValidatorUptimeFunc(c), "page,per_page"),
 		"consensus_params":     rpcserver.NewRPCFunc(makeConsensusParamsFunc(c), "height"),
 		"unconfirmed_txs":      rpcserver.NewRPCFunc(makeUnconfirmedTxsFunc(c), "limit"),
 		"num_unconfirmed_txs":  rpcserver.NewRPCFunc(makeNumUnconfirmedTxsFunc(c), ""),
@@ -66,6 +70,14 @@ func makeStatusFunc(c *lrpc.Client) rpcStatusFunc {
 	}
 }
 
+type rpcLightStatusFunc func(ctx *rpctypes.Context) (*light.Status, error)
+
+func makeLightStatusFunc(c *lrpc.Client) rpcLightStatusFunc {
+	return func(ctx *rpctypes.Context) (*light.Status, error) {
+		return c.LightStatus()
+	}
+}
+
 type rpcNetInfoFunc func(ctx *rpctypes.Context, minHeight, maxHeight int64) (*ctypes.ResultNetInfo, error)
 
 func makeNetInfoFunc(c *lrpc.Client) rpcNetInfoFunc {
@@ -165,6 +177,22 @@ func makeConsensusStateFunc(c *lrpc.Client) rpcConsensusStateFunc {
 	}
 }
 
+type rpcConsensusTimelineFunc func(ctx *rpctypes.Context, heights *int) (*ctypes.ResultConsensusTimeline, error)
+
+func makeConsensusTimelineFunc(c *lrpc.Client) rpcConsensusTimelineFunc {
+	return func(ctx *rpctypes.Context, heights *int) (*ctypes.ResultConsensusTimeline, error) {
+		return c.ConsensusTimeline(heights)
+	}
+}
+
+type rpcValidatorUptimeFunc func(ctx *rpctypes.Context, page, perPage *int) (*ctypes.ResultValidatorUptime, error)
+
+func makeValidatorUptimeFunc(c *lrpc.Client) rpcValidatorUptimeFunc {
+	return func(ctx *rpctypes.Context, page, perPage *int) (*ctypes.ResultValidatorUptime, error) {
+		return c.ValidatorUptime(page, perPage)
+	}
+}
+
 type rpcConsensusParamsFunc func(ctx *rpctypes.Context, height *int64) (*ctypes.ResultConsensusParams, error)
 
 func makeConsensusParamsFunc(c *lrpc.Client) rpcConsensusParamsFunc {
@@ -5,15 +5,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strings"
 	"time"
 
-	"github.com/gogo/protobuf/proto"
-
 	abci "github.com/tendermint/tendermint/abci/types"
 	"github.com/tendermint/tendermint/crypto/merkle"
 	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	tmmath "github.com/tendermint/tendermint/libs/math"
 	service "github.com/tendermint/tendermint/libs/service"
+	tmsync "github.com/tendermint/tendermint/libs/sync"
 	light "github.com/tendermint/tendermint/light"
 	rpcclient "github.com/tendermint/tendermint/rpc/client"
 	ctypes "github.com/tendermint/tendermint/rpc/core/types"
@@ -23,6 +22,11 @@ import (
 
 var errNegOrZeroHeight = errors.New("negative or zero height")
 
+const (
+	// Number of verified validator sets kept in memory.
+	validatorSetCacheSize = 10
+)
+
 // Client is an RPC client, which uses light#Client to verify data (if it can be
 // proved!).
 type Client struct {
@@ -31,18 +35,52 @@ type Client struct {
 	next rpcclient.Client
 	lc   *light.Client
 	prt  *merkle.ProofRuntime
+
+	keyPathFn KeyPathFunc
+
+	// Verified validator sets, by height, and their heights from the oldest
+	// one.
+	valsMtx     tmsync.Mutex
+	vals        map[int64][]*types.Validator
+	valsHeights []int64
 }
 
 var _ rpcclient.Client = (*Client)(nil)
 
+// Option allow you to tweak Client.
+type Option func(*Client)
+
+// KeyPathFn option can be used to set a function, which builds the merkle key
+// path of ABCI query responses. Default: DefaultMerkleKeyPathFn.
+func KeyPathFn(fn KeyPathFunc) Option {
+	return func(c *Client) {
+		c.keyPathFn = fn
+	}
+}
+
+// ProofRuntime option can be used to set the runtime decoding and verifying
+// the proofs of ABCI query responses. Default: merkle.DefaultProofRuntime,
+// which knows about value proofs, and the ICS23 commitment proofs of IAVL and
+// simple merkle trees.
+func ProofRuntime(prt *merkle.ProofRuntime) Option {
+	return func(c *Client) {
+		c.prt = prt
+	}
+}
+
 // NewClient returns a new client.
-func NewClient(next rpcclient.Client, lc *light.Client) *Client {
+func NewClient(next rpcclient.Client, lc *light.Client, opts ...Option) *Client {
 	c := &Client{
-		next: next,
-		lc:   lc,
-		prt:  defaultProofRuntime(),
+		next:      next,
+		lc:        lc,
+		prt:       merkle.DefaultProofRuntime(),
+		keyPathFn: DefaultMerkleKeyPathFn(),
+		vals:      make(map[int64][]*types.Validator),
 	}
 	c.BaseService = *service.NewBaseService(nil, "Client", c)
+	for _, o := range opts {
+		o(c)
+	}
 	return c
 }
 
@@ -73,11 +111,13 @@ func (c *Client) ABCIQuery(path string, data tmbytes.HexBytes) (*ctypes.ResultAB
 	return c.ABCIQueryWithOptions(path, data, rpcclient.DefaultABCIQueryOptions)
 }
 
-// GetWithProofOptions is useful if you want full access to the ABCIQueryOptions.
-// XXX Usage of path?  It's not used, and sometimes it's /, sometimes /key, sometimes /store.
+// ABCIQueryWithOptions requests a proof of the response, and verifies it
+// against the app hash of the trusted header at the next height, with the key
+// path built from the query path and the response key by the KeyPathFn.
 func (c *Client) ABCIQueryWithOptions(path string, data tmbytes.HexBytes,
 	opts rpcclient.ABCIQueryOptions) (*ctypes.ResultABCIQuery, error) {
 
+	opts.Prove = true
 	res, err := c.next.ABCIQueryWithOptions(path, data, opts)
 	if err != nil {
 		return nil, err
@@ -103,16 +143,12 @@ func (c *Client) ABCIQueryWithOptions(path string, data tmbytes.HexBytes,
 	}
 
 	// Validate the value proof against the trusted header.
+	kp, err := c.keyPathFn(path, resp.Key)
+	if err != nil {
+		return nil, fmt.Errorf("can't build merkle key path: %w", err)
+	}
 	if resp.Value != nil {
 		// Value exists
-		// XXX How do we encode the key into a string...
-		storeName, err := parseQueryStorePath(path)
-		if err != nil {
-			return nil, err
-		}
-		kp := merkle.KeyPath{}
-		kp = kp.AppendKey([]byte(storeName), merkle.KeyEncodingURL)
-		kp = kp.AppendKey(resp.Key, merkle.KeyEncodingURL)
 		err = c.prt.VerifyValue(resp.ProofOps, l.AppHash, kp.String(), resp.Value)
 		if err != nil {
 			return nil, fmt.Errorf("verify value proof: %w", err)
@@ -120,9 +156,8 @@ func (c *Client) ABCIQueryWithOptions(path string, data tmbytes.HexBytes,
 		return &ctypes.ResultABCIQuery{Response: resp}, nil
 	}
 
-	// OR validate the ansence proof against the trusted header.
-	// XXX How do we encode the key into a string...
-	err = c.prt.VerifyAbsence(resp.ProofOps, l.AppHash, string(resp.Key))
+	// OR validate the absence proof against the trusted header.
+	err = c.prt.VerifyAbsence(resp.ProofOps, l.AppHash, kp.String())
 	if err != nil {
 		return nil, fmt.Errorf("verify absence proof: %w", err)
 	}
@@ -165,6 +200,16 @@ func (c *Client) ConsensusState() (*ctypes.ResultConsensusState, error) {
 	return c.next.ConsensusState()
 }
 
+func (c *Client) ConsensusTimeline(heights *int) (*ctypes.ResultConsensusTimeline, error) {
+	return c.next.ConsensusTimeline(heights)
+}
+
+// ValidatorUptime calls rpcclient#ValidatorUptime. The uptime isn't verified,
+// since it's tracked by the node.
+func (c *Client) ValidatorUptime(page, perPage *int) (*ctypes.ResultValidatorUptime, error) {
+	return c.next.ValidatorUptime(page, perPage)
+}
+
 func (c *Client) ConsensusParams(height *int64) (*ctypes.ResultConsensusParams, error) {
 	res, err := c.next.ConsensusParams(height)
 	if err != nil {
@@ -198,6 +243,13 @@ func (c *Client) Health() (*ctypes.ResultHealth, error) {
 	return c.next.Health()
 }
 
+// LightStatus returns the status of the light client: its latest trusted
+// light block, the time left in its trusting period and the health of its
+// witnesses.
+func (c *Client) LightStatus() (*light.Status, error) {
+	return c.lc.Status(time.Now())
+}
+
 // BlockchainInfo calls rpcclient#BlockchainInfo and then verifies every header
 // returned.
 func (c *Client) BlockchainInfo(minHeight, maxHeight int64) (*ctypes.ResultBlockchainInfo, error) {
@@ -343,27 +395,10 @@ func (c *Client) BlockResults(height *int64) (*ctypes.ResultBlockResults, error)
 		return nil, err
 	}
 
-	// proto-encode BeginBlock events
-	bbeBytes, err := proto.Marshal(&abci.ResponseBeginBlock{
-		Events: res.BeginBlockEvents,
-	})
-	if err != nil {
-		return nil, err
-	}
-
 	// Build a Merkle tree of proto-encoded DeliverTx results and get a hash.
-	results := types.NewResults(res.TxsResults)
-
-	// proto-encode EndBlock events.
-	ebeBytes, err := proto.Marshal(&abci.ResponseEndBlock{
-		Events: res.EndBlockEvents,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	// Build a Merkle tree out of the above 3 binary slices.
-	rH := merkle.HashFromByteSlices([][]byte{bbeBytes, results.Hash(), ebeBytes})
+	// NOTE: the BeginBlock and EndBlock events aren't part of it, so they
+	// aren't verified.
+	rH := types.NewResults(res.TxsResults).Hash()
 
 	// Verify block results.
 	if !bytes.Equal(rH, trustedBlock.LastResultsHash) {
@@ -426,27 +461,139 @@ func (c *Client) Tx(hash []byte, prove bool) (*ctypes.ResultTx, error) {
 	return res, res.Proof.Validate(l.DataHash)
 }
 
+// TxSearch calls rpcclient#TxSearch method and then verifies the proof of
+// each result, and its DeliverTx response against the trusted block results,
+// if proofs were requested.
+//
+// WARNING: the completeness of the results isn't verified, i.e. the full node
+// can omit some of them. The events of the DeliverTx responses aren't
+// verified either, because they aren't part of the results hash.
 func (c *Client) TxSearch(query string, prove bool, page, perPage *int, orderBy string) (
 	*ctypes.ResultTxSearch, error) {
-	return c.next.TxSearch(query, prove, page, perPage, orderBy)
+	res, err := c.next.TxSearch(query, prove, page, perPage, orderBy)
+	if err != nil || !prove {
+		return res, err
+	}
+
+	blockResults := make(map[int64]*ctypes.ResultBlockResults)
+	for _, tx := range res.Txs {
+		// Validate tx.
+		if tx.Height <= 0 {
+			return nil, errNegOrZeroHeight
+		}
+		if !bytes.Equal(tx.Hash, tx.Tx.Hash()) || !bytes.Equal(tx.Proof.Data, tx.Tx) {
+			return nil, fmt.Errorf("tx %X does not match its hash or proof", tx.Hash)
+		}
+		if tx.Proof.Proof.Index != int64(tx.Index) {
+			return nil, fmt.Errorf("tx %X: index %d does not match the index %d of its proof",
+				tx.Hash, tx.Index, tx.Proof.Proof.Index)
+		}
+
+		// Update the light client if we're behind.
+		l, err := c.updateLightClientIfNeededTo(tx.Height)
+		if err != nil {
+			return nil, err
+		}
+
+		// Validate the proof.
+		if err := tx.Proof.Validate(l.DataHash); err != nil {
+			return nil, fmt.Errorf("tx %X: %w", tx.Hash, err)
+		}
+
+		// Verify the DeliverTx response against the verified block results.
+		results, ok := blockResults[tx.Height]
+		if !ok {
+			height := tx.Height
+			results, err = c.BlockResults(&height)
+			if err != nil {
+				return nil, fmt.Errorf("tx %X: %w", tx.Hash, err)
+			}
+			blockResults[tx.Height] = results
+		}
+		if int(tx.Index) >= len(results.TxsResults) {
+			return nil, fmt.Errorf("tx %X: index %d out of range of the block results", tx.Hash, tx.Index)
+		}
+		rH := types.NewResults([]*abci.ResponseDeliverTx{&tx.TxResult}).Hash()
+		tH := types.NewResults(results.TxsResults[tx.Index : tx.Index+1]).Hash()
+		if !bytes.Equal(rH, tH) {
+			return nil, fmt.Errorf("tx %X: result does not match with trusted block results", tx.Hash)
+		}
+	}
+
+	return res, nil
 }
 
-// Validators fetches and verifies validators.
-//
-// WARNING: only full validator sets are verified (when length of validators is
-// less than +perPage+. +perPage+ default is 30, max is 100).
-func (c *Client) Validators(height *int64, page, perPage *int) (*ctypes.ResultValidators, error) {
-	res, err := c.next.Validators(height, page, perPage)
+// Validators fetches all the pages of the validator set at the given height,
+// verifies the whole set against the trusted header, and returns the requested
+// page of the verified set. The verified sets of the last few heights are
+// cached.
+func (c *Client) Validators(height *int64, pagePtr, perPagePtr *int) (*ctypes.ResultValidators, error) {
+	var (
+		vals []*types.Validator
+		ok   bool
+		err  error
+	)
+	if height != nil {
+		vals, ok = c.cachedValidators(*height)
+	}
+	if !ok {
+		height, vals, err = c.verifiedValidators(height)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	totalCount := len(vals)
+	perPage := ctypes.ValidatePerPage(perPagePtr)
+	page, err := ctypes.ValidatePage(pagePtr, perPage, totalCount)
 	if err != nil {
 		return nil, err
 	}
 
-	// Validate res.
-	if res.BlockHeight <= 0 {
-		return nil, errNegOrZeroHeight
+	skipCount := ctypes.ValidateSkipCount(page, perPage)
+	v := vals[skipCount : skipCount+tmmath.MinInt(perPage, totalCount-skipCount)]
+
+	return &ctypes.ResultValidators{
+		BlockHeight: *height,
+		Validators:  v,
+		Count:       len(v),
+		Total:       totalCount}, nil
+}
+
+// verifiedValidators fetches all the pages of the validator set at the given
+// height (latest if nil), verifies it and caches it.
+func (c *Client) verifiedValidators(height *int64) (*int64, []*types.Validator, error) {
+	var (
+		vals    []*types.Validator
+		perPage = ctypes.MaxPerPage
+	)
+	for page := 1; ; page++ {
+		res, err := c.next.Validators(height, &page, &perPage)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Validate res.
+		switch {
+		case res.BlockHeight <= 0:
+			return nil, nil, errNegOrZeroHeight
+		case height == nil:
+			// The next pages must be of the same height.
+			height = &res.BlockHeight
+		case res.BlockHeight != *height:
+			return nil, nil, fmt.Errorf("validators of height %d instead of %d", res.BlockHeight, *height)
+		}
+		if res.Total > types.MaxVotesCount {
+			return nil, nil, fmt.Errorf("too many validators: %d, max: %d", res.Total, types.MaxVotesCount)
+		}
+
+		vals = append(vals, res.Validators...)
+		if len(vals) >= res.Total || len(res.Validators) == 0 {
+			break
+		}
 	}
 
-	updateHeight := res.BlockHeight - 1
+	updateHeight := *height - 1
 
 	// updateHeight can't be zero which happens when we are looking for the validators of the first block
 	if updateHeight == 0 {
@@ -456,11 +603,11 @@ func (c *Client) Validators(height *int64, page, perPage *int) (*ctypes.ResultVa
 	// Update the light client if we're behind.
 	l, err := c.updateLightClientIfNeededTo(updateHeight)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var tH tmbytes.HexBytes
-	switch res.BlockHeight {
+	switch *height {
 	case 1:
 		// if it's the first block we need to validate with the current validator hash as opposed to the
 		// next validator hash
@@ -469,24 +616,114 @@ func (c *Client) Validators(height *int64, page, perPage *int) (*ctypes.ResultVa
 		tH = l.NextValidatorsHash
 	}
 
-	// Verify validators.
-	if res.Count <= res.Total {
-		if rH := types.NewValidatorSet(res.Validators).Hash(); !bytes.Equal(rH, tH) {
-			return nil, fmt.Errorf("validators %X does not match with trusted validators %X",
-				rH, tH)
-		}
+	// Verify validators, in the order they are served.
+	if rH := (&types.ValidatorSet{Validators: vals}).Hash(); !bytes.Equal(rH, tH) {
+		return nil, nil, fmt.Errorf("validators %X does not match with trusted validators %X",
+			rH, tH)
 	}
 
-	return res, nil
+	c.cacheValidators(*height, vals)
+
+	return height, vals, nil
+}
+
+func (c *Client) cachedValidators(height int64) ([]*types.Validator, bool) {
+	c.valsMtx.Lock()
+	defer c.valsMtx.Unlock()
+	vals, ok := c.vals[height]
+	return vals, ok
+}
+
+func (c *Client) cacheValidators(height int64, vals []*types.Validator) {
+	c.valsMtx.Lock()
+	defer c.valsMtx.Unlock()
+	if _, ok := c.vals[height]; ok {
+		return
+	}
+	if len(c.valsHeights) >= validatorSetCacheSize {
+		delete(c.vals, c.valsHeights[0])
+		c.valsHeights = c.valsHeights[1:]
+	}
+	c.vals[height] = vals
+	c.valsHeights = append(c.valsHeights, height)
 }
 
 func (c *Client) BroadcastEvidence(ev types.Evidence) (*ctypes.ResultBroadcastEvidence, error) {
 	return c.next.BroadcastEvidence(ev)
 }
 
+// Subscribe calls rpcclient#Subscribe method and then verifies the headers of
+// NewBlock and NewBlockHeader events before delivering them. Events which
+// can't be verified are dropped. Other events aren't verified.
 func (c *Client) Subscribe(ctx context.Context, subscriber, query string,
 	outCapacity ...int) (out <-chan ctypes.ResultEvent, err error) {
-	return c.next.Subscribe(ctx, subscriber, query, outCapacity...)
+	in, err := c.next.Subscribe(ctx, subscriber, query, outCapacity...)
+	if err != nil {
+		return nil, err
+	}
+
+	verified := make(chan ctypes.ResultEvent, cap(in))
+	go c.verifyEvents(in, verified)
+	return verified, nil
+}
+
+func (c *Client) verifyEvents(in <-chan ctypes.ResultEvent, out chan<- ctypes.ResultEvent) {
+	defer close(out)
+	for {
+		select {
+		case event, ok := <-in:
+			if !ok {
+				return
+			}
+			if err := c.verifyEvent(event); err != nil {
+				c.Logger.Error("Dropping unverified event", "query", event.Query, "err", err)
+				continue
+			}
+			select {
+			case out <- event:
+			case <-c.Quit():
+				return
+			}
+		case <-c.Quit():
+			return
+		}
+	}
+}
+
+func (c *Client) verifyEvent(event ctypes.ResultEvent) error {
+	var header types.Header
+	switch data := event.Data.(type) {
+	case types.EventDataNewBlock:
+		if data.Block == nil {
+			return errors.New("nil block")
+		}
+		// The block must match its header.
+		if err := data.Block.ValidateBasic(); err != nil {
+			return fmt.Errorf("invalid block: %w", err)
+		}
+		header = data.Block.Header
+	case types.EventDataNewBlockHeader:
+		header = data.Header
+	default:
+		return nil
+	}
+
+	// Validate the header.
+	if header.Height <= 0 {
+		return errNegOrZeroHeight
+	}
+
+	// Update the light client if we're behind.
+	l, err := c.updateLightClientIfNeededTo(header.Height)
+	if err != nil {
+		return err
+	}
+
+	// Verify the header.
+	if rH, tH := header.Hash(), l.Hash(); !bytes.Equal(rH, tH) {
+		return fmt.Errorf("header %X does not match with trusted header %X", rH, tH)
+	}
+	return nil
 }
 
 func (c *Client) Unsubscribe(ctx context.Context, subscriber, query string) error {
@@ -510,10 +747,10 @@ func (c *Client) RegisterOpDecoder(typ string, dec merkle.OpDecoder) {
 }
 
 // SubscribeWS subscribes for events using the given query and remote address as
-// a subscriber, but does not verify responses (UNSAFE)!
-// TODO: verify data
+// a subscriber. Only the NewBlock and NewBlockHeader events are verified (see
+// Subscribe), other events aren't (UNSAFE)!
 func (c *Client) SubscribeWS(ctx *rpctypes.Context, query string) (*ctypes.ResultSubscribe, error) {
-	out, err := c.next.Subscribe(context.Background(), ctx.RemoteAddr(), query)
+	out, err := c.Subscribe(context.Background(), ctx.RemoteAddr(), query)
 	if err != nil {
 		return nil, err
 	}
@@ -521,7 +758,10 @@ func (c *Client) SubscribeWS(ctx *rpctypes.Context, query string) (*ctypes.Resul
 	go func() {
 		for {
 			select {
-			case resultEvent := <-out:
+			case resultEvent, ok := <-out:
+				if !ok {
+					return
+				}
 				// We should have a switch here that performs a validation
 				// depending on the event's type.
 				ctx.WSConn.TryWriteRPCResponse(
@@ -557,21 +797,3 @@ func (c *Client) UnsubscribeAllWS(ctx *rpctypes.Context) (*ctypes.ResultUnsubscr
 	}
 	return &ctypes.ResultUnsubscribe{}, nil
 }
-
-func parseQueryStorePath(path string) (storeName string, err error) {
-	if !strings.HasPrefix(path, "/") {
-		return "", errors.New("expected path to start with /")
-	}
-
-	paths := strings.SplitN(path[1:], "/", 3)
-	switch {
-	case len(paths) != 3:
-		return "", errors.New("expected format like /store/<storeName>/key")
-	case paths[0] != "store":
-		return "", errors.New("expected format like /store/<storeName>/key")
-	case paths[2] != "key":
-		return "", errors.New("expected format like /store/<storeName>/key")
-	}
-
-	return paths[1], nil
-}
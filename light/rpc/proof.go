@@ -1,14 +1,46 @@
 package rpc
 
 import (
+	"errors"
+	"strings"
+
 	"github.com/tendermint/tendermint/crypto/merkle"
 )
 
-func defaultProofRuntime() *merkle.ProofRuntime {
-	prt := merkle.NewProofRuntime()
-	prt.RegisterOpDecoder(
-		merkle.ProofOpValue,
-		merkle.ValueOpDecoder,
-	)
-	return prt
+// KeyPathFunc builds the merkle key path of the proof of an ABCI query
+// response from the query path and the response key.
+type KeyPathFunc func(path string, key []byte) (merkle.KeyPath, error)
+
+// DefaultMerkleKeyPathFn builds the key path /<storeName>/<key> from query
+// paths like /store/<storeName>/key, as used by multistore applications
+// which prove the key in a store, and the store root in the app hash.
+func DefaultMerkleKeyPathFn() KeyPathFunc {
+	return func(path string, key []byte) (merkle.KeyPath, error) {
+		storeName, err := parseQueryStorePath(path)
+		if err != nil {
+			return nil, err
+		}
+		kp := merkle.KeyPath{}
+		kp = kp.AppendKey([]byte(storeName), merkle.KeyEncodingURL)
+		kp = kp.AppendKey(key, merkle.KeyEncodingURL)
+		return kp, nil
+	}
+}
+
+func parseQueryStorePath(path string) (storeName string, err error) {
+	if !strings.HasPrefix(path, "/") {
+		return "", errors.New("expected path to start with /")
+	}
+
+	paths := strings.SplitN(path[1:], "/", 3)
+	switch {
+	case len(paths) != 3:
+		return "", errors.New("expected format like /store/<storeName>/key")
+	case paths[0] != "store":
+		return "", errors.New("expected format like /store/<storeName>/key")
+	case paths[2] != "key":
+		return "", errors.New("expected format like /store/<storeName>/key")
+	}
+
+	return paths[1], nil
 }
@@ -1,160 +1,319 @@
-package rpc
-
-//import (
-//	"fmt"
-//	"os"
-//	"testing"
-//	"time"
-
-//	"github.com/stretchr/testify/assert"
-//	"github.com/stretchr/testify/require"
-
-//	"github.com/tendermint/tendermint/abci/example/kvstore"
-//	"github.com/tendermint/tendermint/crypto/merkle"
-//	nm "github.com/tendermint/tendermint/node"
-//	"github.com/tendermint/tendermint/rpc/client"
-//	rpctest "github.com/tendermint/tendermint/rpc/test"
-//	"github.com/tendermint/tendermint/types"
-//)
-
-//var node *nm.Node
-//var chainID = "tendermint_test" // TODO use from config.
-////nolint:unused
-//var waitForEventTimeout = 5 * time.Second
-
-//// TODO fix tests!!
-
-//func TestMain(m *testing.M) {
-//	app := kvstore.NewKVStoreApplication()
-//	node = rpctest.StartTendermint(app)
-
-//	code := m.Run()
-
-//	rpctest.StopTendermint(node)
-//	os.Exit(code)
-//}
-
-//func kvstoreTx(k, v []byte) []byte {
-//	return []byte(fmt.Sprintf("%s=%s", k, v))
-//}
-
-//// TODO: enable it after general proof format has been adapted
-//// in abci/examples/kvstore.go
-////nolint:unused,deadcode
-//func _TestAppProofs(t *testing.T) {
-//	assert, require := assert.New(t), require.New(t)
-
-//	prt := defaultProofRuntime()
-//	cl := client.NewLocal(node)
-//	client.WaitForHeight(cl, 1, nil)
-
-//	// This sets up our trust on the node based on some past point.
-//	source := certclient.NewProvider(chainID, cl)
-//	seed, err := source.LatestFullCommit(chainID, 1, 1)
-//	require.NoError(err, "%#v", err)
-//	cert := lite.NewBaseVerifier(chainID, seed.Height(), seed.Validators)
-
-//	// Wait for tx confirmation.
-//	done := make(chan int64)
-//	go func() {
-//		evtTyp := types.EventTx
-//		_, err = client.WaitForOneEvent(cl, evtTyp, waitForEventTimeout)
-//		require.Nil(err, "%#v", err)
-//		close(done)
-//	}()
-
-//	// Submit a transaction.
-//	k := []byte("my-key")
-//	v := []byte("my-value")
-//	tx := kvstoreTx(k, v)
-//	br, err := cl.BroadcastTxCommit(tx)
-//	require.NoError(err, "%#v", err)
-//	require.EqualValues(0, br.CheckTx.Code, "%#v", br.CheckTx)
-//	require.EqualValues(0, br.DeliverTx.Code)
-//	brh := br.Height
-
-//	// Fetch latest after tx commit.
-//	<-done
-//	latest, err := source.LatestFullCommit(chainID, 1, 1<<63-1)
-//	require.NoError(err, "%#v", err)
-//	rootHash := latest.SignedHeader.AppHash
-//	if rootHash == nil {
-//		// Fetch one block later, AppHash hasn't been committed yet.
-//		// TODO find a way to avoid doing this.
-//		client.WaitForHeight(cl, latest.SignedHeader.Height+1, nil)
-//		latest, err = source.LatestFullCommit(chainID, latest.SignedHeader.Height+1, 1<<63-1)
-//		require.NoError(err, "%#v", err)
-//		rootHash = latest.SignedHeader.AppHash
-//	}
-//	require.NotNil(rootHash)
-
-//	// verify a query before the tx block has no data (and valid non-exist proof)
-//	bs, height, proof, err := GetWithProof(prt, k, brh-1, cl, cert)
-//	require.NoError(err, "%#v", err)
-//	require.NotNil(proof)
-//	require.Equal(height, brh-1)
-//	// require.NotNil(proof)
-//	// TODO: Ensure that *some* keys will be there, ensuring that proof is nil,
-//	// (currently there's a race condition)
-//	// and ensure that proof proves absence of k.
-//	require.Nil(bs)
-
-//	// but given that block it is good
-//	bs, height, proof, err = GetWithProof(prt, k, brh, cl, cert)
-//	require.NoError(err, "%#v", err)
-//	require.NotNil(proof)
-//	require.Equal(height, brh)
-
-//	assert.EqualValues(v, bs)
-//	err = prt.VerifyValue(proof, rootHash, string(k), bs) // XXX key encoding
-//	assert.NoError(err, "%#v", err)
-
-//	// Test non-existing key.
-//	missing := []byte("my-missing-key")
-//	bs, _, proof, err = GetWithProof(prt, missing, 0, cl, cert)
-//	require.NoError(err)
-//	require.Nil(bs)
-//	require.NotNil(proof)
-//	err = prt.VerifyAbsence(proof, rootHash, string(missing)) // XXX VerifyAbsence(), keyencoding
-//	assert.NoError(err, "%#v", err)
-//	err = prt.VerifyAbsence(proof, rootHash, string(k)) // XXX VerifyAbsence(), keyencoding
-//	assert.Error(err, "%#v", err)
-//}
-
-//func TestTxProofs(t *testing.T) {
-//	assert, require := assert.New(t), require.New(t)
-
-//	cl := client.NewLocal(node)
-//	client.WaitForHeight(cl, 1, nil)
-
-//	tx := kvstoreTx([]byte("key-a"), []byte("value-a"))
-//	br, err := cl.BroadcastTxCommit(tx)
-//	require.NoError(err, "%#v", err)
-//	require.EqualValues(0, br.CheckTx.Code, "%#v", br.CheckTx)
-//	require.EqualValues(0, br.DeliverTx.Code)
-//	brh := br.Height
-
-//	source := certclient.NewProvider(chainID, cl)
-//	seed, err := source.LatestFullCommit(chainID, brh-2, brh-2)
-//	require.NoError(err, "%#v", err)
-//	cert := lite.NewBaseVerifier(chainID, seed.Height(), seed.Validators)
-
-//	// First let's make sure a bogus transaction hash returns a valid non-existence proof.
-//	key := types.Tx([]byte("bogus")).Hash()
-//	_, err = cl.Tx(key, true)
-//	require.NotNil(err)
-//	require.Contains(err.Error(), "not found")
-
-//	// Now let's check with the real tx root hash.
-//	key = types.Tx(tx).Hash()
-//	res, err := cl.Tx(key, true)
-//	require.NoError(err, "%#v", err)
-//	require.NotNil(res)
-//	keyHash := merkle.SimpleHashFromByteSlices([][]byte{key})
-//	err = res.Proof.Validate(keyHash)
-//	assert.NoError(err, "%#v", err)
-
-//	commit, err := GetCertifiedCommit(br.Height, cl, cert)
-//	require.Nil(err, "%#v", err)
-//	require.Equal(res.Proof.RootHash, commit.Header.DataHash)
-//}
+package rpc_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	dbm "github.com/tendermint/tm-db"
+
+	"github.com/tendermint/tendermint/abci/example/kvstore"
+	"github.com/tendermint/tendermint/crypto/merkle"
+	"github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/light"
+	"github.com/tendermint/tendermint/light/provider"
+	httpp "github.com/tendermint/tendermint/light/provider/http"
+	lrpc "github.com/tendermint/tendermint/light/rpc"
+	dbs "github.com/tendermint/tendermint/light/store/db"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	rpctest "github.com/tendermint/tendermint/rpc/test"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "light-rpc-test")
+	if err != nil {
+		panic(err)
+	}
+	app := kvstore.NewPersistentKVStoreApplication(dir)
+	node := rpctest.StartTendermint(app, rpctest.SuppressStdout)
+	code := m.Run()
+	rpctest.StopTendermint(node)
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// newClient returns a verifying client of the test node, trusting its latest
+// block.
+func newClient(t *testing.T, opts ...lrpc.Option) *lrpc.Client {
+	return newClientWithNext(t, nil, opts...)
+}
+
+// newClientWithNext is like newClient, with the client of the test node
+// wrapped by the given function.
+func newClientWithNext(t *testing.T, wrap func(*rpchttp.HTTP) rpcclient.Client,
+	opts ...lrpc.Option) *lrpc.Client {
+	config := rpctest.GetConfig()
+	chainID := config.ChainID()
+
+	node, err := rpchttp.New(config.RPC.ListenAddress, "/websocket")
+	require.NoError(t, err)
+	require.NoError(t, rpcclient.WaitForHeight(node, 2, nil))
+	var next rpcclient.Client = node
+	if wrap != nil {
+		next = wrap(node)
+	}
+
+	store, err := dbs.New(dbm.NewMemDB(), chainID)
+	require.NoError(t, err)
+	primary, err := httpp.New(chainID, config.RPC.ListenAddress)
+	require.NoError(t, err)
+	block, err := primary.LightBlock(0)
+	require.NoError(t, err)
+	lc, err := light.NewClient(
+		chainID,
+		light.TrustOptions{Period: time.Hour, Height: block.Height, Hash: block.Hash()},
+		primary,
+		[]provider.Provider{primary},
+		store,
+		light.Logger(log.TestingLogger()),
+		// The block times of the test node run ahead of the clock, by at least
+		// a second per block, and it commits thousands of blocks per minute.
+		light.MaxClockDrift(1000*time.Hour),
+	)
+	require.NoError(t, err)
+	return lrpc.NewClient(next, lc, opts...)
+}
+
+func TestABCIQuery(t *testing.T) {
+	// The clients trust a block before the transaction.
+	c := newClient(t)
+	cNoOps := newClient(t, lrpc.ProofRuntime(merkle.NewProofRuntime()))
+	cKeyPath := newClient(t, lrpc.KeyPathFn(func(path string, key []byte) (merkle.KeyPath, error) {
+		return merkle.KeyPath{}.AppendKey([]byte(kvstore.StoreName), merkle.KeyEncodingURL).
+			AppendKey(key, merkle.KeyEncodingURL), nil
+	}))
+
+	res, err := c.BroadcastTxCommit(types.Tx("my-key=my-value"))
+	require.NoError(t, err)
+	require.EqualValues(t, 0, res.DeliverTx.Code)
+	// The app hash of a height is in the header of the next one.
+	require.NoError(t, rpcclient.WaitForHeight(c, res.Height+1, nil))
+	opts := rpcclient.ABCIQueryOptions{Height: res.Height}
+
+	path := "/store/" + kvstore.StoreName + "/key"
+	qres, err := c.ABCIQueryWithOptions(path, []byte("my-key"), opts)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("my-value"), qres.Response.Value)
+
+	// Absent keys, before and after the existing key.
+	for _, key := range []string{"a-missing-key", "z-missing-key"} {
+		qres, err = c.ABCIQueryWithOptions(path, []byte(key), opts)
+		require.NoError(t, err, key)
+		assert.Nil(t, qres.Response.Value, key)
+	}
+
+	// The value isn't proven in another store.
+	_, err = c.ABCIQueryWithOptions("/store/other/key", []byte("my-key"), opts)
+	assert.Error(t, err)
+
+	// The proof operators must be known to the proof runtime.
+	_, err = cNoOps.ABCIQueryWithOptions(path, []byte("my-key"), opts)
+	assert.Error(t, err)
+
+	// The key path can be built from other query paths.
+	qres, err = cKeyPath.ABCIQueryWithOptions("/key", []byte("my-key"), opts)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("my-value"), qres.Response.Value)
+}
+
+func tamper(next *rpchttp.HTTP) rpcclient.Client {
+	return tamperingClient{next}
+}
+
+// tamperingClient is a full node client which forges tx_search results and
+// event headers.
+type tamperingClient struct {
+	*rpchttp.HTTP
+}
+
+func (c tamperingClient) TxSearch(query string, prove bool, page, perPage *int, orderBy string) (
+	*ctypes.ResultTxSearch, error) {
+	res, err := c.HTTP.TxSearch(query, prove, page, perPage, orderBy)
+	if err == nil {
+		for _, tx := range res.Txs {
+			tx.TxResult.Code = 1
+		}
+	}
+	return res, err
+}
+
+func (c tamperingClient) Validators(height *int64, page, perPage *int) (*ctypes.ResultValidators, error) {
+	res, err := c.HTTP.Validators(height, page, perPage)
+	if err == nil {
+		for _, val := range res.Validators {
+			val.VotingPower++
+		}
+	}
+	return res, err
+}
+
+func (c tamperingClient) Subscribe(ctx context.Context, subscriber, query string,
+	outCapacity ...int) (<-chan ctypes.ResultEvent, error) {
+	in, err := c.HTTP.Subscribe(ctx, subscriber, query, outCapacity...)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan ctypes.ResultEvent)
+	go func() {
+		for event := range in {
+			if data, ok := event.Data.(types.EventDataNewBlockHeader); ok {
+				data.Header.AppHash = []byte("forged")
+				event.Data = data
+			}
+			out <- event
+		}
+	}()
+	return out, nil
+}
+
+func TestTxSearch(t *testing.T) {
+	c := newClient(t)
+	tampered := newClientWithNext(t, tamper)
+
+	res, err := c.BroadcastTxCommit(types.Tx("search-key=search-value"))
+	require.NoError(t, err)
+	require.EqualValues(t, 0, res.DeliverTx.Code)
+	// The results of a height are in the header of the next one.
+	require.NoError(t, rpcclient.WaitForHeight(c, res.Height+1, nil))
+	query := fmt.Sprintf("tx.height = %d", res.Height)
+
+	sres, err := c.TxSearch(query, true, nil, nil, "")
+	require.NoError(t, err)
+	require.Len(t, sres.Txs, 1)
+	assert.Equal(t, res.Hash, sres.Txs[0].Hash)
+
+	_, err = tampered.TxSearch(query, true, nil, nil, "")
+	assert.Error(t, err)
+	// Without proofs, the results aren't verified.
+	_, err = tampered.TxSearch(query, false, nil, nil, "")
+	assert.NoError(t, err)
+}
+
+// txSearchForgingClient is a full node client which forges the tx_search
+// results with the given function.
+type txSearchForgingClient struct {
+	*rpchttp.HTTP
+	forge func(*ctypes.ResultTx)
+}
+
+func (c txSearchForgingClient) TxSearch(query string, prove bool, page, perPage *int, orderBy string) (
+	*ctypes.ResultTxSearch, error) {
+	res, err := c.HTTP.TxSearch(query, prove, page, perPage, orderBy)
+	if err == nil {
+		for _, tx := range res.Txs {
+			c.forge(tx)
+		}
+	}
+	return res, err
+}
+
+func TestTxSearchForged(t *testing.T) {
+	c := newClient(t)
+
+	res, err := c.BroadcastTxCommit(types.Tx("forged-key=forged-value"))
+	require.NoError(t, err)
+	require.EqualValues(t, 0, res.DeliverTx.Code)
+	// An invalid validator tx, whose result differs.
+	other, err := c.BroadcastTxCommit(types.Tx("val:invalid"))
+	require.NoError(t, err)
+	require.NotEqualValues(t, 0, other.DeliverTx.Code)
+	require.NoError(t, rpcclient.WaitForHeight(c, other.Height+1, nil))
+	query := fmt.Sprintf("tx.height = %d", res.Height)
+
+	// The index of the result doesn't match the index of its proof.
+	mismatched := newClientWithNext(t, func(next *rpchttp.HTTP) rpcclient.Client {
+		return txSearchForgingClient{next, func(tx *ctypes.ResultTx) { tx.Index++ }}
+	})
+	_, err = mismatched.TxSearch(query, true, nil, nil, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not match the index")
+
+	// The result is the one of another tx.
+	swapped := newClientWithNext(t, func(next *rpchttp.HTTP) rpcclient.Client {
+		return txSearchForgingClient{next, func(tx *ctypes.ResultTx) { tx.TxResult = other.DeliverTx }}
+	})
+	_, err = swapped.TxSearch(query, true, nil, nil, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not match with trusted block results")
+}
+
+func TestSubscribe(t *testing.T) {
+	query := types.QueryForEvent(types.EventNewBlockHeader).String()
+	for _, tc := range []struct {
+		name     string
+		wrap     func(*rpchttp.HTTP) rpcclient.Client
+		verified bool
+	}{
+		{"honest", nil, true},
+		{"tampered", tamper, false},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			c := newClientWithNext(t, tc.wrap)
+			require.NoError(t, c.Start())
+			t.Cleanup(func() { require.NoError(t, c.Stop()) })
+
+			out, err := c.Subscribe(context.Background(), "light-test", query)
+			require.NoError(t, err)
+			select {
+			case event := <-out:
+				require.True(t, tc.verified, "forged header delivered")
+				data, ok := event.Data.(types.EventDataNewBlockHeader)
+				require.True(t, ok)
+				assert.True(t, data.Header.Height > 0)
+			case <-time.After(2 * time.Second):
+				require.False(t, tc.verified, "no header delivered")
+			}
+		})
+	}
+}
+
+// countingClient is a full node client which counts the validators requests.
+type countingClient struct {
+	*rpchttp.HTTP
+	validatorsCalls int
+}
+
+func (c *countingClient) Validators(height *int64, page, perPage *int) (*ctypes.ResultValidators, error) {
+	c.validatorsCalls++
+	return c.HTTP.Validators(height, page, perPage)
+}
+
+func TestValidators(t *testing.T) {
+	var counting *countingClient
+	c := newClientWithNext(t, func(next *rpchttp.HTTP) rpcclient.Client {
+		counting = &countingClient{HTTP: next}
+		return counting
+	})
+	tampered := newClientWithNext(t, tamper)
+
+	res, err := c.Validators(nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Total)
+	assert.Len(t, res.Validators, 1)
+
+	// The verified set is served from the cache.
+	calls := counting.validatorsCalls
+	height := res.BlockHeight
+	perPage := 1
+	page := 1
+	res, err = c.Validators(&height, &page, &perPage)
+	require.NoError(t, err)
+	assert.Len(t, res.Validators, 1)
+	assert.Equal(t, calls, counting.validatorsCalls)
+
+	page = 2
+	_, err = c.Validators(&height, &page, &perPage)
+	assert.Error(t, err)
+
+	_, err = tampered.Validators(&height, nil, nil)
+	assert.Error(t, err)
+}
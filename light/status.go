@@ -0,0 +1,92 @@
+package light
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tendermint/tendermint/light/provider"
+)
+
+// Status describes the trusted state and the providers of a light client.
+type Status struct {
+	LatestTrustedHeight int64     `json:"latest_trusted_height"`
+	LatestTrustedTime   time.Time `json:"latest_trusted_time"`
+	// Time left before the latest trusted light block can't be used to verify
+	// new ones. Negative when the trusting period has ended.
+	TrustingPeriodRemaining time.Duration `json:"trusting_period_remaining"`
+
+	Primary        string          `json:"primary"`
+	Witnesses      []WitnessStatus `json:"witnesses"`
+	SpareWitnesses []string        `json:"spare_witnesses"`
+}
+
+// WitnessStatus is the health of a witness, as seen when cross-checking the
+// light blocks of the primary.
+type WitnessStatus struct {
+	Address string `json:"address"`
+	// Last time the witness sent a light block, if ever.
+	LastResponse time.Time `json:"last_response"`
+	// Error of the last request to the witness, if it failed.
+	LastError string `json:"last_error"`
+}
+
+// Status returns the status of the client at the given time. The latest
+// trusted height is 0 if there are no trusted light blocks.
+//
+// Safe for concurrent use by multiple goroutines.
+func (c *Client) Status(now time.Time) (*Status, error) {
+	status := &Status{}
+
+	height, err := c.LastTrustedHeight()
+	if err != nil {
+		return nil, fmt.Errorf("can't get last trusted height: %w", err)
+	}
+	if height > 0 {
+		l, err := c.trustedStore.LightBlock(height)
+		if err != nil {
+			return nil, fmt.Errorf("can't get last trusted light block: %w", err)
+		}
+		status.LatestTrustedHeight = l.Height
+		status.LatestTrustedTime = l.Time
+		status.TrustingPeriodRemaining = l.Time.Add(c.trustingPeriod).Sub(now)
+	}
+
+	// The providers can be read with the healthMutex alone, see Client.
+	c.healthMutex.Lock()
+	defer c.healthMutex.Unlock()
+
+	status.Primary = fmt.Sprint(c.primary)
+	status.Witnesses = make([]WitnessStatus, len(c.witnesses))
+	for i, w := range c.witnesses {
+		status.Witnesses[i] = WitnessStatus{Address: fmt.Sprint(w)}
+		if h, ok := c.health[w]; ok {
+			status.Witnesses[i].LastResponse = h.LastResponse
+			status.Witnesses[i].LastError = h.LastError
+		}
+	}
+	status.SpareWitnesses = make([]string, len(c.spareWitnesses))
+	for i, w := range c.spareWitnesses {
+		status.SpareWitnesses[i] = fmt.Sprint(w)
+	}
+
+	return status, nil
+}
+
+// recordWitnessHealth records the outcome of a light block request to the
+// witness.
+func (c *Client) recordWitnessHealth(witness provider.Provider, err error) {
+	c.healthMutex.Lock()
+	defer c.healthMutex.Unlock()
+
+	h, ok := c.health[witness]
+	if !ok {
+		h = &WitnessStatus{}
+		c.health[witness] = h
+	}
+	if err != nil {
+		h.LastError = err.Error()
+		return
+	}
+	h.LastResponse = time.Now()
+	h.LastError = ""
+}
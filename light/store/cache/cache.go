@@ -0,0 +1,191 @@
+package cache
+
+import (
+	"container/list"
+	"time"
+
+	tmsync "github.com/tendermint/tendermint/libs/sync"
+	"github.com/tendermint/tendermint/light/store"
+	"github.com/tendermint/tendermint/types"
+)
+
+type cachedLightBlock struct {
+	lb   *types.LightBlock
+	size int64
+}
+
+// cache is a Store which keeps the most recently used light blocks in memory
+// in front of a persistent Store. Writes go through to the persistent Store.
+type cache struct {
+	store.Store
+
+	mtx      tmsync.Mutex
+	maxBytes int64
+	bytes    int64
+	blocks   map[int64]*list.Element
+	list     *list.List // least recently used at the front
+	// gen is incremented by deletions and prunings, so that the light blocks
+	// read or written before them aren't cached after them.
+	gen uint64
+}
+
+var _ store.Store = (*cache)(nil)
+
+// New returns a Store which caches up to maxBytes of light blocks, by the
+// size of their protobuf encoding, in front of the given Store.
+func New(s store.Store, maxBytes int64) store.Store {
+	return &cache{
+		Store:    s,
+		maxBytes: maxBytes,
+		blocks:   make(map[int64]*list.Element),
+		list:     list.New(),
+	}
+}
+
+// SaveLightBlock persists the LightBlock to the underlying store and caches
+// it.
+//
+// Safe for concurrent use by multiple goroutines.
+func (c *cache) SaveLightBlock(lb *types.LightBlock) error {
+	gen := c.generation()
+	if err := c.Store.SaveLightBlock(lb); err != nil {
+		return err
+	}
+	c.push(lb, gen)
+	return nil
+}
+
+// DeleteLightBlock deletes the LightBlock from the underlying store and the
+// cache.
+//
+// Safe for concurrent use by multiple goroutines.
+func (c *cache) DeleteLightBlock(height int64) error {
+	if err := c.Store.DeleteLightBlock(height); err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	c.gen++
+	c.remove(height)
+	c.mtx.Unlock()
+	return nil
+}
+
+// LightBlock returns the cached LightBlock, or retrieves it from the
+// underlying store and caches it.
+//
+// Safe for concurrent use by multiple goroutines.
+func (c *cache) LightBlock(height int64) (*types.LightBlock, error) {
+	c.mtx.Lock()
+	if e, ok := c.blocks[height]; ok {
+		c.list.MoveToBack(e)
+		c.mtx.Unlock()
+		return e.Value.(*cachedLightBlock).lb, nil
+	}
+	gen := c.gen
+	c.mtx.Unlock()
+
+	lb, err := c.Store.LightBlock(height)
+	if err != nil {
+		return nil, err
+	}
+	c.push(lb, gen)
+	return lb, nil
+}
+
+// LightBlockBefore retrieves the LightBlock before the given height from the
+// underlying store and caches it.
+//
+// Safe for concurrent use by multiple goroutines.
+func (c *cache) LightBlockBefore(height int64) (*types.LightBlock, error) {
+	gen := c.generation()
+	lb, err := c.Store.LightBlockBefore(height)
+	if err != nil {
+		return nil, err
+	}
+	c.push(lb, gen)
+	return lb, nil
+}
+
+// Prune prunes the underlying store, and evicts the pruned light blocks.
+//
+// Safe for concurrent use by multiple goroutines.
+func (c *cache) Prune(size uint64) error {
+	if err := c.Store.Prune(size); err != nil {
+		return err
+	}
+	return c.evictPruned()
+}
+
+// PruneBefore prunes the underlying store, and evicts the pruned light
+// blocks.
+//
+// Safe for concurrent use by multiple goroutines.
+func (c *cache) PruneBefore(t time.Time) error {
+	if err := c.Store.PruneBefore(t); err != nil {
+		return err
+	}
+	return c.evictPruned()
+}
+
+// evictPruned evicts the light blocks before the first one of the underlying
+// store, as pruning removes the oldest light blocks.
+func (c *cache) evictPruned() error {
+	first, err := c.Store.FirstLightBlockHeight()
+	if err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.gen++
+	for height := range c.blocks {
+		if first == -1 || height < first {
+			c.remove(height)
+		}
+	}
+	return nil
+}
+
+func (c *cache) generation() uint64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.gen
+}
+
+// push caches the light block, unless it was deleted or pruned since the
+// given generation.
+func (c *cache) push(lb *types.LightBlock, gen uint64) {
+	pb, err := lb.ToProto()
+	if err != nil {
+		return
+	}
+	size := int64(pb.Size())
+	if size > c.maxBytes {
+		return
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.gen != gen {
+		return
+	}
+
+	c.remove(lb.Height)
+	for c.bytes+size > c.maxBytes {
+		c.remove(c.list.Front().Value.(*cachedLightBlock).lb.Height)
+	}
+	c.blocks[lb.Height] = c.list.PushBack(&cachedLightBlock{lb: lb, size: size})
+	c.bytes += size
+}
+
+// NOTE: requires the mtx locked.
+func (c *cache) remove(height int64) {
+	e, ok := c.blocks[height]
+	if !ok {
+		return
+	}
+	c.bytes -= e.Value.(*cachedLightBlock).size
+	delete(c.blocks, height)
+	c.list.Remove(e)
+}
@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dbm "github.com/tendermint/tm-db"
+
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/tmhash"
+	"github.com/tendermint/tendermint/light/store"
+	dbs "github.com/tendermint/tendermint/light/store/db"
+	tmversion "github.com/tendermint/tendermint/proto/tendermint/version"
+	"github.com/tendermint/tendermint/types"
+	"github.com/tendermint/tendermint/version"
+)
+
+func TestCache(t *testing.T) {
+	persistent, err := dbs.New(dbm.NewMemDB(), "TestCache")
+	require.NoError(t, err)
+	lbs := make([]*types.LightBlock, 5)
+	for i := range lbs {
+		lbs[i] = randLightBlock(int64(i + 1))
+	}
+	pb, err := lbs[0].ToProto()
+	require.NoError(t, err)
+	// Room for the 2 most recently used light blocks.
+	c := New(persistent, int64(2*pb.Size()+pb.Size()/2))
+
+	for _, lb := range lbs {
+		require.NoError(t, c.SaveLightBlock(lb))
+	}
+	assert.EqualValues(t, 5, c.Size())
+
+	// Light block 4 is used, so 5 is the least recently used one.
+	lb, err := c.LightBlock(4)
+	require.NoError(t, err)
+	assert.Equal(t, lbs[3], lb)
+	lb, err = c.LightBlock(3)
+	require.NoError(t, err)
+	assert.Equal(t, lbs[2].Hash(), lb.Hash())
+
+	// Only the cached light blocks are still found without the persistent store.
+	for height := int64(1); height <= 5; height++ {
+		require.NoError(t, persistent.DeleteLightBlock(height))
+	}
+	_, err = c.LightBlock(5)
+	assert.Error(t, err)
+	for _, height := range []int64{3, 4} {
+		lb, err = c.LightBlock(height)
+		require.NoError(t, err)
+		assert.EqualValues(t, height, lb.Height)
+	}
+
+	// Deleted and pruned light blocks are evicted.
+	for _, lb := range lbs {
+		require.NoError(t, c.SaveLightBlock(lb))
+	}
+	require.NoError(t, c.DeleteLightBlock(5))
+	_, err = c.LightBlock(5)
+	assert.Error(t, err)
+	require.NoError(t, c.Prune(1))
+	for height := int64(1); height <= 3; height++ {
+		_, err = c.LightBlock(height)
+		assert.Error(t, err, height)
+	}
+	lb, err = c.LightBlock(4)
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, lb.Height)
+
+	// Light blocks larger than the cache aren't cached.
+	c = New(persistent, 1)
+	_, err = c.LightBlock(4)
+	require.NoError(t, err)
+	require.NoError(t, persistent.DeleteLightBlock(4))
+	_, err = c.LightBlock(4)
+	assert.Error(t, err)
+}
+
+// pausingStore is a Store pausing after reading a light block, until resumed.
+type pausingStore struct {
+	store.Store
+	read, resume chan struct{}
+}
+
+func (s pausingStore) LightBlock(height int64) (*types.LightBlock, error) {
+	lb, err := s.Store.LightBlock(height)
+	s.read <- struct{}{}
+	<-s.resume
+	return lb, err
+}
+
+func TestCacheDeleteDuringRead(t *testing.T) {
+	persistent, err := dbs.New(dbm.NewMemDB(), "TestCacheDeleteDuringRead")
+	require.NoError(t, err)
+	lb := randLightBlock(1)
+	require.NoError(t, persistent.SaveLightBlock(lb))
+	pausing := pausingStore{Store: persistent, read: make(chan struct{}, 1), resume: make(chan struct{})}
+	c := New(pausing, 1<<20)
+
+	// The light block read before its deletion isn't cached after it.
+	done := make(chan error)
+	go func() {
+		_, err := c.LightBlock(1)
+		done <- err
+	}()
+	<-pausing.read
+	require.NoError(t, c.DeleteLightBlock(1))
+	close(pausing.resume)
+	require.NoError(t, <-done)
+
+	_, err = c.LightBlock(1)
+	assert.Error(t, err)
+}
+
+func randLightBlock(height int64) *types.LightBlock {
+	vals, _ := types.RandValidatorSet(2, 1)
+	return &types.LightBlock{
+		SignedHeader: &types.SignedHeader{
+			Header: &types.Header{
+				Version:            tmversion.Consensus{Block: version.BlockProtocol, App: 0},
+				ChainID:            "test",
+				Height:             height,
+				Time:               time.Now(),
+				LastCommitHash:     crypto.CRandBytes(tmhash.Size),
+				DataHash:           crypto.CRandBytes(tmhash.Size),
+				ValidatorsHash:     crypto.CRandBytes(tmhash.Size),
+				NextValidatorsHash: crypto.CRandBytes(tmhash.Size),
+				ConsensusHash:      crypto.CRandBytes(tmhash.Size),
+				AppHash:            crypto.CRandBytes(tmhash.Size),
+				LastResultsHash:    crypto.CRandBytes(tmhash.Size),
+				EvidenceHash:       crypto.CRandBytes(tmhash.Size),
+				ProposerAddress:    crypto.CRandBytes(crypto.AddressSize),
+			},
+			Commit: &types.Commit{},
+		},
+		ValidatorSet: vals,
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"time"
 
 	dbm "github.com/tendermint/tm-db"
 
@@ -14,29 +15,70 @@ import (
 	"github.com/tendermint/tendermint/types"
 )
 
-var (
-	sizeKey = []byte("size")
-)
-
 type dbs struct {
 	db     dbm.DB
 	prefix string
 
 	mtx  tmsync.RWMutex
-	size uint16
+	size uint64
 }
 
 // New returns a Store that wraps any DB (with an optional prefix in case you
 // want to use one DB with many light clients).
-func New(db dbm.DB, prefix string) store.Store {
+//
+// Stores written by previous versions, which kept a single uint16 size for
+// all the prefixes, are migrated by counting the light blocks of the prefix,
+// and the old size is deleted.
+func New(db dbm.DB, prefix string) (store.Store, error) {
+	s := &dbs{db: db, prefix: prefix}
+
+	bz, err := db.Get(s.sizeKey())
+	if err != nil {
+		return nil, fmt.Errorf("can't read the size: %w", err)
+	}
+	if len(bz) > 0 {
+		s.size = unmarshalSize(bz)
+		return s, nil
+	}
 
-	size := uint16(0)
-	bz, err := db.Get(sizeKey)
-	if err == nil && len(bz) > 0 {
-		size = unmarshalSize(bz)
+	s.size, err = s.countLightBlocks()
+	if err != nil {
+		return nil, fmt.Errorf("can't count the light blocks: %w", err)
+	}
+	b := db.NewBatch()
+	defer b.Close()
+	if s.size > 0 {
+		if err = b.Set(s.sizeKey(), marshalSize(s.size)); err != nil {
+			return nil, err
+		}
+	}
+	if err = b.Delete(legacySizeKey); err != nil {
+		return nil, err
 	}
+	if err = b.WriteSync(); err != nil {
+		return nil, fmt.Errorf("can't write the size: %w", err)
+	}
+
+	return s, nil
+}
+
+func (s *dbs) countLightBlocks() (uint64, error) {
+	itr, err := s.db.Iterator(
+		s.lbKey(1),
+		append(s.lbKey(1<<63-1), byte(0x00)),
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer itr.Close()
 
-	return &dbs{db: db, prefix: prefix, size: size}
+	size := uint64(0)
+	for ; itr.Valid(); itr.Next() {
+		if _, _, ok := parseLbKey(itr.Key()); ok {
+			size++
+		}
+	}
+	return size, itr.Error()
 }
 
 // SaveLightBlock persists LightBlock to the db.
@@ -60,18 +102,28 @@ func (s *dbs) SaveLightBlock(lb *types.LightBlock) error {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
+	// Overwriting a light block doesn't change the size.
+	size := s.size
+	exists, err := s.db.Has(s.lbKey(lb.Height))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		size++
+	}
+
 	b := s.db.NewBatch()
 	defer b.Close()
 	if err = b.Set(s.lbKey(lb.Height), lbBz); err != nil {
 		return err
 	}
-	if err = b.Set(sizeKey, marshalSize(s.size+1)); err != nil {
+	if err = b.Set(s.sizeKey(), marshalSize(size)); err != nil {
 		return err
 	}
 	if err = b.WriteSync(); err != nil {
 		return err
 	}
-	s.size++
+	s.size = size
 
 	return nil
 }
@@ -88,12 +140,20 @@ func (s *dbs) DeleteLightBlock(height int64) error {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
+	exists, err := s.db.Has(s.lbKey(height))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+
 	b := s.db.NewBatch()
 	defer b.Close()
 	if err := b.Delete(s.lbKey(height)); err != nil {
 		return err
 	}
-	if err := b.Set(sizeKey, marshalSize(s.size-1)); err != nil {
+	if err := b.Set(s.sizeKey(), marshalSize(s.size-1)); err != nil {
 		return err
 	}
 	if err := b.WriteSync(); err != nil {
@@ -120,8 +180,12 @@ func (s *dbs) LightBlock(height int64) (*types.LightBlock, error) {
 		return nil, store.ErrLightBlockNotFound
 	}
 
+	return unmarshalLightBlock(bz)
+}
+
+func unmarshalLightBlock(bz []byte) (*types.LightBlock, error) {
 	var lbpb tmproto.LightBlock
-	err = lbpb.Unmarshal(bz)
+	err := lbpb.Unmarshal(bz)
 	if err != nil {
 		return nil, fmt.Errorf("unmarshal error: %w", err)
 	}
@@ -217,22 +281,95 @@ func (s *dbs) LightBlockBefore(height int64) (*types.LightBlock, error) {
 	return nil, store.ErrLightBlockNotFound
 }
 
+// IterateLightBlocks calls fn with the LightBlocks from height from to height
+// to (inclusive), in ascending order, until fn returns false.
+//
+// Safe for concurrent use by multiple goroutines.
+func (s *dbs) IterateLightBlocks(from, to int64, fn func(*types.LightBlock) bool) error {
+	if from <= 0 || from > to {
+		panic(fmt.Sprintf("invalid range [%d, %d]", from, to))
+	}
+
+	itr, err := s.db.Iterator(
+		s.lbKey(from),
+		append(s.lbKey(to), byte(0x00)),
+	)
+	if err != nil {
+		return err
+	}
+	defer itr.Close()
+
+	for ; itr.Valid(); itr.Next() {
+		if _, _, ok := parseLbKey(itr.Key()); !ok {
+			continue
+		}
+		lb, err := unmarshalLightBlock(itr.Value())
+		if err != nil {
+			return err
+		}
+		if !fn(lb) {
+			break
+		}
+	}
+
+	return itr.Error()
+}
+
 // Prune prunes header & validator set pairs until there are only size pairs
 // left.
 //
 // Safe for concurrent use by multiple goroutines.
-func (s *dbs) Prune(size uint16) error {
-	// 1) Check how many we need to prune.
-	s.mtx.RLock()
-	sSize := s.size
-	s.mtx.RUnlock()
+func (s *dbs) Prune(size uint64) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
 
-	if sSize <= size { // nothing to prune
+	// 1) Check how many we need to prune.
+	if s.size <= size { // nothing to prune
 		return nil
 	}
-	numToPrune := sSize - size
+	numToPrune := s.size - size
 
-	// 2) Iterate over headers and perform a batch operation.
+	// 2) Delete the oldest pairs.
+	return s.pruneWhile(func(_ int64, _ []byte) (bool, error) {
+		if numToPrune == 0 {
+			return false, nil
+		}
+		numToPrune--
+		return true, nil
+	})
+}
+
+// PruneBefore prunes the header & validator set pairs with a time before t,
+// except the last one.
+//
+// Safe for concurrent use by multiple goroutines.
+func (s *dbs) PruneBefore(t time.Time) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	// The times of the headers increase with their heights.
+	left := s.size
+	return s.pruneWhile(func(_ int64, bz []byte) (bool, error) {
+		if left <= 1 {
+			return false, nil
+		}
+		lb, err := unmarshalLightBlock(bz)
+		if err != nil {
+			return false, err
+		}
+		if !lb.Time.Before(t) {
+			return false, nil
+		}
+		left--
+		return true, nil
+	})
+}
+
+// pruneWhile deletes the header & validator set pairs, from the oldest one,
+// while shouldPrune returns true, and updates the size.
+//
+// NOTE: requires the mtx locked.
+func (s *dbs) pruneWhile(shouldPrune func(height int64, bz []byte) (bool, error)) error {
 	itr, err := s.db.Iterator(
 		s.lbKey(1),
 		append(s.lbKey(1<<63-1), byte(0x00)),
@@ -245,37 +382,38 @@ func (s *dbs) Prune(size uint16) error {
 	b := s.db.NewBatch()
 	defer b.Close()
 
-	pruned := 0
-	for itr.Valid() && numToPrune > 0 {
-		key := itr.Key()
-		_, height, ok := parseLbKey(key)
-		if ok {
-			if err = b.Delete(s.lbKey(height)); err != nil {
-				return err
-			}
+	pruned := uint64(0)
+	for ; itr.Valid(); itr.Next() {
+		_, height, ok := parseLbKey(itr.Key())
+		if !ok {
+			continue
+		}
+		prune, err := shouldPrune(height, itr.Value())
+		if err != nil {
+			return err
+		}
+		if !prune {
+			break
+		}
+		if err = b.Delete(s.lbKey(height)); err != nil {
+			return err
 		}
-		itr.Next()
-		numToPrune--
 		pruned++
 	}
 	if err = itr.Error(); err != nil {
 		return err
 	}
+	if pruned == 0 {
+		return nil
+	}
 
-	err = b.WriteSync()
-	if err != nil {
+	if err = b.Set(s.sizeKey(), marshalSize(s.size-pruned)); err != nil {
 		return err
 	}
-
-	// 3) Update size.
-	s.mtx.Lock()
-	defer s.mtx.Unlock()
-
-	s.size -= uint16(pruned)
-
-	if wErr := s.db.SetSync(sizeKey, marshalSize(s.size)); wErr != nil {
-		return fmt.Errorf("failed to persist size: %w", wErr)
+	if err = b.WriteSync(); err != nil {
+		return err
 	}
+	s.size -= pruned
 
 	return nil
 }
@@ -283,12 +421,19 @@ func (s *dbs) Prune(size uint16) error {
 // Size returns the number of header & validator set pairs.
 //
 // Safe for concurrent use by multiple goroutines.
-func (s *dbs) Size() uint16 {
+func (s *dbs) Size() uint64 {
 	s.mtx.RLock()
 	defer s.mtx.RUnlock()
 	return s.size
 }
 
+// legacySizeKey is the key of the size of all the prefixes, in previous versions.
+var legacySizeKey = []byte("size")
+
+func (s *dbs) sizeKey() []byte {
+	return []byte(fmt.Sprintf("size/%s", s.prefix))
+}
+
 func (s *dbs) lbKey(height int64) []byte {
 	return []byte(fmt.Sprintf("lb/%s/%020d", s.prefix, height))
 }
@@ -319,12 +464,12 @@ func parseLbKey(key []byte) (prefix string, height int64, ok bool) {
 	return
 }
 
-func marshalSize(size uint16) []byte {
-	bs := make([]byte, 2)
-	binary.LittleEndian.PutUint16(bs, size)
+func marshalSize(size uint64) []byte {
+	bs := make([]byte, 8)
+	binary.LittleEndian.PutUint64(bs, size)
 	return bs
 }
 
-func unmarshalSize(bz []byte) uint16 {
-	return binary.LittleEndian.Uint16(bz)
+func unmarshalSize(bz []byte) uint64 {
+	return binary.LittleEndian.Uint64(bz)
 }
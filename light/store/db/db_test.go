@@ -1,6 +1,7 @@
 package db
 
 import (
+	"errors"
 	"sync"
 	"testing"
 	"time"
@@ -13,13 +14,14 @@ import (
 	"github.com/tendermint/tendermint/crypto"
 	"github.com/tendermint/tendermint/crypto/tmhash"
 	tmrand "github.com/tendermint/tendermint/libs/rand"
+	"github.com/tendermint/tendermint/light/store"
 	tmversion "github.com/tendermint/tendermint/proto/tendermint/version"
 	"github.com/tendermint/tendermint/types"
 	"github.com/tendermint/tendermint/version"
 )
 
 func TestLast_FirstLightBlockHeight(t *testing.T) {
-	dbStore := New(dbm.NewMemDB(), "TestLast_FirstLightBlockHeight")
+	dbStore := newStore(t, dbm.NewMemDB(), "TestLast_FirstLightBlockHeight")
 
 	// Empty store
 	height, err := dbStore.LastLightBlockHeight()
@@ -44,7 +46,7 @@ func TestLast_FirstLightBlockHeight(t *testing.T) {
 }
 
 func Test_SaveLightBlock(t *testing.T) {
-	dbStore := New(dbm.NewMemDB(), "Test_SaveLightBlockAndValidatorSet")
+	dbStore := newStore(t, dbm.NewMemDB(), "Test_SaveLightBlockAndValidatorSet")
 
 	// Empty store
 	h, err := dbStore.LightBlock(1)
@@ -56,13 +58,18 @@ func Test_SaveLightBlock(t *testing.T) {
 	require.NoError(t, err)
 
 	size := dbStore.Size()
-	assert.Equal(t, uint16(1), size)
+	assert.Equal(t, uint64(1), size)
 	t.Log(size)
 
 	h, err = dbStore.LightBlock(1)
 	require.NoError(t, err)
 	assert.NotNil(t, h)
 
+	// Overwriting the light block doesn't change the size
+	err = dbStore.SaveLightBlock(randLightBlock(1))
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, dbStore.Size())
+
 	// Empty store
 	err = dbStore.DeleteLightBlock(1)
 	require.NoError(t, err)
@@ -70,11 +77,16 @@ func Test_SaveLightBlock(t *testing.T) {
 	h, err = dbStore.LightBlock(1)
 	require.Error(t, err)
 	assert.Nil(t, h)
+	assert.EqualValues(t, 0, dbStore.Size())
 
+	// Deleting a missing light block doesn't change the size
+	err = dbStore.DeleteLightBlock(1)
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, dbStore.Size())
 }
 
 func Test_LightBlockBefore(t *testing.T) {
-	dbStore := New(dbm.NewMemDB(), "Test_LightBlockBefore")
+	dbStore := newStore(t, dbm.NewMemDB(), "Test_LightBlockBefore")
 
 	assert.Panics(t, func() {
 		_, _ = dbStore.LightBlockBefore(0)
@@ -92,7 +104,7 @@ func Test_LightBlockBefore(t *testing.T) {
 }
 
 func Test_Prune(t *testing.T) {
-	dbStore := New(dbm.NewMemDB(), "Test_Prune")
+	dbStore := newStore(t, dbm.NewMemDB(), "Test_Prune")
 
 	// Empty store
 	assert.EqualValues(t, 0, dbStore.Size())
@@ -128,8 +140,107 @@ func Test_Prune(t *testing.T) {
 	assert.EqualValues(t, 7, dbStore.Size())
 }
 
+func Test_PruneBefore(t *testing.T) {
+	dbStore := newStore(t, dbm.NewMemDB(), "Test_PruneBefore")
+	t0 := time.Now()
+
+	for i := 1; i <= 10; i++ {
+		lb := randLightBlock(int64(i))
+		lb.Time = t0.Add(time.Duration(i) * time.Hour)
+		err := dbStore.SaveLightBlock(lb)
+		require.NoError(t, err)
+	}
+
+	// Light blocks 1 to 4 are older than 5h
+	err := dbStore.PruneBefore(t0.Add(5 * time.Hour))
+	require.NoError(t, err)
+	assert.EqualValues(t, 6, dbStore.Size())
+	height, err := dbStore.FirstLightBlockHeight()
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, height)
+
+	// The last light block is kept
+	err = dbStore.PruneBefore(t0.Add(24 * time.Hour))
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, dbStore.Size())
+	height, err = dbStore.FirstLightBlockHeight()
+	require.NoError(t, err)
+	assert.EqualValues(t, 10, height)
+}
+
+func Test_IterateLightBlocks(t *testing.T) {
+	dbStore := newStore(t, dbm.NewMemDB(), "Test_IterateLightBlocks")
+
+	for _, height := range []int64{1, 2, 4, 5, 7} {
+		err := dbStore.SaveLightBlock(randLightBlock(height))
+		require.NoError(t, err)
+	}
+
+	assert.Panics(t, func() {
+		_ = dbStore.IterateLightBlocks(0, 1, func(*types.LightBlock) bool { return true })
+	})
+
+	var heights []int64
+	err := dbStore.IterateLightBlocks(2, 5, func(lb *types.LightBlock) bool {
+		heights = append(heights, lb.Height)
+		return true
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2, 4, 5}, heights)
+
+	// Iteration stops when fn returns false
+	heights = nil
+	err = dbStore.IterateLightBlocks(1, 1<<63-1, func(lb *types.LightBlock) bool {
+		heights = append(heights, lb.Height)
+		return lb.Height < 4
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 4}, heights)
+}
+
+func Test_MigrateSize(t *testing.T) {
+	db := dbm.NewMemDB()
+	dbStore := newStore(t, db, "a")
+	for i := 1; i <= 3; i++ {
+		err := dbStore.SaveLightBlock(randLightBlock(int64(i)))
+		require.NoError(t, err)
+	}
+	err := newStore(t, db, "b").SaveLightBlock(randLightBlock(1))
+	require.NoError(t, err)
+
+	// Previous versions kept a single uint16 size for all the prefixes.
+	require.NoError(t, db.Delete([]byte("size/a")))
+	require.NoError(t, db.Delete([]byte("size/b")))
+	require.NoError(t, db.Set([]byte("size"), []byte{4, 0}))
+
+	assert.EqualValues(t, 3, newStore(t, db, "a").Size())
+	assert.EqualValues(t, 1, newStore(t, db, "b").Size())
+	bz, err := db.Get([]byte("size/a"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), unmarshalSize(bz))
+
+	// the old size is deleted
+	has, err := db.Has([]byte("size"))
+	require.NoError(t, err)
+	assert.False(t, has)
+}
+
+// failingDB is a DB failing all the reads.
+type failingDB struct {
+	*dbm.MemDB
+}
+
+func (db failingDB) Get([]byte) ([]byte, error) {
+	return nil, errors.New("read failed")
+}
+
+func Test_NewError(t *testing.T) {
+	_, err := New(failingDB{dbm.NewMemDB()}, "a")
+	assert.Error(t, err)
+}
+
 func Test_Concurrency(t *testing.T) {
-	dbStore := New(dbm.NewMemDB(), "Test_Prune")
+	dbStore := newStore(t, dbm.NewMemDB(), "Test_Prune")
 
 	var wg sync.WaitGroup
 	for i := 1; i <= 100; i++ {
@@ -195,3 +306,10 @@ func randLightBlock(height int64) *types.LightBlock {
 		ValidatorSet: vals,
 	}
 }
+
+func newStore(t *testing.T, db dbm.DB, prefix string) store.Store {
+	t.Helper()
+	s, err := New(db, prefix)
+	require.NoError(t, err)
+	return s
+}
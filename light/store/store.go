@@ -1,6 +1,10 @@
 package store
 
-import "github.com/tendermint/tendermint/types"
+import (
+	"time"
+
+	"github.com/tendermint/tendermint/types"
+)
 
 // Store is anything that can persistently store headers.
 type Store interface {
@@ -39,10 +43,20 @@ type Store interface {
 	// height must be > 0 && <= LastLightBlockHeight.
 	LightBlockBefore(height int64) (*types.LightBlock, error)
 
+	// IterateLightBlocks calls fn with the LightBlocks from height from to
+	// height to (inclusive), in ascending order, until fn returns false.
+	//
+	// from must be > 0 && <= to.
+	IterateLightBlocks(from, to int64, fn func(*types.LightBlock) bool) error
+
 	// Prune removes headers & the associated validator sets when Store reaches a
 	// defined size (number of header & validator set pairs).
-	Prune(size uint16) error
+	Prune(size uint64) error
+
+	// PruneBefore removes the headers & the associated validator sets with a
+	// time before t, except the last one.
+	PruneBefore(t time.Time) error
 
 	// Size returns a number of currently existing header & validator set pairs.
-	Size() uint16
+	Size() uint64
 }
@@ -8,6 +8,7 @@ import (
 	"net"
 	"net/http"
 	_ "net/http/pprof" // nolint: gosec // securely exposed on separate, optional port
+	"os"
 	"strings"
 	"time"
 
@@ -18,6 +19,7 @@ import (
 	dbm "github.com/tendermint/tm-db"
 
 	abci "github.com/tendermint/tendermint/abci/types"
+	bc "github.com/tendermint/tendermint/blockchain"
 	bcv0 "github.com/tendermint/tendermint/blockchain/v0"
 	bcv1 "github.com/tendermint/tendermint/blockchain/v1"
 	bcv2 "github.com/tendermint/tendermint/blockchain/v2"
@@ -29,6 +31,7 @@ import (
 	"github.com/tendermint/tendermint/libs/log"
 	tmpubsub "github.com/tendermint/tendermint/libs/pubsub"
 	"github.com/tendermint/tendermint/libs/service"
+	"github.com/tendermint/tendermint/libs/trace"
 	"github.com/tendermint/tendermint/light"
 	mempl "github.com/tendermint/tendermint/mempool"
 	"github.com/tendermint/tendermint/p2p"
@@ -103,20 +106,27 @@ func DefaultNewNode(config *cfg.Config, logger log.Logger) (*Node, error) {
 	)
 }
 
-// MetricsProvider returns a consensus, p2p and mempool Metrics.
-type MetricsProvider func(chainID string) (*cs.Metrics, *p2p.Metrics, *mempl.Metrics, *sm.Metrics)
+// MetricsProvider returns a consensus, p2p, mempool, state, blockchain, state
+// sync and proxy Metrics.
+type MetricsProvider func(chainID string) (*cs.Metrics, *p2p.Metrics, *mempl.Metrics, *sm.Metrics, *bc.Metrics,
+	*statesync.Metrics, *proxy.Metrics)
 
 // DefaultMetricsProvider returns Metrics build using Prometheus client library
 // if Prometheus is enabled. Otherwise, it returns no-op Metrics.
 func DefaultMetricsProvider(config *cfg.InstrumentationConfig) MetricsProvider {
-	return func(chainID string) (*cs.Metrics, *p2p.Metrics, *mempl.Metrics, *sm.Metrics) {
+	return func(chainID string) (*cs.Metrics, *p2p.Metrics, *mempl.Metrics, *sm.Metrics, *bc.Metrics,
+		*statesync.Metrics, *proxy.Metrics) {
 		if config.Prometheus {
 			return cs.PrometheusMetrics(config.Namespace, "chain_id", chainID),
 				p2p.PrometheusMetrics(config.Namespace, "chain_id", chainID),
 				mempl.PrometheusMetrics(config.Namespace, "chain_id", chainID),
-				sm.PrometheusMetrics(config.Namespace, "chain_id", chainID)
+				sm.PrometheusMetrics(config.Namespace, "chain_id", chainID),
+				bc.PrometheusMetrics(config.Namespace, "chain_id", chainID),
+				statesync.PrometheusMetrics(config.Namespace, "chain_id", chainID),
+				proxy.PrometheusMetrics(config.Namespace, "chain_id", chainID)
 		}
-		return cs.NopMetrics(), p2p.NopMetrics(), mempl.NopMetrics(), sm.NopMetrics()
+		return cs.NopMetrics(), p2p.NopMetrics(), mempl.NopMetrics(), sm.NopMetrics(), bc.NopMetrics(),
+			statesync.NopMetrics(), proxy.NopMetrics()
 	}
 }
 
@@ -202,7 +212,11 @@ type Node struct {
 	rpcListeners      []net.Listener          // rpc servers
 	txIndexer         txindex.TxIndexer
 	indexerService    *txindex.IndexerService
+	pruner            *sm.Pruner        // nil if the retention policy keeps everything
+	uptimeTracker     *sm.UptimeTracker // nil if the uptime of the validators isn't tracked
 	prometheusSrv     *http.Server
+	tracer            *trace.Tracer
+	tracesFile        *os.File // nil unless the traces are exported to a file
 }
 
 func initDBs(config *cfg.Config, dbProvider DBProvider) (blockStore *store.BlockStore, stateDB dbm.DB, err error) {
@@ -221,8 +235,9 @@ func initDBs(config *cfg.Config, dbProvider DBProvider) (blockStore *store.Block
 	return
 }
 
-func createAndStartProxyAppConns(clientCreator proxy.ClientCreator, logger log.Logger) (proxy.AppConns, error) {
-	proxyApp := proxy.NewAppConns(clientCreator)
+func createAndStartProxyAppConns(clientCreator proxy.ClientCreator, metrics *proxy.Metrics,
+	logger log.Logger) (proxy.AppConns, error) {
+	proxyApp := proxy.NewAppConns(clientCreator, metrics)
 	proxyApp.SetLogger(logger.With("module", "proxy"))
 	if err := proxyApp.Start(); err != nil {
 		return nil, fmt.Errorf("error starting proxy app connections: %v", err)
@@ -230,6 +245,33 @@ func createAndStartProxyAppConns(clientCreator proxy.ClientCreator, logger log.L
 	return proxyApp, nil
 }
 
+func createAndStartTracer(config *cfg.Config, logger log.Logger) (*trace.Tracer, *os.File, error) {
+	var (
+		exporter trace.Exporter
+		file     *os.File
+	)
+	switch config.Instrumentation.TracingExporter {
+	case cfg.TracingExporterOTLP:
+		exporter = trace.NewOTLPExporter(config.Instrumentation.TracingEndpoint, config.Moniker)
+	case cfg.TracingExporterFile:
+		var err error
+		file, err = os.OpenFile(config.Instrumentation.TracingFilePath(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open traces file: %w", err)
+		}
+		exporter = trace.NewFileExporter(file, config.Moniker)
+	default:
+		return trace.NopTracer(), nil, nil
+	}
+
+	tracer := trace.NewTracer(exporter)
+	tracer.SetLogger(logger.With("module", "trace"))
+	if err := tracer.Start(); err != nil {
+		return nil, nil, err
+	}
+	return tracer, file, nil
+}
+
 func createAndStartEventBus(logger log.Logger) (*types.EventBus, error) {
 	eventBus := types.NewEventBus()
 	eventBus.SetLogger(logger.With("module", "events"))
@@ -324,7 +366,7 @@ func createMempoolAndMempoolReactor(config *cfg.Config, proxyApp proxy.AppConns,
 		mempl.WithPreCheck(sm.TxPreCheck(state)),
 		mempl.WithPostCheck(sm.TxPostCheck(state)),
 	)
-	mempoolLogger := logger.With("module", "mempool")
+	mempoolLogger := limitDebugLogRate(config, logger.With("module", "mempool"))
 	mempoolReactor := mempl.NewReactor(config.Mempool, mempool)
 	mempoolReactor.SetLogger(mempoolLogger)
 
@@ -334,6 +376,15 @@ func createMempoolAndMempoolReactor(config *cfg.Config, proxyApp proxy.AppConns,
 	return mempoolReactor, mempool
 }
 
+// limitDebugLogRate limits the rate of the debug log events of the noisy
+// modules, if log_debug_rate_limit is set.
+func limitDebugLogRate(config *cfg.Config, logger log.Logger) log.Logger {
+	if config.LogDebugRateLimit > 0 {
+		return log.NewRateLimitedLogger(logger, config.LogDebugRateLimit)
+	}
+	return logger
+}
+
 func createEvidenceReactor(config *cfg.Config, dbProvider DBProvider,
 	stateDB dbm.DB, blockStore *store.BlockStore, logger log.Logger) (*evidence.Reactor, *evidence.Pool, error) {
 
@@ -356,11 +407,13 @@ func createBlockchainReactor(config *cfg.Config,
 	blockExec *sm.BlockExecutor,
 	blockStore *store.BlockStore,
 	fastSync bool,
+	bcMetrics *bc.Metrics,
 	logger log.Logger) (bcReactor p2p.Reactor, err error) {
 
 	switch config.FastSync.Version {
 	case "v0":
-		bcReactor = bcv0.NewBlockchainReactor(state.Copy(), blockExec, blockStore, fastSync)
+		bcReactor = bcv0.NewBlockchainReactor(state.Copy(), blockExec, blockStore, fastSync,
+			bcv0.ReactorMetrics(bcMetrics))
 	case "v1":
 		bcReactor = bcv1.NewBlockchainReactor(state.Copy(), blockExec, blockStore, fastSync)
 	case "v2":
@@ -381,10 +434,16 @@ func createConsensusReactor(config *cfg.Config,
 	evidencePool *evidence.Pool,
 	privValidator types.PrivValidator,
 	csMetrics *cs.Metrics,
+	tracer *trace.Tracer,
 	waitSync bool,
 	eventBus *types.EventBus,
 	consensusLogger log.Logger) (*cs.Reactor, *cs.State) {
 
+	options := []cs.StateOption{cs.StateMetrics(csMetrics), cs.StateTracer(tracer)}
+	if config.Pruning.Enabled() {
+		// The pruner also prunes the blocks the application doesn't need anymore.
+		options = append(options, cs.StateWithoutPruning())
+	}
 	consensusState := cs.NewState(
 		config.Consensus,
 		state.Copy(),
@@ -392,7 +451,7 @@ func createConsensusReactor(config *cfg.Config,
 		blockStore,
 		mempool,
 		evidencePool,
-		cs.StateMetrics(csMetrics),
+		options...,
 	)
 	consensusState.SetLogger(consensusLogger)
 	if privValidator != nil {
@@ -564,13 +623,20 @@ func startStateSync(ssR *statesync.Reactor, bcR fastSyncReactor, conR *cs.Reacto
 
 	if stateProvider == nil {
 		var err error
-		stateProvider, err = statesync.NewLightClientStateProvider(
-			state.ChainID, state.Version, state.InitialHeight,
-			config.RPCServers, light.TrustOptions{
-				Period: config.TrustPeriod,
-				Height: config.TrustHeight,
-				Hash:   config.TrustHashBytes(),
-			}, ssR.Logger.With("module", "light"))
+		trustOptions := light.TrustOptions{
+			Period: config.TrustPeriod,
+			Height: config.TrustHeight,
+			Hash:   config.TrustHashBytes(),
+		}
+		if config.UseP2P {
+			stateProvider, err = ssR.NewP2PStateProvider(
+				state.ChainID, state.Version, state.InitialHeight,
+				trustOptions, ssR.Logger.With("module", "light"))
+		} else {
+			stateProvider, err = statesync.NewLightClientStateProvider(
+				state.ChainID, state.Version, state.InitialHeight,
+				config.RPCServers, trustOptions, ssR.Logger.With("module", "light"))
+		}
 		if err != nil {
 			return fmt.Errorf("failed to set up light client state provider: %w", err)
 		}
@@ -593,6 +659,13 @@ func startStateSync(ssR *statesync.Reactor, bcR fastSyncReactor, conR *cs.Reacto
 			return
 		}
 
+		// Backfill the headers needed to verify evidence in the background.
+		go func() {
+			if err := ssR.Backfill(state); err != nil {
+				ssR.Logger.Error("Failed to backfill headers", "err", err)
+			}
+		}()
+
 		if fastSync {
 			// FIXME Very ugly to have these metrics bleed through here.
 			conR.Metrics.StateSyncing.Set(0)
@@ -632,8 +705,18 @@ func NewNode(config *cfg.Config,
 		return nil, err
 	}
 
+	// Trace the consensus steps, ABCI requests, block executions and RPC calls,
+	// if enabled.
+	tracer, tracesFile, err := createAndStartTracer(config, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	csMetrics, p2pMetrics, memplMetrics, smMetrics, bcMetrics, ssMetrics, proxyMetrics := metricsProvider(genDoc.ChainID)
+
 	// Create the proxyApp and establish connections to the ABCI app (consensus, mempool, query).
-	proxyApp, err := createAndStartProxyAppConns(clientCreator, logger)
+	proxyApp, err := createAndStartProxyAppConns(proxy.NewTracingClientCreator(clientCreator, tracer), proxyMetrics,
+		logger)
 	if err != nil {
 		return nil, err
 	}
@@ -698,8 +781,6 @@ func NewNode(config *cfg.Config,
 
 	logNodeStartupInfo(state, pubKey, logger, consensusLogger)
 
-	csMetrics, p2pMetrics, memplMetrics, smMetrics := metricsProvider(genDoc.ChainID)
-
 	// Make MempoolReactor
 	mempoolReactor, mempool := createMempoolAndMempoolReactor(config, proxyApp, state, memplMetrics, logger)
 
@@ -709,6 +790,23 @@ func NewNode(config *cfg.Config,
 		return nil, err
 	}
 
+	// Make the uptime tracker, tracking the validators who signed the blocks executed
+	blockExecOptions := []sm.BlockExecutorOption{
+		sm.BlockExecutorWithMetrics(smMetrics),
+		sm.BlockExecutorWithTracer(tracer),
+	}
+	var uptimeTracker *sm.UptimeTracker
+	if config.Instrumentation.ValidatorUptimeWindow > 0 {
+		uptimeTracker, err = sm.NewUptimeTracker(stateStore, config.Instrumentation.ValidatorUptimeWindow,
+			config.Instrumentation.ValidatorMissedBlocksThreshold,
+			sm.UptimeTrackerWithMetrics(smMetrics), sm.UptimeTrackerWithLocalValidator(pubKey.Address()))
+		if err != nil {
+			return nil, fmt.Errorf("could not create uptime tracker: %w", err)
+		}
+		uptimeTracker.SetLogger(logger.With("module", "uptime"))
+		blockExecOptions = append(blockExecOptions, sm.BlockExecutorWithUptimeTracker(uptimeTracker))
+	}
+
 	// make block executor for consensus and blockchain reactors to execute blocks
 	blockExec := sm.NewBlockExecutor(
 		stateStore,
@@ -716,11 +814,18 @@ func NewNode(config *cfg.Config,
 		proxyApp.Consensus(),
 		mempool,
 		evidencePool,
-		sm.BlockExecutorWithMetrics(smMetrics),
+		blockExecOptions...,
 	)
 
+	// Make the pruner, enforcing the retention policy in the background
+	var pruner *sm.Pruner
+	if config.Pruning.Enabled() {
+		pruner = sm.NewPruner(config.Pruning, stateStore, blockStore, txIndexer, sm.PrunerWithMetrics(smMetrics))
+		pruner.SetLogger(logger.With("module", "pruner"))
+	}
+
 	// Make BlockchainReactor. Don't start fast sync if we're doing a state sync first.
-	bcReactor, err := createBlockchainReactor(config, state, blockExec, blockStore, fastSync && !stateSync, logger)
+	bcReactor, err := createBlockchainReactor(config, state, blockExec, blockStore, fastSync && !stateSync, bcMetrics, logger)
 	if err != nil {
 		return nil, fmt.Errorf("could not create blockchain reactor: %w", err)
 	}
@@ -734,7 +839,7 @@ func NewNode(config *cfg.Config,
 	}
 	consensusReactor, consensusState := createConsensusReactor(
 		config, state, blockExec, blockStore, mempool, evidencePool,
-		privValidator, csMetrics, stateSync || fastSync, eventBus, consensusLogger,
+		privValidator, csMetrics, tracer, stateSync || fastSync, eventBus, consensusLogger,
 	)
 
 	// Set up state sync reactor, and schedule a sync if requested.
@@ -742,7 +847,7 @@ func NewNode(config *cfg.Config,
 	// we should clean this whole thing up. See:
 	// https://github.com/tendermint/tendermint/issues/4644
 	stateSyncReactor := statesync.NewReactor(proxyApp.Snapshot(), proxyApp.Query(),
-		config.StateSync.TempDir)
+		stateStore, blockStore, config.StateSync.TempDir, statesync.ReactorMetrics(ssMetrics))
 	stateSyncReactor.SetLogger(logger.With("module", "statesync"))
 
 	nodeInfo, err := makeNodeInfo(config, nodeKey, txIndexer, genDoc, state)
@@ -754,7 +859,7 @@ func NewNode(config *cfg.Config,
 	transport, peerFilters := createTransport(config, nodeInfo, nodeKey, proxyApp)
 
 	// Setup Switch.
-	p2pLogger := logger.With("module", "p2p")
+	p2pLogger := limitDebugLogRate(config, logger.With("module", "p2p"))
 	sw := createSwitch(
 		config, transport, p2pMetrics, peerFilters, mempoolReactor, bcReactor,
 		stateSyncReactor, consensusReactor, evidenceReactor, nodeInfo, nodeKey, p2pLogger,
@@ -825,7 +930,11 @@ func NewNode(config *cfg.Config,
 		proxyApp:         proxyApp,
 		txIndexer:        txIndexer,
 		indexerService:   indexerService,
+		pruner:           pruner,
+		uptimeTracker:    uptimeTracker,
 		eventBus:         eventBus,
+		tracer:           tracer,
+		tracesFile:       tracesFile,
 	}
 	node.BaseService = *service.NewBaseService(logger, "Node", node)
 
@@ -881,6 +990,12 @@ func (n *Node) OnStart() error {
 		}
 	}
 
+	if n.pruner != nil {
+		if err := n.pruner.Start(); err != nil {
+			return fmt.Errorf("failed to start pruner: %w", err)
+		}
+	}
+
 	// Start the switch (the P2P server).
 	err = n.sw.Start()
 	if err != nil {
@@ -922,6 +1037,11 @@ func (n *Node) OnStop() {
 	if err := n.indexerService.Stop(); err != nil {
 		n.Logger.Error("Error closing indexerService", "err", err)
 	}
+	if n.pruner != nil {
+		if err := n.pruner.Stop(); err != nil {
+			n.Logger.Error("Error closing pruner", "err", err)
+		}
+	}
 
 	// now stop the reactors
 	if err := n.sw.Stop(); err != nil {
@@ -959,6 +1079,18 @@ func (n *Node) OnStop() {
 			n.Logger.Error("Prometheus HTTP server Shutdown", "err", err)
 		}
 	}
+
+	// export the last traces
+	if n.tracer.IsRunning() {
+		if err := n.tracer.Stop(); err != nil {
+			n.Logger.Error("Error closing tracer", "err", err)
+		}
+	}
+	if n.tracesFile != nil {
+		if err := n.tracesFile.Close(); err != nil {
+			n.Logger.Error("Error closing traces file", "err", err)
+		}
+	}
 }
 
 // ConfigureRPC makes sure RPC has all the objects it needs to operate.
@@ -981,13 +1113,15 @@ func (n *Node) ConfigureRPC() error {
 		PubKey:           pubKey,
 		GenDoc:           n.genesisDoc,
 		TxIndexer:        n.txIndexer,
+		UptimeTracker:    n.uptimeTracker,
 		ConsensusReactor: n.consensusReactor,
 		EventBus:         n.eventBus,
 		Mempool:          n.mempool,
 
 		Logger: n.Logger.With("module", "rpc"),
 
-		Config: *n.config.RPC,
+		Config:            *n.config.RPC,
+		ExpectedBlockTime: n.config.Consensus.ExpectedBlockTime(),
 	})
 	return nil
 }
@@ -1021,7 +1155,8 @@ func (n *Node) startRPC() ([]net.Listener, error) {
 		mux := http.NewServeMux()
 		rpcLogger := n.Logger.With("module", "rpc-server")
 		wmLogger := rpcLogger.With("protocol", "websocket")
-		wm := rpcserver.NewWebsocketManager(rpccore.Routes,
+		routes := rpcserver.TraceRPCFuncs(rpccore.Routes, n.tracer)
+		wm := rpcserver.NewWebsocketManager(routes,
 			rpcserver.OnDisconnect(func(remoteAddr string) {
 				err := n.eventBus.UnsubscribeAll(context.Background(), remoteAddr)
 				if err != nil && err != tmpubsub.ErrSubscriptionNotFound {
@@ -1032,7 +1167,9 @@ func (n *Node) startRPC() ([]net.Listener, error) {
 		)
 		wm.SetLogger(wmLogger)
 		mux.HandleFunc("/websocket", wm.WebsocketHandler)
-		rpcserver.RegisterRPCFuncs(mux, rpccore.Routes, rpcLogger)
+		mux.HandleFunc("/health/live", rpccore.LiveHandler)
+		mux.HandleFunc("/health/ready", rpccore.ReadyHandler)
+		rpcserver.RegisterRPCFuncs(mux, routes, rpcLogger)
 		listener, err := rpcserver.Listen(
 			listenAddr,
 			config,
@@ -1252,7 +1389,7 @@ func makeNodeInfo(
 			cs.StateChannel, cs.DataChannel, cs.VoteChannel, cs.VoteSetBitsChannel,
 			mempl.MempoolChannel,
 			evidence.EvidenceChannel,
-			statesync.SnapshotChannel, statesync.ChunkChannel,
+			statesync.SnapshotChannel, statesync.ChunkChannel, statesync.LightBlockChannel,
 		},
 		Moniker: config.Moniker,
 		Other: p2p.DefaultNodeInfoOther{
@@ -1261,6 +1398,10 @@ func makeNodeInfo(
 		},
 	}
 
+	if config.FastSync.Version == "v0" {
+		nodeInfo.Channels = append(nodeInfo.Channels, bcv0.LightBlockChannel)
+	}
+
 	if config.P2P.PexReactor {
 		nodeInfo.Channels = append(nodeInfo.Channels, pex.PexChannel)
 	}
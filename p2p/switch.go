@@ -790,7 +790,7 @@ func (sw *Switch) addPeer(p Peer) error {
 		return err
 	}
 
-	p.SetLogger(sw.Logger.With("peer", p.SocketAddr()))
+	p.SetLogger(sw.Logger.With("peer", p.ID(), "addr", p.SocketAddr().DialString()))
 
 	// Handle the shut down case where the switch has stopped but we're
 	// concurrently trying to add a peer.
@@ -0,0 +1,1776 @@
+// Code generated by protoc-gen-gogo. DO NOT EDIT.
+// source: tendermint/crypto/commitment.proto
+
+package crypto
+
+import (
+	fmt "fmt"
+	proto "github.com/gogo/protobuf/proto"
+	io "io"
+	math "math"
+	math_bits "math/bits"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
+
+// HashOp is the hash function applied by a LeafOp or InnerOp.
+type HashOp int32
+
+const (
+	HashOp_HASH_OP_NO_HASH HashOp = 0
+	HashOp_HASH_OP_SHA256  HashOp = 1
+)
+
+var HashOp_name = map[int32]string{
+	0: "HASH_OP_NO_HASH",
+	1: "HASH_OP_SHA256",
+}
+
+var HashOp_value = map[string]int32{
+	"HASH_OP_NO_HASH": 0,
+	"HASH_OP_SHA256":  1,
+}
+
+func (x HashOp) String() string {
+	return proto.EnumName(HashOp_name, int32(x))
+}
+
+func (HashOp) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_253c45e4477f7a54, []int{0}
+}
+
+// LengthOp is the length prefix applied to the key and value of a LeafOp.
+type LengthOp int32
+
+const (
+	LengthOp_LENGTH_OP_NO_PREFIX LengthOp = 0
+	LengthOp_LENGTH_OP_VAR_PROTO LengthOp = 1
+)
+
+var LengthOp_name = map[int32]string{
+	0: "LENGTH_OP_NO_PREFIX",
+	1: "LENGTH_OP_VAR_PROTO",
+}
+
+var LengthOp_value = map[string]int32{
+	"LENGTH_OP_NO_PREFIX": 0,
+	"LENGTH_OP_VAR_PROTO": 1,
+}
+
+func (x LengthOp) String() string {
+	return proto.EnumName(LengthOp_name, int32(x))
+}
+
+func (LengthOp) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_253c45e4477f7a54, []int{1}
+}
+
+// LeafOp hashes a key-value pair into a leaf:
+// hash(prefix || length(prehash_key(key)) || length(prehash_value(value))).
+type LeafOp struct {
+	Hash         HashOp   `protobuf:"varint,1,opt,name=hash,proto3,enum=tendermint.crypto.HashOp" json:"hash,omitempty"`
+	PrehashKey   HashOp   `protobuf:"varint,2,opt,name=prehash_key,json=prehashKey,proto3,enum=tendermint.crypto.HashOp" json:"prehash_key,omitempty"`
+	PrehashValue HashOp   `protobuf:"varint,3,opt,name=prehash_value,json=prehashValue,proto3,enum=tendermint.crypto.HashOp" json:"prehash_value,omitempty"`
+	Length       LengthOp `protobuf:"varint,4,opt,name=length,proto3,enum=tendermint.crypto.LengthOp" json:"length,omitempty"`
+	Prefix       []byte   `protobuf:"bytes,5,opt,name=prefix,proto3" json:"prefix,omitempty"`
+}
+
+func (m *LeafOp) Reset()         { *m = LeafOp{} }
+func (m *LeafOp) String() string { return proto.CompactTextString(m) }
+func (*LeafOp) ProtoMessage()    {}
+func (*LeafOp) Descriptor() ([]byte, []int) {
+	return fileDescriptor_253c45e4477f7a54, []int{0}
+}
+func (m *LeafOp) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *LeafOp) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_LeafOp.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *LeafOp) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LeafOp.Merge(m, src)
+}
+func (m *LeafOp) XXX_Size() int {
+	return m.Size()
+}
+func (m *LeafOp) XXX_DiscardUnknown() {
+	xxx_messageInfo_LeafOp.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LeafOp proto.InternalMessageInfo
+
+func (m *LeafOp) GetHash() HashOp {
+	if m != nil {
+		return m.Hash
+	}
+	return HashOp_HASH_OP_NO_HASH
+}
+
+func (m *LeafOp) GetPrehashKey() HashOp {
+	if m != nil {
+		return m.PrehashKey
+	}
+	return HashOp_HASH_OP_NO_HASH
+}
+
+func (m *LeafOp) GetPrehashValue() HashOp {
+	if m != nil {
+		return m.PrehashValue
+	}
+	return HashOp_HASH_OP_NO_HASH
+}
+
+func (m *LeafOp) GetLength() LengthOp {
+	if m != nil {
+		return m.Length
+	}
+	return LengthOp_LENGTH_OP_NO_PREFIX
+}
+
+func (m *LeafOp) GetPrefix() []byte {
+	if m != nil {
+		return m.Prefix
+	}
+	return nil
+}
+
+// InnerOp hashes a child into its parent: hash(prefix || child || suffix),
+// where prefix and suffix contain the hashes of the siblings.
+type InnerOp struct {
+	Hash   HashOp `protobuf:"varint,1,opt,name=hash,proto3,enum=tendermint.crypto.HashOp" json:"hash,omitempty"`
+	Prefix []byte `protobuf:"bytes,2,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Suffix []byte `protobuf:"bytes,3,opt,name=suffix,proto3" json:"suffix,omitempty"`
+}
+
+func (m *InnerOp) Reset()         { *m = InnerOp{} }
+func (m *InnerOp) String() string { return proto.CompactTextString(m) }
+func (*InnerOp) ProtoMessage()    {}
+func (*InnerOp) Descriptor() ([]byte, []int) {
+	return fileDescriptor_253c45e4477f7a54, []int{1}
+}
+func (m *InnerOp) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *InnerOp) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_InnerOp.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *InnerOp) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_InnerOp.Merge(m, src)
+}
+func (m *InnerOp) XXX_Size() int {
+	return m.Size()
+}
+func (m *InnerOp) XXX_DiscardUnknown() {
+	xxx_messageInfo_InnerOp.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_InnerOp proto.InternalMessageInfo
+
+func (m *InnerOp) GetHash() HashOp {
+	if m != nil {
+		return m.Hash
+	}
+	return HashOp_HASH_OP_NO_HASH
+}
+
+func (m *InnerOp) GetPrefix() []byte {
+	if m != nil {
+		return m.Prefix
+	}
+	return nil
+}
+
+func (m *InnerOp) GetSuffix() []byte {
+	if m != nil {
+		return m.Suffix
+	}
+	return nil
+}
+
+// ExistenceProof proves that a key-value pair is in a tree, with the path
+// from the leaf to the root.
+type ExistenceProof struct {
+	Key   []byte     `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value []byte     `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Leaf  *LeafOp    `protobuf:"bytes,3,opt,name=leaf,proto3" json:"leaf,omitempty"`
+	Path  []*InnerOp `protobuf:"bytes,4,rep,name=path,proto3" json:"path,omitempty"`
+}
+
+func (m *ExistenceProof) Reset()         { *m = ExistenceProof{} }
+func (m *ExistenceProof) String() string { return proto.CompactTextString(m) }
+func (*ExistenceProof) ProtoMessage()    {}
+func (*ExistenceProof) Descriptor() ([]byte, []int) {
+	return fileDescriptor_253c45e4477f7a54, []int{2}
+}
+func (m *ExistenceProof) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ExistenceProof) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ExistenceProof.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ExistenceProof) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExistenceProof.Merge(m, src)
+}
+func (m *ExistenceProof) XXX_Size() int {
+	return m.Size()
+}
+func (m *ExistenceProof) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExistenceProof.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExistenceProof proto.InternalMessageInfo
+
+func (m *ExistenceProof) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *ExistenceProof) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *ExistenceProof) GetLeaf() *LeafOp {
+	if m != nil {
+		return m.Leaf
+	}
+	return nil
+}
+
+func (m *ExistenceProof) GetPath() []*InnerOp {
+	if m != nil {
+		return m.Path
+	}
+	return nil
+}
+
+// NonExistenceProof proves that a key is not in a tree, with the existence
+// proofs of its neighbors. One of them is omitted if the key would be the
+// leftmost or rightmost key.
+type NonExistenceProof struct {
+	Key   []byte          `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Left  *ExistenceProof `protobuf:"bytes,2,opt,name=left,proto3" json:"left,omitempty"`
+	Right *ExistenceProof `protobuf:"bytes,3,opt,name=right,proto3" json:"right,omitempty"`
+}
+
+func (m *NonExistenceProof) Reset()         { *m = NonExistenceProof{} }
+func (m *NonExistenceProof) String() string { return proto.CompactTextString(m) }
+func (*NonExistenceProof) ProtoMessage()    {}
+func (*NonExistenceProof) Descriptor() ([]byte, []int) {
+	return fileDescriptor_253c45e4477f7a54, []int{3}
+}
+func (m *NonExistenceProof) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *NonExistenceProof) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_NonExistenceProof.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *NonExistenceProof) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NonExistenceProof.Merge(m, src)
+}
+func (m *NonExistenceProof) XXX_Size() int {
+	return m.Size()
+}
+func (m *NonExistenceProof) XXX_DiscardUnknown() {
+	xxx_messageInfo_NonExistenceProof.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NonExistenceProof proto.InternalMessageInfo
+
+func (m *NonExistenceProof) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *NonExistenceProof) GetLeft() *ExistenceProof {
+	if m != nil {
+		return m.Left
+	}
+	return nil
+}
+
+func (m *NonExistenceProof) GetRight() *ExistenceProof {
+	if m != nil {
+		return m.Right
+	}
+	return nil
+}
+
+// CommitmentProof is the proof of the existence or non-existence of a key.
+type CommitmentProof struct {
+	// Types that are valid to be assigned to Proof:
+	//	*CommitmentProof_Exist
+	//	*CommitmentProof_Nonexist
+	Proof isCommitmentProof_Proof `protobuf_oneof:"proof"`
+}
+
+func (m *CommitmentProof) Reset()         { *m = CommitmentProof{} }
+func (m *CommitmentProof) String() string { return proto.CompactTextString(m) }
+func (*CommitmentProof) ProtoMessage()    {}
+func (*CommitmentProof) Descriptor() ([]byte, []int) {
+	return fileDescriptor_253c45e4477f7a54, []int{4}
+}
+func (m *CommitmentProof) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *CommitmentProof) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_CommitmentProof.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *CommitmentProof) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CommitmentProof.Merge(m, src)
+}
+func (m *CommitmentProof) XXX_Size() int {
+	return m.Size()
+}
+func (m *CommitmentProof) XXX_DiscardUnknown() {
+	xxx_messageInfo_CommitmentProof.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CommitmentProof proto.InternalMessageInfo
+
+type isCommitmentProof_Proof interface {
+	isCommitmentProof_Proof()
+	MarshalTo([]byte) (int, error)
+	Size() int
+}
+
+type CommitmentProof_Exist struct {
+	Exist *ExistenceProof `protobuf:"bytes,1,opt,name=exist,proto3,oneof" json:"exist,omitempty"`
+}
+type CommitmentProof_Nonexist struct {
+	Nonexist *NonExistenceProof `protobuf:"bytes,2,opt,name=nonexist,proto3,oneof" json:"nonexist,omitempty"`
+}
+
+func (*CommitmentProof_Exist) isCommitmentProof_Proof()    {}
+func (*CommitmentProof_Nonexist) isCommitmentProof_Proof() {}
+
+func (m *CommitmentProof) GetProof() isCommitmentProof_Proof {
+	if m != nil {
+		return m.Proof
+	}
+	return nil
+}
+
+func (m *CommitmentProof) GetExist() *ExistenceProof {
+	if x, ok := m.GetProof().(*CommitmentProof_Exist); ok {
+		return x.Exist
+	}
+	return nil
+}
+
+func (m *CommitmentProof) GetNonexist() *NonExistenceProof {
+	if x, ok := m.GetProof().(*CommitmentProof_Nonexist); ok {
+		return x.Nonexist
+	}
+	return nil
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*CommitmentProof) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*CommitmentProof_Exist)(nil),
+		(*CommitmentProof_Nonexist)(nil),
+	}
+}
+
+func init() {
+	proto.RegisterEnum("tendermint.crypto.HashOp", HashOp_name, HashOp_value)
+	proto.RegisterEnum("tendermint.crypto.LengthOp", LengthOp_name, LengthOp_value)
+	proto.RegisterType((*LeafOp)(nil), "tendermint.crypto.LeafOp")
+	proto.RegisterType((*InnerOp)(nil), "tendermint.crypto.InnerOp")
+	proto.RegisterType((*ExistenceProof)(nil), "tendermint.crypto.ExistenceProof")
+	proto.RegisterType((*NonExistenceProof)(nil), "tendermint.crypto.NonExistenceProof")
+	proto.RegisterType((*CommitmentProof)(nil), "tendermint.crypto.CommitmentProof")
+}
+
+func init() {
+	proto.RegisterFile("tendermint/crypto/commitment.proto", fileDescriptor_253c45e4477f7a54)
+}
+
+var fileDescriptor_253c45e4477f7a54 = []byte{
+	// 514 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x94, 0xcd, 0x6a, 0xdb, 0x40,
+	0x14, 0x85, 0x35, 0xfe, 0x4b, 0xb8, 0x4e, 0x1d, 0x67, 0x52, 0x5a, 0xb5, 0x05, 0x91, 0x8a, 0x2e,
+	0x4c, 0xa0, 0x32, 0x75, 0x48, 0xff, 0x28, 0x05, 0xbb, 0xb8, 0x55, 0xa8, 0xb1, 0xcc, 0x24, 0x84,
+	0xd2, 0x8d, 0x51, 0xdc, 0x91, 0x25, 0x6a, 0x8d, 0x84, 0x3c, 0x2e, 0xf1, 0xae, 0x8f, 0xd0, 0x4d,
+	0x29, 0x7d, 0xa3, 0x2e, 0xb3, 0xec, 0xb2, 0xd8, 0x2f, 0x52, 0x66, 0x46, 0x4e, 0x1c, 0x2c, 0x70,
+	0xb2, 0xbb, 0x73, 0xf5, 0x9d, 0xc3, 0x99, 0xab, 0xe1, 0x82, 0xc9, 0x29, 0xfb, 0x42, 0x93, 0x30,
+	0x60, 0xbc, 0x3e, 0x48, 0xa6, 0x31, 0x8f, 0xea, 0x83, 0x28, 0x0c, 0x03, 0x1e, 0x52, 0xc6, 0xad,
+	0x38, 0x89, 0x78, 0x84, 0x77, 0xae, 0x18, 0x4b, 0x31, 0xe6, 0xf7, 0x1c, 0x94, 0x3a, 0xd4, 0xf5,
+	0x9c, 0x18, 0x3f, 0x85, 0x82, 0xef, 0x8e, 0x7d, 0x1d, 0xed, 0xa1, 0x5a, 0xa5, 0xf1, 0xc0, 0x5a,
+	0x81, 0x2d, 0xdb, 0x1d, 0xfb, 0x4e, 0x4c, 0x24, 0x86, 0x5f, 0x43, 0x39, 0x4e, 0xa8, 0x28, 0xfb,
+	0x5f, 0xe9, 0x54, 0xcf, 0xad, 0x53, 0x41, 0x4a, 0x7f, 0xa4, 0x53, 0xfc, 0x16, 0xee, 0x2c, 0xb4,
+	0xdf, 0xdc, 0xd1, 0x84, 0xea, 0xf9, 0x75, 0xea, 0xad, 0x94, 0x3f, 0x15, 0x38, 0x3e, 0x80, 0xd2,
+	0x88, 0xb2, 0x21, 0xf7, 0xf5, 0x82, 0x14, 0x3e, 0xca, 0x10, 0x76, 0x24, 0xe0, 0xc4, 0x24, 0x45,
+	0xf1, 0x3d, 0x28, 0xc5, 0x09, 0xf5, 0x82, 0x73, 0xbd, 0xb8, 0x87, 0x6a, 0x5b, 0x24, 0x3d, 0x99,
+	0x3e, 0x6c, 0x1c, 0x31, 0x46, 0x93, 0xdb, 0x8f, 0xe0, 0xca, 0x31, 0xb7, 0xec, 0x28, 0xfa, 0xe3,
+	0x89, 0x27, 0xfa, 0x79, 0xd5, 0x57, 0x27, 0xf3, 0x17, 0x82, 0x4a, 0xfb, 0x3c, 0x18, 0x73, 0xca,
+	0x06, 0xb4, 0x97, 0x44, 0x91, 0x87, 0xab, 0x90, 0x17, 0xd3, 0x43, 0x92, 0x13, 0x25, 0xbe, 0x0b,
+	0x45, 0x35, 0x13, 0xe5, 0xa9, 0x0e, 0x22, 0xd9, 0x88, 0xba, 0x9e, 0x34, 0x2c, 0x67, 0x26, 0x53,
+	0x7f, 0x91, 0x48, 0x0c, 0x5b, 0x50, 0x88, 0x5d, 0x39, 0x9e, 0x7c, 0xad, 0xdc, 0x78, 0x98, 0x81,
+	0xa7, 0x57, 0x26, 0x92, 0x33, 0x7f, 0x22, 0xd8, 0xe9, 0x46, 0x6c, 0x6d, 0xb8, 0x43, 0x11, 0xc3,
+	0xe3, 0x32, 0x5b, 0xb9, 0xf1, 0x38, 0xc3, 0xf7, 0xba, 0x05, 0x91, 0x38, 0x7e, 0x01, 0xc5, 0x24,
+	0x18, 0xfa, 0x5c, 0xcf, 0xdf, 0x54, 0xa7, 0x78, 0xf3, 0x37, 0x82, 0xed, 0x77, 0x97, 0xcf, 0x58,
+	0xa5, 0x7a, 0x05, 0x45, 0x2a, 0x60, 0x1d, 0xdd, 0xd0, 0xcc, 0xd6, 0x88, 0x52, 0xe0, 0x16, 0x6c,
+	0xb2, 0x88, 0x29, 0xb5, 0xba, 0xc2, 0x93, 0x0c, 0xf5, 0xca, 0x20, 0x6c, 0x8d, 0x5c, 0xea, 0x5a,
+	0x1b, 0x50, 0x8c, 0x45, 0x73, 0xff, 0x19, 0x94, 0xd4, 0x6b, 0xc0, 0xbb, 0xb0, 0x6d, 0x37, 0x8f,
+	0xed, 0xbe, 0xd3, 0xeb, 0x77, 0x9d, 0xbe, 0x28, 0xab, 0x1a, 0xc6, 0x50, 0x59, 0x34, 0x8f, 0xed,
+	0x66, 0xe3, 0xf0, 0x79, 0x15, 0xed, 0xbf, 0x81, 0xcd, 0xc5, 0xb3, 0xc4, 0xf7, 0x61, 0xb7, 0xd3,
+	0xee, 0x7e, 0x38, 0x59, 0xc8, 0x7a, 0xa4, 0xfd, 0xfe, 0xe8, 0x53, 0x55, 0xbb, 0xfe, 0xe1, 0xb4,
+	0x49, 0xfa, 0x3d, 0xe2, 0x9c, 0x38, 0x55, 0xd4, 0x22, 0x7f, 0x66, 0x06, 0xba, 0x98, 0x19, 0xe8,
+	0xdf, 0xcc, 0x40, 0x3f, 0xe6, 0x86, 0x76, 0x31, 0x37, 0xb4, 0xbf, 0x73, 0x43, 0xfb, 0xfc, 0x72,
+	0x18, 0x70, 0x7f, 0x72, 0x66, 0x0d, 0xa2, 0xb0, 0xbe, 0xb4, 0x07, 0x96, 0x4a, 0xb9, 0x00, 0xea,
+	0x2b, 0x3b, 0xe2, 0xac, 0x24, 0x3f, 0x1c, 0xfc, 0x1f, 0x00, 0x93, 0xb8, 0xba, 0x16, 0x3f, 0x04,
+	0x00, 0x00,
+}
+
+func (m *LeafOp) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *LeafOp) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LeafOp) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Prefix) > 0 {
+		i -= len(m.Prefix)
+		copy(dAtA[i:], m.Prefix)
+		i = encodeVarintCommitment(dAtA, i, uint64(len(m.Prefix)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if m.Length != 0 {
+		i = encodeVarintCommitment(dAtA, i, uint64(m.Length))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.PrehashValue != 0 {
+		i = encodeVarintCommitment(dAtA, i, uint64(m.PrehashValue))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.PrehashKey != 0 {
+		i = encodeVarintCommitment(dAtA, i, uint64(m.PrehashKey))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Hash != 0 {
+		i = encodeVarintCommitment(dAtA, i, uint64(m.Hash))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *InnerOp) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *InnerOp) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *InnerOp) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Suffix) > 0 {
+		i -= len(m.Suffix)
+		copy(dAtA[i:], m.Suffix)
+		i = encodeVarintCommitment(dAtA, i, uint64(len(m.Suffix)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Prefix) > 0 {
+		i -= len(m.Prefix)
+		copy(dAtA[i:], m.Prefix)
+		i = encodeVarintCommitment(dAtA, i, uint64(len(m.Prefix)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Hash != 0 {
+		i = encodeVarintCommitment(dAtA, i, uint64(m.Hash))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ExistenceProof) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ExistenceProof) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ExistenceProof) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Path) > 0 {
+		for iNdEx := len(m.Path) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Path[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintCommitment(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x22
+		}
+	}
+	if m.Leaf != nil {
+		{
+			size, err := m.Leaf.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintCommitment(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Value) > 0 {
+		i -= len(m.Value)
+		copy(dAtA[i:], m.Value)
+		i = encodeVarintCommitment(dAtA, i, uint64(len(m.Value)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Key) > 0 {
+		i -= len(m.Key)
+		copy(dAtA[i:], m.Key)
+		i = encodeVarintCommitment(dAtA, i, uint64(len(m.Key)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *NonExistenceProof) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *NonExistenceProof) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *NonExistenceProof) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Right != nil {
+		{
+			size, err := m.Right.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintCommitment(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.Left != nil {
+		{
+			size, err := m.Left.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintCommitment(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Key) > 0 {
+		i -= len(m.Key)
+		copy(dAtA[i:], m.Key)
+		i = encodeVarintCommitment(dAtA, i, uint64(len(m.Key)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *CommitmentProof) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CommitmentProof) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *CommitmentProof) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Proof != nil {
+		{
+			size := m.Proof.Size()
+			i -= size
+			if _, err := m.Proof.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *CommitmentProof_Exist) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *CommitmentProof_Exist) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.Exist != nil {
+		{
+			size, err := m.Exist.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintCommitment(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+func (m *CommitmentProof_Nonexist) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *CommitmentProof_Nonexist) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.Nonexist != nil {
+		{
+			size, err := m.Nonexist.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintCommitment(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	return len(dAtA) - i, nil
+}
+func encodeVarintCommitment(dAtA []byte, offset int, v uint64) int {
+	offset -= sovCommitment(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *LeafOp) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Hash != 0 {
+		n += 1 + sovCommitment(uint64(m.Hash))
+	}
+	if m.PrehashKey != 0 {
+		n += 1 + sovCommitment(uint64(m.PrehashKey))
+	}
+	if m.PrehashValue != 0 {
+		n += 1 + sovCommitment(uint64(m.PrehashValue))
+	}
+	if m.Length != 0 {
+		n += 1 + sovCommitment(uint64(m.Length))
+	}
+	l = len(m.Prefix)
+	if l > 0 {
+		n += 1 + l + sovCommitment(uint64(l))
+	}
+	return n
+}
+
+func (m *InnerOp) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Hash != 0 {
+		n += 1 + sovCommitment(uint64(m.Hash))
+	}
+	l = len(m.Prefix)
+	if l > 0 {
+		n += 1 + l + sovCommitment(uint64(l))
+	}
+	l = len(m.Suffix)
+	if l > 0 {
+		n += 1 + l + sovCommitment(uint64(l))
+	}
+	return n
+}
+
+func (m *ExistenceProof) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Key)
+	if l > 0 {
+		n += 1 + l + sovCommitment(uint64(l))
+	}
+	l = len(m.Value)
+	if l > 0 {
+		n += 1 + l + sovCommitment(uint64(l))
+	}
+	if m.Leaf != nil {
+		l = m.Leaf.Size()
+		n += 1 + l + sovCommitment(uint64(l))
+	}
+	if len(m.Path) > 0 {
+		for _, e := range m.Path {
+			l = e.Size()
+			n += 1 + l + sovCommitment(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *NonExistenceProof) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Key)
+	if l > 0 {
+		n += 1 + l + sovCommitment(uint64(l))
+	}
+	if m.Left != nil {
+		l = m.Left.Size()
+		n += 1 + l + sovCommitment(uint64(l))
+	}
+	if m.Right != nil {
+		l = m.Right.Size()
+		n += 1 + l + sovCommitment(uint64(l))
+	}
+	return n
+}
+
+func (m *CommitmentProof) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Proof != nil {
+		n += m.Proof.Size()
+	}
+	return n
+}
+
+func (m *CommitmentProof_Exist) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Exist != nil {
+		l = m.Exist.Size()
+		n += 1 + l + sovCommitment(uint64(l))
+	}
+	return n
+}
+func (m *CommitmentProof_Nonexist) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Nonexist != nil {
+		l = m.Nonexist.Size()
+		n += 1 + l + sovCommitment(uint64(l))
+	}
+	return n
+}
+
+func sovCommitment(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozCommitment(x uint64) (n int) {
+	return sovCommitment(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *LeafOp) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowCommitment
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LeafOp: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LeafOp: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hash", wireType)
+			}
+			m.Hash = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommitment
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Hash |= HashOp(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PrehashKey", wireType)
+			}
+			m.PrehashKey = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommitment
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.PrehashKey |= HashOp(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PrehashValue", wireType)
+			}
+			m.PrehashValue = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommitment
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.PrehashValue |= HashOp(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Length", wireType)
+			}
+			m.Length = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommitment
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Length |= LengthOp(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Prefix", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommitment
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Prefix = append(m.Prefix[:0], dAtA[iNdEx:postIndex]...)
+			if m.Prefix == nil {
+				m.Prefix = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipCommitment(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *InnerOp) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowCommitment
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: InnerOp: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: InnerOp: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hash", wireType)
+			}
+			m.Hash = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommitment
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Hash |= HashOp(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Prefix", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommitment
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Prefix = append(m.Prefix[:0], dAtA[iNdEx:postIndex]...)
+			if m.Prefix == nil {
+				m.Prefix = []byte{}
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Suffix", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommitment
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Suffix = append(m.Suffix[:0], dAtA[iNdEx:postIndex]...)
+			if m.Suffix == nil {
+				m.Suffix = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipCommitment(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ExistenceProof) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowCommitment
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ExistenceProof: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ExistenceProof: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommitment
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Key = append(m.Key[:0], dAtA[iNdEx:postIndex]...)
+			if m.Key == nil {
+				m.Key = []byte{}
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommitment
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Value = append(m.Value[:0], dAtA[iNdEx:postIndex]...)
+			if m.Value == nil {
+				m.Value = []byte{}
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Leaf", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommitment
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Leaf == nil {
+				m.Leaf = &LeafOp{}
+			}
+			if err := m.Leaf.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Path", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommitment
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Path = append(m.Path, &InnerOp{})
+			if err := m.Path[len(m.Path)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipCommitment(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *NonExistenceProof) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowCommitment
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: NonExistenceProof: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: NonExistenceProof: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommitment
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Key = append(m.Key[:0], dAtA[iNdEx:postIndex]...)
+			if m.Key == nil {
+				m.Key = []byte{}
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Left", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommitment
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Left == nil {
+				m.Left = &ExistenceProof{}
+			}
+			if err := m.Left.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Right", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommitment
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Right == nil {
+				m.Right = &ExistenceProof{}
+			}
+			if err := m.Right.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipCommitment(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *CommitmentProof) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowCommitment
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CommitmentProof: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CommitmentProof: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Exist", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommitment
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &ExistenceProof{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Proof = &CommitmentProof_Exist{v}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Nonexist", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommitment
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &NonExistenceProof{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Proof = &CommitmentProof_Nonexist{v}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipCommitment(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthCommitment
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func skipCommitment(dAtA []byte) (n int, err error) {
+	l := len(dAtA)
+	iNdEx := 0
+	depth := 0
+	for iNdEx < l {
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return 0, ErrIntOverflowCommitment
+			}
+			if iNdEx >= l {
+				return 0, io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		wireType := int(wire & 0x7)
+		switch wireType {
+		case 0:
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowCommitment
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				iNdEx++
+				if dAtA[iNdEx-1] < 0x80 {
+					break
+				}
+			}
+		case 1:
+			iNdEx += 8
+		case 2:
+			var length int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowCommitment
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				length |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if length < 0 {
+				return 0, ErrInvalidLengthCommitment
+			}
+			iNdEx += length
+		case 3:
+			depth++
+		case 4:
+			if depth == 0 {
+				return 0, ErrUnexpectedEndOfGroupCommitment
+			}
+			depth--
+		case 5:
+			iNdEx += 4
+		default:
+			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
+		}
+		if iNdEx < 0 {
+			return 0, ErrInvalidLengthCommitment
+		}
+		if depth == 0 {
+			return iNdEx, nil
+		}
+	}
+	return 0, io.ErrUnexpectedEOF
+}
+
+var (
+	ErrInvalidLengthCommitment        = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowCommitment          = fmt.Errorf("proto: integer overflow")
+	ErrUnexpectedEndOfGroupCommitment = fmt.Errorf("proto: unexpected end of group")
+)
@@ -3,6 +3,7 @@ package proxy
 import (
 	abcicli "github.com/tendermint/tendermint/abci/client"
 	"github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/trace"
 )
 
 //go:generate mockery --case underscore --name AppConnConsensus|AppConnMempool|AppConnQuery|AppConnSnapshot
@@ -93,6 +94,13 @@ func (app *appConnConsensus) CommitSync() (*types.ResponseCommit, error) {
 	return app.appConn.CommitSync()
 }
 
+// SetTraceParent implements abcicli.TraceParentSetter, if the client does.
+func (app *appConnConsensus) SetTraceParent(sc trace.SpanContext) {
+	if setter, ok := app.appConn.(abcicli.TraceParentSetter); ok {
+		setter.SetTraceParent(sc)
+	}
+}
+
 //------------------------------------------------
 // Implements AppConnMempool (subset of abcicli.Client)
 
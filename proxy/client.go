@@ -8,6 +8,7 @@ import (
 	"github.com/tendermint/tendermint/abci/example/kvstore"
 	"github.com/tendermint/tendermint/abci/types"
 	tmsync "github.com/tendermint/tendermint/libs/sync"
+	"github.com/tendermint/tendermint/libs/trace"
 )
 
 // ClientCreator creates new ABCI clients.
@@ -66,6 +67,33 @@ func (r *remoteClientCreator) NewABCIClient() (abcicli.Client, error) {
 	return remoteApp, nil
 }
 
+//---------------------------------------------------------------
+// tracing proxy traces the requests of the clients of another creator
+
+type tracingClientCreator struct {
+	clientCreator ClientCreator
+	tracer        *trace.Tracer
+}
+
+// NewTracingClientCreator returns a ClientCreator for clients of
+// clientCreator tracing their requests with tracer (see
+// abcicli.NewTracingClient).
+func NewTracingClientCreator(clientCreator ClientCreator, tracer *trace.Tracer) ClientCreator {
+	return &tracingClientCreator{
+		clientCreator: clientCreator,
+		tracer:        tracer,
+	}
+}
+
+func (t *tracingClientCreator) NewABCIClient() (abcicli.Client, error) {
+	client, err := t.clientCreator.NewABCIClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return abcicli.NewTracingClient(client, t.tracer), nil
+}
+
 // DefaultClientCreator returns a default ClientCreator, which will create a
 // local client if addr is one of: 'counter', 'counter_serial', 'kvstore',
 // 'persistent_kvstore' or 'noop', otherwise - a remote client.
@@ -0,0 +1,80 @@
+package proxy
+
+import (
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/discard"
+	"github.com/go-kit/kit/metrics/prometheus"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// MetricsSubsystem is a subsystem shared by all metrics exposed by this
+	// package.
+	MetricsSubsystem = "abci_connection"
+)
+
+// Metrics contains metrics exposed by this package.
+//
+// The metrics of the requests are labeled by the ABCI connection (consensus,
+// mempool, query or snapshot) and method (e.g. check_tx), with the
+// "connection" and "method" labels.
+type Metrics struct {
+	// Time between sending a request and receiving its response, in seconds.
+	MethodTimingSeconds metrics.Histogram
+	// Number of requests which failed.
+	MethodErrors metrics.Counter
+	// Number of requests sent, waiting for their response.
+	InFlightRequests metrics.Gauge
+	// Number of requests queued by a socket client, waiting to be written to
+	// the connection. Labeled by the ABCI connection only.
+	SocketQueueSize metrics.Gauge
+}
+
+// PrometheusMetrics returns Metrics build using Prometheus client library.
+// Optionally, labels can be provided along with their values ("foo",
+// "fooValue").
+func PrometheusMetrics(namespace string, labelsAndValues ...string) *Metrics {
+	labels := []string{}
+	for i := 0; i < len(labelsAndValues); i += 2 {
+		labels = append(labels, labelsAndValues[i])
+	}
+	connLabels := append(labels[:len(labels):len(labels)], "connection")
+	methodLabels := append(connLabels[:len(connLabels):len(connLabels)], "method")
+	return &Metrics{
+		MethodTimingSeconds: prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "method_timing_seconds",
+			Help:      "Time between sending an ABCI request and receiving its response, in seconds.",
+			Buckets:   []float64{.0001, .0004, .002, .009, .02, .1, .65, 2, 6, 25},
+		}, methodLabels).With(labelsAndValues...),
+		MethodErrors: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "method_errors",
+			Help:      "Number of ABCI requests which failed.",
+		}, methodLabels).With(labelsAndValues...),
+		InFlightRequests: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "in_flight_requests",
+			Help:      "Number of ABCI requests sent, waiting for their response.",
+		}, methodLabels).With(labelsAndValues...),
+		SocketQueueSize: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "socket_queue_size",
+			Help:      "Number of ABCI requests queued by a socket client, waiting to be written to the connection.",
+		}, connLabels).With(labelsAndValues...),
+	}
+}
+
+// NopMetrics returns no-op Metrics.
+func NopMetrics() *Metrics {
+	return &Metrics{
+		MethodTimingSeconds: discard.NewHistogram(),
+		MethodErrors:        discard.NewCounter(),
+		InFlightRequests:    discard.NewGauge(),
+		SocketQueueSize:     discard.NewGauge(),
+	}
+}
@@ -0,0 +1,169 @@
+package proxy
+
+import (
+	"time"
+
+	abcicli "github.com/tendermint/tendermint/abci/client"
+	"github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/trace"
+)
+
+// metricsClient records the metrics of the requests of the methods used by
+// the app conns.
+type metricsClient struct {
+	abcicli.Client
+	metrics    *Metrics
+	connection string
+}
+
+var _ abcicli.Client = (*metricsClient)(nil)
+
+func newMetricsClient(client abcicli.Client, metrics *Metrics, connection string) abcicli.Client {
+	return &metricsClient{Client: client, metrics: metrics, connection: connection}
+}
+
+// startRequest records the start of a request of the method, e.g. check_tx,
+// and returns the function recording its end.
+func (cli *metricsClient) startRequest(method string) func(failed bool) {
+	labels := []string{"connection", cli.connection, "method", method}
+	inFlight := cli.metrics.InFlightRequests.With(labels...)
+	inFlight.Add(1)
+	start := time.Now()
+	return func(failed bool) {
+		inFlight.Add(-1)
+		cli.metrics.MethodTimingSeconds.With(labels...).Observe(time.Since(start).Seconds())
+		if failed {
+			cli.metrics.MethodErrors.With(labels...).Add(1)
+		}
+	}
+}
+
+// endOnResponse records the end of the request once its response is
+// received, or it fails.
+func endOnResponse(reqres *abcicli.ReqRes, end func(failed bool)) {
+	reqres.SetOnEnd(func(res *types.Response) {
+		end(res == nil || res.GetException() != nil)
+	})
+}
+
+// SetTraceParent implements abcicli.TraceParentSetter, if the wrapped client
+// does.
+func (cli *metricsClient) SetTraceParent(sc trace.SpanContext) {
+	if setter, ok := cli.Client.(abcicli.TraceParentSetter); ok {
+		setter.SetTraceParent(sc)
+	}
+}
+
+func (cli *metricsClient) InitChainSync(req types.RequestInitChain) (*types.ResponseInitChain, error) {
+	end := cli.startRequest("init_chain")
+	res, err := cli.Client.InitChainSync(req)
+	end(err != nil)
+	return res, err
+}
+
+func (cli *metricsClient) BeginBlockSync(req types.RequestBeginBlock) (*types.ResponseBeginBlock, error) {
+	end := cli.startRequest("begin_block")
+	res, err := cli.Client.BeginBlockSync(req)
+	end(err != nil)
+	return res, err
+}
+
+func (cli *metricsClient) DeliverTxAsync(req types.RequestDeliverTx) *abcicli.ReqRes {
+	end := cli.startRequest("deliver_tx")
+	reqres := cli.Client.DeliverTxAsync(req)
+	endOnResponse(reqres, end)
+	return reqres
+}
+
+func (cli *metricsClient) EndBlockSync(req types.RequestEndBlock) (*types.ResponseEndBlock, error) {
+	end := cli.startRequest("end_block")
+	res, err := cli.Client.EndBlockSync(req)
+	end(err != nil)
+	return res, err
+}
+
+func (cli *metricsClient) CommitSync() (*types.ResponseCommit, error) {
+	end := cli.startRequest("commit")
+	res, err := cli.Client.CommitSync()
+	end(err != nil)
+	return res, err
+}
+
+func (cli *metricsClient) CheckTxAsync(req types.RequestCheckTx) *abcicli.ReqRes {
+	end := cli.startRequest("check_tx")
+	reqres := cli.Client.CheckTxAsync(req)
+	endOnResponse(reqres, end)
+	return reqres
+}
+
+func (cli *metricsClient) CheckTxSync(req types.RequestCheckTx) (*types.ResponseCheckTx, error) {
+	end := cli.startRequest("check_tx")
+	res, err := cli.Client.CheckTxSync(req)
+	end(err != nil)
+	return res, err
+}
+
+func (cli *metricsClient) FlushAsync() *abcicli.ReqRes {
+	end := cli.startRequest("flush")
+	reqres := cli.Client.FlushAsync()
+	endOnResponse(reqres, end)
+	return reqres
+}
+
+func (cli *metricsClient) FlushSync() error {
+	end := cli.startRequest("flush")
+	err := cli.Client.FlushSync()
+	end(err != nil)
+	return err
+}
+
+func (cli *metricsClient) EchoSync(msg string) (*types.ResponseEcho, error) {
+	end := cli.startRequest("echo")
+	res, err := cli.Client.EchoSync(msg)
+	end(err != nil)
+	return res, err
+}
+
+func (cli *metricsClient) InfoSync(req types.RequestInfo) (*types.ResponseInfo, error) {
+	end := cli.startRequest("info")
+	res, err := cli.Client.InfoSync(req)
+	end(err != nil)
+	return res, err
+}
+
+func (cli *metricsClient) QuerySync(req types.RequestQuery) (*types.ResponseQuery, error) {
+	end := cli.startRequest("query")
+	res, err := cli.Client.QuerySync(req)
+	end(err != nil)
+	return res, err
+}
+
+func (cli *metricsClient) ListSnapshotsSync(req types.RequestListSnapshots) (*types.ResponseListSnapshots, error) {
+	end := cli.startRequest("list_snapshots")
+	res, err := cli.Client.ListSnapshotsSync(req)
+	end(err != nil)
+	return res, err
+}
+
+func (cli *metricsClient) OfferSnapshotSync(req types.RequestOfferSnapshot) (*types.ResponseOfferSnapshot, error) {
+	end := cli.startRequest("offer_snapshot")
+	res, err := cli.Client.OfferSnapshotSync(req)
+	end(err != nil)
+	return res, err
+}
+
+func (cli *metricsClient) LoadSnapshotChunkSync(
+	req types.RequestLoadSnapshotChunk) (*types.ResponseLoadSnapshotChunk, error) {
+	end := cli.startRequest("load_snapshot_chunk")
+	res, err := cli.Client.LoadSnapshotChunkSync(req)
+	end(err != nil)
+	return res, err
+}
+
+func (cli *metricsClient) ApplySnapshotChunkSync(
+	req types.RequestApplySnapshotChunk) (*types.ResponseApplySnapshotChunk, error) {
+	end := cli.startRequest("apply_snapshot_chunk")
+	res, err := cli.Client.ApplySnapshotChunkSync(req)
+	end(err != nil)
+	return res, err
+}
@@ -0,0 +1,75 @@
+package proxy
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/abci/example/kvstore"
+	"github.com/tendermint/tendermint/abci/server"
+	"github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/log"
+	tmrand "github.com/tendermint/tendermint/libs/rand"
+)
+
+// gatherMetric returns the metric of the family with the name and labels.
+func gatherMetric(t *testing.T, name string, labels map[string]string) *dto.Metric {
+	families, err := stdprometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	METRICS:
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if value, ok := labels[label.GetName()]; ok && value != label.GetValue() {
+					continue METRICS
+				}
+			}
+			return metric
+		}
+	}
+	return nil
+}
+
+func TestAppConnsMetrics(t *testing.T) {
+	sockPath := fmt.Sprintf("unix:///tmp/metrics_%v.sock", tmrand.Str(6))
+	s := server.NewSocketServer(sockPath, kvstore.NewApplication())
+	s.SetLogger(log.TestingLogger().With("module", "abci-server"))
+	require.NoError(t, s.Start())
+	t.Cleanup(func() { s.Stop() }) // nolint:errcheck // ignore for tests
+
+	appConns := NewAppConns(NewRemoteClientCreator(sockPath, SOCKET, true), PrometheusMetrics("proxy_test"))
+	appConns.SetLogger(log.TestingLogger())
+	require.NoError(t, appConns.Start())
+	t.Cleanup(func() { appConns.Stop() }) // nolint:errcheck // ignore for tests
+
+	appConns.Mempool().SetResponseCallback(func(*types.Request, *types.Response) {})
+	for i := 0; i < 10; i++ {
+		appConns.Mempool().CheckTxAsync(types.RequestCheckTx{Tx: []byte(fmt.Sprintf("key%d=value", i))})
+	}
+	require.NoError(t, appConns.Mempool().FlushSync())
+	_, err := appConns.Consensus().CommitSync()
+	require.NoError(t, err)
+
+	checkTx := map[string]string{"connection": "mempool", "method": "check_tx"}
+	assert.Eventually(t, func() bool {
+		metric := gatherMetric(t, "proxy_test_abci_connection_method_timing_seconds", checkTx)
+		return metric != nil && metric.GetHistogram().GetSampleCount() == 10
+	}, time.Second, 10*time.Millisecond)
+	assert.Zero(t, gatherMetric(t, "proxy_test_abci_connection_in_flight_requests", checkTx).GetGauge().GetValue())
+	assert.Nil(t, gatherMetric(t, "proxy_test_abci_connection_method_errors", checkTx))
+
+	commit := gatherMetric(t, "proxy_test_abci_connection_method_timing_seconds",
+		map[string]string{"connection": "consensus", "method": "commit"})
+	require.NotNil(t, commit)
+	assert.EqualValues(t, 1, commit.GetHistogram().GetSampleCount())
+	assert.NotNil(t, gatherMetric(t, "proxy_test_abci_connection_socket_queue_size",
+		map[string]string{"connection": "mempool"}))
+}
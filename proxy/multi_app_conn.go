@@ -32,8 +32,8 @@ type AppConns interface {
 }
 
 // NewAppConns calls NewMultiAppConn.
-func NewAppConns(clientCreator ClientCreator) AppConns {
-	return NewMultiAppConn(clientCreator)
+func NewAppConns(clientCreator ClientCreator, metrics *Metrics) AppConns {
+	return NewMultiAppConn(clientCreator, metrics)
 }
 
 // multiAppConn implements AppConns.
@@ -55,12 +55,15 @@ type multiAppConn struct {
 	snapshotConnClient  abcicli.Client
 
 	clientCreator ClientCreator
+	metrics       *Metrics
 }
 
-// NewMultiAppConn makes all necessary abci connections to the application.
-func NewMultiAppConn(clientCreator ClientCreator) AppConns {
+// NewMultiAppConn makes all necessary abci connections to the application,
+// recording the metrics of their requests.
+func NewMultiAppConn(clientCreator ClientCreator, metrics *Metrics) AppConns {
 	multiAppConn := &multiAppConn{
 		clientCreator: clientCreator,
+		metrics:       metrics,
 	}
 	multiAppConn.BaseService = *service.NewBaseService(nil, "multiAppConn", multiAppConn)
 	return multiAppConn
@@ -184,8 +187,9 @@ func (app *multiAppConn) abciClientFor(conn string) (abcicli.Client, error) {
 		return nil, fmt.Errorf("error creating ABCI client (%s connection): %w", conn, err)
 	}
 	c.SetLogger(app.Logger.With("module", "abci-client", "connection", conn))
+	abcicli.SetQueueSizeGauge(c, app.metrics.SocketQueueSize.With("connection", conn))
 	if err := c.Start(); err != nil {
 		return nil, fmt.Errorf("error starting ABCI client (%s connection): %w", conn, err)
 	}
-	return c, nil
+	return newMetricsClient(c, app.metrics, conn), nil
 }
@@ -324,6 +324,19 @@ func (c *baseRPCClient) ConsensusState() (*ctypes.ResultConsensusState, error) {
 	return result, nil
 }
 
+func (c *baseRPCClient) ConsensusTimeline(heights *int) (*ctypes.ResultConsensusTimeline, error) {
+	result := new(ctypes.ResultConsensusTimeline)
+	params := make(map[string]interface{})
+	if heights != nil {
+		params["heights"] = heights
+	}
+	_, err := c.caller.Call("consensus_timeline", params, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 func (c *baseRPCClient) ConsensusParams(height *int64) (*ctypes.ResultConsensusParams, error) {
 	result := new(ctypes.ResultConsensusParams)
 	params := make(map[string]interface{})
@@ -470,6 +483,22 @@ func (c *baseRPCClient) Validators(height *int64, page, perPage *int) (*ctypes.R
 	return result, nil
 }
 
+func (c *baseRPCClient) ValidatorUptime(page, perPage *int) (*ctypes.ResultValidatorUptime, error) {
+	result := new(ctypes.ResultValidatorUptime)
+	params := make(map[string]interface{})
+	if page != nil {
+		params["page"] = page
+	}
+	if perPage != nil {
+		params["per_page"] = perPage
+	}
+	_, err := c.caller.Call("validator_uptime", params, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 func (c *baseRPCClient) BroadcastEvidence(ev types.Evidence) (*ctypes.ResultBroadcastEvidence, error) {
 	result := new(ctypes.ResultBroadcastEvidence)
 	_, err := c.caller.Call("broadcast_evidence", map[string]interface{}{"evidence": ev}, result)
@@ -90,6 +90,8 @@ type NetworkClient interface {
 	NetInfo() (*ctypes.ResultNetInfo, error)
 	DumpConsensusState() (*ctypes.ResultDumpConsensusState, error)
 	ConsensusState() (*ctypes.ResultConsensusState, error)
+	ConsensusTimeline(heights *int) (*ctypes.ResultConsensusTimeline, error)
+	ValidatorUptime(page, perPage *int) (*ctypes.ResultValidatorUptime, error)
 	ConsensusParams(height *int64) (*ctypes.ResultConsensusParams, error)
 	Health() (*ctypes.ResultHealth, error)
 }
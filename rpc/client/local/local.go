@@ -122,6 +122,14 @@ func (c *Local) ConsensusState() (*ctypes.ResultConsensusState, error) {
 	return core.ConsensusState(c.ctx)
 }
 
+func (c *Local) ConsensusTimeline(heights *int) (*ctypes.ResultConsensusTimeline, error) {
+	return core.ConsensusTimeline(c.ctx, heights)
+}
+
+func (c *Local) ValidatorUptime(page, perPage *int) (*ctypes.ResultValidatorUptime, error) {
+	return core.ValidatorUptime(c.ctx, page, perPage)
+}
+
 func (c *Local) ConsensusParams(height *int64) (*ctypes.ResultConsensusParams, error) {
 	return core.ConsensusParams(c.ctx, height)
 }
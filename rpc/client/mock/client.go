@@ -122,6 +122,14 @@ func (c Client) ConsensusState() (*ctypes.ResultConsensusState, error) {
 	return core.ConsensusState(&rpctypes.Context{})
 }
 
+func (c Client) ConsensusTimeline(heights *int) (*ctypes.ResultConsensusTimeline, error) {
+	return core.ConsensusTimeline(&rpctypes.Context{}, heights)
+}
+
+func (c Client) ValidatorUptime(page, perPage *int) (*ctypes.ResultValidatorUptime, error) {
+	return core.ValidatorUptime(&rpctypes.Context{}, page, perPage)
+}
+
 func (c Client) DumpConsensusState() (*ctypes.ResultDumpConsensusState, error) {
 	return core.DumpConsensusState(&rpctypes.Context{})
 }
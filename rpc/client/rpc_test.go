@@ -1,6 +1,7 @@
 package client_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"net/http"
@@ -147,12 +148,51 @@ func TestConsensusState(t *testing.T) {
 	}
 }
 
+func TestConsensusTimeline(t *testing.T) {
+	for i, c := range GetClients() {
+		nc, ok := c.(client.NetworkClient)
+		require.True(t, ok, "%d", i)
+		heights := 1
+		res, err := nc.ConsensusTimeline(&heights)
+		require.Nil(t, err, "%d: %+v", i, err)
+		var timeline []map[string]interface{}
+		require.NoError(t, json.Unmarshal(res.Timeline, &timeline))
+		assert.Len(t, timeline, 1)
+	}
+}
+
+func TestValidatorUptime(t *testing.T) {
+	for i, c := range GetClients() {
+		nc, ok := c.(client.NetworkClient)
+		require.True(t, ok, "%d", i)
+		require.NoError(t, client.WaitForHeight(c, 3, nil))
+		res, err := nc.ValidatorUptime(nil, nil)
+		require.Nil(t, err, "%d: %+v", i, err)
+		require.Len(t, res.Validators, 1)
+		assert.True(t, res.ToHeight >= 2)
+		assert.NotZero(t, res.Validators[0].SignedBlocks)
+		assert.Zero(t, res.Validators[0].MissedBlocks)
+		assert.EqualValues(t, 1, res.Validators[0].Uptime)
+	}
+}
+
 func TestHealth(t *testing.T) {
 	for i, c := range GetClients() {
 		nc, ok := c.(client.NetworkClient)
 		require.True(t, ok, "%d", i)
-		_, err := nc.Health()
+		res, err := nc.Health()
 		require.Nil(t, err, "%d: %+v", i, err)
+		assert.True(t, res.Live, "%d: %v", i, res.Errors)
+		assert.True(t, res.Ready, "%d: %v", i, res.Errors)
+	}
+
+	// The liveness and readiness are served over HTTP with their status code.
+	rpcAddr := strings.Replace(rpctest.GetConfig().RPC.ListenAddress, "tcp://", "http://", 1)
+	for _, path := range []string{"/health/live", "/health/ready"} {
+		resp, err := http.Get(rpcAddr + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode, path)
 	}
 }
 
@@ -85,6 +85,10 @@ func Block(ctx *rpctypes.Context, heightPtr *int64) (*ctypes.ResultBlock, error)
 	if blockMeta == nil {
 		return &ctypes.ResultBlock{BlockID: types.BlockID{}, Block: block}, nil
 	}
+	// Headers below the base may have been backfilled without their block after state sync.
+	if block == nil {
+		return nil, fmt.Errorf("block at height %d is not available, only its header and commit are (see /commit)", height)
+	}
 	return &ctypes.ResultBlock{BlockID: blockMeta.BlockID, Block: block}, nil
 }
 
@@ -115,6 +115,26 @@ func TestBlockResults(t *testing.T) {
 	}
 }
 
+func TestBlockBackfilledHeader(t *testing.T) {
+	env = &Environment{}
+	env.BlockStore = headerOnlyBlockStore{mockBlockStore{height: 100}}
+
+	height := int64(50)
+	_, err := Block(&rpctypes.Context{}, &height)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only its header and commit are")
+}
+
+// headerOnlyBlockStore is a block store with the headers of the blocks, but not their contents,
+// as when backfilled after state sync.
+type headerOnlyBlockStore struct {
+	mockBlockStore
+}
+
+func (headerOnlyBlockStore) LoadBlockMeta(height int64) *types.BlockMeta {
+	return &types.BlockMeta{Header: types.Header{Height: height}, BlockSize: -1, NumTxs: -1}
+}
+
 type mockBlockStore struct {
 	height int64
 }
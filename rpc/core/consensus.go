@@ -1,6 +1,9 @@
 package core
 
 import (
+	"errors"
+	"fmt"
+
 	cm "github.com/tendermint/tendermint/consensus"
 	tmmath "github.com/tendermint/tendermint/libs/math"
 	ctypes "github.com/tendermint/tendermint/rpc/core/types"
@@ -28,13 +31,13 @@ func Validators(ctx *rpctypes.Context, heightPtr *int64, pagePtr, perPagePtr *in
 	}
 
 	totalCount := len(validators.Validators)
-	perPage := validatePerPage(perPagePtr)
-	page, err := validatePage(pagePtr, perPage, totalCount)
+	perPage := ctypes.ValidatePerPage(perPagePtr)
+	page, err := ctypes.ValidatePage(pagePtr, perPage, totalCount)
 	if err != nil {
 		return nil, err
 	}
 
-	skipCount := validateSkipCount(page, perPage)
+	skipCount := ctypes.ValidateSkipCount(page, perPage)
 
 	v := validators.Validators[skipCount : skipCount+tmmath.MinInt(perPage, totalCount-skipCount)]
 
@@ -45,6 +48,46 @@ func Validators(ctx *rpctypes.Context, heightPtr *int64, pagePtr, perPagePtr *in
 		Total:       totalCount}, nil
 }
 
+// ValidatorUptime gets the uptime of the validators over the last blocks (see
+// validator_uptime_window in the instrumentation config), i.e. the blocks
+// they signed and missed, sorted by address.
+//
+// More: https://docs.tendermint.com/master/rpc/#/Info/validator_uptime
+func ValidatorUptime(ctx *rpctypes.Context, pagePtr, perPagePtr *int) (*ctypes.ResultValidatorUptime, error) {
+	if env.UptimeTracker == nil {
+		return nil, errors.New("the uptime of the validators isn't tracked (validator_uptime_window is 0)")
+	}
+	fromHeight, toHeight, uptimes := env.UptimeTracker.Uptime()
+
+	totalCount := len(uptimes)
+	perPage := ctypes.ValidatePerPage(perPagePtr)
+	page, err := ctypes.ValidatePage(pagePtr, perPage, totalCount)
+	if err != nil {
+		return nil, err
+	}
+
+	skipCount := ctypes.ValidateSkipCount(page, perPage)
+
+	v := make([]ctypes.ValidatorUptime, 0, tmmath.MinInt(perPage, totalCount-skipCount))
+	for _, vu := range uptimes[skipCount : skipCount+tmmath.MinInt(perPage, totalCount-skipCount)] {
+		v = append(v, ctypes.ValidatorUptime{
+			Address:                 vu.Address,
+			SignedBlocks:            vu.SignedBlocks,
+			MissedBlocks:            vu.MissedBlocks,
+			ConsecutiveMissedBlocks: vu.ConsecutiveMissedBlocks,
+			LastSignedHeight:        vu.LastSignedHeight,
+			Uptime:                  vu.Uptime(),
+		})
+	}
+
+	return &ctypes.ResultValidatorUptime{
+		FromHeight: fromHeight,
+		ToHeight:   toHeight,
+		Validators: v,
+		Count:      len(v),
+		Total:      totalCount}, nil
+}
+
 // DumpConsensusState dumps consensus state.
 // UNSTABLE
 // More: https://docs.tendermint.com/master/rpc/#/Info/dump_consensus_state
@@ -87,6 +130,22 @@ func ConsensusState(ctx *rpctypes.Context) (*ctypes.ResultConsensusState, error)
 	return &ctypes.ResultConsensusState{RoundState: bz}, err
 }
 
+// ConsensusTimeline returns the timeline of the consensus of the last heights
+// (10 by default, up to the last 100), to diagnose why their rounds failed.
+// UNSTABLE
+// More: https://docs.tendermint.com/master/rpc/#/Info/consensus_timeline
+func ConsensusTimeline(ctx *rpctypes.Context, heightsPtr *int) (*ctypes.ResultConsensusTimeline, error) {
+	heights := defaultTimelineHeights
+	if heightsPtr != nil {
+		if *heightsPtr <= 0 {
+			return nil, fmt.Errorf("heights must be greater than 0, but got %d", *heightsPtr)
+		}
+		heights = *heightsPtr
+	}
+	bz, err := env.ConsensusState.GetTimelineJSON(heights)
+	return &ctypes.ResultConsensusTimeline{Timeline: bz}, err
+}
+
 // ConsensusParams gets the consensus parameters at the given block height.
 // If no height is provided, it will fetch the latest consensus params.
 // More: https://docs.tendermint.com/master/rpc/#/Info/consensus_params
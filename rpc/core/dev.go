@@ -1,6 +1,8 @@
 package core
 
 import (
+	cfg "github.com/tendermint/tendermint/config"
+	tmflags "github.com/tendermint/tendermint/libs/cli/flags"
 	ctypes "github.com/tendermint/tendermint/rpc/core/types"
 	rpctypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
 )
@@ -10,3 +12,14 @@ func UnsafeFlushMempool(ctx *rpctypes.Context) (*ctypes.ResultUnsafeFlushMempool
 	env.Mempool.Flush()
 	return &ctypes.ResultUnsafeFlushMempool{}, nil
 }
+
+// UnsafeSetLogLevel sets the log level of the running node, in the format of
+// the log_level config option. The level is kept until the node is restarted
+// or the config file is reloaded.
+func UnsafeSetLogLevel(ctx *rpctypes.Context, level string) (*ctypes.ResultUnsafeSetLogLevel, error) {
+	if err := tmflags.SetLogLevel(level, env.Logger, cfg.DefaultLogLevel()); err != nil {
+		return nil, err
+	}
+	env.Logger.Info("Set log level", "level", level)
+	return &ctypes.ResultUnsafeSetLogLevel{LogLevel: level}, nil
+}
@@ -17,9 +17,8 @@ import (
 )
 
 const (
-	// see README
-	defaultPerPage = 30
-	maxPerPage     = 100
+	// number of heights returned by consensus_timeline by default
+	defaultTimelineHeights = 10
 
 	// SubscribeTimeout is the maximum time we wait to subscribe for an event.
 	// must be less than the server's write timeout (see rpcserver.DefaultConfig)
@@ -46,6 +45,7 @@ type Consensus interface {
 	GetLastHeight() int64
 	GetRoundStateJSON() ([]byte, error)
 	GetRoundStateSimpleJSON() ([]byte, error)
+	GetTimelineJSON(heights int) ([]byte, error)
 }
 
 type transport interface {
@@ -82,6 +82,7 @@ type Environment struct {
 	PubKey           crypto.PubKey
 	GenDoc           *types.GenesisDoc // cache the genesis structure
 	TxIndexer        txindex.TxIndexer
+	UptimeTracker    *sm.UptimeTracker // nil if the uptime of the validators isn't tracked
 	ConsensusReactor *consensus.Reactor
 	EventBus         *types.EventBus // thread safe
 	Mempool          mempl.Mempool
@@ -89,54 +90,13 @@ type Environment struct {
 	Logger log.Logger
 
 	Config cfg.RPCConfig
+	// Expected block time, the health is checked against (see
+	// cfg.ConsensusConfig.ExpectedBlockTime)
+	ExpectedBlockTime time.Duration
 }
 
 //----------------------------------------------
 
-func validatePage(pagePtr *int, perPage, totalCount int) (int, error) {
-	if perPage < 1 {
-		panic(fmt.Sprintf("zero or negative perPage: %d", perPage))
-	}
-
-	if pagePtr == nil { // no page parameter
-		return 1, nil
-	}
-
-	pages := ((totalCount - 1) / perPage) + 1
-	if pages == 0 {
-		pages = 1 // one page (even if it's empty)
-	}
-	page := *pagePtr
-	if page <= 0 || page > pages {
-		return 1, fmt.Errorf("page should be within [1, %d] range, given %d", pages, page)
-	}
-
-	return page, nil
-}
-
-func validatePerPage(perPagePtr *int) int {
-	if perPagePtr == nil { // no per_page parameter
-		return defaultPerPage
-	}
-
-	perPage := *perPagePtr
-	if perPage < 1 {
-		return defaultPerPage
-	} else if perPage > maxPerPage {
-		return maxPerPage
-	}
-	return perPage
-}
-
-func validateSkipCount(page, perPage int) int {
-	skipCount := (page - 1) * perPage
-	if skipCount < 0 {
-		return 0
-	}
-
-	return skipCount
-}
-
 // latestHeight can be either latest committed or uncommitted (+1) height.
 func getHeight(latestHeight int64, heightPtr *int64) (int64, error) {
 	if heightPtr != nil {
@@ -148,8 +108,9 @@ func getHeight(latestHeight int64, heightPtr *int64) (int64, error) {
 			return 0, fmt.Errorf("height %d must be less than or equal to the current blockchain height %d",
 				height, latestHeight)
 		}
+		// Headers and commits below the base may have been backfilled after state sync.
 		base := env.BlockStore.Base()
-		if height < base {
+		if height < base && env.BlockStore.LoadBlockMeta(height) == nil {
 			return 0, fmt.Errorf("height %v is not available, lowest height is %v",
 				height, base)
 		}
@@ -159,6 +120,11 @@ func getHeight(latestHeight int64, heightPtr *int64) (int64, error) {
 }
 
 func latestUncommittedHeight() int64 {
+	// Without consensus, e.g. when inspecting a stopped node, the latest
+	// height is the last stored block.
+	if env.ConsensusReactor == nil {
+		return env.BlockStore.Height()
+	}
 	nodeIsSyncing := env.ConsensusReactor.WaitSync()
 	if nodeIsSyncing {
 		return env.BlockStore.Height()
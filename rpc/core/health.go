@@ -1,13 +1,99 @@
 package core
 
 import (
+	"fmt"
+	"net/http"
+	"time"
+
+	tmjson "github.com/tendermint/tendermint/libs/json"
 	ctypes "github.com/tendermint/tendermint/rpc/core/types"
 	rpctypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
+	tmtime "github.com/tendermint/tendermint/types/time"
 )
 
-// Health gets node health. Returns empty result (200 OK) on success, no
-// response - in case of an error.
+// Health gets node health: whether the node is live, i.e. its ABCI
+// connections work, and whether it's ready, i.e. live, caught up, with enough
+// peers and its consensus not stalled. It always returns the health (200 OK),
+// see /health/live and /health/ready for the status codes.
 // More: https://docs.tendermint.com/master/rpc/#/Info/health
 func Health(ctx *rpctypes.Context) (*ctypes.ResultHealth, error) {
-	return &ctypes.ResultHealth{}, nil
+	return health(), nil
+}
+
+// LiveHandler serves the health of the node over HTTP, with the 503 status
+// code if the node isn't live, e.g. for a liveness probe restarting the node.
+func LiveHandler(w http.ResponseWriter, r *http.Request) {
+	h := health()
+	writeHealth(w, h, h.Live)
+}
+
+// ReadyHandler serves the health of the node over HTTP, with the 503 status
+// code if the node isn't ready, e.g. for a load balancer routing requests
+// only to the nodes which are caught up.
+func ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	h := health()
+	writeHealth(w, h, h.Ready)
+}
+
+func writeHealth(w http.ResponseWriter, h *ctypes.ResultHealth, ok bool) {
+	bz, err := tmjson.Marshal(h)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if ok {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if _, err := w.Write(bz); err != nil {
+		env.Logger.Error("Failed to write health", "err", err)
+	}
+}
+
+// health checks the health of the node against the thresholds of the RPC
+// config.
+func health() *ctypes.ResultHealth {
+	h := &ctypes.ResultHealth{
+		CatchingUp:        env.ConsensusReactor.WaitSync(),
+		NumPeers:          env.P2PPeers.Peers().Size(),
+		LatestBlockHeight: env.BlockStore.Height(),
+	}
+	var notLive, notReady []string
+
+	if err := env.ProxyAppQuery.Error(); err != nil {
+		notLive = append(notLive, fmt.Sprintf("ABCI query connection failed: %v", err))
+	}
+	if err := env.ProxyAppMempool.Error(); err != nil {
+		notLive = append(notLive, fmt.Sprintf("ABCI mempool connection failed: %v", err))
+	}
+
+	// The consensus is stalled if no block was made for too long, unless the
+	// node is catching up or the chain didn't start yet. A stalled node isn't
+	// ready, but it's live, as restarting it doesn't help the chain make
+	// blocks when the other validators are down.
+	if meta := env.BlockStore.LoadBlockMeta(h.LatestBlockHeight); meta != nil {
+		h.LatestBlockTime = meta.Header.Time
+	}
+	maxDelay := time.Duration(env.Config.HealthMaxBlockDelay) * env.ExpectedBlockTime
+	if !h.CatchingUp && maxDelay > 0 && !h.LatestBlockTime.IsZero() {
+		if delay := tmtime.Now().Sub(h.LatestBlockTime); delay > maxDelay {
+			notReady = append(notReady, fmt.Sprintf("no block for %v, more than %d times the expected block time of %v",
+				delay.Truncate(time.Millisecond), env.Config.HealthMaxBlockDelay, env.ExpectedBlockTime))
+		}
+	}
+
+	if h.CatchingUp {
+		notReady = append(notReady, "catching up")
+	}
+	if h.NumPeers < env.Config.HealthMinPeers {
+		notReady = append(notReady, fmt.Sprintf("%d peers, fewer than the minimum of %d",
+			h.NumPeers, env.Config.HealthMinPeers))
+	}
+
+	h.Live = len(notLive) == 0
+	h.Ready = h.Live && len(notReady) == 0
+	h.Errors = append(notLive, notReady...)
+	return h
 }
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	abcicli "github.com/tendermint/tendermint/abci/client"
+	abcimocks "github.com/tendermint/tendermint/abci/client/mocks"
+	"github.com/tendermint/tendermint/abci/example/kvstore"
+	cfg "github.com/tendermint/tendermint/config"
+	"github.com/tendermint/tendermint/consensus"
+	tmjson "github.com/tendermint/tendermint/libs/json"
+	"github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/p2p"
+	"github.com/tendermint/tendermint/proxy"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	rpctypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
+	"github.com/tendermint/tendermint/types"
+	tmtime "github.com/tendermint/tendermint/types/time"
+)
+
+// timedBlockStore is a block store whose latest block was made at the given time.
+type timedBlockStore struct {
+	mockBlockStore
+	time time.Time
+}
+
+func (store timedBlockStore) LoadBlockMeta(height int64) *types.BlockMeta {
+	return &types.BlockMeta{Header: types.Header{Height: height, Time: store.time}}
+}
+
+func TestHealth(t *testing.T) {
+	app := abcicli.NewLocalClient(nil, kvstore.NewApplication())
+	failed := &abcimocks.Client{}
+	failed.On("Error").Return(errors.New("connection reset"))
+
+	testCases := []struct {
+		name       string
+		catchingUp bool
+		minPeers   int
+		blockAge   time.Duration
+		query      abcicli.Client
+		live       bool
+		ready      bool
+	}{
+		{"healthy", false, 0, time.Second, app, true, true},
+		{"not enough peers", false, 1, time.Second, app, true, false},
+		{"stalled", false, 0, time.Hour, app, true, false},
+		{"catching up", true, 0, time.Hour, app, true, false},
+		{"ABCI connection failed", false, 0, time.Second, failed, false, false},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			config := cfg.TestRPCConfig()
+			config.HealthMinPeers = tc.minPeers
+			env = &Environment{
+				ProxyAppQuery:   proxy.NewAppConnQuery(tc.query),
+				ProxyAppMempool: proxy.NewAppConnMempool(app),
+				BlockStore:      timedBlockStore{mockBlockStore{height: 10}, tmtime.Now().Add(-tc.blockAge)},
+				P2PPeers: p2p.MakeSwitch(cfg.DefaultP2PConfig(), 1, "testing", "123.123.123",
+					func(n int, sw *p2p.Switch) *p2p.Switch { return sw }),
+				ConsensusReactor:  consensus.NewReactor(nil, tc.catchingUp),
+				Logger:            log.TestingLogger(),
+				Config:            *config,
+				ExpectedBlockTime: time.Second,
+			}
+
+			res, err := Health(&rpctypes.Context{})
+			require.NoError(t, err)
+			assert.Equal(t, tc.live, res.Live)
+			assert.Equal(t, tc.ready, res.Ready)
+			assert.Equal(t, tc.catchingUp, res.CatchingUp)
+			assert.EqualValues(t, 10, res.LatestBlockHeight)
+			assert.Equal(t, tc.ready, len(res.Errors) == 0, res.Errors)
+
+			for _, handler := range []struct {
+				handler http.HandlerFunc
+				ok      bool
+			}{{LiveHandler, tc.live}, {ReadyHandler, tc.ready}} {
+				rec := httptest.NewRecorder()
+				handler.handler(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+				if handler.ok {
+					assert.Equal(t, http.StatusOK, rec.Code)
+				} else {
+					assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+				}
+				var health ctypes.ResultHealth
+				require.NoError(t, tmjson.Unmarshal(rec.Body.Bytes(), &health))
+				assert.Equal(t, res.Errors, health.Errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package coretypes
+
+import "fmt"
+
+const (
+	// DefaultPerPage is the number of items per page when per_page isn't given.
+	DefaultPerPage = 30
+	// MaxPerPage is the maximum number of items per page.
+	MaxPerPage = 100
+)
+
+// ValidatePage returns the requested page, 1 if pagePtr is nil, or an error if
+// it's out of the range of pages of perPage items out of totalCount.
+func ValidatePage(pagePtr *int, perPage, totalCount int) (int, error) {
+	if perPage < 1 {
+		panic(fmt.Sprintf("zero or negative perPage: %d", perPage))
+	}
+
+	if pagePtr == nil { // no page parameter
+		return 1, nil
+	}
+
+	pages := ((totalCount - 1) / perPage) + 1
+	if pages == 0 {
+		pages = 1 // one page (even if it's empty)
+	}
+	page := *pagePtr
+	if page <= 0 || page > pages {
+		return 1, fmt.Errorf("page should be within [1, %d] range, given %d", pages, page)
+	}
+
+	return page, nil
+}
+
+// ValidatePerPage returns the requested number of items per page, bounded by
+// MaxPerPage, or DefaultPerPage if perPagePtr is nil or not positive.
+func ValidatePerPage(perPagePtr *int) int {
+	if perPagePtr == nil { // no per_page parameter
+		return DefaultPerPage
+	}
+
+	perPage := *perPagePtr
+	if perPage < 1 {
+		return DefaultPerPage
+	} else if perPage > MaxPerPage {
+		return MaxPerPage
+	}
+	return perPage
+}
+
+// ValidateSkipCount returns the number of items before the given page.
+func ValidateSkipCount(page, perPage int) int {
+	skipCount := (page - 1) * perPage
+	if skipCount < 0 {
+		return 0
+	}
+
+	return skipCount
+}
@@ -1,4 +1,4 @@
-package core
+package coretypes
 
 import (
 	"fmt"
@@ -40,7 +40,7 @@ func TestPaginationPage(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		p, err := validatePage(&c.page, c.perPage, c.totalCount)
+		p, err := ValidatePage(&c.page, c.perPage, c.totalCount)
 		if c.expErr {
 			assert.Error(t, err)
 			continue
@@ -50,7 +50,7 @@ func TestPaginationPage(t *testing.T) {
 	}
 
 	// nil case
-	p, err := validatePage(nil, 1, 1)
+	p, err := ValidatePage(nil, 1, 1)
 	if assert.NoError(t, err) {
 		assert.Equal(t, 1, p)
 	}
@@ -62,21 +62,21 @@ func TestPaginationPerPage(t *testing.T) {
 		perPage    int
 		newPerPage int
 	}{
-		{5, 0, defaultPerPage},
+		{5, 0, DefaultPerPage},
 		{5, 1, 1},
 		{5, 2, 2},
-		{5, defaultPerPage, defaultPerPage},
-		{5, maxPerPage - 1, maxPerPage - 1},
-		{5, maxPerPage, maxPerPage},
-		{5, maxPerPage + 1, maxPerPage},
+		{5, DefaultPerPage, DefaultPerPage},
+		{5, MaxPerPage - 1, MaxPerPage - 1},
+		{5, MaxPerPage, MaxPerPage},
+		{5, MaxPerPage + 1, MaxPerPage},
 	}
 
 	for _, c := range cases {
-		p := validatePerPage(&c.perPage)
+		p := ValidatePerPage(&c.perPage)
 		assert.Equal(t, c.newPerPage, p, fmt.Sprintf("%v", c))
 	}
 
 	// nil case
-	p := validatePerPage(nil)
-	assert.Equal(t, defaultPerPage, p)
+	p := ValidatePerPage(nil)
+	assert.Equal(t, DefaultPerPage, p)
 }
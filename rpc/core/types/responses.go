@@ -109,6 +109,11 @@ type ResultDialSeeds struct {
 	Log string `json:"log"`
 }
 
+// Log level set with unsafe_set_log_level
+type ResultUnsafeSetLogLevel struct {
+	LogLevel string `json:"log_level"`
+}
+
 // Log from dialing peers
 type ResultDialPeers struct {
 	Log string `json:"log"`
@@ -132,6 +137,32 @@ type ResultValidators struct {
 	Total int `json:"total"`
 }
 
+// Uptime of the validators over the last blocks.
+type ResultValidatorUptime struct {
+	// Heights of the first and last blocks of the window
+	FromHeight int64             `json:"from_height"`
+	ToHeight   int64             `json:"to_height"`
+	Validators []ValidatorUptime `json:"validators"`
+	// Count of actual validators in this result
+	Count int `json:"count"`
+	// Total number of validators
+	Total int `json:"total"`
+}
+
+// ValidatorUptime is the uptime of a validator over the last blocks.
+type ValidatorUptime struct {
+	Address      bytes.HexBytes `json:"address"`
+	SignedBlocks int64          `json:"signed_blocks"`
+	MissedBlocks int64          `json:"missed_blocks"`
+	// Number of blocks missed in a row, since the last block signed
+	ConsecutiveMissedBlocks int64 `json:"consecutive_missed_blocks"`
+	// Height of the last block signed, or 0 if none was in the window
+	LastSignedHeight int64 `json:"last_signed_height"`
+	// Ratio of the blocks signed, among those the validator was in the
+	// validator set for
+	Uptime float64 `json:"uptime"`
+}
+
 // ConsensusParams for given height
 type ResultConsensusParams struct {
 	BlockHeight     int64                   `json:"block_height"`
@@ -156,6 +187,12 @@ type ResultConsensusState struct {
 	RoundState json.RawMessage `json:"round_state"`
 }
 
+// Timeline of the consensus of the last heights.
+// UNSTABLE
+type ResultConsensusTimeline struct {
+	Timeline json.RawMessage `json:"timeline"`
+}
+
 // CheckTx result
 type ResultBroadcastTx struct {
 	Code      uint32         `json:"code"`
@@ -224,9 +261,24 @@ type (
 	ResultUnsafeProfile      struct{}
 	ResultSubscribe          struct{}
 	ResultUnsubscribe        struct{}
-	ResultHealth             struct{}
 )
 
+// Node health
+type ResultHealth struct {
+	// Whether the ABCI connections work
+	Live bool `json:"live"`
+	// Whether the node is live, caught up, has enough peers and its consensus
+	// isn't stalled
+	Ready bool `json:"ready"`
+
+	CatchingUp        bool      `json:"catching_up"`
+	NumPeers          int       `json:"n_peers"`
+	LatestBlockHeight int64     `json:"latest_block_height"`
+	LatestBlockTime   time.Time `json:"latest_block_time"`
+	// Reasons the node isn't live or ready
+	Errors []string `json:"errors"`
+}
+
 // Event data from a subscription
 type ResultEvent struct {
 	Query  string              `json:"query"`
@@ -99,7 +99,7 @@ func makeJSONRPCHandler(funcMap map[string]*RPCFunc, logger log.Logger) http.Han
 				}
 				args = append(args, fnArgs...)
 			}
-			returns := rpcFunc.f.Call(args)
+			returns := rpcFunc.call(r, args)
 			logger.Info("HTTPJSONRPC", "method", request.Method, "args", args, "returns", returns)
 			result, err := unreflectResult(returns)
 			if err != nil {
@@ -3,6 +3,7 @@ package server
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -13,6 +14,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/libs/trace"
 	types "github.com/tendermint/tendermint/rpc/jsonrpc/types"
 )
 
@@ -227,3 +229,51 @@ func TestUnknownRPCPath(t *testing.T) {
 	require.Equal(t, http.StatusNotFound, res.StatusCode, "should always return 404")
 	res.Body.Close()
 }
+
+func TestTraceRPCFuncs(t *testing.T) {
+	funcMap := map[string]*RPCFunc{
+		"c": NewRPCFunc(func(ctx *types.Context, s string) (string, error) { return s, nil }, "s"),
+		"e": NewRPCFunc(func(ctx *types.Context) (string, error) { return "", errors.New("failed") }, ""),
+	}
+	var out bytes.Buffer
+	tracer := trace.NewTracer(trace.NewFileExporter(&out, "test"))
+	require.NoError(t, tracer.Start())
+	mux := http.NewServeMux()
+	RegisterRPCFuncs(mux, TraceRPCFuncs(funcMap, tracer), log.NewNopLogger())
+
+	parent := trace.SpanContext{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{2}}
+	req := httptest.NewRequest("POST", "http://localhost/", strings.NewReader(`{"jsonrpc": "2.0", "method": "c", "id": "0", "params": ["a"]}`))
+	req.Header.Set(trace.TraceParentHeader, trace.TraceParent(parent))
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "http://localhost/e", nil))
+	require.NoError(t, tracer.Stop())
+
+	var data struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					Name         string `json:"name"`
+					TraceID      string `json:"traceId"`
+					ParentSpanID string `json:"parentSpanId"`
+					Status       *struct {
+						Message string `json:"message"`
+					} `json:"status"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &data))
+	spans := data.ResourceSpans[0].ScopeSpans[0].Spans
+	require.Len(t, spans, 2)
+	assert.Equal(t, "rpc.c", spans[0].Name)
+	assert.Equal(t, parent.TraceID.String(), spans[0].TraceID)
+	assert.Equal(t, parent.SpanID.String(), spans[0].ParentSpanID)
+	assert.Nil(t, spans[0].Status)
+	assert.Equal(t, "rpc.e", spans[1].Name)
+	assert.Empty(t, spans[1].ParentSpanID)
+	require.NotNil(t, spans[1].Status)
+	assert.Equal(t, "failed", spans[1].Status.Message)
+
+	// The functions aren't copied without tracing.
+	assert.Equal(t, funcMap, TraceRPCFuncs(funcMap, trace.NopTracer()))
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/libs/trace"
 )
 
 // RegisterRPCFuncs adds a route for each function in the funcMap, as well as
@@ -34,6 +35,9 @@ type RPCFunc struct {
 	returns  []reflect.Type // type of each return arg
 	argNames []string       // name of each argument
 	ws       bool           // websocket only
+
+	name   string        // name of the route, if traced
+	tracer *trace.Tracer // traces the calls, if set
 }
 
 // NewRPCFunc wraps a function for introspection.
@@ -61,6 +65,39 @@ func newRPCFunc(f interface{}, args string, ws bool) *RPCFunc {
 	}
 }
 
+// TraceRPCFuncs returns a copy of funcMap tracing the calls of every function
+// with tracer, with spans named after the routes, e.g. rpc.status. The calls
+// made over HTTP are traced as children of the spans propagated with the
+// traceparent header, if any.
+func TraceRPCFuncs(funcMap map[string]*RPCFunc, tracer *trace.Tracer) map[string]*RPCFunc {
+	if !tracer.Enabled() {
+		return funcMap
+	}
+	traced := make(map[string]*RPCFunc, len(funcMap))
+	for name, rpcFunc := range funcMap {
+		f := *rpcFunc
+		f.name = name
+		f.tracer = tracer
+		traced[name] = &f
+	}
+	return traced
+}
+
+// call calls the function with the arguments, for the HTTP request r, if any.
+func (f *RPCFunc) call(r *http.Request, args []reflect.Value) []reflect.Value {
+	if f.tracer == nil {
+		return f.f.Call(args)
+	}
+
+	span := f.tracer.StartSpan("rpc."+f.name, trace.SpanContextFromHTTPRequest(r), "rpc.method", f.name)
+	returns := f.f.Call(args)
+	if err, ok := returns[len(returns)-1].Interface().(error); ok {
+		span.SetError(err)
+	}
+	span.End()
+	return returns
+}
+
 // return a function's argument types
 func funcArgTypes(f interface{}) []reflect.Type {
 	t := reflect.TypeOf(f)
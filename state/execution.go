@@ -1,14 +1,17 @@
 package state
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
 
+	abcicli "github.com/tendermint/tendermint/abci/client"
 	abci "github.com/tendermint/tendermint/abci/types"
 	cryptoenc "github.com/tendermint/tendermint/crypto/encoding"
 	"github.com/tendermint/tendermint/libs/fail"
 	"github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/libs/trace"
 	mempl "github.com/tendermint/tendermint/mempool"
 	tmstate "github.com/tendermint/tendermint/proto/tendermint/state"
 	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
@@ -40,6 +43,8 @@ type BlockExecutor struct {
 	logger log.Logger
 
 	metrics *Metrics
+	tracer  *trace.Tracer
+	uptime  *UptimeTracker // nil if the uptime of the validators isn't tracked
 }
 
 type BlockExecutorOption func(executor *BlockExecutor)
@@ -50,6 +55,21 @@ func BlockExecutorWithMetrics(metrics *Metrics) BlockExecutorOption {
 	}
 }
 
+// BlockExecutorWithTracer traces the blocks applied, and their phases.
+func BlockExecutorWithTracer(tracer *trace.Tracer) BlockExecutorOption {
+	return func(blockExec *BlockExecutor) {
+		blockExec.tracer = tracer
+	}
+}
+
+// BlockExecutorWithUptimeTracker tracks the uptime of the validators from the LastCommit of the
+// blocks applied.
+func BlockExecutorWithUptimeTracker(tracker *UptimeTracker) BlockExecutorOption {
+	return func(blockExec *BlockExecutor) {
+		blockExec.uptime = tracker
+	}
+}
+
 // NewBlockExecutor returns a new BlockExecutor with a NopEventBus.
 // Call SetEventBus to provide one.
 func NewBlockExecutor(
@@ -68,6 +88,7 @@ func NewBlockExecutor(
 		evpool:   evpool,
 		logger:   logger,
 		metrics:  NopMetrics(),
+		tracer:   trace.NopTracer(),
 	}
 
 	for _, option := range options {
@@ -126,15 +147,42 @@ func (blockExec *BlockExecutor) ValidateBlock(state State, block *types.Block) e
 func (blockExec *BlockExecutor) ApplyBlock(
 	state State, blockID types.BlockID, block *types.Block,
 ) (State, int64, error) {
+	return blockExec.ApplyBlockWithContext(context.Background(), state, blockID, block)
+}
+
+// ApplyBlockWithContext is like ApplyBlock, and traces the block as a child of
+// the span of ctx, if any (see BlockExecutorWithTracer).
+func (blockExec *BlockExecutor) ApplyBlockWithContext(
+	ctx context.Context, state State, blockID types.BlockID, block *types.Block,
+) (State, int64, error) {
+	_, span := blockExec.tracer.StartSpanFromContext(ctx, "state.ApplyBlock",
+		"height", block.Height, "txs", len(block.Txs))
+	defer span.End()
+	defer blockExec.setTraceParent(trace.SpanContext{})
+
+	state, retainHeight, err := blockExec.applyBlock(span, state, blockID, block)
+	span.SetError(err)
+	return state, retainHeight, err
+}
 
-	if err := blockExec.ValidateBlock(state, block); err != nil {
+func (blockExec *BlockExecutor) applyBlock(
+	span *trace.Span, state State, blockID types.BlockID, block *types.Block,
+) (State, int64, error) {
+
+	phase := blockExec.startPhase(span, "ValidateBlock")
+	err := blockExec.ValidateBlock(state, block)
+	phase.End()
+	if err != nil {
 		return state, 0, ErrInvalidBlock(err)
 	}
 
+	phase = blockExec.startPhase(span, "ExecBlock")
 	startTime := time.Now().UnixNano()
 	abciResponses, err := execBlockOnProxyApp(blockExec.logger, blockExec.proxyApp, block,
 		blockExec.store, state.InitialHeight)
 	endTime := time.Now().UnixNano()
+	phase.SetError(err)
+	phase.End()
 	blockExec.metrics.BlockProcessingTime.Observe(float64(endTime-startTime) / 1000000)
 	if err != nil {
 		return state, 0, ErrProxyAppConn(err)
@@ -143,7 +191,10 @@ func (blockExec *BlockExecutor) ApplyBlock(
 	fail.Fail() // XXX
 
 	// Save the results before we commit.
-	if err := blockExec.store.SaveABCIResponses(block.Height, abciResponses); err != nil {
+	phase = blockExec.startPhase(span, "SaveABCIResponses")
+	err = blockExec.store.SaveABCIResponses(block.Height, abciResponses)
+	phase.End()
+	if err != nil {
 		return state, 0, err
 	}
 
@@ -163,26 +214,43 @@ func (blockExec *BlockExecutor) ApplyBlock(
 		blockExec.logger.Info("Updates to validators", "updates", types.ValidatorListString(validatorUpdates))
 	}
 
+	// Track the validators who signed the previous block, before the state is updated.
+	missedBlocks := blockExec.trackUptime(state, block)
+
 	// Update the state with the block and responses.
+	phase = blockExec.startPhase(span, "UpdateState")
 	state, err = updateState(state, blockID, &block.Header, abciResponses, validatorUpdates)
+	phase.End()
 	if err != nil {
 		return state, 0, fmt.Errorf("commit failed for application: %v", err)
 	}
 
 	// Lock mempool, commit app state, update mempoool.
+	phase = blockExec.startPhase(span, "Commit")
 	appHash, retainHeight, err := blockExec.Commit(state, block, abciResponses.DeliverTxs)
+	phase.SetError(err)
+	phase.End()
 	if err != nil {
 		return state, 0, fmt.Errorf("commit failed for application: %v", err)
 	}
 
 	// Update evpool with the block and state.
+	phase = blockExec.startPhase(span, "UpdateEvidencePool")
 	blockExec.evpool.Update(block, state)
+	phase.End()
 
 	fail.Fail() // XXX
 
 	// Update the app hash and save the state.
+	phase = blockExec.startPhase(span, "SaveState")
 	state.AppHash = appHash
-	if err := blockExec.store.Save(state); err != nil {
+	err = blockExec.store.Save(state)
+	if err == nil {
+		// Save the retain height, so that the pruner never prunes blocks the app still needs.
+		err = blockExec.store.SaveApplicationRetainHeight(retainHeight)
+	}
+	phase.End()
+	if err != nil {
 		return state, 0, err
 	}
 
@@ -190,11 +258,54 @@ func (blockExec *BlockExecutor) ApplyBlock(
 
 	// Events are fired after everything else.
 	// NOTE: if we crash between Commit and Save, events wont be fired during replay
+	phase = blockExec.startPhase(span, "FireEvents")
 	fireEvents(blockExec.logger, blockExec.eventBus, block, abciResponses, validatorUpdates)
+	if missedBlocks != nil {
+		if err := blockExec.eventBus.PublishEventValidatorMissedBlocks(*missedBlocks); err != nil {
+			blockExec.logger.Error("Error publishing validator missed blocks", "err", err)
+		}
+	}
+	phase.End()
 
 	return state, retainHeight, nil
 }
 
+// trackUptime tracks which validators signed the block before the given one, if the uptime is
+// tracked, and returns the event to fire if the local validator missed too many blocks in a row.
+// Failing to track the uptime doesn't fail the block.
+func (blockExec *BlockExecutor) trackUptime(
+	state State, block *types.Block,
+) *types.EventDataValidatorMissedBlocks {
+	// The first LastCommit is empty.
+	if blockExec.uptime == nil || block.Height <= state.InitialHeight {
+		return nil
+	}
+	missedBlocks, err := blockExec.uptime.Track(block.Height-1, block.LastCommit, state.LastValidators)
+	if err != nil {
+		blockExec.logger.Error("Failed to track the uptime of the validators", "height", block.Height-1, "err", err)
+	}
+	return missedBlocks
+}
+
+// startPhase starts the span of a phase of applying a block, which is the
+// parent of the spans of the ABCI requests made in the phase.
+func (blockExec *BlockExecutor) startPhase(span *trace.Span, name string) *trace.Span {
+	phase := blockExec.tracer.StartSpan("state."+name, span.Context())
+	blockExec.setTraceParent(phase.Context())
+	return phase
+}
+
+// setTraceParent sets the parent of the spans of the ABCI requests, if they
+// are traced (see abcicli.TraceParentSetter).
+func (blockExec *BlockExecutor) setTraceParent(sc trace.SpanContext) {
+	if !blockExec.tracer.Enabled() {
+		return
+	}
+	if setter, ok := blockExec.proxyApp.(abcicli.TraceParentSetter); ok {
+		setter.SetTraceParent(sc)
+	}
+}
+
 // Commit locks the mempool, runs the ABCI Commit message, and updates the
 // mempool.
 // It returns the result of calling abci.Commit (the AppHash) and the height to retain (if any).
@@ -1,7 +1,9 @@
 package state_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -12,6 +14,7 @@ import (
 	"github.com/tendermint/tendermint/crypto/ed25519"
 	cryptoenc "github.com/tendermint/tendermint/crypto/encoding"
 	"github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/libs/trace"
 	"github.com/tendermint/tendermint/mempool/mock"
 	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
 	"github.com/tendermint/tendermint/proxy"
@@ -29,7 +32,7 @@ var (
 func TestApplyBlock(t *testing.T) {
 	app := &testApp{}
 	cc := proxy.NewLocalClientCreator(app)
-	proxyApp := proxy.NewAppConns(cc)
+	proxyApp := proxy.NewAppConns(cc, proxy.NopMetrics())
 	err := proxyApp.Start()
 	require.Nil(t, err)
 	defer proxyApp.Stop() //nolint:errcheck // ignore for tests
@@ -46,16 +49,81 @@ func TestApplyBlock(t *testing.T) {
 	state, retainHeight, err := blockExec.ApplyBlock(state, blockID, block)
 	require.Nil(t, err)
 	assert.EqualValues(t, retainHeight, 1)
+	appRetainHeight, err := stateStore.LoadApplicationRetainHeight()
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, appRetainHeight)
 
 	// TODO check state and mempool
 	assert.EqualValues(t, 1, state.Version.Consensus.App, "App version wasn't updated")
 }
 
+func TestApplyBlockTracing(t *testing.T) {
+	var buf bytes.Buffer
+	tracer := trace.NewTracer(trace.NewFileExporter(&buf, "test"))
+	require.NoError(t, tracer.Start())
+
+	app := &testApp{}
+	cc := proxy.NewTracingClientCreator(proxy.NewLocalClientCreator(app), tracer)
+	proxyApp := proxy.NewAppConns(cc, proxy.NopMetrics())
+	err := proxyApp.Start()
+	require.Nil(t, err)
+	defer proxyApp.Stop() //nolint:errcheck // ignore for tests
+
+	state, stateDB, _ := makeState(1, 1)
+	stateStore := sm.NewStore(stateDB)
+
+	blockExec := sm.NewBlockExecutor(stateStore, log.TestingLogger(), proxyApp.Consensus(),
+		mock.Mempool{}, sm.MockEvidencePool{}, sm.BlockExecutorWithTracer(tracer))
+
+	block := makeBlock(state, 1)
+	blockID := types.BlockID{Hash: block.Hash(), PartSetHeader: block.MakePartSet(testPartSize).Header()}
+
+	parent := tracer.StartSpan("parent", trace.SpanContext{})
+	_, _, err = blockExec.ApplyBlockWithContext(trace.ContextWithSpan(context.Background(), parent), state, blockID, block)
+	require.NoError(t, err)
+	parent.End()
+	require.NoError(t, tracer.Stop())
+
+	// Every phase is traced as a child of the block, itself a child of the
+	// parent span.
+	type span struct {
+		Name         string `json:"name"`
+		SpanID       string `json:"spanId"`
+		ParentSpanID string `json:"parentSpanId"`
+	}
+	var data struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []span `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &data))
+	spans := make(map[string]span)
+	for _, s := range data.ResourceSpans[0].ScopeSpans[0].Spans {
+		spans[s.Name] = s
+	}
+	require.Contains(t, spans, "state.ApplyBlock")
+	assert.Equal(t, parent.Context().SpanID.String(), spans["state.ApplyBlock"].ParentSpanID)
+	for _, phase := range []string{"ValidateBlock", "ExecBlock", "SaveABCIResponses", "UpdateState",
+		"Commit", "UpdateEvidencePool", "SaveState", "FireEvents"} {
+		require.Contains(t, spans, "state."+phase)
+		assert.Equal(t, spans["state.ApplyBlock"].SpanID, spans["state."+phase].ParentSpanID, phase)
+	}
+
+	// The ABCI requests are traced as children of their phase.
+	for req, phase := range map[string]string{"BeginBlock": "ExecBlock", "EndBlock": "ExecBlock",
+		"Commit": "Commit"} {
+		require.Contains(t, spans, "abci."+req)
+		assert.Equal(t, spans["state."+phase].SpanID, spans["abci."+req].ParentSpanID, req)
+	}
+}
+
 // TestBeginBlockValidators ensures we send absent validators list.
 func TestBeginBlockValidators(t *testing.T) {
 	app := &testApp{}
 	cc := proxy.NewLocalClientCreator(app)
-	proxyApp := proxy.NewAppConns(cc)
+	proxyApp := proxy.NewAppConns(cc, proxy.NopMetrics())
 	err := proxyApp.Start()
 	require.Nil(t, err)
 	defer proxyApp.Stop() //nolint:errcheck // no need to check error again
@@ -118,7 +186,7 @@ func TestBeginBlockValidators(t *testing.T) {
 func TestBeginBlockByzantineValidators(t *testing.T) {
 	app := &testApp{}
 	cc := proxy.NewLocalClientCreator(app)
-	proxyApp := proxy.NewAppConns(cc)
+	proxyApp := proxy.NewAppConns(cc, proxy.NopMetrics())
 	err := proxyApp.Start()
 	require.Nil(t, err)
 	defer proxyApp.Stop() //nolint:errcheck // ignore for tests
@@ -308,7 +376,7 @@ func TestUpdateValidators(t *testing.T) {
 func TestEndBlockValidatorUpdates(t *testing.T) {
 	app := &testApp{}
 	cc := proxy.NewLocalClientCreator(app)
-	proxyApp := proxy.NewAppConns(cc)
+	proxyApp := proxy.NewAppConns(cc, proxy.NopMetrics())
 	err := proxyApp.Start()
 	require.Nil(t, err)
 	defer proxyApp.Stop() //nolint:errcheck // ignore for tests
@@ -379,7 +447,7 @@ func TestEndBlockValidatorUpdates(t *testing.T) {
 func TestEndBlockValidatorUpdatesResultingInEmptySet(t *testing.T) {
 	app := &testApp{}
 	cc := proxy.NewLocalClientCreator(app)
-	proxyApp := proxy.NewAppConns(cc)
+	proxyApp := proxy.NewAppConns(cc, proxy.NopMetrics())
 	err := proxyApp.Start()
 	require.Nil(t, err)
 	defer proxyApp.Stop() //nolint:errcheck // ignore for tests
@@ -17,6 +17,28 @@ const (
 type Metrics struct {
 	// Time between BeginBlock and EndBlock.
 	BlockProcessingTime metrics.Histogram
+	// Number of blocks pruned by the pruner.
+	PrunedBlocks metrics.Counter
+	// Number of ABCI responses pruned by the pruner.
+	PrunedABCIResponses metrics.Counter
+	// Number of indexed transactions pruned by the pruner.
+	PrunedTxs metrics.Counter
+	// Size of the blocks and ABCI responses pruned by the pruner, in bytes.
+	PrunedBytes metrics.Counter
+	// Height below which the pruner pruned the blocks.
+	PruningRetainHeight metrics.Gauge
+	// Ratio of the blocks a validator signed in the uptime window, labeled by
+	// "validator_address".
+	ValidatorUptime metrics.Gauge
+	// Number of blocks a validator missed in the uptime window, labeled by
+	// "validator_address".
+	ValidatorMissedBlocks metrics.Gauge
+	// Ratio of the blocks the local validator signed in the uptime window.
+	LocalValidatorUptime metrics.Gauge
+	// Number of blocks the local validator missed in the uptime window.
+	LocalValidatorMissedBlocks metrics.Gauge
+	// Number of blocks the local validator missed in a row.
+	LocalValidatorConsecutiveMissedBlocks metrics.Gauge
 }
 
 // PrometheusMetrics returns Metrics build using Prometheus client library.
@@ -27,6 +49,7 @@ func PrometheusMetrics(namespace string, labelsAndValues ...string) *Metrics {
 	for i := 0; i < len(labelsAndValues); i += 2 {
 		labels = append(labels, labelsAndValues[i])
 	}
+	validatorLabels := append(labels[:len(labels):len(labels)], "validator_address")
 	return &Metrics{
 		BlockProcessingTime: prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
 			Namespace: namespace,
@@ -35,6 +58,66 @@ func PrometheusMetrics(namespace string, labelsAndValues ...string) *Metrics {
 			Help:      "Time between BeginBlock and EndBlock in ms.",
 			Buckets:   stdprometheus.LinearBuckets(1, 10, 10),
 		}, labels).With(labelsAndValues...),
+		PrunedBlocks: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "pruned_blocks",
+			Help:      "Number of blocks pruned by the pruner.",
+		}, labels).With(labelsAndValues...),
+		PrunedABCIResponses: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "pruned_abci_responses",
+			Help:      "Number of ABCI responses pruned by the pruner.",
+		}, labels).With(labelsAndValues...),
+		PrunedTxs: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "pruned_txs",
+			Help:      "Number of indexed transactions pruned by the pruner.",
+		}, labels).With(labelsAndValues...),
+		PrunedBytes: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "pruned_bytes",
+			Help:      "Size of the blocks and ABCI responses pruned by the pruner, in bytes.",
+		}, labels).With(labelsAndValues...),
+		PruningRetainHeight: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "pruning_retain_height",
+			Help:      "Height below which the pruner pruned the blocks.",
+		}, labels).With(labelsAndValues...),
+		ValidatorUptime: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "validator_uptime",
+			Help:      "Ratio of the blocks a validator signed in the uptime window.",
+		}, validatorLabels).With(labelsAndValues...),
+		ValidatorMissedBlocks: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "validator_window_missed_blocks",
+			Help:      "Number of blocks a validator missed in the uptime window.",
+		}, validatorLabels).With(labelsAndValues...),
+		LocalValidatorUptime: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "local_validator_uptime",
+			Help:      "Ratio of the blocks the local validator signed in the uptime window.",
+		}, labels).With(labelsAndValues...),
+		LocalValidatorMissedBlocks: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "local_validator_window_missed_blocks",
+			Help:      "Number of blocks the local validator missed in the uptime window.",
+		}, labels).With(labelsAndValues...),
+		LocalValidatorConsecutiveMissedBlocks: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "local_validator_consecutive_missed_blocks",
+			Help:      "Number of blocks the local validator missed in a row.",
+		}, labels).With(labelsAndValues...),
 	}
 }
 
@@ -42,5 +125,16 @@ func PrometheusMetrics(namespace string, labelsAndValues ...string) *Metrics {
 func NopMetrics() *Metrics {
 	return &Metrics{
 		BlockProcessingTime: discard.NewHistogram(),
+		PrunedBlocks:        discard.NewCounter(),
+		PrunedABCIResponses: discard.NewCounter(),
+		PrunedTxs:           discard.NewCounter(),
+		PrunedBytes:         discard.NewCounter(),
+		PruningRetainHeight: discard.NewGauge(),
+
+		ValidatorUptime:                       discard.NewGauge(),
+		ValidatorMissedBlocks:                 discard.NewGauge(),
+		LocalValidatorUptime:                  discard.NewGauge(),
+		LocalValidatorMissedBlocks:            discard.NewGauge(),
+		LocalValidatorConsecutiveMissedBlocks: discard.NewGauge(),
 	}
 }
@@ -0,0 +1,202 @@
+package state
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	cfg "github.com/tendermint/tendermint/config"
+	tmmath "github.com/tendermint/tendermint/libs/math"
+	"github.com/tendermint/tendermint/libs/service"
+	"github.com/tendermint/tendermint/state/txindex"
+	tmtime "github.com/tendermint/tendermint/types/time"
+)
+
+// Pruner is a service which periodically prunes the blocks, states, ABCI responses and indexed
+// transactions according to the operator's retention policy. It never prunes blocks above the
+// retain height last returned by the application on Commit, unless it's 0 (the application
+// doesn't need any block pruned, and leaves it to the operator), nor blocks still needed to
+// verify evidence, i.e. unless they are older than both the evidence max age in blocks and in
+// time. When it runs, consensus leaves the pruning
+// requested by the application to it (see consensus.StateWithoutPruning), so that both don't
+// prune concurrently.
+//
+// Blocks are pruned last, since the block store base is the pruning progress: if pruning is
+// interrupted, the next run prunes the rest from the base again.
+type Pruner struct {
+	service.BaseService
+
+	config     *cfg.PruningConfig
+	stateStore Store
+	blockStore PrunableBlockStore
+	txIndexer  txindex.TxIndexer
+	metrics    *Metrics
+}
+
+// PrunerOption sets an optional parameter on the Pruner.
+type PrunerOption func(*Pruner)
+
+// PrunerWithMetrics sets the metrics.
+func PrunerWithMetrics(metrics *Metrics) PrunerOption {
+	return func(p *Pruner) { p.metrics = metrics }
+}
+
+// NewPruner returns a new pruner of the given stores. The tx indexer may be nil.
+func NewPruner(
+	config *cfg.PruningConfig,
+	stateStore Store,
+	blockStore PrunableBlockStore,
+	txIndexer txindex.TxIndexer,
+	options ...PrunerOption,
+) *Pruner {
+	p := &Pruner{
+		config:     config,
+		stateStore: stateStore,
+		blockStore: blockStore,
+		txIndexer:  txIndexer,
+		metrics:    NopMetrics(),
+	}
+	p.BaseService = *service.NewBaseService(nil, "Pruner", p)
+	for _, option := range options {
+		option(p)
+	}
+	return p
+}
+
+// OnStart implements service.Service.
+func (p *Pruner) OnStart() error {
+	go p.pruneRoutine()
+	return nil
+}
+
+func (p *Pruner) pruneRoutine() {
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := p.Prune(); err != nil {
+				p.Logger.Error("Failed to prune", "err", err)
+			}
+		case <-p.Quit():
+			return
+		}
+	}
+}
+
+// Prune prunes once according to the retention policy.
+func (p *Pruner) Prune() error {
+	state, err := p.stateStore.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+	if state.IsEmpty() || state.LastBlockHeight == 0 {
+		return nil
+	}
+	base := p.blockStore.Base()
+	if base == 0 {
+		return nil
+	}
+
+	var (
+		retainHeight     = p.blockRetainHeight(state, base)
+		abciRetainHeight = p.abciResponsesRetainHeight(state)
+		stats            = struct{ blocks, abciResponses, txs uint64 }{}
+		size             int64
+	)
+
+	if retainHeight > base && p.txIndexer != nil {
+		stats.txs, err = p.txIndexer.Prune(base, retainHeight)
+		if err != nil {
+			return fmt.Errorf("failed to prune tx index: %w", err)
+		}
+	}
+	if abciRetainHeight > base {
+		stats.abciResponses, size, err = p.stateStore.PruneABCIResponses(base, abciRetainHeight)
+		if err != nil {
+			return fmt.Errorf("failed to prune ABCI responses: %w", err)
+		}
+	}
+	if retainHeight > base {
+		if err := p.stateStore.PruneStates(base, retainHeight); err != nil {
+			return fmt.Errorf("failed to prune state database: %w", err)
+		}
+		var blocksSize int64
+		stats.blocks, blocksSize, err = p.blockStore.PruneBlocksKeepEvery(retainHeight, p.config.KeepEvery)
+		// The blocks may have been pruned past the retain height meanwhile, e.g. on commit.
+		if err != nil && p.blockStore.Base() < retainHeight {
+			return fmt.Errorf("failed to prune block store: %w", err)
+		}
+		size += blocksSize
+		p.metrics.PruningRetainHeight.Set(float64(retainHeight))
+	}
+
+	p.metrics.PrunedBlocks.Add(float64(stats.blocks))
+	p.metrics.PrunedABCIResponses.Add(float64(stats.abciResponses))
+	p.metrics.PrunedTxs.Add(float64(stats.txs))
+	p.metrics.PrunedBytes.Add(float64(size))
+	if stats.blocks > 0 || stats.abciResponses > 0 || stats.txs > 0 {
+		p.Logger.Info("Pruned", "retainHeight", retainHeight, "blocks", stats.blocks,
+			"abciResponses", stats.abciResponses, "txs", stats.txs, "reclaimed", size)
+	}
+	return nil
+}
+
+// blockRetainHeight returns the height below which blocks, states and indexed transactions can
+// be pruned, or the base if none can.
+func (p *Pruner) blockRetainHeight(state State, base int64) int64 {
+	height := p.blockStore.Height()
+	if state.LastBlockHeight < height {
+		height = state.LastBlockHeight
+	}
+
+	appRetainHeight, err := p.stateStore.LoadApplicationRetainHeight()
+	if err != nil {
+		p.Logger.Error("Failed to load the application retain height", "err", err)
+		return base
+	}
+	if appRetainHeight <= 0 && p.config.KeepRecent <= 0 && p.config.KeepDuration <= 0 {
+		return base
+	}
+
+	// A block is kept as long as either retention keeps it.
+	retainHeight := height + 1
+	if appRetainHeight > 0 {
+		retainHeight = appRetainHeight
+	}
+	if p.config.KeepRecent > 0 {
+		retainHeight = tmmath.MinInt64(retainHeight, height-p.config.KeepRecent+1)
+	}
+	if p.config.KeepDuration > 0 {
+		keepAfter := tmtime.Now().Add(-p.config.KeepDuration)
+		retainHeight = tmmath.MinInt64(retainHeight, p.firstHeightAfter(base, height, keepAfter))
+	}
+
+	// Evidence expires once it's older than both max ages.
+	evidenceParams := state.ConsensusParams.Evidence
+	retainHeight = tmmath.MinInt64(retainHeight, tmmath.MinInt64(
+		state.LastBlockHeight-evidenceParams.MaxAgeNumBlocks,
+		p.firstHeightAfter(base, height, state.LastBlockTime.Add(-evidenceParams.MaxAgeDuration)),
+	))
+
+	// The latest block is always kept.
+	return tmmath.MaxInt64(base, tmmath.MinInt64(retainHeight, height))
+}
+
+// abciResponsesRetainHeight returns the height below which ABCI responses can be pruned, or 0.
+func (p *Pruner) abciResponsesRetainHeight(state State) int64 {
+	if p.config.ABCIResponsesKeepRecent <= 0 {
+		return 0
+	}
+	// The responses of the latest block are needed to recover from a crash.
+	return tmmath.MinInt64(state.LastBlockHeight, state.LastBlockHeight-p.config.ABCIResponsesKeepRecent+1)
+}
+
+// firstHeightAfter returns the first height between from and to (inclusive) with a block time at
+// or after t, or to+1 if there is none.
+func (p *Pruner) firstHeightAfter(from, to int64, t time.Time) int64 {
+	return from + int64(sort.Search(int(to-from+1), func(i int) bool {
+		meta := p.blockStore.LoadBlockMeta(from + int64(i))
+		return meta != nil && !meta.Header.Time.Before(t)
+	}))
+}
@@ -0,0 +1,162 @@
+package state_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	dbm "github.com/tendermint/tm-db"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	cfg "github.com/tendermint/tendermint/config"
+	tmstate "github.com/tendermint/tendermint/proto/tendermint/state"
+	sm "github.com/tendermint/tendermint/state"
+	"github.com/tendermint/tendermint/state/txindex/kv"
+	"github.com/tendermint/tendermint/store"
+	"github.com/tendermint/tendermint/types"
+	tmtime "github.com/tendermint/tendermint/types/time"
+)
+
+// makePrunerStores makes 100 blocks, one minute apart up to now, with their state, ABCI responses
+// and one indexed transaction each. Evidence expires after the given number of blocks and duration.
+func makePrunerStores(t *testing.T, evidenceBlocks int64, evidenceDuration time.Duration) (
+	sm.Store, *store.BlockStore, *kv.TxIndex) {
+	state, stateDB, _ := makeState(1, 1)
+	state.ConsensusParams.Evidence.MaxAgeNumBlocks = evidenceBlocks
+	state.ConsensusParams.Evidence.MaxAgeDuration = evidenceDuration
+	stateStore := sm.NewStore(stateDB)
+	blockStore := store.NewBlockStore(dbm.NewMemDB())
+	txIndexer := kv.NewTxIndex(dbm.NewMemDB())
+
+	now := tmtime.Now()
+	for h := int64(1); h <= 100; h++ {
+		block := makeBlock(state, h)
+		block.Time = now.Add(time.Duration(h-100) * time.Minute)
+		blockStore.SaveBlock(block, block.MakePartSet(types.BlockPartSizeBytes), &types.Commit{Height: h})
+
+		state.LastBlockHeight = h
+		state.LastBlockTime = block.Time
+		state.LastValidators = state.Validators.Copy()
+		require.NoError(t, stateStore.Save(state))
+		require.NoError(t, stateStore.SaveABCIResponses(h, &tmstate.ABCIResponses{
+			DeliverTxs: []*abci.ResponseDeliverTx{{Data: []byte{1}}},
+		}))
+		require.NoError(t, txIndexer.Index(&abci.TxResult{Height: h, Tx: types.Tx(fmt.Sprintf("tx%d", h))}))
+	}
+	return stateStore, blockStore, txIndexer
+}
+
+func TestPruner(t *testing.T) {
+	testcases := map[string]struct {
+		config           cfg.PruningConfig
+		appRetainHeight  int64
+		evidenceBlocks   int64
+		evidenceDuration time.Duration
+		expectBase       int64
+		expectABCIBase   int64
+	}{
+		"keep all":                   {cfg.PruningConfig{}, 0, 1, time.Minute, 1, 1},
+		"no app retain height":       {cfg.PruningConfig{KeepRecent: 10}, 0, 1, time.Minute, 91, 91},
+		"no app retain height time":  {cfg.PruningConfig{KeepDuration: 30*time.Minute + time.Second}, 0, 1, time.Minute, 70, 70},
+		"app retain height only":     {cfg.PruningConfig{ABCIResponsesKeepRecent: 5}, 50, 1, time.Minute, 50, 96},
+		"keep recent":                {cfg.PruningConfig{KeepRecent: 10}, 100, 1, time.Minute, 91, 91},
+		"keep duration":              {cfg.PruningConfig{KeepDuration: 30*time.Minute + time.Second}, 100, 1, time.Minute, 70, 70},
+		"keep recent or duration":    {cfg.PruningConfig{KeepRecent: 10, KeepDuration: 30*time.Minute + time.Second}, 100, 1, time.Minute, 70, 70},
+		"app retain height":          {cfg.PruningConfig{KeepRecent: 10}, 50, 1, time.Minute, 50, 50},
+		"evidence max age blocks":    {cfg.PruningConfig{KeepRecent: 10}, 100, 40, time.Minute, 60, 60},
+		"evidence max age duration":  {cfg.PruningConfig{KeepRecent: 10}, 100, 1, 45 * time.Minute, 55, 55},
+		"evidence max age both":      {cfg.PruningConfig{KeepRecent: 10}, 100, 40, 45 * time.Minute, 55, 55},
+		"abci responses":             {cfg.PruningConfig{ABCIResponsesKeepRecent: 5}, 0, 1, time.Minute, 1, 96},
+		"abci responses with blocks": {cfg.PruningConfig{KeepRecent: 10, ABCIResponsesKeepRecent: 5}, 100, 1, time.Minute, 91, 96},
+		"abci responses beyond blocks": {cfg.PruningConfig{KeepRecent: 10, ABCIResponsesKeepRecent: 20}, 100, 1,
+			time.Minute, 91, 91},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			stateStore, blockStore, txIndexer := makePrunerStores(t, tc.evidenceBlocks, tc.evidenceDuration)
+			require.NoError(t, stateStore.SaveApplicationRetainHeight(tc.appRetainHeight))
+
+			config := tc.config
+			pruner := sm.NewPruner(&config, stateStore, blockStore, txIndexer)
+			require.NoError(t, pruner.Prune())
+
+			assert.EqualValues(t, tc.expectBase, blockStore.Base())
+			for h := int64(1); h <= 100; h++ {
+				result, err := txIndexer.Get(types.Tx(fmt.Sprintf("tx%d", h)).Hash())
+				require.NoError(t, err)
+				_, valsErr := stateStore.LoadValidators(h)
+				_, abciErr := stateStore.LoadABCIResponses(h)
+				if h < tc.expectBase {
+					assert.Nil(t, blockStore.LoadBlockMeta(h), "block %v", h)
+					assert.Nil(t, result, "tx %v", h)
+				} else {
+					assert.NotNil(t, blockStore.LoadBlockMeta(h), "block %v", h)
+					assert.NotNil(t, result, "tx %v", h)
+					assert.NoError(t, valsErr, "validators %v", h)
+				}
+				if h < tc.expectABCIBase {
+					assert.Error(t, abciErr, "abci responses %v", h)
+				} else {
+					assert.NoError(t, abciErr, "abci responses %v", h)
+				}
+			}
+
+			// pruning again is a noop
+			require.NoError(t, pruner.Prune())
+			assert.EqualValues(t, tc.expectBase, blockStore.Base())
+		})
+	}
+}
+
+func TestPruner_KeepEvery(t *testing.T) {
+	stateStore, blockStore, txIndexer := makePrunerStores(t, 1, time.Minute)
+	require.NoError(t, stateStore.SaveApplicationRetainHeight(100))
+	config := cfg.PruningConfig{KeepRecent: 10, KeepEvery: 25}
+	pruner := sm.NewPruner(&config, stateStore, blockStore, txIndexer)
+	require.NoError(t, pruner.Prune())
+
+	assert.EqualValues(t, 91, blockStore.Base())
+	for h := int64(1); h < 91; h++ {
+		if h%25 == 0 {
+			assert.NotNil(t, blockStore.LoadBlock(h), h)
+		} else {
+			assert.Nil(t, blockStore.LoadBlockMeta(h), h)
+		}
+	}
+}
+
+// TestPruner_ConcurrentPruning prunes with the pruner while blocks are pruned on commit, as
+// consensus does for the application's retain height, and checks that the base never moves back
+// to deleted blocks.
+func TestPruner_ConcurrentPruning(t *testing.T) {
+	stateStore, blockStore, txIndexer := makePrunerStores(t, 1, time.Minute)
+	require.NoError(t, stateStore.SaveApplicationRetainHeight(100))
+	config := cfg.PruningConfig{KeepRecent: 10}
+	pruner := sm.NewPruner(&config, stateStore, blockStore, txIndexer)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for retainHeight := int64(2); retainHeight <= 95; retainHeight++ {
+			// errors are expected once the pruner went past the retain height
+			_, _ = blockStore.PruneBlocks(retainHeight)
+		}
+	}()
+
+	base := blockStore.Base()
+	for i := 0; i < 10; i++ {
+		require.NoError(t, pruner.Prune())
+		require.GreaterOrEqual(t, blockStore.Base(), base)
+		base = blockStore.Base()
+	}
+	<-done
+
+	base = blockStore.Base()
+	assert.GreaterOrEqual(t, base, int64(91))
+	for h := base; h <= blockStore.Height(); h++ {
+		require.NotNil(t, blockStore.LoadBlock(h), h)
+	}
+}
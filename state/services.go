@@ -32,6 +32,13 @@ type BlockStore interface {
 	LoadSeenCommit(height int64) *types.Commit
 }
 
+// PrunableBlockStore defines the interface used by the Pruner.
+type PrunableBlockStore interface {
+	BlockStore
+
+	PruneBlocksKeepEvery(height int64, keepEvery int64) (uint64, int64, error)
+}
+
 //-----------------------------------------------------------------------------
 // evidence pool
 
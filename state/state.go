@@ -354,3 +354,57 @@ func MakeGenesisState(genDoc *types.GenesisDoc) (State, error) {
 		AppHash: genDoc.AppHash,
 	}, nil
 }
+
+// RebuildState rebuilds the state after the block at the given height, like state sync does from
+// light blocks, i.e. from the header of the next block and the validator sets and consensus params
+// of the state store. The height may be the one before the initial height, for the state the
+// initial block was executed against.
+func RebuildState(stateStore Store, blockStore BlockStore, height int64) (State, error) {
+	latest, err := stateStore.Load()
+	if err != nil {
+		return State{}, err
+	}
+	if latest.IsEmpty() {
+		return State{}, errors.New("no state found")
+	}
+	if height == latest.LastBlockHeight {
+		return latest, nil
+	}
+	nextMeta := blockStore.LoadBlockMeta(height + 1)
+	if nextMeta == nil {
+		return State{}, fmt.Errorf("block at height %v not found", height+1)
+	}
+
+	state := latest.Copy()
+	state.Version.Consensus = nextMeta.Header.Version
+	state.LastBlockHeight = height
+	state.LastBlockID = nextMeta.Header.LastBlockID
+	state.LastResultsHash = nextMeta.Header.LastResultsHash
+	state.AppHash = nextMeta.Header.AppHash
+	if height+1 == state.InitialHeight {
+		// The initial block has the genesis time.
+		state.LastBlockTime = nextMeta.Header.Time
+		state.LastValidators = types.NewValidatorSet(nil)
+	} else {
+		meta := blockStore.LoadBlockMeta(height)
+		if meta == nil {
+			return State{}, fmt.Errorf("block at height %v not found", height)
+		}
+		state.LastBlockTime = meta.Header.Time
+		if state.LastValidators, err = stateStore.LoadValidators(height); err != nil {
+			return State{}, err
+		}
+	}
+	if state.Validators, err = stateStore.LoadValidators(height + 1); err != nil {
+		return State{}, err
+	}
+	if state.NextValidators, err = stateStore.LoadValidators(height + 2); err != nil {
+		return State{}, err
+	}
+	state.LastHeightValidatorsChanged = height + 2
+	if state.ConsensusParams, err = stateStore.LoadConsensusParams(height + 1); err != nil {
+		return State{}, err
+	}
+	state.LastHeightConsensusParamsChanged = height + 1
+	return state, nil
+}
@@ -17,10 +17,13 @@ import (
 	cfg "github.com/tendermint/tendermint/config"
 	"github.com/tendermint/tendermint/crypto/ed25519"
 	cryptoenc "github.com/tendermint/tendermint/crypto/encoding"
+	"github.com/tendermint/tendermint/libs/log"
 	tmrand "github.com/tendermint/tendermint/libs/rand"
+	memmock "github.com/tendermint/tendermint/mempool/mock"
 	tmstate "github.com/tendermint/tendermint/proto/tendermint/state"
 	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
 	sm "github.com/tendermint/tendermint/state"
+	"github.com/tendermint/tendermint/store"
 	"github.com/tendermint/tendermint/types"
 )
 
@@ -1083,3 +1086,47 @@ func TestStateProto(t *testing.T) {
 		}
 	}
 }
+
+func TestRebuildState(t *testing.T) {
+	proxyApp := newTestApp()
+	require.NoError(t, proxyApp.Start())
+	defer proxyApp.Stop() //nolint:errcheck // ignore for tests
+
+	state, stateDB, privVals := makeState(2, 1)
+	stateStore := sm.NewStore(stateDB)
+	blockStore := store.NewBlockStore(dbm.NewMemDB())
+	blockExec := sm.NewBlockExecutor(stateStore, log.TestingLogger(), proxyApp.Consensus(),
+		memmock.Mempool{}, sm.MockEvidencePool{})
+
+	states := []sm.State{state}
+	lastCommit := types.NewCommit(0, 0, types.BlockID{}, nil)
+	for height := int64(1); height <= 5; height++ {
+		block, parts := state.MakeBlock(height, makeTxs(height), lastCommit, nil, state.Validators.GetProposer().Address)
+		blockID := types.BlockID{Hash: block.Hash(), PartSetHeader: parts.Header()}
+		var err error
+		state, _, err = blockExec.ApplyBlock(state, blockID, block)
+		require.NoError(t, err)
+		lastCommit, err = makeValidCommit(height, blockID, state.LastValidators, privVals)
+		require.NoError(t, err)
+		blockStore.SaveBlock(block, parts, lastCommit)
+		states = append(states, state)
+	}
+
+	for height := int64(0); height <= 5; height++ {
+		expect := states[height]
+		rebuilt, err := sm.RebuildState(stateStore, blockStore, height)
+		require.NoError(t, err, height)
+		assert.Equal(t, expect.LastBlockHeight, rebuilt.LastBlockHeight, height)
+		assert.Equal(t, expect.LastBlockID, rebuilt.LastBlockID, height)
+		assert.True(t, expect.LastBlockTime.Equal(rebuilt.LastBlockTime), height)
+		assert.Equal(t, expect.LastValidators.Hash(), rebuilt.LastValidators.Hash(), height)
+		assert.Equal(t, expect.Validators, rebuilt.Validators, height)
+		assert.Equal(t, expect.NextValidators, rebuilt.NextValidators, height)
+		assert.Equal(t, expect.ConsensusParams, rebuilt.ConsensusParams, height)
+		assert.Equal(t, expect.LastResultsHash, rebuilt.LastResultsHash, height)
+		assert.Equal(t, expect.AppHash, rebuilt.AppHash, height)
+	}
+
+	_, err := sm.RebuildState(stateStore, blockStore, 6)
+	require.Error(t, err)
+}
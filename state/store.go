@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/gogo/protobuf/proto"
+	gogotypes "github.com/gogo/protobuf/types"
 	dbm "github.com/tendermint/tm-db"
 
 	abci "github.com/tendermint/tendermint/abci/types"
@@ -37,6 +38,15 @@ func calcABCIResponsesKey(height int64) []byte {
 	return []byte(fmt.Sprintf("abciResponsesKey:%v", height))
 }
 
+func calcCommitInfoKey(height int64) []byte {
+	return []byte(fmt.Sprintf("commitInfoKey:%v", height))
+}
+
+var (
+	appRetainHeightKey           = []byte("appRetainHeightKey")
+	abciResponsesRetainHeightKey = []byte("abciResponsesRetainHeightKey")
+)
+
 //----------------------
 
 type Store interface {
@@ -60,8 +70,28 @@ type Store interface {
 	SaveABCIResponses(int64, *tmstate.ABCIResponses) error
 	// Bootstrap is used for bootstrapping state when not starting from a initial height.
 	Bootstrap(State) error
+	// SaveValidatorSets saves the validator set for a range of heights (inclusive), e.g. when
+	// backfilling after state sync
+	SaveValidatorSets(lowerHeight, upperHeight int64, vals *types.ValidatorSet) error
+	// SaveConsensusParams saves the consensus params for a range of heights (inclusive), e.g. when
+	// importing a chain archive
+	SaveConsensusParams(lowerHeight, upperHeight int64, params tmproto.ConsensusParams) error
 	// PruneStates takes the height from which to start prning and which height stop at
 	PruneStates(int64, int64) error
+	// PruneABCIResponses prunes the ABCI responses between the given heights, returning the
+	// number of heights pruned and the size of the responses deleted
+	PruneABCIResponses(from int64, to int64) (uint64, int64, error)
+	// SaveApplicationRetainHeight saves the last retain height returned by the application on Commit
+	SaveApplicationRetainHeight(int64) error
+	// LoadApplicationRetainHeight loads the last retain height returned by the application, or 0
+	LoadApplicationRetainHeight() (int64, error)
+	// SaveCommitInfo saves which validators signed the block at a given height
+	SaveCommitInfo(int64, abci.LastCommitInfo) error
+	// LoadCommitInfo loads which validators signed the block at a given height, or nil if it
+	// wasn't saved
+	LoadCommitInfo(int64) (*abci.LastCommitInfo, error)
+	// DeleteCommitInfo deletes which validators signed the block at a given height
+	DeleteCommitInfo(int64) error
 }
 
 //dbStore wraps a db (github.com/tendermint/tm-db)
@@ -206,6 +236,36 @@ func (store dbStore) Bootstrap(state State) error {
 	return store.db.SetSync(stateKey, state.Bytes())
 }
 
+// SaveValidatorSets saves the validator set for all heights from lowerHeight to upperHeight
+// (inclusive). The full set is only stored at lowerHeight and at checkpoints, like when the set
+// last changed at lowerHeight.
+func (store dbStore) SaveValidatorSets(lowerHeight, upperHeight int64, vals *types.ValidatorSet) error {
+	if lowerHeight <= 0 || lowerHeight > upperHeight {
+		return fmt.Errorf("invalid height range %v-%v", lowerHeight, upperHeight)
+	}
+	for height := lowerHeight; height <= upperHeight; height++ {
+		if err := store.saveValidatorsInfo(height, lowerHeight, vals); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveConsensusParams saves the consensus params for all heights from lowerHeight to upperHeight
+// (inclusive). The params are only stored at lowerHeight, like when they last changed at
+// lowerHeight.
+func (store dbStore) SaveConsensusParams(lowerHeight, upperHeight int64, params tmproto.ConsensusParams) error {
+	if lowerHeight <= 0 || lowerHeight > upperHeight {
+		return fmt.Errorf("invalid height range %v-%v", lowerHeight, upperHeight)
+	}
+	for height := lowerHeight; height <= upperHeight; height++ {
+		if err := store.saveConsensusParamsInfo(height, lowerHeight, params); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // PruneStates deletes states between the given heights (including from, excluding to). It is not
 // guaranteed to delete all states, since the last checkpointed state and states being pointed to by
 // e.g. `LastHeightChanged` must remain. The state at to must also exist.
@@ -338,6 +398,122 @@ func (store dbStore) PruneStates(from int64, to int64) error {
 	return nil
 }
 
+// PruneABCIResponses deletes the ABCI responses between the given heights (including from,
+// excluding to), independently of the states, e.g. to keep them for fewer heights than the blocks.
+// Heights below the one pruned to by a previous call are skipped, since they are known to be
+// pruned already. It returns the number of heights pruned and the size of the responses deleted.
+func (store dbStore) PruneABCIResponses(from int64, to int64) (uint64, int64, error) {
+	if from <= 0 || to <= 0 {
+		return 0, 0, fmt.Errorf("from height %v and to height %v must be greater than 0", from, to)
+	}
+	retainHeight, err := store.loadInt64(abciResponsesRetainHeightKey)
+	if err != nil {
+		return 0, 0, err
+	}
+	if retainHeight > from {
+		from = retainHeight
+	}
+	if from >= to {
+		return 0, 0, nil
+	}
+
+	batch := store.db.NewBatch()
+	defer batch.Close()
+	var (
+		pruned uint64
+		size   int64
+	)
+	for h := from; h < to; h++ {
+		bz, err := store.db.Get(calcABCIResponsesKey(h))
+		if err != nil {
+			return pruned, size, err
+		}
+		if bz == nil {
+			continue
+		}
+		if err := batch.Delete(calcABCIResponsesKey(h)); err != nil {
+			return pruned, size, err
+		}
+		pruned++
+		size += int64(len(bz))
+
+		// avoid batches growing too large by flushing to database regularly
+		if pruned%1000 == 0 {
+			if err := batch.Write(); err != nil {
+				return pruned, size, err
+			}
+			batch.Close()
+			batch = store.db.NewBatch()
+			defer batch.Close()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return pruned, size, err
+	}
+	return pruned, size, store.saveInt64(abciResponsesRetainHeightKey, to)
+}
+
+// SaveApplicationRetainHeight saves the last retain height returned by the application on
+// Commit, so that the pruner doesn't prune blocks the application still needs.
+func (store dbStore) SaveApplicationRetainHeight(height int64) error {
+	return store.saveInt64(appRetainHeightKey, height)
+}
+
+// LoadApplicationRetainHeight loads the last retain height returned by the application on
+// Commit, or 0 if it never returned one.
+func (store dbStore) LoadApplicationRetainHeight() (int64, error) {
+	return store.loadInt64(appRetainHeightKey)
+}
+
+// SaveCommitInfo saves which validators signed the block at the given height, i.e. the
+// LastCommitInfo of the next block, to track their uptime.
+func (store dbStore) SaveCommitInfo(height int64, info abci.LastCommitInfo) error {
+	bz, err := info.Marshal()
+	if err != nil {
+		return err
+	}
+	return store.db.Set(calcCommitInfoKey(height), bz)
+}
+
+// LoadCommitInfo loads which validators signed the block at the given height, or nil if it
+// wasn't saved.
+func (store dbStore) LoadCommitInfo(height int64) (*abci.LastCommitInfo, error) {
+	bz, err := store.db.Get(calcCommitInfoKey(height))
+	if err != nil || bz == nil {
+		return nil, err
+	}
+	info := new(abci.LastCommitInfo)
+	if err := info.Unmarshal(bz); err != nil {
+		return nil, fmt.Errorf("invalid commit info at height %d: %w", height, err)
+	}
+	return info, nil
+}
+
+// DeleteCommitInfo deletes which validators signed the block at the given height.
+func (store dbStore) DeleteCommitInfo(height int64) error {
+	return store.db.Delete(calcCommitInfoKey(height))
+}
+
+func (store dbStore) loadInt64(key []byte) (int64, error) {
+	bz, err := store.db.Get(key)
+	if err != nil || bz == nil {
+		return 0, err
+	}
+	var v gogotypes.Int64Value
+	if err := proto.Unmarshal(bz, &v); err != nil {
+		return 0, fmt.Errorf("invalid value for key %q: %w", key, err)
+	}
+	return v.Value, nil
+}
+
+func (store dbStore) saveInt64(key []byte, value int64) error {
+	bz, err := proto.Marshal(&gogotypes.Int64Value{Value: value})
+	if err != nil {
+		return err
+	}
+	return store.db.Set(key, bz)
+}
+
 //------------------------------------------------------------------------
 
 // ABCIResponsesResultsHash returns the root hash of a Merkle tree of
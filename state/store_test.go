@@ -83,6 +83,42 @@ func BenchmarkLoadValidators(b *testing.B) {
 	}
 }
 
+func TestStoreSaveValidatorSets(t *testing.T) {
+	stateStore := sm.NewStore(dbm.NewMemDB())
+	vals, _ := types.RandValidatorSet(3, 10)
+
+	require.Error(t, stateStore.SaveValidatorSets(0, 10, vals))
+	require.Error(t, stateStore.SaveValidatorSets(10, 9, vals))
+
+	// spans a checkpoint height
+	require.NoError(t, stateStore.SaveValidatorSets(99990, 100010, vals))
+	for h := int64(99990); h <= 100010; h++ {
+		loaded, err := stateStore.LoadValidators(h)
+		require.NoError(t, err, "height %v", h)
+		assert.Equal(t, vals.Hash(), loaded.Hash(), "height %v", h)
+	}
+	_, err := stateStore.LoadValidators(99989)
+	require.Error(t, err)
+}
+
+func TestStoreSaveConsensusParams(t *testing.T) {
+	stateStore := sm.NewStore(dbm.NewMemDB())
+	params := types.DefaultConsensusParams()
+	params.Block.MaxGas = 1000
+
+	require.Error(t, stateStore.SaveConsensusParams(0, 10, *params))
+	require.Error(t, stateStore.SaveConsensusParams(10, 9, *params))
+
+	require.NoError(t, stateStore.SaveConsensusParams(5, 10, *params))
+	for h := int64(5); h <= 10; h++ {
+		loaded, err := stateStore.LoadConsensusParams(h)
+		require.NoError(t, err, "height %v", h)
+		assert.Equal(t, *params, loaded, "height %v", h)
+	}
+	_, err := stateStore.LoadConsensusParams(4)
+	require.Error(t, err)
+}
+
 func TestPruneStates(t *testing.T) {
 	testcases := map[string]struct {
 		makeHeights  int64
@@ -200,6 +236,54 @@ func TestPruneStates(t *testing.T) {
 	}
 }
 
+func TestPruneABCIResponses(t *testing.T) {
+	stateStore := sm.NewStore(dbm.NewMemDB())
+	for h := int64(1); h <= 10; h++ {
+		err := stateStore.SaveABCIResponses(h, &tmstate.ABCIResponses{
+			DeliverTxs: []*abci.ResponseDeliverTx{{Data: []byte{byte(h)}}},
+		})
+		require.NoError(t, err)
+	}
+
+	_, _, err := stateStore.PruneABCIResponses(0, 5)
+	require.Error(t, err)
+
+	pruned, size, err := stateStore.PruneABCIResponses(1, 5)
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, pruned)
+	assert.True(t, size > 0)
+
+	// heights pruned by an earlier call are skipped
+	pruned, _, err = stateStore.PruneABCIResponses(1, 8)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, pruned)
+	pruned, _, err = stateStore.PruneABCIResponses(1, 8)
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, pruned)
+
+	for h := int64(1); h <= 10; h++ {
+		_, err := stateStore.LoadABCIResponses(h)
+		if h < 8 {
+			require.Equal(t, sm.ErrNoABCIResponsesForHeight{Height: h}, err)
+		} else {
+			require.NoError(t, err)
+		}
+	}
+}
+
+func TestApplicationRetainHeight(t *testing.T) {
+	stateStore := sm.NewStore(dbm.NewMemDB())
+
+	height, err := stateStore.LoadApplicationRetainHeight()
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, height)
+
+	require.NoError(t, stateStore.SaveApplicationRetainHeight(42))
+	height, err = stateStore.LoadApplicationRetainHeight()
+	require.NoError(t, err)
+	assert.EqualValues(t, 42, height)
+}
+
 func TestABCIResponsesResultsHash(t *testing.T) {
 	responses := &tmstate.ABCIResponses{
 		BeginBlock: &abci.ResponseBeginBlock{},
@@ -23,6 +23,10 @@ type TxIndexer interface {
 
 	// Search allows you to query for transactions.
 	Search(ctx context.Context, q *query.Query) ([]*abci.TxResult, error)
+
+	// Prune removes the transactions indexed between the given heights (including from,
+	// excluding to), returning the number of transactions removed.
+	Prune(from, to int64) (uint64, error)
 }
 
 //----------------------------------------------------
@@ -158,6 +158,94 @@ func (txi *TxIndex) indexEvents(result *abci.TxResult, hash []byte, store dbm.Ba
 	return nil
 }
 
+// Prune removes the transactions indexed between the given heights (including from, excluding
+// to), along with their event keys, returning the number of transactions removed. A transaction
+// which was indexed again at a later height is only removed from the index of the pruned height.
+func (txi *TxIndex) Prune(from, to int64) (uint64, error) {
+	if from <= 0 || to <= 0 {
+		return 0, fmt.Errorf("from height %v and to height %v must be greater than 0", from, to)
+	}
+	batch := txi.store.NewBatch()
+	defer batch.Close()
+
+	var (
+		pruned  = uint64(0)
+		pending = 0 // transactions deleted in the batch
+	)
+	for h := from; h < to; h++ {
+		keys, hashes, err := txi.heightKeys(h)
+		if err != nil {
+			return pruned, err
+		}
+		for i, key := range keys {
+			if err := batch.Delete(key); err != nil {
+				return pruned, err
+			}
+			result, err := txi.Get(hashes[i])
+			if err != nil {
+				return pruned, err
+			}
+			if result == nil || result.Height != h {
+				continue
+			}
+			if err := txi.deleteEvents(result, batch); err != nil {
+				return pruned, err
+			}
+			if err := batch.Delete(hashes[i]); err != nil {
+				return pruned, err
+			}
+			pruned++
+		}
+
+		// avoid batches growing too large by flushing to database regularly
+		pending += len(keys)
+		if pending >= 1000 {
+			pending = 0
+			if err := batch.Write(); err != nil {
+				return pruned, err
+			}
+			batch.Close()
+			batch = txi.store.NewBatch()
+			defer batch.Close()
+		}
+	}
+	return pruned, batch.WriteSync()
+}
+
+// heightKeys returns the height index keys of the transactions at a height, and their hashes.
+func (txi *TxIndex) heightKeys(height int64) (keys [][]byte, hashes [][]byte, err error) {
+	it, err := dbm.IteratePrefix(txi.store, startKey(types.TxHeightKey, height, height))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer it.Close()
+
+	for ; it.Valid(); it.Next() {
+		keys = append(keys, append([]byte{}, it.Key()...))
+		hashes = append(hashes, append([]byte{}, it.Value()...))
+	}
+	return keys, hashes, it.Error()
+}
+
+// deleteEvents deletes the event keys indexed for a transaction.
+func (txi *TxIndex) deleteEvents(result *abci.TxResult, batch dbm.Batch) error {
+	for _, event := range result.Result.Events {
+		if len(event.Type) == 0 {
+			continue
+		}
+		for _, attr := range event.Attributes {
+			if len(attr.Key) == 0 || !attr.GetIndex() {
+				continue
+			}
+			compositeTag := fmt.Sprintf("%s.%s", event.Type, string(attr.Key))
+			if err := batch.Delete(keyForEvent(compositeTag, attr.Value, result)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // Search performs a search using the given query.
 //
 // It breaks the query into conditions (like "tx.height > 5"). For each
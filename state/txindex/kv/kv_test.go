@@ -313,6 +313,55 @@ func TestTxSearchMultipleTxs(t *testing.T) {
 	require.Len(t, results, 3)
 }
 
+func TestTxIndexPrune(t *testing.T) {
+	indexer := NewTxIndex(db.NewMemDB())
+
+	for h := int64(1); h <= 10; h++ {
+		txResult := txResultWithEvents([]abci.Event{
+			{Type: "account", Attributes: []abci.EventAttribute{{Key: []byte("number"), Value: []byte("1"), Index: true}}},
+		})
+		txResult.Tx = types.Tx(fmt.Sprintf("tx%d", h))
+		txResult.Height = h
+		require.NoError(t, indexer.Index(txResult))
+	}
+	// a transaction indexed again at a later height is kept at that height
+	retx := txResultWithEvents(nil)
+	retx.Tx = types.Tx("tx2")
+	retx.Height = 9
+	retx.Index = 1
+	require.NoError(t, indexer.Index(retx))
+
+	_, err := indexer.Prune(0, 5)
+	require.Error(t, err)
+
+	pruned, err := indexer.Prune(1, 5)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, pruned)
+
+	for h := int64(1); h <= 10; h++ {
+		result, err := indexer.Get(types.Tx(fmt.Sprintf("tx%d", h)).Hash())
+		require.NoError(t, err)
+		switch {
+		case h == 2:
+			require.NotNil(t, result)
+			assert.EqualValues(t, 9, result.Height)
+		case h < 5:
+			assert.Nil(t, result, h)
+		default:
+			assert.NotNil(t, result, h)
+		}
+	}
+
+	results, err := indexer.Search(context.Background(), query.MustParse("account.number = 1"))
+	require.NoError(t, err)
+	for _, result := range results {
+		assert.True(t, result.Height >= 5, result.Height)
+	}
+	results, err = indexer.Search(context.Background(), query.MustParse("tx.height < 5"))
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
 func txResultWithEvents(events []abci.Event) *abci.TxResult {
 	tx := types.Tx("HELLO WORLD")
 	return &abci.TxResult{
@@ -0,0 +1,274 @@
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/log"
+	tmmath "github.com/tendermint/tendermint/libs/math"
+	tmsync "github.com/tendermint/tendermint/libs/sync"
+	"github.com/tendermint/tendermint/types"
+)
+
+// ValidatorUptime is the uptime of a validator over the uptime window, i.e. the blocks it signed
+// and missed among the last blocks.
+type ValidatorUptime struct {
+	Address      types.Address
+	SignedBlocks int64
+	MissedBlocks int64
+	// Number of blocks missed in a row, since the last block signed.
+	ConsecutiveMissedBlocks int64
+	// Height of the last block signed, or 0 if it signed none in the window.
+	LastSignedHeight int64
+}
+
+// Uptime returns the ratio of the blocks signed in the window, among those the validator was in
+// the validator set for.
+func (vu ValidatorUptime) Uptime() float64 {
+	if vu.SignedBlocks+vu.MissedBlocks == 0 {
+		return 0
+	}
+	return float64(vu.SignedBlocks) / float64(vu.SignedBlocks+vu.MissedBlocks)
+}
+
+// UptimeTracker tracks which validators signed the last blocks, over a sliding window of
+// heights, from the LastCommit of the blocks applied (see BlockExecutorWithUptimeTracker). Which
+// validators signed each block of the window is saved in the state store, so that the window is
+// restored on restart.
+//
+// The local validator is also tracked with metrics of its own, and the blocks it misses in a row
+// are reported with an EventValidatorMissedBlocks event.
+type UptimeTracker struct {
+	mtx tmsync.RWMutex
+
+	store     Store
+	window    int64
+	threshold int64 // blocks missed in a row by the local validator firing an event
+	local     types.Address
+	metrics   *Metrics
+	logger    log.Logger
+
+	base       int64 // lowest height of the window which may still be saved
+	height     int64 // last height tracked
+	validators map[string]*ValidatorUptime
+}
+
+// UptimeTrackerOption sets an optional parameter on the UptimeTracker.
+type UptimeTrackerOption func(*UptimeTracker)
+
+// UptimeTrackerWithMetrics sets the metrics.
+func UptimeTrackerWithMetrics(metrics *Metrics) UptimeTrackerOption {
+	return func(t *UptimeTracker) { t.metrics = metrics }
+}
+
+// UptimeTrackerWithLocalValidator sets the address of the local validator.
+func UptimeTrackerWithLocalValidator(address types.Address) UptimeTrackerOption {
+	return func(t *UptimeTracker) { t.local = address }
+}
+
+// NewUptimeTracker returns a new tracker of the uptime of the validators over the given number
+// of blocks, firing an event when the local validator misses missedBlocksThreshold blocks in a
+// row (never if 0), up to the window. The window of the last height applied is loaded from the
+// store.
+func NewUptimeTracker(
+	store Store,
+	window, missedBlocksThreshold int64,
+	options ...UptimeTrackerOption,
+) (*UptimeTracker, error) {
+	if window <= 0 {
+		return nil, fmt.Errorf("uptime window must be positive, got %d", window)
+	}
+	if missedBlocksThreshold > window {
+		return nil, fmt.Errorf("missed blocks threshold (%d) can't be greater than the uptime window (%d)",
+			missedBlocksThreshold, window)
+	}
+	t := &UptimeTracker{
+		store:      store,
+		window:     window,
+		threshold:  missedBlocksThreshold,
+		metrics:    NopMetrics(),
+		logger:     log.NewNopLogger(),
+		validators: make(map[string]*ValidatorUptime),
+	}
+	for _, option := range options {
+		option(t)
+	}
+
+	state, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	// The last block applied has the LastCommit of the previous height.
+	t.height = state.LastBlockHeight - 1
+	t.base = tmmath.MaxInt64(t.height-window+1, state.InitialHeight)
+	for h := t.base; h <= t.height; h++ {
+		info, err := store.LoadCommitInfo(h)
+		if err != nil {
+			return nil, err
+		}
+		if info != nil {
+			t.add(h, info)
+		}
+	}
+	return t, nil
+}
+
+// SetLogger sets the logger.
+func (t *UptimeTracker) SetLogger(logger log.Logger) {
+	t.logger = logger
+}
+
+// Track tracks which validators of the given set signed the block at the given height, from its
+// commit, and slides the window to end at the height. It returns the event to fire if the local
+// validator reached the threshold of blocks missed in a row, or nil. Heights already tracked are
+// ignored.
+func (t *UptimeTracker) Track(
+	height int64,
+	commit *types.Commit,
+	vals *types.ValidatorSet,
+) (*types.EventDataValidatorMissedBlocks, error) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if height <= t.height {
+		return nil, nil
+	}
+	if len(commit.Signatures) != vals.Size() {
+		return nil, fmt.Errorf("commit size (%d) doesn't match valset length (%d) at height %d",
+			len(commit.Signatures), vals.Size(), height)
+	}
+
+	info := abci.LastCommitInfo{Round: commit.Round, Votes: make([]abci.VoteInfo, vals.Size())}
+	for i, val := range vals.Validators {
+		info.Votes[i] = abci.VoteInfo{
+			Validator:       types.TM2PB.Validator(val),
+			SignedLastBlock: !commit.Signatures[i].Absent(),
+		}
+	}
+	if err := t.store.SaveCommitInfo(height, info); err != nil {
+		return nil, err
+	}
+
+	var missed int64
+	if local, ok := t.validators[string(t.local)]; ok {
+		missed = local.ConsecutiveMissedBlocks
+	}
+
+	// Remove the heights leaving the window.
+	from := height - t.window + 1
+	for ; t.base < from && t.base <= t.height; t.base++ {
+		if err := t.remove(t.base); err != nil {
+			return nil, err
+		}
+	}
+	t.base = tmmath.MaxInt64(t.base, from)
+
+	t.add(height, &info)
+	t.height = height
+
+	// The event is fired once, when the blocks missed in a row reach the threshold.
+	local, ok := t.validators[string(t.local)]
+	if !ok || t.threshold <= 0 || missed >= t.threshold || local.ConsecutiveMissedBlocks < t.threshold {
+		return nil, nil
+	}
+	t.logger.Error("Local validator missed blocks in a row",
+		"height", height, "missed_blocks", local.ConsecutiveMissedBlocks)
+	return &types.EventDataValidatorMissedBlocks{
+		Address:      t.local,
+		Height:       height,
+		MissedBlocks: local.ConsecutiveMissedBlocks,
+	}, nil
+}
+
+// Uptime returns the first and last heights of the window, and the uptime of the validators in
+// the window, sorted by address.
+func (t *UptimeTracker) Uptime() (int64, int64, []ValidatorUptime) {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+	uptimes := make([]ValidatorUptime, 0, len(t.validators))
+	for _, vu := range t.validators {
+		uptimes = append(uptimes, *vu)
+	}
+	sort.Slice(uptimes, func(i, j int) bool {
+		return bytes.Compare(uptimes[i].Address, uptimes[j].Address) < 0
+	})
+	return t.base, t.height, uptimes
+}
+
+// add adds the votes of the height to the window.
+func (t *UptimeTracker) add(height int64, info *abci.LastCommitInfo) {
+	for _, vote := range info.Votes {
+		vu, ok := t.validators[string(vote.Validator.Address)]
+		if !ok {
+			vu = &ValidatorUptime{Address: vote.Validator.Address}
+			t.validators[string(vu.Address)] = vu
+		}
+		if vote.SignedLastBlock {
+			vu.SignedBlocks++
+			vu.ConsecutiveMissedBlocks = 0
+			vu.LastSignedHeight = height
+		} else {
+			vu.MissedBlocks++
+			vu.ConsecutiveMissedBlocks++
+		}
+		t.observe(vu)
+	}
+}
+
+// remove removes the votes of the height from the window, and deletes them from the store.
+func (t *UptimeTracker) remove(height int64) error {
+	info, err := t.store.LoadCommitInfo(height)
+	if err != nil || info == nil {
+		return err
+	}
+	for _, vote := range info.Votes {
+		vu, ok := t.validators[string(vote.Validator.Address)]
+		if !ok {
+			continue
+		}
+		if vote.SignedLastBlock {
+			vu.SignedBlocks--
+		} else {
+			vu.MissedBlocks--
+			// The blocks missed in a row are only counted in the window, as on restart.
+			if vu.LastSignedHeight < height {
+				vu.ConsecutiveMissedBlocks--
+			}
+		}
+		if vu.LastSignedHeight == height {
+			vu.LastSignedHeight = 0
+		}
+		if vu.SignedBlocks+vu.MissedBlocks == 0 {
+			t.unobserve(vu.Address)
+			delete(t.validators, string(vu.Address))
+			continue
+		}
+		t.observe(vu)
+	}
+	return t.store.DeleteCommitInfo(height)
+}
+
+// observe updates the metrics of the validator.
+func (t *UptimeTracker) observe(vu *ValidatorUptime) {
+	address := vu.Address.String()
+	t.metrics.ValidatorUptime.With("validator_address", address).Set(vu.Uptime())
+	t.metrics.ValidatorMissedBlocks.With("validator_address", address).Set(float64(vu.MissedBlocks))
+	if t.local != nil && bytes.Equal(vu.Address, t.local) {
+		t.metrics.LocalValidatorUptime.Set(vu.Uptime())
+		t.metrics.LocalValidatorMissedBlocks.Set(float64(vu.MissedBlocks))
+		t.metrics.LocalValidatorConsecutiveMissedBlocks.Set(float64(vu.ConsecutiveMissedBlocks))
+	}
+}
+
+// unobserve zeroes the metrics of the validator which left the window, as the gauges of the
+// validators are kept by Prometheus.
+func (t *UptimeTracker) unobserve(address types.Address) {
+	t.metrics.ValidatorUptime.With("validator_address", address.String()).Set(0)
+	t.metrics.ValidatorMissedBlocks.With("validator_address", address.String()).Set(0)
+	if t.local != nil && bytes.Equal(address, t.local) {
+		t.metrics.LocalValidatorUptime.Set(0)
+		t.metrics.LocalValidatorMissedBlocks.Set(0)
+		t.metrics.LocalValidatorConsecutiveMissedBlocks.Set(0)
+	}
+}
@@ -0,0 +1,162 @@
+package state_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sm "github.com/tendermint/tendermint/state"
+	"github.com/tendermint/tendermint/types"
+)
+
+// makeUptimeCommit makes a commit of the height signed by the validators at the given indexes.
+func makeUptimeCommit(height int64, vals *types.ValidatorSet, signers ...int) *types.Commit {
+	sigs := make([]types.CommitSig, vals.Size())
+	for i := range sigs {
+		sigs[i] = types.NewCommitSigAbsent()
+	}
+	for _, i := range signers {
+		sigs[i] = types.CommitSig{
+			BlockIDFlag:      types.BlockIDFlagCommit,
+			ValidatorAddress: vals.Validators[i].Address,
+			Signature:        []byte("signature"),
+		}
+	}
+	return types.NewCommit(height, 0, types.BlockID{}, sigs)
+}
+
+func TestUptimeTracker(t *testing.T) {
+	state, stateDB, _ := makeState(2, 1)
+	stateStore := sm.NewStore(stateDB)
+	vals := state.Validators
+	signer, local := vals.Validators[0], vals.Validators[1]
+
+	tracker, err := sm.NewUptimeTracker(stateStore, 5, 3, sm.UptimeTrackerWithLocalValidator(local.Address))
+	require.NoError(t, err)
+
+	// The local validator signs the first 2 blocks, then misses the next 6.
+	var events []*types.EventDataValidatorMissedBlocks
+	for h := int64(1); h <= 8; h++ {
+		signers := []int{0}
+		if h <= 2 {
+			signers = append(signers, 1)
+		}
+		event, err := tracker.Track(h, makeUptimeCommit(h, vals, signers...), vals)
+		require.NoError(t, err)
+		if event != nil {
+			events = append(events, event)
+		}
+	}
+	// Heights already tracked are ignored.
+	event, err := tracker.Track(8, makeUptimeCommit(8, vals, 0, 1), vals)
+	require.NoError(t, err)
+	require.Nil(t, event)
+
+	// The event is fired once the local validator missed 3 blocks in a row, and not again while it
+	// keeps missing blocks. The blocks missed in a row are only counted in the window.
+	assert.Equal(t, []*types.EventDataValidatorMissedBlocks{{Address: local.Address, Height: 5, MissedBlocks: 3}},
+		events)
+
+	expected := map[string]sm.ValidatorUptime{
+		signer.Address.String(): {Address: signer.Address, SignedBlocks: 5, LastSignedHeight: 8},
+		local.Address.String():  {Address: local.Address, MissedBlocks: 5, ConsecutiveMissedBlocks: 5},
+	}
+	from, to, uptimes := tracker.Uptime()
+	assert.EqualValues(t, 4, from)
+	assert.EqualValues(t, 8, to)
+	require.Len(t, uptimes, 2)
+	for _, vu := range uptimes {
+		assert.Equal(t, expected[vu.Address.String()], vu)
+	}
+	assert.EqualValues(t, 1, expected[signer.Address.String()].Uptime())
+	assert.Zero(t, expected[local.Address.String()].Uptime())
+
+	// The heights which left the window are deleted.
+	info, err := stateStore.LoadCommitInfo(3)
+	require.NoError(t, err)
+	assert.Nil(t, info)
+	info, err = stateStore.LoadCommitInfo(4)
+	require.NoError(t, err)
+	require.NotNil(t, info)
+	assert.Len(t, info.Votes, 2)
+
+	// The window is restored on restart, after the block with the last commit tracked.
+	state.LastBlockHeight = 9
+	state.LastValidators = state.Validators.Copy()
+	require.NoError(t, stateStore.Save(state))
+	tracker, err = sm.NewUptimeTracker(stateStore, 5, 3)
+	require.NoError(t, err)
+	from, to, uptimes = tracker.Uptime()
+	assert.EqualValues(t, 4, from)
+	assert.EqualValues(t, 8, to)
+	require.Len(t, uptimes, 2)
+	for _, vu := range uptimes {
+		assert.Equal(t, expected[vu.Address.String()], vu)
+	}
+}
+
+// labeledGauge is a gauge keeping the last value set for each set of label values.
+type labeledGauge struct {
+	values map[string]float64
+	lvs    []string
+}
+
+func newLabeledGauge() *labeledGauge {
+	return &labeledGauge{values: make(map[string]float64)}
+}
+
+func (g *labeledGauge) With(labelValues ...string) metrics.Gauge {
+	return &labeledGauge{values: g.values, lvs: append(append([]string{}, g.lvs...), labelValues...)}
+}
+
+func (g *labeledGauge) Set(value float64) {
+	g.values[strings.Join(g.lvs, ",")] = value
+}
+
+func (g *labeledGauge) Add(delta float64) {
+	g.values[strings.Join(g.lvs, ",")] += delta
+}
+
+func TestUptimeTrackerMetrics(t *testing.T) {
+	state, stateDB, _ := makeState(2, 1)
+	stateStore := sm.NewStore(stateDB)
+	vals := state.Validators
+	signer, local := vals.Validators[0], vals.Validators[1]
+
+	_, err := sm.NewUptimeTracker(stateStore, 2, 3)
+	require.Error(t, err)
+
+	metrics := sm.NopMetrics()
+	uptime, missed, localMissed := newLabeledGauge(), newLabeledGauge(), newLabeledGauge()
+	metrics.ValidatorUptime = uptime
+	metrics.ValidatorMissedBlocks = missed
+	metrics.LocalValidatorMissedBlocks = localMissed
+	tracker, err := sm.NewUptimeTracker(stateStore, 2, 2, sm.UptimeTrackerWithMetrics(metrics),
+		sm.UptimeTrackerWithLocalValidator(local.Address))
+	require.NoError(t, err)
+
+	// The local validator misses 2 blocks, then leaves the validator set.
+	for h := int64(1); h <= 2; h++ {
+		_, err = tracker.Track(h, makeUptimeCommit(h, vals, 0), vals)
+		require.NoError(t, err)
+	}
+	label := "validator_address," + local.Address.String()
+	assert.EqualValues(t, 2, missed.values[label])
+	assert.EqualValues(t, 2, localMissed.values[""])
+
+	signerVals := types.NewValidatorSet([]*types.Validator{signer})
+	for h := int64(3); h <= 4; h++ {
+		_, err = tracker.Track(h, makeUptimeCommit(h, signerVals, 0), signerVals)
+		require.NoError(t, err)
+	}
+	_, _, uptimes := tracker.Uptime()
+	require.Len(t, uptimes, 1)
+	// The gauges of the validator which left the window are zeroed.
+	assert.Zero(t, uptime.values[label])
+	assert.Zero(t, missed.values[label])
+	assert.Zero(t, localMissed.values[""])
+	assert.EqualValues(t, 1, uptime.values["validator_address,"+signer.Address.String()])
+}
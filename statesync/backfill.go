@@ -0,0 +1,179 @@
+package statesync
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tendermint/tendermint/p2p"
+	sm "github.com/tendermint/tendermint/state"
+	"github.com/tendermint/tendermint/types"
+)
+
+const (
+	// backfillFetchers is the number of light blocks fetched concurrently while backfilling.
+	backfillFetchers = 8
+	// backfillFetchTimeout is how long to keep trying to fetch a light block from the peers.
+	backfillFetchTimeout = 2 * time.Minute
+)
+
+// backfillRetryInterval is how long to wait before asking the peers for a light block again.
+var backfillRetryInterval = time.Second // not const so we can override with tests
+
+// Backfill fetches, verifies and stores the headers, commits and validator sets of the blocks
+// below the state's last block, so that the node can serve them, verify evidence and act as a
+// light client provider after state sync. Light blocks are verified backwards, each being
+// hash-linked to the one above, starting from the trusted last block ID of the state, until both
+// the evidence max age in blocks and in time are exceeded, or the initial height is reached.
+func (r *Reactor) Backfill(state sm.State) error {
+	if r.blockStore == nil || r.stateStore == nil {
+		return errors.New("backfill requires the block and state stores")
+	}
+	r.metrics.BackfillSyncing.Set(1)
+	defer r.metrics.BackfillSyncing.Set(0)
+
+	r.Logger.Info("Backfilling headers", "height", state.LastBlockHeight)
+	lowest, err := r.backfill(state)
+	if lowest <= state.LastBlockHeight {
+		r.Logger.Info("Backfilled headers", "from", state.LastBlockHeight, "to", lowest)
+	}
+	return err
+}
+
+// backfill does the backfilling, returning the lowest height backfilled.
+func (r *Reactor) backfill(state sm.State) (int64, error) {
+	var (
+		evidenceParams = state.ConsensusParams.Evidence
+		stopHeight     = state.LastBlockHeight - evidenceParams.MaxAgeNumBlocks
+		stopTime       = state.LastBlockTime.Add(-evidenceParams.MaxAgeDuration)
+		trustedBlockID = state.LastBlockID
+		badPeers       = make(map[p2p.ID]bool)
+
+		// validator set of the heights from valsLow to valsHigh, which haven't been stored yet
+		vals              *types.ValidatorSet
+		valsLow, valsHigh int64
+	)
+	// The validator set at the last height is already stored when bootstrapping.
+	saveVals := func() error {
+		if vals == nil || valsLow >= state.LastBlockHeight {
+			return nil
+		}
+		if valsHigh >= state.LastBlockHeight {
+			valsHigh = state.LastBlockHeight - 1
+		}
+		return r.stateStore.SaveValidatorSets(valsLow, valsHigh, vals)
+	}
+
+	height := state.LastBlockHeight
+	for height >= state.InitialHeight {
+		// Fetch a batch of light blocks concurrently, then verify them from the top. A light
+		// block that fails verification is fetched again from another peer with the next batch.
+		batch := r.requestLightBlocks(height, state.InitialHeight, badPeers)
+		for ; height >= state.InitialHeight; height-- {
+			resp, ok := batch[height]
+			if !ok {
+				break
+			}
+			if resp.err != nil {
+				if err := saveVals(); err != nil {
+					return height + 1, err
+				}
+				return height + 1, fmt.Errorf("failed to fetch light block at height %d: %w", height, resp.err)
+			}
+			lb := resp.lightBlock
+			if err := verifyBackfill(state.ChainID, lb, trustedBlockID); err != nil {
+				r.Logger.Info("Peer sent an invalid light block", "height", height, "peer", resp.peerID, "err", err)
+				badPeers[resp.peerID] = true
+				break
+			}
+
+			if err := r.blockStore.SaveSignedHeader(lb.SignedHeader, trustedBlockID); err != nil {
+				return height + 1, fmt.Errorf("failed to store signed header at height %d: %w", height, err)
+			}
+			if vals != nil && bytes.Equal(vals.Hash(), lb.ValidatorsHash) {
+				valsLow = height
+			} else {
+				if err := saveVals(); err != nil {
+					return height + 1, fmt.Errorf("failed to store validator sets: %w", err)
+				}
+				vals, valsLow, valsHigh = lb.ValidatorSet, height, height
+			}
+			trustedBlockID = lb.LastBlockID
+			r.metrics.BackfilledBlocks.Add(1)
+			r.metrics.BackfillHeight.Set(float64(height))
+
+			// Evidence expires once it's older than both max ages.
+			if height <= stopHeight && lb.Time.Before(stopTime) {
+				return height, saveVals()
+			}
+		}
+	}
+	return height + 1, saveVals()
+}
+
+// verifyBackfill verifies a light block going backwards, i.e. that it is valid and that it is the
+// block with the trusted block ID.
+func verifyBackfill(chainID string, lb *types.LightBlock, trustedBlockID types.BlockID) error {
+	if err := lb.ValidateBasic(chainID); err != nil {
+		return err
+	}
+	if !lb.Commit.BlockID.Equals(trustedBlockID) {
+		return fmt.Errorf("expected block ID %v, got %v", trustedBlockID, lb.Commit.BlockID)
+	}
+	return lb.ValidatorSet.VerifyCommitLight(chainID, lb.Commit.BlockID, lb.Height, lb.Commit)
+}
+
+type backfillResponse struct {
+	lightBlock *types.LightBlock
+	peerID     p2p.ID
+	err        error
+}
+
+// requestLightBlocks fetches up to backfillFetchers light blocks concurrently, from the height
+// down, without going below the min height.
+func (r *Reactor) requestLightBlocks(height, minHeight int64, badPeers map[p2p.ID]bool) map[int64]backfillResponse {
+	var (
+		wg    sync.WaitGroup
+		mtx   sync.Mutex
+		batch = make(map[int64]backfillResponse, backfillFetchers)
+	)
+	for h := height; h > height-backfillFetchers && h >= minHeight; h-- {
+		wg.Add(1)
+		go func(h int64) {
+			defer wg.Done()
+			resp := r.requestLightBlock(h, badPeers)
+			mtx.Lock()
+			batch[h] = resp
+			mtx.Unlock()
+		}(h)
+	}
+	wg.Wait()
+	return batch
+}
+
+// requestLightBlock fetches the light block at the height from any peer serving light blocks,
+// except the bad ones, retrying until backfillFetchTimeout.
+func (r *Reactor) requestLightBlock(height int64, badPeers map[p2p.ID]bool) backfillResponse {
+	deadline := time.Now().Add(backfillFetchTimeout)
+	for {
+		peers := r.lightBlockPeers()
+		// start with a different peer for each height to spread the load
+		for i := range peers {
+			peer := peers[(int(height)+i)%len(peers)]
+			if badPeers[peer.ID()] {
+				continue
+			}
+			lb, err := r.dispatcher.lightBlock(peer, uint64(height))
+			if err != nil || lb == nil {
+				continue
+			}
+			return backfillResponse{lightBlock: lb, peerID: peer.ID()}
+		}
+		if time.Now().After(deadline) {
+			return backfillResponse{err: errors.New("no peer provided it")}
+		}
+		time.Sleep(backfillRetryInterval)
+	}
+}
@@ -0,0 +1,125 @@
+package statesync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	dbm "github.com/tendermint/tm-db"
+
+	"github.com/tendermint/tendermint/config"
+	"github.com/tendermint/tendermint/crypto/tmhash"
+	"github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/p2p"
+	ssproto "github.com/tendermint/tendermint/proto/tendermint/statesync"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	proxymocks "github.com/tendermint/tendermint/proxy/mocks"
+	sm "github.com/tendermint/tendermint/state"
+	"github.com/tendermint/tendermint/store"
+	"github.com/tendermint/tendermint/types"
+	"github.com/tendermint/tendermint/version"
+)
+
+func TestReactor_Backfill(t *testing.T) {
+	const chainID = "backfill-test"
+	genesisTime := time.Now().Add(-time.Hour)
+
+	// the validator set changes at height 11, and the chain is at height 20
+	vals1, privVals1 := types.RandValidatorSet(3, 10)
+	vals2, privVals2 := types.RandValidatorSet(3, 10)
+	otherVals, otherPrivVals := types.RandValidatorSet(3, 10)
+	chain := make(map[int64]*types.LightBlock)
+	forged := make(map[int64]*types.LightBlock)
+	lastBlockID := types.BlockID{}
+	for h := int64(1); h <= 20; h++ {
+		vals, privVals := vals1, privVals1
+		if h > 10 {
+			vals, privVals = vals2, privVals2
+		}
+		blockTime := genesisTime.Add(time.Duration(h) * time.Second)
+		chain[h] = makeBackfillLightBlock(t, chainID, h, blockTime, lastBlockID, vals, privVals)
+		forged[h] = makeBackfillLightBlock(t, chainID, h, blockTime, lastBlockID, otherVals, otherPrivVals)
+		lastBlockID = chain[h].Commit.BlockID
+	}
+
+	stateStore := sm.NewStore(dbm.NewMemDB())
+	blockStore := store.NewBlockStore(dbm.NewMemDB())
+	r := NewReactor(&proxymocks.AppConnSnapshot{}, nil, stateStore, blockStore, "")
+	r.SetLogger(log.TestingLogger())
+	sw := p2p.NewSwitch(config.DefaultP2PConfig(), nil)
+	r.SetSwitch(sw)
+
+	// one peer serves the chain, the other one forged light blocks
+	for id, blocks := range map[p2p.ID]map[int64]*types.LightBlock{"good": chain, "bad": forged} {
+		blocks := blocks
+		peer := respondingPeer(r.dispatcher, id, func(request interface{}) interface{} {
+			height := request.(*ssproto.LightBlockRequest).Height
+			lb, err := blocks[int64(height)].ToProto()
+			require.NoError(t, err)
+			return &ssproto.LightBlockResponse{Height: height, LightBlock: lb}
+		})
+		peer.On("NodeInfo").Return(p2p.DefaultNodeInfo{Channels: []byte{LightBlockChannel}})
+		p2p.AddPeerToSwitchPeerSet(sw, peer)
+	}
+
+	// evidence expires after 10 blocks and 5 seconds, i.e. below height 10
+	params := types.DefaultConsensusParams()
+	params.Evidence.MaxAgeNumBlocks = 10
+	params.Evidence.MaxAgeDuration = 5 * time.Second
+	state := sm.State{
+		ChainID:         chainID,
+		InitialHeight:   1,
+		LastBlockHeight: 20,
+		LastBlockID:     chain[20].Commit.BlockID,
+		LastBlockTime:   chain[20].Time,
+		ConsensusParams: *params,
+	}
+
+	lowest, err := r.backfill(state)
+	require.NoError(t, err)
+	assert.EqualValues(t, 10, lowest)
+
+	for h := int64(10); h <= 20; h++ {
+		meta := blockStore.LoadBlockMeta(h)
+		require.NotNil(t, meta, "height %v", h)
+		assert.Equal(t, chain[h].Hash(), meta.Header.Hash())
+		assert.Equal(t, chain[h].Commit.Hash(), blockStore.LoadBlockCommit(h).Hash())
+	}
+	assert.Nil(t, blockStore.LoadBlockMeta(9))
+	assert.EqualValues(t, 0, blockStore.Height())
+
+	// the validator set at the last height is stored when bootstrapping
+	for h := int64(10); h < 20; h++ {
+		vals, err := stateStore.LoadValidators(h)
+		require.NoError(t, err, "height %v", h)
+		assert.Equal(t, chain[h].ValidatorsHash.Bytes(), vals.Hash(), "height %v", h)
+	}
+	_, err = stateStore.LoadValidators(9)
+	require.Error(t, err)
+}
+
+func makeBackfillLightBlock(t *testing.T, chainID string, height int64, blockTime time.Time,
+	lastBlockID types.BlockID, vals *types.ValidatorSet, privVals []types.PrivValidator) *types.LightBlock {
+	header := &types.Header{
+		ChainID:            chainID,
+		Height:             height,
+		Time:               blockTime,
+		LastBlockID:        lastBlockID,
+		ValidatorsHash:     vals.Hash(),
+		NextValidatorsHash: vals.Hash(),
+		ProposerAddress:    vals.Proposer.Address,
+	}
+	header.Version.Block = version.BlockProtocol
+	blockID := types.BlockID{
+		Hash:          header.Hash(),
+		PartSetHeader: types.PartSetHeader{Total: 1, Hash: tmhash.Sum([]byte("parts"))},
+	}
+	voteSet := types.NewVoteSet(chainID, height, 0, tmproto.PrecommitType, vals)
+	commit, err := types.MakeCommit(blockID, height, 0, voteSet, privVals, blockTime)
+	require.NoError(t, err)
+	return &types.LightBlock{
+		SignedHeader: &types.SignedHeader{Header: header, Commit: commit},
+		ValidatorSet: vals,
+	}
+}
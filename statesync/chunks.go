@@ -41,23 +41,63 @@ type chunkQueue struct {
 
 // newChunkQueue creates a new chunk queue for a snapshot, using a temp dir for storage.
 // Callers must call Close() when done.
+//
+// If tempDir is given, the chunks are stored in a directory specific to the snapshot, and any
+// chunks left there by an interrupted sync (e.g. a restart) are added to the queue, so that the
+// sync resumes without fetching them again. Otherwise, a new directory is created in the OS
+// temp dir.
 func newChunkQueue(snapshot *snapshot, tempDir string) (*chunkQueue, error) {
-	dir, err := ioutil.TempDir(tempDir, "tm-statesync")
-	if err != nil {
-		return nil, fmt.Errorf("unable to create temp dir for state sync chunks: %w", err)
-	}
 	if snapshot.Chunks == 0 {
 		return nil, errors.New("snapshot has no chunks")
 	}
-	return &chunkQueue{
+	q := &chunkQueue{
 		snapshot:       snapshot,
-		dir:            dir,
 		chunkFiles:     make(map[uint32]string, snapshot.Chunks),
 		chunkSenders:   make(map[uint32]p2p.ID, snapshot.Chunks),
 		chunkAllocated: make(map[uint32]bool, snapshot.Chunks),
 		chunkReturned:  make(map[uint32]bool, snapshot.Chunks),
 		waiters:        make(map[uint32][]chan<- uint32),
-	}, nil
+	}
+	if tempDir == "" {
+		dir, err := ioutil.TempDir("", "tm-statesync")
+		if err != nil {
+			return nil, fmt.Errorf("unable to create temp dir for state sync chunks: %w", err)
+		}
+		q.dir = dir
+		return q, nil
+	}
+
+	q.dir = filepath.Join(tempDir, fmt.Sprintf("tm-statesync-%v-%v-%X",
+		snapshot.Height, snapshot.Format, snapshot.Hash))
+	if err := os.MkdirAll(q.dir, 0700); err != nil {
+		return nil, fmt.Errorf("unable to create temp dir for state sync chunks: %w", err)
+	}
+	if err := q.loadFiles(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// loadFiles adds the chunk files already in the queue directory to the queue, removing any
+// partially written file.
+func (q *chunkQueue) loadFiles() error {
+	files, err := ioutil.ReadDir(q.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read state sync chunks from %v: %w", q.dir, err)
+	}
+	for _, file := range files {
+		path := filepath.Join(q.dir, file.Name())
+		index, err := strconv.ParseUint(file.Name(), 10, 32)
+		if err != nil || uint32(index) >= q.snapshot.Chunks || file.IsDir() {
+			if err := os.RemoveAll(path); err != nil {
+				return fmt.Errorf("failed to remove %v: %w", path, err)
+			}
+			continue
+		}
+		q.chunkFiles[uint32(index)] = path
+		q.chunkAllocated[uint32(index)] = true
+	}
+	return nil
 }
 
 // Add adds a chunk to the queue. It ignores chunks that already exist, returning false.
@@ -83,8 +123,13 @@ func (q *chunkQueue) Add(chunk *chunk) (bool, error) {
 		return false, nil
 	}
 
+	// Write the chunk to a temporary file first, so that an interrupted write doesn't leave a
+	// partial chunk to be resumed.
 	path := filepath.Join(q.dir, strconv.FormatUint(uint64(chunk.Index), 10))
-	err := ioutil.WriteFile(path, chunk.Chunk, 0600)
+	err := ioutil.WriteFile(path+".tmp", chunk.Chunk, 0600)
+	if err == nil {
+		err = os.Rename(path+".tmp", path)
+	}
 	if err != nil {
 		return false, fmt.Errorf("failed to save chunk %v to file %v: %w", chunk.Index, path, err)
 	}
@@ -286,6 +331,16 @@ func (q *chunkQueue) RetryAll() {
 	q.chunkReturned = make(map[uint32]bool)
 }
 
+// Received returns the number of chunks in the queue, or 0 when closed.
+func (q *chunkQueue) Received() uint32 {
+	q.Lock()
+	defer q.Unlock()
+	if q.snapshot == nil {
+		return 0
+	}
+	return uint32(len(q.chunkFiles))
+}
+
 // Size returns the total number of chunks for the snapshot and queue, or 0 when closed.
 func (q *chunkQueue) Size() uint32 {
 	q.Lock()
@@ -3,6 +3,7 @@ package statesync
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -54,6 +55,49 @@ func TestNewChunkQueue_TempDir(t *testing.T) {
 	assert.Len(t, files, 0)
 }
 
+func TestNewChunkQueue_Resume(t *testing.T) {
+	s := &snapshot{Height: 3, Format: 1, Chunks: 5, Hash: []byte{7}}
+	dir, err := ioutil.TempDir("", "newchunkqueue")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	queue, err := newChunkQueue(s, dir)
+	require.NoError(t, err)
+	for _, index := range []uint32{0, 3} {
+		_, err = queue.Add(&chunk{Height: 3, Format: 1, Index: index, Chunk: []byte{byte(index)}})
+		require.NoError(t, err)
+	}
+	// simulate an interrupted write
+	err = ioutil.WriteFile(filepath.Join(queue.dir, "1.tmp"), []byte{1}, 0600)
+	require.NoError(t, err)
+
+	// a new queue for the same snapshot resumes with the chunks, without allocating them
+	queue, err = newChunkQueue(s, dir)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, queue.Received())
+	for _, index := range []uint32{1, 2, 4} {
+		allocated, err := queue.Allocate()
+		require.NoError(t, err)
+		assert.Equal(t, index, allocated)
+	}
+	chunk, err := queue.Next()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0}, chunk.Chunk)
+	_, err = os.Stat(filepath.Join(queue.dir, "1.tmp"))
+	assert.True(t, os.IsNotExist(err))
+
+	// but not a queue for another snapshot
+	other, err := newChunkQueue(&snapshot{Height: 4, Format: 1, Chunks: 5, Hash: []byte{8}}, dir)
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, other.Received())
+	require.NoError(t, other.Close())
+
+	require.NoError(t, queue.Close())
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
 func TestChunkQueue(t *testing.T) {
 	queue, teardown := setupChunkQueue(t)
 	defer teardown()
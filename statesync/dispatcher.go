@@ -0,0 +1,160 @@
+package statesync
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+
+	tmsync "github.com/tendermint/tendermint/libs/sync"
+	lightprovider "github.com/tendermint/tendermint/light/provider"
+	"github.com/tendermint/tendermint/p2p"
+	ssproto "github.com/tendermint/tendermint/proto/tendermint/statesync"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+// errNoResponse is returned by the dispatcher when a peer doesn't respond in time.
+var errNoResponse = errors.New("peer did not respond in time")
+
+// dispatcher sends light block and consensus params requests to peers over the
+// LightBlockChannel, and hands the responses back to the callers waiting for them.
+type dispatcher struct {
+	timeout time.Duration
+
+	mtx   tmsync.Mutex
+	calls map[dispatchKey]chan proto.Message
+}
+
+// dispatchKey identifies a pending request. Peers echo the requested height in
+// their responses.
+type dispatchKey struct {
+	peerID p2p.ID
+	height uint64
+	params bool
+}
+
+func newDispatcher(timeout time.Duration) *dispatcher {
+	return &dispatcher{
+		timeout: timeout,
+		calls:   make(map[dispatchKey]chan proto.Message),
+	}
+}
+
+// lightBlock requests the light block at the height from the peer, or the
+// latest one if the height is 0. It returns nil if the peer doesn't have it.
+func (d *dispatcher) lightBlock(peer p2p.Peer, height uint64) (*types.LightBlock, error) {
+	key := dispatchKey{peerID: peer.ID(), height: height}
+	resp, err := d.call(peer, key, &ssproto.LightBlockRequest{Height: height})
+	if err != nil {
+		return nil, err
+	}
+	lb := resp.(*ssproto.LightBlockResponse).LightBlock
+	if lb == nil {
+		return nil, nil
+	}
+	return types.LightBlockFromProto(lb)
+}
+
+// consensusParams requests the consensus params at the height from the peer.
+// It returns nil if the peer doesn't have them.
+func (d *dispatcher) consensusParams(peer p2p.Peer, height uint64) (*tmproto.ConsensusParams, error) {
+	key := dispatchKey{peerID: peer.ID(), height: height, params: true}
+	resp, err := d.call(peer, key, &ssproto.ParamsRequest{Height: height})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*ssproto.ParamsResponse).ConsensusParams, nil
+}
+
+// call sends the request to the peer and waits for the response.
+func (d *dispatcher) call(peer p2p.Peer, key dispatchKey, request proto.Message) (proto.Message, error) {
+	d.mtx.Lock()
+	if _, ok := d.calls[key]; ok {
+		d.mtx.Unlock()
+		return nil, fmt.Errorf("a request for height %v is already pending with peer %v", key.height, key.peerID)
+	}
+	ch := make(chan proto.Message, 1)
+	d.calls[key] = ch
+	d.mtx.Unlock()
+	defer func() {
+		d.mtx.Lock()
+		delete(d.calls, key)
+		d.mtx.Unlock()
+	}()
+
+	if !peer.Send(LightBlockChannel, mustEncodeMsg(request)) {
+		return nil, fmt.Errorf("failed to send request to peer %v", peer.ID())
+	}
+
+	timer := time.NewTimer(d.timeout)
+	defer timer.Stop()
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-timer.C:
+		return nil, errNoResponse
+	}
+}
+
+// respond hands a response from a peer to the caller waiting for it. It returns
+// false if the response wasn't expected, e.g. because the request timed out.
+func (d *dispatcher) respond(key dispatchKey, resp proto.Message) bool {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	ch, ok := d.calls[key]
+	if !ok {
+		return false
+	}
+	delete(d.calls, key)
+	ch <- resp
+	return true
+}
+
+// blockProvider is a light client provider fetching light blocks from a peer
+// over the LightBlockChannel.
+type blockProvider struct {
+	chainID    string
+	peer       p2p.Peer
+	dispatcher *dispatcher
+}
+
+var _ lightprovider.Provider = (*blockProvider)(nil)
+
+// ChainID implements lightprovider.Provider.
+func (p *blockProvider) ChainID() string {
+	return p.chainID
+}
+
+// LightBlock implements lightprovider.Provider.
+func (p *blockProvider) LightBlock(height int64) (*types.LightBlock, error) {
+	lb, err := p.dispatcher.lightBlock(p.peer, uint64(height))
+	switch {
+	case errors.Is(err, errNoResponse):
+		return nil, lightprovider.ErrNoResponse
+	case err != nil:
+		return nil, lightprovider.ErrBadLightBlock{Reason: err}
+	case lb == nil:
+		return nil, lightprovider.ErrLightBlockNotFound
+	}
+	if err := lb.ValidateBasic(p.chainID); err != nil {
+		return nil, lightprovider.ErrBadLightBlock{Reason: err}
+	}
+	if height != 0 && lb.Height != height {
+		return nil, lightprovider.ErrBadLightBlock{
+			Reason: fmt.Errorf("expected height %d, got %d", height, lb.Height),
+		}
+	}
+	return lb, nil
+}
+
+// ReportEvidence implements lightprovider.Provider. Evidence can't be reported
+// to peers over the state sync channels.
+func (p *blockProvider) ReportEvidence(ev types.Evidence) error {
+	return errors.New("reporting evidence to peers is not supported")
+}
+
+func (p *blockProvider) String() string {
+	return fmt.Sprintf("peer{%v}", p.peer.ID())
+}
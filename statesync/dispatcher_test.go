@@ -0,0 +1,125 @@
+package statesync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	lightprovider "github.com/tendermint/tendermint/light/provider"
+	"github.com/tendermint/tendermint/p2p"
+	p2pmocks "github.com/tendermint/tendermint/p2p/mocks"
+	ssproto "github.com/tendermint/tendermint/proto/tendermint/statesync"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	"github.com/tendermint/tendermint/types"
+	"github.com/tendermint/tendermint/version"
+)
+
+// respondingPeer returns a mock peer passing its responses to the dispatcher, or not
+// responding at all if respond returns nil.
+func respondingPeer(d *dispatcher, id p2p.ID, respond func(request interface{}) interface{}) *p2pmocks.Peer {
+	peer := &p2pmocks.Peer{}
+	peer.On("ID").Return(id)
+	peer.On("Send", LightBlockChannel, mock.Anything).Run(func(args mock.Arguments) {
+		request, err := decodeMsg(args[1].([]byte))
+		if err != nil {
+			panic(err)
+		}
+		switch resp := respond(request).(type) {
+		case *ssproto.LightBlockResponse:
+			go d.respond(dispatchKey{peerID: id, height: resp.Height}, resp)
+		case *ssproto.ParamsResponse:
+			go d.respond(dispatchKey{peerID: id, height: resp.Height, params: true}, resp)
+		}
+	}).Return(true)
+	return peer
+}
+
+func TestBlockProvider_LightBlock(t *testing.T) {
+	lb := makeTestLightBlock(t, "test-chain", 3)
+	lbpb, err := lb.ToProto()
+	require.NoError(t, err)
+
+	testcases := map[string]struct {
+		height    int64
+		response  *ssproto.LightBlockResponse
+		expectErr error
+	}{
+		"light block is returned":        {3, &ssproto.LightBlockResponse{Height: 3, LightBlock: lbpb}, nil},
+		"latest light block is returned": {0, &ssproto.LightBlockResponse{Height: 0, LightBlock: lbpb}, nil},
+		"missing light block": {
+			3, &ssproto.LightBlockResponse{Height: 3}, lightprovider.ErrLightBlockNotFound},
+		"light block at another height": {
+			4, &ssproto.LightBlockResponse{Height: 4, LightBlock: lbpb}, lightprovider.ErrBadLightBlock{}},
+		"no response": {3, nil, lightprovider.ErrNoResponse},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			d := newDispatcher(100 * time.Millisecond)
+			peer := respondingPeer(d, "id", func(request interface{}) interface{} {
+				assert.Equal(t, &ssproto.LightBlockRequest{Height: uint64(tc.height)}, request)
+				if tc.response == nil {
+					return nil
+				}
+				return tc.response
+			})
+			provider := &blockProvider{chainID: "test-chain", peer: peer, dispatcher: d}
+
+			result, err := provider.LightBlock(tc.height)
+			if tc.expectErr != nil {
+				require.Error(t, err)
+				assert.IsType(t, tc.expectErr, err)
+				if _, ok := tc.expectErr.(lightprovider.ErrBadLightBlock); !ok {
+					assert.Equal(t, tc.expectErr, err)
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, lb.Hash(), result.Hash())
+		})
+	}
+}
+
+func TestDispatcher_ConsensusParams(t *testing.T) {
+	params := types.DefaultConsensusParams()
+	d := newDispatcher(100 * time.Millisecond)
+	peer := respondingPeer(d, "id", func(request interface{}) interface{} {
+		height := request.(*ssproto.ParamsRequest).Height
+		if height == 1 {
+			return &ssproto.ParamsResponse{Height: 1, ConsensusParams: params}
+		}
+		return &ssproto.ParamsResponse{Height: height}
+	})
+
+	result, err := d.consensusParams(peer, 1)
+	require.NoError(t, err)
+	assert.Equal(t, params, result)
+
+	result, err = d.consensusParams(peer, 2)
+	require.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func makeTestLightBlock(t *testing.T, chainID string, height int64) *types.LightBlock {
+	vals, privVals := types.RandValidatorSet(2, 10)
+	header := &types.Header{
+		ChainID:            chainID,
+		Height:             height,
+		Time:               time.Now(),
+		ValidatorsHash:     vals.Hash(),
+		NextValidatorsHash: vals.Hash(),
+		ProposerAddress:    vals.Proposer.Address,
+	}
+	header.Version.Block = version.BlockProtocol
+	blockID := types.BlockID{Hash: header.Hash(), PartSetHeader: types.PartSetHeader{Total: 1, Hash: make([]byte, 32)}}
+	voteSet := types.NewVoteSet(chainID, height, 0, tmproto.PrecommitType, vals)
+	commit, err := types.MakeCommit(blockID, height, 0, voteSet, privVals, time.Now())
+	require.NoError(t, err)
+	return &types.LightBlock{
+		SignedHeader: &types.SignedHeader{Header: header, Commit: commit},
+		ValidatorSet: vals,
+	}
+}
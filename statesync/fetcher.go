@@ -0,0 +1,257 @@
+package statesync
+
+import (
+	"math/rand"
+	"time"
+
+	tmsync "github.com/tendermint/tendermint/libs/sync"
+	"github.com/tendermint/tendermint/p2p"
+)
+
+const (
+	// maxChunkFetchers is the maximum number of chunks fetched concurrently. The actual concurrency
+	// adapts to the number of peers, since each peer has at most maxPeerChunkRequests pending.
+	maxChunkFetchers = 16
+	// maxPeerChunkRequests is the maximum number of pending chunk requests per peer.
+	maxPeerChunkRequests = 2
+	// minChunkThroughput is the minimum throughput expected from peers, in bytes per second. It
+	// scales the chunk request timeout with the size of the chunks.
+	minChunkThroughput = 256 * 1024
+	// initialChunkThroughput is the throughput assumed for peers which haven't sent any chunk
+	// yet, in bytes per second. It is optimistic, so that new peers are tried.
+	initialChunkThroughput = 10 * 1024 * 1024
+	// throughputWeight is the weight of new samples in the peer throughput moving average.
+	throughputWeight = 0.3
+	// maxPeerBadChunks is the number of bad chunks after which a peer is banned.
+	maxPeerBadChunks = 3
+	// maxPeerChunkTimeouts is the number of consecutive request timeouts after which a peer is
+	// banned.
+	maxPeerChunkTimeouts = 5
+)
+
+// chunkFetcher schedules chunk requests across the peers holding a snapshot. It estimates the
+// throughput of each peer, sending requests to the peers expected to respond the soonest, and
+// bans peers repeatedly sending bad chunks or not responding.
+type chunkFetcher struct {
+	metrics *Metrics
+
+	mtx          tmsync.Mutex
+	peers        map[p2p.ID]*peerScore
+	requests     map[uint32]*chunkRequest // pending requests, by chunk index
+	started      time.Time
+	fetched      int64
+	fetchedBytes int64
+}
+
+// peerScore tracks the chunk fetching performance of a peer.
+type peerScore struct {
+	peer       p2p.Peer
+	throughput float64 // bytes per second, moving average
+	pending    int
+	timeouts   int // consecutive
+	badChunks  int
+	banned     bool
+}
+
+// chunkRequest is a pending chunk request.
+type chunkRequest struct {
+	peer    p2p.Peer
+	height  uint64
+	format  uint32
+	index   uint32
+	sent    time.Time
+	timeout time.Duration
+	missing chan struct{} // closed if the peer doesn't have the chunk, or is removed
+}
+
+func newChunkFetcher(metrics *Metrics) *chunkFetcher {
+	return &chunkFetcher{
+		metrics:  metrics,
+		peers:    make(map[p2p.ID]*peerScore),
+		requests: make(map[uint32]*chunkRequest),
+	}
+}
+
+// score returns the score of a peer, creating it if necessary. The caller must hold the mutex.
+func (f *chunkFetcher) score(peerID p2p.ID) *peerScore {
+	score, ok := f.peers[peerID]
+	if !ok {
+		score = &peerScore{throughput: initialChunkThroughput}
+		f.peers[peerID] = score
+	}
+	return score
+}
+
+// selectPeer selects the peer expected to deliver a chunk the soonest, given its throughput
+// and pending requests, or nil if all peers are busy or banned.
+func (f *chunkFetcher) selectPeer(peers []p2p.Peer) p2p.Peer {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	var (
+		best     p2p.Peer
+		bestTime float64
+	)
+	// shuffle the peers, to spread requests across peers with the same score
+	for _, i := range rand.Perm(len(peers)) {
+		peer := peers[i]
+		score := f.score(peer.ID())
+		if score.banned || score.pending >= maxPeerChunkRequests {
+			continue
+		}
+		expected := float64(score.pending+1) / score.throughput
+		if best == nil || expected < bestTime {
+			best, bestTime = peer, expected
+		}
+	}
+	return best
+}
+
+// request registers a chunk request to a peer, replacing any pending request for the chunk.
+func (f *chunkFetcher) request(peer p2p.Peer, snapshot *snapshot, index uint32) *chunkRequest {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	if f.started.IsZero() {
+		f.started = time.Now()
+	}
+	f.remove(index)
+	score := f.score(peer.ID())
+	score.peer = peer
+	score.pending++
+
+	// Scale the timeout with the average chunk size, at the minimum expected throughput.
+	timeout := chunkRequestTimeout
+	if f.fetched > 0 {
+		timeout += time.Duration(float64(f.fetchedBytes/f.fetched) / minChunkThroughput * float64(time.Second))
+	}
+	req := &chunkRequest{
+		peer:    peer,
+		height:  snapshot.Height,
+		format:  snapshot.Format,
+		index:   index,
+		sent:    time.Now(),
+		timeout: timeout,
+		missing: make(chan struct{}),
+	}
+	f.requests[index] = req
+	return req
+}
+
+// remove removes the pending request for a chunk, if any. The caller must hold the mutex.
+func (f *chunkFetcher) remove(index uint32) *chunkRequest {
+	req, ok := f.requests[index]
+	if !ok {
+		return nil
+	}
+	delete(f.requests, index)
+	if score, ok := f.peers[req.peer.ID()]; ok && score.pending > 0 {
+		score.pending--
+	}
+	return req
+}
+
+// received records the arrival of a chunk, updating the throughput of its sender if it was
+// requested from it.
+func (f *chunkFetcher) received(chunk *chunk) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	f.fetched++
+	f.fetchedBytes += int64(len(chunk.Chunk))
+	f.metrics.ChunksFetched.Add(1)
+	f.metrics.ChunkBytesFetched.Add(float64(len(chunk.Chunk)))
+	if !f.started.IsZero() {
+		if elapsed := time.Since(f.started).Seconds(); elapsed > 0 {
+			f.metrics.ChunkFetchRate.Set(float64(f.fetched) / elapsed)
+		}
+	}
+
+	req, ok := f.requests[chunk.Index]
+	if !ok || req.height != chunk.Height || req.format != chunk.Format {
+		return
+	}
+	f.remove(chunk.Index)
+	if req.peer.ID() != chunk.Sender {
+		return
+	}
+	score := f.score(chunk.Sender)
+	score.timeouts = 0
+	if elapsed := time.Since(req.sent).Seconds(); elapsed > 0 {
+		sample := float64(len(chunk.Chunk)) / elapsed
+		score.throughput = (1-throughputWeight)*score.throughput + throughputWeight*sample
+	}
+}
+
+// missing records that a peer doesn't have a requested chunk, signalling the fetcher waiting
+// for it to request it from another peer.
+func (f *chunkFetcher) missing(peerID p2p.ID, height uint64, format uint32, index uint32) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	req, ok := f.requests[index]
+	if !ok || req.peer.ID() != peerID || req.height != height || req.format != format {
+		return
+	}
+	f.remove(index)
+	f.score(peerID).throughput /= 2
+	close(req.missing)
+}
+
+// timedOut records that a chunk request timed out. It returns the peer if it must be banned.
+func (f *chunkFetcher) timedOut(req *chunkRequest) p2p.Peer {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	if f.requests[req.index] == req {
+		f.remove(req.index)
+	}
+	score := f.score(req.peer.ID())
+	score.throughput /= 2
+	score.timeouts++
+	if score.timeouts >= maxPeerChunkTimeouts {
+		return f.ban(score)
+	}
+	return nil
+}
+
+// badChunk records that a peer sent a bad chunk. It returns the peer if it must be banned.
+func (f *chunkFetcher) badChunk(peerID p2p.ID) p2p.Peer {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	score := f.score(peerID)
+	score.badChunks++
+	if score.badChunks >= maxPeerBadChunks {
+		return f.ban(score)
+	}
+	return nil
+}
+
+// ban bans a peer, returning it unless it was already banned or is unknown. The caller must
+// hold the mutex.
+func (f *chunkFetcher) ban(score *peerScore) p2p.Peer {
+	if score.banned || score.peer == nil {
+		return nil
+	}
+	score.banned = true
+	f.metrics.ChunkPeersBanned.Add(1)
+	return score.peer
+}
+
+// removePeer removes a peer, signalling the fetchers waiting for its pending requests to
+// request the chunks from other peers.
+func (f *chunkFetcher) removePeer(peerID p2p.ID) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	for index, req := range f.requests {
+		if req.peer.ID() == peerID {
+			f.remove(index)
+			close(req.missing)
+		}
+	}
+	if score, ok := f.peers[peerID]; ok && !score.banned {
+		delete(f.peers, peerID)
+	}
+}
@@ -0,0 +1,102 @@
+package statesync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/p2p"
+)
+
+func TestChunkFetcher_selectPeer(t *testing.T) {
+	fetcher := newChunkFetcher(NopMetrics())
+	s := &snapshot{Height: 1, Format: 1, Chunks: 10}
+	peerA, peerB := simplePeer("a"), simplePeer("b")
+	peers := []p2p.Peer{peerA, peerB}
+
+	// a slow response lowers the throughput of peer a below the initial one of peer b
+	req := fetcher.request(peerA, s, 0)
+	req.sent = time.Now().Add(-time.Second)
+	fetcher.received(&chunk{Height: 1, Format: 1, Index: 0, Chunk: make([]byte, 1024), Sender: "a"})
+	assert.Equal(t, peerB, fetcher.selectPeer(peers))
+
+	// peers have a limited number of pending requests
+	for i := uint32(1); i <= maxPeerChunkRequests; i++ {
+		fetcher.request(peerB, s, i)
+	}
+	assert.Equal(t, peerA, fetcher.selectPeer(peers))
+	for i := uint32(maxPeerChunkRequests + 1); i <= 2*maxPeerChunkRequests; i++ {
+		fetcher.request(peerA, s, i)
+	}
+	assert.Nil(t, fetcher.selectPeer(peers))
+
+	// a chunk from a peer frees up a request
+	fetcher.received(&chunk{Height: 1, Format: 1, Index: 1, Chunk: []byte{1}, Sender: "b"})
+	assert.Equal(t, peerB, fetcher.selectPeer(peers))
+}
+
+func TestChunkFetcher_timeout(t *testing.T) {
+	fetcher := newChunkFetcher(NopMetrics())
+	s := &snapshot{Height: 1, Format: 1, Chunks: 10}
+	peer := simplePeer("a")
+
+	req := fetcher.request(peer, s, 0)
+	assert.Equal(t, chunkRequestTimeout, req.timeout)
+
+	// the timeout grows with the chunk size
+	fetcher.received(&chunk{Height: 1, Format: 1, Index: 0, Chunk: make([]byte, 10*minChunkThroughput)})
+	req = fetcher.request(peer, s, 1)
+	assert.Equal(t, chunkRequestTimeout+10*time.Second, req.timeout)
+
+	// consecutive timeouts ban the peer
+	for i := 0; i < maxPeerChunkTimeouts-1; i++ {
+		assert.Nil(t, fetcher.timedOut(fetcher.request(peer, s, 1)))
+	}
+	assert.Equal(t, peer, fetcher.timedOut(fetcher.request(peer, s, 1)))
+	assert.Nil(t, fetcher.selectPeer([]p2p.Peer{peer}))
+}
+
+func TestChunkFetcher_badChunk(t *testing.T) {
+	fetcher := newChunkFetcher(NopMetrics())
+	s := &snapshot{Height: 1, Format: 1, Chunks: 10}
+	peer := simplePeer("a")
+
+	// unknown peers can't be banned
+	for i := 0; i < maxPeerBadChunks; i++ {
+		assert.Nil(t, fetcher.badChunk("unknown"))
+	}
+
+	fetcher.request(peer, s, 0)
+	for i := 0; i < maxPeerBadChunks-1; i++ {
+		assert.Nil(t, fetcher.badChunk("a"))
+	}
+	assert.Equal(t, peer, fetcher.badChunk("a"))
+	// peers are only banned once
+	assert.Nil(t, fetcher.badChunk("a"))
+}
+
+func TestChunkFetcher_missing(t *testing.T) {
+	fetcher := newChunkFetcher(NopMetrics())
+	s := &snapshot{Height: 1, Format: 1, Chunks: 10}
+	peerA, peerB := simplePeer("a"), simplePeer("b")
+
+	req := fetcher.request(peerA, s, 0)
+	// responses from other peers or for other snapshots are ignored
+	fetcher.missing("b", 1, 1, 0)
+	fetcher.missing("a", 2, 1, 0)
+	select {
+	case <-req.missing:
+		require.Fail(t, "unexpected missing signal")
+	default:
+	}
+	fetcher.missing("a", 1, 1, 0)
+	<-req.missing
+
+	// removing a peer signals its pending requests
+	req = fetcher.request(peerB, s, 1)
+	fetcher.removePeer("b")
+	<-req.missing
+	assert.Equal(t, peerB, fetcher.selectPeer([]p2p.Peer{peerB}))
+}
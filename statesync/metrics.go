@@ -0,0 +1,100 @@
+package statesync
+
+import (
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/discard"
+	"github.com/go-kit/kit/metrics/prometheus"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// MetricsSubsystem is a subsystem shared by all metrics exposed by this
+	// package.
+	MetricsSubsystem = "statesync"
+)
+
+// Metrics contains metrics exposed by this package.
+type Metrics struct {
+	// Whether or not the node is backfilling headers after state sync.
+	BackfillSyncing metrics.Gauge
+	// Number of light blocks backfilled.
+	BackfilledBlocks metrics.Counter
+	// Lowest height backfilled.
+	BackfillHeight metrics.Gauge
+	// Number of snapshot chunks fetched.
+	ChunksFetched metrics.Counter
+	// Number of snapshot chunk bytes fetched.
+	ChunkBytesFetched metrics.Counter
+	// Snapshot chunks fetched per second.
+	ChunkFetchRate metrics.Gauge
+	// Number of peers banned for sending bad chunks or not responding.
+	ChunkPeersBanned metrics.Counter
+}
+
+// PrometheusMetrics returns Metrics build using Prometheus client library.
+// Optionally, labels can be provided along with their values ("foo",
+// "fooValue").
+func PrometheusMetrics(namespace string, labelsAndValues ...string) *Metrics {
+	labels := []string{}
+	for i := 0; i < len(labelsAndValues); i += 2 {
+		labels = append(labels, labelsAndValues[i])
+	}
+	return &Metrics{
+		BackfillSyncing: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "backfill_syncing",
+			Help:      "Whether or not the node is backfilling headers after state sync. 1 if yes, 0 if no.",
+		}, labels).With(labelsAndValues...),
+		BackfilledBlocks: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "backfilled_blocks",
+			Help:      "Number of light blocks backfilled.",
+		}, labels).With(labelsAndValues...),
+		BackfillHeight: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "backfill_height",
+			Help:      "Lowest height backfilled.",
+		}, labels).With(labelsAndValues...),
+		ChunksFetched: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "chunks_fetched",
+			Help:      "Number of snapshot chunks fetched.",
+		}, labels).With(labelsAndValues...),
+		ChunkBytesFetched: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "chunk_bytes_fetched",
+			Help:      "Number of snapshot chunk bytes fetched.",
+		}, labels).With(labelsAndValues...),
+		ChunkFetchRate: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "chunk_fetch_rate",
+			Help:      "Snapshot chunks fetched per second.",
+		}, labels).With(labelsAndValues...),
+		ChunkPeersBanned: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "chunk_peers_banned",
+			Help:      "Number of peers banned for sending bad chunks or not responding.",
+		}, labels).With(labelsAndValues...),
+	}
+}
+
+// NopMetrics returns no-op Metrics.
+func NopMetrics() *Metrics {
+	return &Metrics{
+		BackfillSyncing:  discard.NewGauge(),
+		BackfilledBlocks: discard.NewCounter(),
+		BackfillHeight:   discard.NewGauge(),
+
+		ChunksFetched:     discard.NewCounter(),
+		ChunkBytesFetched: discard.NewCounter(),
+		ChunkFetchRate:    discard.NewGauge(),
+		ChunkPeersBanned:  discard.NewCounter(),
+	}
+}
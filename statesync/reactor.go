@@ -1,8 +1,10 @@
 package statesync
 
 import (
+	"bytes"
 	"errors"
 	"sort"
+	"time"
 
 	abci "github.com/tendermint/tendermint/abci/types"
 	tmsync "github.com/tendermint/tendermint/libs/sync"
@@ -10,6 +12,7 @@ import (
 	ssproto "github.com/tendermint/tendermint/proto/tendermint/statesync"
 	"github.com/tendermint/tendermint/proxy"
 	sm "github.com/tendermint/tendermint/state"
+	"github.com/tendermint/tendermint/store"
 	"github.com/tendermint/tendermint/types"
 )
 
@@ -18,18 +21,44 @@ const (
 	SnapshotChannel = byte(0x60)
 	// ChunkChannel exchanges chunk contents
 	ChunkChannel = byte(0x61)
+	// LightBlockChannel exchanges light blocks and consensus params
+	LightBlockChannel = byte(0x62)
 	// recentSnapshots is the number of recent snapshots to send and receive per peer.
 	recentSnapshots = 10
+	// lightBlockResponseTimeout is how long to wait for a peer to respond to a light
+	// block or consensus params request.
+	lightBlockResponseTimeout = 10 * time.Second
+	// snapshotQueueSize is the number of received snapshots waiting to be verified and added
+	// to the sync. Further snapshots are dropped until there's room.
+	snapshotQueueSize = 4 * recentSnapshots
 )
 
+// snapshotEnvelope is a snapshot received from a peer, waiting to be added to the sync.
+type snapshotEnvelope struct {
+	peer p2p.Peer
+	msg  *ssproto.SnapshotsResponse
+}
+
 // Reactor handles state sync, both restoring snapshots for the local node and serving snapshots
 // for other nodes.
 type Reactor struct {
 	p2p.BaseReactor
 
-	conn      proxy.AppConnSnapshot
-	connQuery proxy.AppConnQuery
-	tempDir   string
+	conn       proxy.AppConnSnapshot
+	connQuery  proxy.AppConnQuery
+	stateStore sm.Store
+	blockStore *store.BlockStore
+	tempDir    string
+
+	// dispatcher sends light block and consensus params requests to peers, for the
+	// p2p state provider and backfilling.
+	dispatcher *dispatcher
+
+	metrics *Metrics
+
+	// snapshotCh queues the received snapshots for the snapshot routine, which verifies them
+	// one at a time.
+	snapshotCh chan snapshotEnvelope
 
 	// This will only be set when a state sync is in progress. It is used to feed received
 	// snapshots and chunks into the sync.
@@ -37,13 +66,31 @@ type Reactor struct {
 	syncer *syncer
 }
 
-// NewReactor creates a new state sync reactor.
-func NewReactor(conn proxy.AppConnSnapshot, connQuery proxy.AppConnQuery, tempDir string) *Reactor {
+// ReactorOption sets an optional parameter on the Reactor.
+type ReactorOption func(*Reactor)
+
+// ReactorMetrics sets the metrics.
+func ReactorMetrics(metrics *Metrics) ReactorOption {
+	return func(r *Reactor) { r.metrics = metrics }
+}
+
+// NewReactor creates a new state sync reactor. The state and block stores are used to serve
+// light blocks and consensus params to peers and to backfill, and may be nil.
+func NewReactor(conn proxy.AppConnSnapshot, connQuery proxy.AppConnQuery,
+	stateStore sm.Store, blockStore *store.BlockStore, tempDir string, options ...ReactorOption) *Reactor {
 	r := &Reactor{
-		conn:      conn,
-		connQuery: connQuery,
+		conn:       conn,
+		connQuery:  connQuery,
+		stateStore: stateStore,
+		blockStore: blockStore,
+		dispatcher: newDispatcher(lightBlockResponseTimeout),
+		metrics:    NopMetrics(),
+		snapshotCh: make(chan snapshotEnvelope, snapshotQueueSize),
 	}
 	r.BaseReactor = *p2p.NewBaseReactor("StateSync", r)
+	for _, option := range options {
+		option(r)
+	}
 	return r
 }
 
@@ -62,11 +109,18 @@ func (r *Reactor) GetChannels() []*p2p.ChannelDescriptor {
 			SendQueueCapacity:   4,
 			RecvMessageCapacity: chunkMsgSize,
 		},
+		{
+			ID:                  LightBlockChannel,
+			Priority:            2,
+			SendQueueCapacity:   10,
+			RecvMessageCapacity: lightBlockMsgSize,
+		},
 	}
 }
 
 // OnStart implements p2p.Reactor.
 func (r *Reactor) OnStart() error {
+	go r.snapshotRoutine()
 	return nil
 }
 
@@ -129,24 +183,15 @@ func (r *Reactor) Receive(chID byte, src p2p.Peer, msgBytes []byte) {
 			}
 
 		case *ssproto.SnapshotsResponse:
-			r.mtx.RLock()
-			defer r.mtx.RUnlock()
-			if r.syncer == nil {
-				r.Logger.Debug("Received unexpected snapshot, no state sync in progress")
-				return
-			}
-			r.Logger.Debug("Received snapshot", "height", msg.Height, "format", msg.Format, "peer", src.ID())
-			_, err := r.syncer.AddSnapshot(src, &snapshot{
-				Height:   msg.Height,
-				Format:   msg.Format,
-				Chunks:   msg.Chunks,
-				Hash:     msg.Hash,
-				Metadata: msg.Metadata,
-			})
-			if err != nil {
-				r.Logger.Error("Failed to add snapshot", "height", msg.Height, "format", msg.Format,
-					"peer", src.ID(), "err", err)
-				return
+			// Adding a snapshot verifies its app hash with the state provider, which may
+			// request light blocks from this same peer, so we mustn't block its receive
+			// routine: the snapshot is queued for the snapshot routine, or dropped if the
+			// queue is full.
+			select {
+			case r.snapshotCh <- snapshotEnvelope{peer: src, msg: msg}:
+			default:
+				r.Logger.Debug("Dropping snapshot, too many snapshots waiting to be verified",
+					"height", msg.Height, "format", msg.Format, "peer", src.ID())
 			}
 
 		default:
@@ -185,6 +230,10 @@ func (r *Reactor) Receive(chID byte, src p2p.Peer, msgBytes []byte) {
 				r.Logger.Debug("Received unexpected chunk, no state sync in progress", "peer", src.ID())
 				return
 			}
+			if msg.Missing {
+				r.syncer.MissingChunk(src.ID(), msg.Height, msg.Format, msg.Index)
+				return
+			}
 			r.Logger.Debug("Received chunk, adding to sync", "height", msg.Height, "format", msg.Format,
 				"chunk", msg.Index, "peer", src.ID())
 			_, err := r.syncer.AddChunk(&chunk{
@@ -204,11 +253,138 @@ func (r *Reactor) Receive(chID byte, src p2p.Peer, msgBytes []byte) {
 			r.Logger.Error("Received unknown message %T", msg)
 		}
 
+	case LightBlockChannel:
+		switch msg := msg.(type) {
+		case *ssproto.LightBlockRequest:
+			r.Logger.Debug("Received light block request", "height", msg.Height, "peer", src.ID())
+			resp := &ssproto.LightBlockResponse{Height: msg.Height}
+			lb, err := r.fetchLightBlock(msg.Height)
+			if err != nil {
+				r.Logger.Error("Failed to load light block", "height", msg.Height, "err", err)
+				return
+			}
+			if lb != nil {
+				resp.LightBlock, err = lb.ToProto()
+				if err != nil {
+					r.Logger.Error("Failed to convert light block to proto", "height", msg.Height, "err", err)
+					return
+				}
+			}
+			src.Send(LightBlockChannel, mustEncodeMsg(resp))
+
+		case *ssproto.ParamsRequest:
+			r.Logger.Debug("Received consensus params request", "height", msg.Height, "peer", src.ID())
+			resp := &ssproto.ParamsResponse{Height: msg.Height}
+			if r.stateStore != nil {
+				params, err := r.stateStore.LoadConsensusParams(int64(msg.Height))
+				if err == nil {
+					resp.ConsensusParams = &params
+				}
+			}
+			src.Send(LightBlockChannel, mustEncodeMsg(resp))
+
+		case *ssproto.LightBlockResponse:
+			if !r.dispatcher.respond(dispatchKey{peerID: src.ID(), height: msg.Height}, msg) {
+				r.Logger.Debug("Received unexpected light block", "height", msg.Height, "peer", src.ID())
+			}
+
+		case *ssproto.ParamsResponse:
+			if !r.dispatcher.respond(dispatchKey{peerID: src.ID(), height: msg.Height, params: true}, msg) {
+				r.Logger.Debug("Received unexpected consensus params", "height", msg.Height, "peer", src.ID())
+			}
+
+		default:
+			r.Logger.Error("Received unknown message %T", msg)
+		}
+
 	default:
 		r.Logger.Error("Received message on invalid channel %x", chID)
 	}
 }
 
+// snapshotRoutine adds the queued snapshots to the sync in progress, until the reactor stops.
+func (r *Reactor) snapshotRoutine() {
+	for {
+		select {
+		case e := <-r.snapshotCh:
+			r.addSnapshot(e.peer, e.msg)
+		case <-r.Quit():
+			return
+		}
+	}
+}
+
+// addSnapshot adds a snapshot received from a peer to the sync in progress, if any. The lock
+// isn't held while the snapshot is verified, which may take a while.
+func (r *Reactor) addSnapshot(src p2p.Peer, msg *ssproto.SnapshotsResponse) {
+	r.mtx.RLock()
+	syncer := r.syncer
+	r.mtx.RUnlock()
+	if syncer == nil {
+		r.Logger.Debug("Received unexpected snapshot, no state sync in progress")
+		return
+	}
+	r.Logger.Debug("Received snapshot", "height", msg.Height, "format", msg.Format, "peer", src.ID())
+	_, err := syncer.AddSnapshot(src, &snapshot{
+		Height:   msg.Height,
+		Format:   msg.Format,
+		Chunks:   msg.Chunks,
+		Hash:     msg.Hash,
+		Metadata: msg.Metadata,
+	})
+	if err != nil {
+		r.Logger.Error("Failed to add snapshot", "height", msg.Height, "format", msg.Format,
+			"peer", src.ID(), "err", err)
+	}
+}
+
+// fetchLightBlock loads the light block at the height, or the latest one if the height is 0,
+// from the block and state stores. It returns nil if we don't have it.
+func (r *Reactor) fetchLightBlock(height uint64) (*types.LightBlock, error) {
+	if r.blockStore == nil || r.stateStore == nil {
+		return nil, nil
+	}
+	h := int64(height)
+	if h == 0 {
+		h = r.blockStore.Height()
+	}
+	meta := r.blockStore.LoadBlockMeta(h)
+	if meta == nil {
+		return nil, nil
+	}
+	// The canonical commit is only available once the next block is committed, so we fall
+	// back to the commit we've seen for the latest block.
+	commit := r.blockStore.LoadBlockCommit(h)
+	if commit == nil {
+		commit = r.blockStore.LoadSeenCommit(h)
+	}
+	if commit == nil {
+		return nil, nil
+	}
+	vals, err := r.stateStore.LoadValidators(h)
+	if errors.As(err, &sm.ErrNoValSetForHeight{}) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &types.LightBlock{
+		SignedHeader: &types.SignedHeader{Header: &meta.Header, Commit: commit},
+		ValidatorSet: vals,
+	}, nil
+}
+
+// lightBlockPeers returns the connected peers serving light blocks.
+func (r *Reactor) lightBlockPeers() []p2p.Peer {
+	peers := []p2p.Peer{}
+	for _, peer := range r.Switch.Peers().List() {
+		nodeInfo, ok := peer.NodeInfo().(p2p.DefaultNodeInfo)
+		if ok && bytes.Contains(nodeInfo.Channels, []byte{LightBlockChannel}) {
+			peers = append(peers, peer)
+		}
+	}
+	return peers
+}
+
 // recentSnapshots fetches the n most recent snapshots from the app
 func (r *Reactor) recentSnapshots(n uint32) ([]*snapshot, error) {
 	resp, err := r.conn.ListSnapshotsSync(abci.RequestListSnapshots{})
@@ -251,7 +427,8 @@ func (r *Reactor) Sync(stateProvider StateProvider) (sm.State, *types.Commit, er
 		r.mtx.Unlock()
 		return sm.State{}, nil, errors.New("a state sync is already in progress")
 	}
-	r.syncer = newSyncer(r.Logger, r.conn, r.connQuery, stateProvider, r.tempDir)
+	r.syncer = newSyncer(r.Logger, r.conn, r.connQuery, stateProvider, r.tempDir, r.metrics,
+		r.Switch.StopPeerForError)
 	r.mtx.Unlock()
 
 	// Request snapshots from all currently connected peers
@@ -1,18 +1,27 @@
 package statesync
 
 import (
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/gogo/protobuf/proto"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	dbm "github.com/tendermint/tm-db"
 
 	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/log"
 	"github.com/tendermint/tendermint/p2p"
 	p2pmocks "github.com/tendermint/tendermint/p2p/mocks"
 	ssproto "github.com/tendermint/tendermint/proto/tendermint/statesync"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
 	proxymocks "github.com/tendermint/tendermint/proxy/mocks"
+	sm "github.com/tendermint/tendermint/state"
+	"github.com/tendermint/tendermint/statesync/mocks"
+	"github.com/tendermint/tendermint/store"
+	"github.com/tendermint/tendermint/types"
 )
 
 func TestReactor_Receive_ChunkRequest(t *testing.T) {
@@ -60,7 +69,7 @@ func TestReactor_Receive_ChunkRequest(t *testing.T) {
 			}
 
 			// Start a reactor and send a ssproto.ChunkRequest, then wait for and check response
-			r := NewReactor(conn, nil, "")
+			r := NewReactor(conn, nil, nil, nil, "")
 			err := r.Start()
 			require.NoError(t, err)
 			t.Cleanup(func() {
@@ -137,7 +146,7 @@ func TestReactor_Receive_SnapshotsRequest(t *testing.T) {
 			}
 
 			// Start a reactor and send a SnapshotsRequestMessage, then wait for and check responses
-			r := NewReactor(conn, nil, "")
+			r := NewReactor(conn, nil, nil, nil, "")
 			err := r.Start()
 			require.NoError(t, err)
 			t.Cleanup(func() {
@@ -155,3 +164,149 @@ func TestReactor_Receive_SnapshotsRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestReactor_Receive_LightBlockChannel(t *testing.T) {
+	vals, privVals := types.RandValidatorSet(2, 10)
+	genDoc := &types.GenesisDoc{ChainID: "test-chain"}
+	for _, val := range vals.Validators {
+		genDoc.Validators = append(genDoc.Validators, types.GenesisValidator{PubKey: val.PubKey, Power: val.VotingPower})
+	}
+	require.NoError(t, genDoc.ValidateAndComplete())
+	state, err := sm.MakeGenesisState(genDoc)
+	require.NoError(t, err)
+
+	stateStore := sm.NewStore(dbm.NewMemDB())
+	require.NoError(t, stateStore.Save(state))
+	blockStore := store.NewBlockStore(dbm.NewMemDB())
+
+	// Store a block at height 1 with its seen commit.
+	block, parts := state.MakeBlock(1, nil, new(types.Commit), nil, state.Validators.Proposer.Address)
+	blockID := types.BlockID{Hash: block.Hash(), PartSetHeader: parts.Header()}
+	voteSet := types.NewVoteSet(genDoc.ChainID, 1, 0, tmproto.PrecommitType, state.Validators)
+	commit, err := types.MakeCommit(blockID, 1, 0, voteSet, privVals, time.Now())
+	require.NoError(t, err)
+	blockStore.SaveBlock(block, parts, commit)
+
+	expectLightBlock, err := (&types.LightBlock{
+		SignedHeader: &types.SignedHeader{Header: &block.Header, Commit: commit},
+		ValidatorSet: state.Validators,
+	}).ToProto()
+	require.NoError(t, err)
+	expectParams := state.ConsensusParams
+
+	testcases := map[string]struct {
+		request        proto.Message
+		expectResponse proto.Message
+	}{
+		"light block is returned": {
+			&ssproto.LightBlockRequest{Height: 1},
+			&ssproto.LightBlockResponse{Height: 1, LightBlock: expectLightBlock}},
+		"latest light block is returned for height 0": {
+			&ssproto.LightBlockRequest{Height: 0},
+			&ssproto.LightBlockResponse{Height: 0, LightBlock: expectLightBlock}},
+		"missing light block is returned empty": {
+			&ssproto.LightBlockRequest{Height: 2},
+			&ssproto.LightBlockResponse{Height: 2}},
+		"consensus params are returned": {
+			&ssproto.ParamsRequest{Height: 1},
+			&ssproto.ParamsResponse{Height: 1, ConsensusParams: &expectParams}},
+		"missing consensus params are returned empty": {
+			&ssproto.ParamsRequest{Height: 5},
+			&ssproto.ParamsResponse{Height: 5}},
+	}
+
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			// Mock peer to store response
+			peer := &p2pmocks.Peer{}
+			peer.On("ID").Return(p2p.ID("id"))
+			var response proto.Message
+			peer.On("Send", LightBlockChannel, mock.Anything).Run(func(args mock.Arguments) {
+				msg, err := decodeMsg(args[1].([]byte))
+				require.NoError(t, err)
+				response = msg
+			}).Return(true)
+
+			r := NewReactor(&proxymocks.AppConnSnapshot{}, nil, stateStore, blockStore, "")
+			err := r.Start()
+			require.NoError(t, err)
+			t.Cleanup(func() {
+				if err := r.Stop(); err != nil {
+					t.Error(err)
+				}
+			})
+
+			r.Receive(LightBlockChannel, peer, mustEncodeMsg(tc.request))
+			time.Sleep(100 * time.Millisecond)
+			// compare encoded messages, since timestamps lose their location when decoded
+			require.NotNil(t, response)
+			assert.Equal(t, mustEncodeMsg(tc.expectResponse), mustEncodeMsg(response))
+
+			peer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestReactor_Receive_SnapshotsResponse(t *testing.T) {
+	// The state provider blocks verification until released.
+	release := make(chan struct{})
+	var verified int32
+	stateProvider := &mocks.StateProvider{}
+	stateProvider.On("AppHash", mock.Anything).Run(func(args mock.Arguments) {
+		<-release
+		atomic.AddInt32(&verified, 1)
+	}).Return([]byte("app_hash"), nil)
+
+	peer := &p2pmocks.Peer{}
+	peer.On("ID").Return(p2p.ID("id"))
+
+	r := NewReactor(&proxymocks.AppConnSnapshot{}, nil, nil, nil, "")
+	require.NoError(t, r.Start())
+	t.Cleanup(func() {
+		if err := r.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+	r.mtx.Lock()
+	r.syncer = newSyncer(log.NewNopLogger(), &proxymocks.AppConnSnapshot{}, nil, stateProvider, "",
+		NopMetrics(), nil)
+	r.mtx.Unlock()
+
+	// Receiving more snapshots than can be queued doesn't block, the extra ones are dropped.
+	sent := 2 * snapshotQueueSize
+	received := make(chan struct{})
+	go func() {
+		for i := 1; i <= sent; i++ {
+			r.Receive(SnapshotChannel, peer, mustEncodeMsg(&ssproto.SnapshotsResponse{
+				Height: uint64(i), Format: 1, Chunks: 1, Hash: []byte{1},
+			}))
+		}
+		close(received)
+	}()
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Fatal("receiving snapshots blocked")
+	}
+
+	// The reactor lock isn't held while a snapshot is verified.
+	locked := make(chan struct{})
+	go func() {
+		r.mtx.Lock()
+		r.mtx.Unlock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("reactor lock held during snapshot verification")
+	}
+
+	close(release)
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&verified) >= snapshotQueueSize
+	}, time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	assert.LessOrEqual(t, int(atomic.LoadInt32(&verified)), snapshotQueueSize+1)
+}
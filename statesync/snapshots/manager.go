@@ -0,0 +1,296 @@
+// Package snapshots provides Tendermint-managed snapshotting and restoring of
+// ABCI applications, for state sync.
+//
+// An application implements Snapshotter to write and restore its state, and
+// delegates the four snapshot ABCI methods to a Manager, calling
+// Manager.Commit after each Commit. The Manager takes snapshots at the
+// configured interval, splits them into chunks stored on disk by a Store,
+// prunes old snapshots, serves them to state syncing peers, and verifies and
+// streams the chunks of offered snapshots back into the Snapshotter.
+package snapshots
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/log"
+	tmsync "github.com/tendermint/tendermint/libs/sync"
+)
+
+const (
+	// DefaultChunkSize is the default maximum size of snapshot chunks, which must
+	// fit in state sync p2p messages.
+	DefaultChunkSize = 10e6
+	// DefaultKeepRecent is the default number of snapshot heights to keep.
+	DefaultKeepRecent = 2
+)
+
+// Snapshotter is implemented by applications to write and restore their state.
+type Snapshotter interface {
+	// SnapshotFormat returns the format of the snapshots written by Snapshot.
+	// Snapshots in other formats are rejected when offered.
+	SnapshotFormat() uint32
+
+	// Snapshot writes the application state at the given height, which is the
+	// last committed height, to the writer.
+	Snapshot(height uint64, w io.Writer) error
+
+	// Restore replaces the application state with the snapshot read from the
+	// reader, discarding any earlier partial restore. The state must then be
+	// at the given height.
+	Restore(height uint64, format uint32, r io.Reader) error
+}
+
+// Options configures a Manager.
+type Options struct {
+	// Interval is the height interval at which to take snapshots. 0 disables
+	// taking snapshots, but still allows restoring them.
+	Interval uint64
+	// KeepRecent is the number of recent snapshot heights to keep. 0 keeps all
+	// of them.
+	KeepRecent uint32
+	// ChunkSize is the maximum size of snapshot chunks. Defaults to
+	// DefaultChunkSize.
+	ChunkSize int
+}
+
+// DefaultOptions returns the default options taking snapshots at the given interval.
+func DefaultOptions(interval uint64) Options {
+	return Options{
+		Interval:   interval,
+		KeepRecent: DefaultKeepRecent,
+		ChunkSize:  DefaultChunkSize,
+	}
+}
+
+// Manager takes snapshots of a Snapshotter and answers the snapshot ABCI
+// methods on its behalf. Its ABCI methods have the same signatures as the
+// abci.Application ones, so that applications can delegate to them.
+type Manager struct {
+	store  *Store
+	target Snapshotter
+	opts   Options
+	logger log.Logger
+
+	mtx     tmsync.Mutex
+	restore *restore
+}
+
+// NewManager creates a snapshot manager for the target, storing its snapshots
+// in the store.
+func NewManager(store *Store, target Snapshotter, opts Options) *Manager {
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = DefaultChunkSize
+	}
+	return &Manager{
+		store:  store,
+		target: target,
+		opts:   opts,
+		logger: log.NewNopLogger(),
+	}
+}
+
+// SetLogger sets the logger of the manager.
+func (m *Manager) SetLogger(l log.Logger) {
+	m.logger = l
+}
+
+// Commit must be called after the application has committed the given height.
+// It takes a snapshot if the height is at the snapshot interval, and prunes old
+// snapshots. The snapshot is taken synchronously, so that the application state
+// doesn't change while it is written.
+func (m *Manager) Commit(height uint64) error {
+	if m.opts.Interval == 0 || height%m.opts.Interval != 0 {
+		return nil
+	}
+	snapshot, err := m.Create(height)
+	if err != nil {
+		return err
+	}
+	m.logger.Info("Created state sync snapshot", "height", snapshot.Height, "format", snapshot.Format,
+		"chunks", snapshot.Chunks, "hash", fmt.Sprintf("%X", snapshot.Hash))
+
+	if m.opts.KeepRecent > 0 {
+		pruned, err := m.store.Prune(m.opts.KeepRecent)
+		if err != nil {
+			return err
+		}
+		if pruned > 0 {
+			m.logger.Debug("Pruned state sync snapshots", "heights", pruned)
+		}
+	}
+	return nil
+}
+
+// Create takes a snapshot of the target at the given height.
+func (m *Manager) Create(height uint64) (*abci.Snapshot, error) {
+	return m.store.Create(height, m.target.SnapshotFormat(), m.opts.ChunkSize, func(w io.Writer) error {
+		return m.target.Snapshot(height, w)
+	})
+}
+
+// ListSnapshots lists the stored snapshots, from the most recent one.
+func (m *Manager) ListSnapshots(req abci.RequestListSnapshots) abci.ResponseListSnapshots {
+	snapshots, err := m.store.List()
+	if err != nil {
+		m.logger.Error("Failed to list snapshots", "err", err)
+		return abci.ResponseListSnapshots{}
+	}
+	return abci.ResponseListSnapshots{Snapshots: snapshots}
+}
+
+// LoadSnapshotChunk loads a stored snapshot chunk, or returns an empty chunk
+// if it doesn't exist.
+func (m *Manager) LoadSnapshotChunk(req abci.RequestLoadSnapshotChunk) abci.ResponseLoadSnapshotChunk {
+	chunk, err := m.store.LoadChunk(req.Height, req.Format, req.Chunk)
+	if err != nil {
+		m.logger.Error("Failed to load snapshot chunk", "height", req.Height, "format", req.Format,
+			"chunk", req.Chunk, "err", err)
+		return abci.ResponseLoadSnapshotChunk{}
+	}
+	return abci.ResponseLoadSnapshotChunk{Chunk: chunk}
+}
+
+// OfferSnapshot starts restoring the offered snapshot if it's valid and in the
+// target's format, abandoning any restore in progress.
+func (m *Manager) OfferSnapshot(req abci.RequestOfferSnapshot) abci.ResponseOfferSnapshot {
+	snapshot := req.Snapshot
+	switch {
+	case snapshot == nil:
+		return abci.ResponseOfferSnapshot{Result: abci.ResponseOfferSnapshot_REJECT}
+	case snapshot.Format != m.target.SnapshotFormat():
+		return abci.ResponseOfferSnapshot{Result: abci.ResponseOfferSnapshot_REJECT_FORMAT}
+	case snapshot.Height == 0, snapshot.Chunks == 0, len(snapshot.Hash) != sha256.Size,
+		len(snapshot.Metadata) != int(snapshot.Chunks)*sha256.Size:
+		m.logger.Info("Rejecting invalid snapshot", "height", snapshot.Height, "format", snapshot.Format)
+		return abci.ResponseOfferSnapshot{Result: abci.ResponseOfferSnapshot_REJECT}
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if m.restore != nil {
+		m.restore.abort()
+	}
+	m.restore = newRestore(m.target, snapshot)
+	return abci.ResponseOfferSnapshot{Result: abci.ResponseOfferSnapshot_ACCEPT}
+}
+
+// ApplySnapshotChunk verifies a chunk of the snapshot being restored against
+// its hash, and feeds it to the target. Invalid chunks are refetched from
+// other peers. The snapshot is rejected if the target fails to restore it.
+func (m *Manager) ApplySnapshotChunk(req abci.RequestApplySnapshotChunk) abci.ResponseApplySnapshotChunk {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	r := m.restore
+	if r == nil {
+		m.logger.Error("Received snapshot chunk without a snapshot being restored", "index", req.Index)
+		return abci.ResponseApplySnapshotChunk{Result: abci.ResponseApplySnapshotChunk_ABORT}
+	}
+	if req.Index != r.next {
+		m.logger.Error("Received snapshot chunk out of order", "index", req.Index, "expected", r.next)
+		return abci.ResponseApplySnapshotChunk{Result: abci.ResponseApplySnapshotChunk_ABORT}
+	}
+
+	if !r.verifyChunk(req.Index, req.Chunk) {
+		m.logger.Info("Invalid snapshot chunk, refetching it", "index", req.Index, "sender", req.Sender)
+		resp := abci.ResponseApplySnapshotChunk{
+			Result:        abci.ResponseApplySnapshotChunk_RETRY,
+			RefetchChunks: []uint32{req.Index},
+		}
+		if req.Sender != "" {
+			resp.RejectSenders = []string{req.Sender}
+		}
+		return resp
+	}
+
+	if err := r.apply(req.Chunk); err != nil {
+		m.logger.Error("Failed to restore snapshot", "height", r.snapshot.Height,
+			"format", r.snapshot.Format, "err", err)
+		m.restore = nil
+		return abci.ResponseApplySnapshotChunk{Result: abci.ResponseApplySnapshotChunk_REJECT_SNAPSHOT}
+	}
+	if r.next == r.snapshot.Chunks {
+		m.logger.Info("Restored snapshot", "height", r.snapshot.Height, "format", r.snapshot.Format)
+		m.restore = nil
+	}
+	return abci.ResponseApplySnapshotChunk{Result: abci.ResponseApplySnapshotChunk_ACCEPT}
+}
+
+// restore streams the chunks of a snapshot into the Snapshotter, which
+// restores it in a separate goroutine.
+type restore struct {
+	snapshot *abci.Snapshot
+	next     uint32
+	hasher   hash.Hash
+	writer   *io.PipeWriter
+	done     chan error
+}
+
+// errRestoreAborted is passed to the restoring Snapshotter when a restore is abandoned.
+var errRestoreAborted = errors.New("snapshot restore aborted")
+
+func newRestore(target Snapshotter, snapshot *abci.Snapshot) *restore {
+	reader, writer := io.Pipe()
+	r := &restore{
+		snapshot: snapshot,
+		hasher:   sha256.New(),
+		writer:   writer,
+		done:     make(chan error, 1),
+	}
+	go func() {
+		err := target.Restore(snapshot.Height, snapshot.Format, reader)
+		if err == nil {
+			// fail any further writes, if the target returned before the last chunk
+			reader.CloseWithError(errors.New("snapshot restored before its last chunk"))
+		} else {
+			reader.CloseWithError(err)
+		}
+		r.done <- err
+	}()
+	return r
+}
+
+// verifyChunk verifies a chunk against its hash in the snapshot metadata.
+func (r *restore) verifyChunk(index uint32, chunk []byte) bool {
+	chunkHash := sha256.Sum256(chunk)
+	expected := r.snapshot.Metadata[int(index)*sha256.Size : int(index+1)*sha256.Size]
+	return bytes.Equal(chunkHash[:], expected)
+}
+
+// apply writes the next chunk to the Snapshotter. After the last chunk, it
+// waits for the restore to complete and verifies the snapshot hash.
+func (r *restore) apply(chunk []byte) error {
+	if _, err := r.writer.Write(chunk); err != nil {
+		// the write only fails once the Snapshotter has returned
+		if restoreErr := <-r.done; restoreErr != nil {
+			return restoreErr
+		}
+		return err
+	}
+	r.hasher.Write(chunk) //nolint:errcheck // never fails
+	r.next++
+	if r.next < r.snapshot.Chunks {
+		return nil
+	}
+
+	r.writer.Close()
+	if err := <-r.done; err != nil {
+		return err
+	}
+	if !bytes.Equal(r.hasher.Sum(nil), r.snapshot.Hash) {
+		return fmt.Errorf("snapshot hash %X does not match the expected hash %X",
+			r.hasher.Sum(nil), r.snapshot.Hash)
+	}
+	return nil
+}
+
+// abort abandons the restore, failing the Snapshotter's reads.
+func (r *restore) abort() {
+	r.writer.CloseWithError(errRestoreAborted)
+	<-r.done
+}
@@ -0,0 +1,130 @@
+package snapshots
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+// memSnapshotter is a Snapshotter with its state in memory.
+type memSnapshotter struct {
+	height     uint64
+	state      []byte
+	restoreErr error
+}
+
+func (s *memSnapshotter) SnapshotFormat() uint32 { return 1 }
+
+func (s *memSnapshotter) Snapshot(height uint64, w io.Writer) error {
+	if height != s.height {
+		return fmt.Errorf("unexpected height %v", height)
+	}
+	_, err := w.Write(s.state)
+	return err
+}
+
+func (s *memSnapshotter) Restore(height uint64, format uint32, r io.Reader) error {
+	state, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if s.restoreErr != nil {
+		return s.restoreErr
+	}
+	s.height, s.state = height, state
+	return nil
+}
+
+func TestManager_Commit(t *testing.T) {
+	target := &memSnapshotter{}
+	manager := NewManager(setupStore(t), target, Options{Interval: 2, KeepRecent: 2, ChunkSize: 4})
+
+	for h := uint64(1); h <= 7; h++ {
+		target.height, target.state = h, []byte(fmt.Sprintf("state at height %v", h))
+		require.NoError(t, manager.Commit(h))
+	}
+
+	snapshots := manager.ListSnapshots(abci.RequestListSnapshots{}).Snapshots
+	require.Len(t, snapshots, 2)
+	assert.EqualValues(t, 6, snapshots[0].Height)
+	assert.EqualValues(t, 4, snapshots[1].Height)
+
+	chunks := [][]byte{}
+	for i := uint32(0); i < snapshots[0].Chunks; i++ {
+		resp := manager.LoadSnapshotChunk(abci.RequestLoadSnapshotChunk{Height: 6, Format: 1, Chunk: i})
+		chunks = append(chunks, resp.Chunk)
+	}
+	assert.Equal(t, []byte("state at height 6"), bytes.Join(chunks, nil))
+
+	resp := manager.LoadSnapshotChunk(abci.RequestLoadSnapshotChunk{Height: 2, Format: 1, Chunk: 0})
+	assert.Nil(t, resp.Chunk)
+}
+
+func TestManager_Restore(t *testing.T) {
+	source := &memSnapshotter{height: 5, state: []byte("the state to restore")}
+	sourceManager := NewManager(setupStore(t), source, Options{ChunkSize: 8})
+	snapshot, err := sourceManager.Create(5)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, snapshot.Chunks)
+	chunks := [][]byte{}
+	for i := uint32(0); i < snapshot.Chunks; i++ {
+		chunk, err := sourceManager.store.LoadChunk(5, 1, i)
+		require.NoError(t, err)
+		chunks = append(chunks, chunk)
+	}
+
+	target := &memSnapshotter{}
+	manager := NewManager(setupStore(t), target, Options{})
+
+	offer := func(snapshot *abci.Snapshot) abci.ResponseOfferSnapshot_Result {
+		return manager.OfferSnapshot(abci.RequestOfferSnapshot{Snapshot: snapshot}).Result
+	}
+	apply := func(index uint32, chunk []byte) abci.ResponseApplySnapshotChunk {
+		return manager.ApplySnapshotChunk(abci.RequestApplySnapshotChunk{Index: index, Chunk: chunk, Sender: "peer"})
+	}
+
+	// invalid snapshots are rejected
+	other := *snapshot
+	other.Format = 2
+	assert.Equal(t, abci.ResponseOfferSnapshot_REJECT_FORMAT, offer(&other))
+	other = *snapshot
+	other.Metadata = other.Metadata[1:]
+	assert.Equal(t, abci.ResponseOfferSnapshot_REJECT, offer(&other))
+	assert.Equal(t, abci.ResponseOfferSnapshot_REJECT, offer(nil))
+
+	// chunks can't be applied without a snapshot
+	assert.Equal(t, abci.ResponseApplySnapshotChunk_ABORT, apply(0, chunks[0]).Result)
+
+	// an abandoned restore is replaced
+	require.Equal(t, abci.ResponseOfferSnapshot_ACCEPT, offer(snapshot))
+	require.Equal(t, abci.ResponseApplySnapshotChunk_ACCEPT, apply(0, chunks[0]).Result)
+	require.Equal(t, abci.ResponseOfferSnapshot_ACCEPT, offer(snapshot))
+
+	// invalid chunks are refetched from other senders
+	require.Equal(t, abci.ResponseApplySnapshotChunk_ACCEPT, apply(0, chunks[0]).Result)
+	resp := apply(1, []byte("invalid"))
+	assert.Equal(t, abci.ResponseApplySnapshotChunk_RETRY, resp.Result)
+	assert.Equal(t, []uint32{1}, resp.RefetchChunks)
+	assert.Equal(t, []string{"peer"}, resp.RejectSenders)
+
+	require.Equal(t, abci.ResponseApplySnapshotChunk_ACCEPT, apply(1, chunks[1]).Result)
+	require.Equal(t, abci.ResponseApplySnapshotChunk_ACCEPT, apply(2, chunks[2]).Result)
+	assert.EqualValues(t, 5, target.height)
+	assert.Equal(t, source.state, target.state)
+
+	// the snapshot is rejected if the target fails to restore it
+	target.restoreErr = errors.New("failed")
+	require.Equal(t, abci.ResponseOfferSnapshot_ACCEPT, offer(snapshot))
+	require.Equal(t, abci.ResponseApplySnapshotChunk_ACCEPT, apply(0, chunks[0]).Result)
+	require.Equal(t, abci.ResponseApplySnapshotChunk_ACCEPT, apply(1, chunks[1]).Result)
+	assert.Equal(t, abci.ResponseApplySnapshotChunk_REJECT_SNAPSHOT, apply(2, chunks[2]).Result)
+	assert.Equal(t, abci.ResponseApplySnapshotChunk_ABORT, apply(0, chunks[0]).Result)
+}
@@ -0,0 +1,315 @@
+package snapshots
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/gogo/protobuf/proto"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	tmsync "github.com/tendermint/tendermint/libs/sync"
+)
+
+const (
+	// metadataFile is the name of the file holding the snapshot metadata, next
+	// to the chunk files named by their index.
+	metadataFile = "metadata"
+	// tmpDir is where snapshots are written before being moved in place.
+	tmpDir = "tmp"
+)
+
+// Store stores snapshots on disk, in <dir>/<height>/<format>/, with one file per
+// chunk named by its index and a metadata file holding the abci.Snapshot.
+//
+// The snapshot hash is the SHA-256 hash of the snapshot contents, and the
+// snapshot metadata is the concatenation of the SHA-256 hashes of its chunks,
+// which allows verifying each chunk as it is restored.
+type Store struct {
+	dir string
+	mtx tmsync.Mutex
+}
+
+// NewStore creates a snapshot store in the given directory.
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory %q: %w", dir, err)
+	}
+	// remove any snapshot left behind by an interrupted Create
+	if err := os.RemoveAll(filepath.Join(dir, tmpDir)); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Create creates a snapshot at the given height and format, splitting the
+// contents written by the write function into chunks of at most chunkSize bytes.
+// The snapshot only becomes visible once it has been completely written.
+func (s *Store) Create(height uint64, format uint32, chunkSize int,
+	write func(io.Writer) error) (*abci.Snapshot, error) {
+	if height == 0 {
+		return nil, errors.New("snapshot height cannot be 0")
+	}
+	if chunkSize <= 0 {
+		return nil, errors.New("chunk size must be positive")
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	dir := s.pathSnapshot(height, format)
+	if _, err := os.Stat(dir); err == nil {
+		return nil, fmt.Errorf("snapshot already exists at height %v, format %v", height, format)
+	}
+	tmp := filepath.Join(s.dir, tmpDir, fmt.Sprintf("%v-%v", height, format))
+	if err := os.RemoveAll(tmp); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(tmp, 0755); err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	w := &chunkWriter{dir: tmp, chunkSize: chunkSize, hasher: sha256.New()}
+	if err := write(w); err != nil {
+		w.close() //nolint:errcheck // the write error is more relevant
+		return nil, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := w.close(); err != nil {
+		return nil, err
+	}
+	snapshot := &abci.Snapshot{
+		Height:   height,
+		Format:   format,
+		Chunks:   w.chunks,
+		Hash:     w.hasher.Sum(nil),
+		Metadata: w.chunkHashes,
+	}
+	bz, err := proto.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmp, metadataFile), bz, 0644); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// Load loads the snapshot at the given height and format, or nil if it doesn't exist.
+func (s *Store) Load(height uint64, format uint32) (*abci.Snapshot, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.load(height, format)
+}
+
+func (s *Store) load(height uint64, format uint32) (*abci.Snapshot, error) {
+	bz, err := ioutil.ReadFile(filepath.Join(s.pathSnapshot(height, format), metadataFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	snapshot := &abci.Snapshot{}
+	if err := proto.Unmarshal(bz, snapshot); err != nil {
+		return nil, fmt.Errorf("invalid snapshot metadata at height %v, format %v: %w", height, format, err)
+	}
+	return snapshot, nil
+}
+
+// LoadChunk loads a snapshot chunk, or nil if it doesn't exist.
+func (s *Store) LoadChunk(height uint64, format uint32, chunk uint32) ([]byte, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	bz, err := ioutil.ReadFile(s.pathChunk(height, format, chunk))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return bz, err
+}
+
+// List lists the snapshots in the store, from the most recent one, i.e. by
+// descending height and format.
+func (s *Store) List() ([]*abci.Snapshot, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	snapshots := []*abci.Snapshot{}
+	heights, err := s.listHeights()
+	if err != nil {
+		return nil, err
+	}
+	for _, height := range heights {
+		entries, err := ioutil.ReadDir(s.pathHeight(height))
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			format, err := strconv.ParseUint(entry.Name(), 10, 32)
+			if err != nil || !entry.IsDir() {
+				continue
+			}
+			snapshot, err := s.load(height, uint32(format))
+			if err != nil {
+				return nil, err
+			}
+			if snapshot != nil {
+				snapshots = append(snapshots, snapshot)
+			}
+		}
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		if snapshots[i].Height != snapshots[j].Height {
+			return snapshots[i].Height > snapshots[j].Height
+		}
+		return snapshots[i].Format > snapshots[j].Format
+	})
+	return snapshots, nil
+}
+
+// Delete deletes the snapshot at the given height and format, if it exists.
+func (s *Store) Delete(height uint64, format uint32) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return os.RemoveAll(s.pathSnapshot(height, format))
+}
+
+// Prune deletes the snapshots of all but the keepRecent most recent heights,
+// returning the number of heights pruned.
+func (s *Store) Prune(keepRecent uint32) (int, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	heights, err := s.listHeights()
+	if err != nil {
+		return 0, err
+	}
+	if len(heights) <= int(keepRecent) {
+		return 0, nil
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] > heights[j] })
+	pruned := 0
+	for _, height := range heights[keepRecent:] {
+		if err := os.RemoveAll(s.pathHeight(height)); err != nil {
+			return pruned, fmt.Errorf("failed to prune snapshots at height %v: %w", height, err)
+		}
+		pruned++
+	}
+	return pruned, nil
+}
+
+// listHeights lists the heights with snapshot directories.
+func (s *Store) listHeights() ([]uint64, error) {
+	entries, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	heights := []uint64{}
+	for _, entry := range entries {
+		height, err := strconv.ParseUint(entry.Name(), 10, 64)
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		heights = append(heights, height)
+	}
+	return heights, nil
+}
+
+func (s *Store) pathHeight(height uint64) string {
+	return filepath.Join(s.dir, strconv.FormatUint(height, 10))
+}
+
+func (s *Store) pathSnapshot(height uint64, format uint32) string {
+	return filepath.Join(s.pathHeight(height), strconv.FormatUint(uint64(format), 10))
+}
+
+func (s *Store) pathChunk(height uint64, format uint32, chunk uint32) string {
+	return filepath.Join(s.pathSnapshot(height, format), strconv.FormatUint(uint64(chunk), 10))
+}
+
+// chunkWriter splits the snapshot contents into chunk files, hashing the
+// contents and each chunk.
+type chunkWriter struct {
+	dir         string
+	chunkSize   int
+	hasher      hash.Hash
+	file        *os.File
+	written     int
+	chunks      uint32
+	chunkHasher hash.Hash
+	chunkHashes []byte
+}
+
+// Write implements io.Writer.
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		if w.file == nil || w.written >= w.chunkSize {
+			if err := w.nextChunk(); err != nil {
+				return n, err
+			}
+		}
+		size := w.chunkSize - w.written
+		if size > len(p) {
+			size = len(p)
+		}
+		written, err := w.file.Write(p[:size])
+		n += written
+		w.written += written
+		w.hasher.Write(p[:written])      //nolint:errcheck // never fails
+		w.chunkHasher.Write(p[:written]) //nolint:errcheck // never fails
+		if err != nil {
+			return n, err
+		}
+		p = p[size:]
+	}
+	return n, nil
+}
+
+// nextChunk closes the current chunk file, if any, and opens the next one.
+func (w *chunkWriter) nextChunk() error {
+	if err := w.closeChunk(); err != nil {
+		return err
+	}
+	file, err := os.Create(filepath.Join(w.dir, strconv.FormatUint(uint64(w.chunks), 10)))
+	if err != nil {
+		return err
+	}
+	w.file = file
+	w.written = 0
+	w.chunkHasher = sha256.New()
+	w.chunks++
+	return nil
+}
+
+func (w *chunkWriter) closeChunk() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	w.chunkHashes = append(w.chunkHashes, w.chunkHasher.Sum(nil)...)
+	return err
+}
+
+// close closes the last chunk. A snapshot always has at least one chunk, even if empty.
+func (w *chunkWriter) close() error {
+	if w.chunks == 0 {
+		if err := w.nextChunk(); err != nil {
+			return err
+		}
+	}
+	return w.closeChunk()
+}
@@ -0,0 +1,122 @@
+package snapshots
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupStore(t *testing.T) *Store {
+	dir, err := ioutil.TempDir("", "snapshots")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	store, err := NewStore(dir)
+	require.NoError(t, err)
+	return store
+}
+
+func writeBytes(bz []byte) func(io.Writer) error {
+	return func(w io.Writer) error {
+		_, err := w.Write(bz)
+		return err
+	}
+}
+
+func TestStore_Create(t *testing.T) {
+	store := setupStore(t)
+	contents := []byte("0123456789abcdefghij")
+
+	snapshot, err := store.Create(3, 1, 8, writeBytes(contents))
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, snapshot.Height)
+	assert.EqualValues(t, 1, snapshot.Format)
+	assert.EqualValues(t, 3, snapshot.Chunks)
+	hash := sha256.Sum256(contents)
+	assert.Equal(t, hash[:], snapshot.Hash)
+
+	// the chunks are stored, with their hashes in the metadata
+	chunks := [][]byte{contents[:8], contents[8:16], contents[16:]}
+	for i, expected := range chunks {
+		chunk, err := store.LoadChunk(3, 1, uint32(i))
+		require.NoError(t, err)
+		assert.Equal(t, expected, chunk)
+		chunkHash := sha256.Sum256(expected)
+		assert.Equal(t, chunkHash[:], snapshot.Metadata[i*sha256.Size:(i+1)*sha256.Size])
+	}
+	chunk, err := store.LoadChunk(3, 1, 3)
+	require.NoError(t, err)
+	assert.Nil(t, chunk)
+
+	loaded, err := store.Load(3, 1)
+	require.NoError(t, err)
+	assert.Equal(t, snapshot, loaded)
+
+	// snapshots can't be overwritten
+	_, err = store.Create(3, 1, 8, writeBytes(contents))
+	require.Error(t, err)
+
+	// empty snapshots have an empty chunk
+	snapshot, err = store.Create(4, 1, 8, writeBytes(nil))
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, snapshot.Chunks)
+
+	// failed snapshots aren't stored
+	_, err = store.Create(5, 1, 8, func(w io.Writer) error {
+		_, err := w.Write(contents)
+		require.NoError(t, err)
+		return errors.New("failed")
+	})
+	require.Error(t, err)
+	loaded, err = store.Load(5, 1)
+	require.NoError(t, err)
+	assert.Nil(t, loaded)
+	entries, err := ioutil.ReadDir(filepath.Join(store.dir, tmpDir))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestStore_ListPruneDelete(t *testing.T) {
+	store := setupStore(t)
+	for _, hf := range [][2]int{{1, 1}, {3, 1}, {2, 1}, {3, 2}} {
+		_, err := store.Create(uint64(hf[0]), uint32(hf[1]), 8, writeBytes([]byte{byte(hf[0])}))
+		require.NoError(t, err)
+	}
+	listHeightFormats := func() [][2]int {
+		snapshots, err := store.List()
+		require.NoError(t, err)
+		hfs := [][2]int{}
+		for _, s := range snapshots {
+			hfs = append(hfs, [2]int{int(s.Height), int(s.Format)})
+		}
+		return hfs
+	}
+	assert.Equal(t, [][2]int{{3, 2}, {3, 1}, {2, 1}, {1, 1}}, listHeightFormats())
+
+	pruned, err := store.Prune(2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pruned)
+	assert.Equal(t, [][2]int{{3, 2}, {3, 1}, {2, 1}}, listHeightFormats())
+
+	pruned, err = store.Prune(2)
+	require.NoError(t, err)
+	assert.Equal(t, 0, pruned)
+
+	require.NoError(t, store.Delete(3, 2))
+	assert.Equal(t, [][2]int{{3, 1}, {2, 1}}, listHeightFormats())
+
+	// the store can be reopened
+	store, err = NewStore(store.dir)
+	require.NoError(t, err)
+	assert.Equal(t, [][2]int{{3, 1}, {2, 1}}, listHeightFormats())
+	chunk, err := store.LoadChunk(3, 1, 0)
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal([]byte{3}, chunk))
+}
@@ -1,6 +1,7 @@
 package statesync
 
 import (
+	"bytes"
 	"fmt"
 	"strings"
 	"time"
@@ -14,12 +15,16 @@ import (
 	lighthttp "github.com/tendermint/tendermint/light/provider/http"
 	lightrpc "github.com/tendermint/tendermint/light/rpc"
 	lightdb "github.com/tendermint/tendermint/light/store/db"
+	"github.com/tendermint/tendermint/p2p"
 	tmstate "github.com/tendermint/tendermint/proto/tendermint/state"
 	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
 	sm "github.com/tendermint/tendermint/state"
 	"github.com/tendermint/tendermint/types"
 )
 
+// peerWaitTimeout is how long the p2p state provider waits for enough peers serving light blocks.
+const peerWaitTimeout = time.Minute
+
 //go:generate mockery --case underscore --name StateProvider
 
 // StateProvider is a provider of trusted state data for bootstrapping a node. This refers
@@ -69,8 +74,12 @@ func NewLightClientStateProvider(
 		providerRemotes[provider] = server
 	}
 
+	lightStore, err := lightdb.New(dbm.NewMemDB(), "")
+	if err != nil {
+		return nil, err
+	}
 	lc, err := light.NewClient(chainID, trustOptions, providers[0], providers[1:],
-		lightdb.New(dbm.NewMemDB(), ""), light.Logger(logger), light.MaxRetryAttempts(5))
+		lightStore, light.Logger(logger), light.MaxRetryAttempts(5))
 	if err != nil {
 		return nil, err
 	}
@@ -111,10 +120,40 @@ func (s *lightClientStateProvider) State(height uint64) (sm.State, error) {
 	s.Lock()
 	defer s.Unlock()
 
+	state, nextLightBlock, err := lightClientState(s.lc, s.version, s.initialHeight, height)
+	if err != nil {
+		return sm.State{}, err
+	}
+
+	// We'll also need to fetch consensus params via RPC, using light client verification.
+	primaryURL, ok := s.providers[s.lc.Primary()]
+	if !ok || primaryURL == "" {
+		return sm.State{}, fmt.Errorf("could not find address for primary light client provider")
+	}
+	primaryRPC, err := rpcClient(primaryURL)
+	if err != nil {
+		return sm.State{}, fmt.Errorf("unable to create RPC client: %w", err)
+	}
+	rpcclient := lightrpc.NewClient(primaryRPC, s.lc)
+	result, err := rpcclient.ConsensusParams(&nextLightBlock.Height)
+	if err != nil {
+		return sm.State{}, fmt.Errorf("unable to fetch consensus parameters for height %v: %w",
+			nextLightBlock.Height, err)
+	}
+	state.ConsensusParams = result.ConsensusParams
+
+	return state, nil
+}
+
+// lightClientState builds a state object at the given height using light client verification,
+// except for the consensus params. It also returns the light block at height+2, whose consensus
+// hash the params must match.
+func lightClientState(lc *light.Client, version tmstate.Version, initialHeight int64,
+	height uint64) (sm.State, *types.LightBlock, error) {
 	state := sm.State{
-		ChainID:       s.lc.ChainID(),
-		Version:       s.version,
-		InitialHeight: s.initialHeight,
+		ChainID:       lc.ChainID(),
+		Version:       version,
+		InitialHeight: initialHeight,
 	}
 	if state.InitialHeight == 0 {
 		state.InitialHeight = 1
@@ -128,17 +167,17 @@ func (s *lightClientStateProvider) State(height uint64) (sm.State, error) {
 	//
 	// We need to fetch the NextValidators from height+2 because if the application changed
 	// the validator set at the snapshot height then this only takes effect at height+2.
-	lastLightBlock, err := s.lc.VerifyLightBlockAtHeight(int64(height), time.Now())
+	lastLightBlock, err := lc.VerifyLightBlockAtHeight(int64(height), time.Now())
 	if err != nil {
-		return sm.State{}, err
+		return sm.State{}, nil, err
 	}
-	curLightBlock, err := s.lc.VerifyLightBlockAtHeight(int64(height+1), time.Now())
+	curLightBlock, err := lc.VerifyLightBlockAtHeight(int64(height+1), time.Now())
 	if err != nil {
-		return sm.State{}, err
+		return sm.State{}, nil, err
 	}
-	nextLightBlock, err := s.lc.VerifyLightBlockAtHeight(int64(height+2), time.Now())
+	nextLightBlock, err := lc.VerifyLightBlockAtHeight(int64(height+2), time.Now())
 	if err != nil {
-		return sm.State{}, err
+		return sm.State{}, nil, err
 	}
 
 	state.LastBlockHeight = lastLightBlock.Height
@@ -151,24 +190,150 @@ func (s *lightClientStateProvider) State(height uint64) (sm.State, error) {
 	state.NextValidators = nextLightBlock.ValidatorSet
 	state.LastHeightValidatorsChanged = nextLightBlock.Height
 
-	// We'll also need to fetch consensus params via RPC, using light client verification.
-	primaryURL, ok := s.providers[s.lc.Primary()]
-	if !ok || primaryURL == "" {
-		return sm.State{}, fmt.Errorf("could not find address for primary light client provider")
+	return state, nextLightBlock, nil
+}
+
+// p2pStateProvider is a state provider using the light client with connected peers as light
+// block providers, so that no RPC servers are needed.
+type p2pStateProvider struct {
+	tmsync.Mutex  // light.Client is not concurrency-safe
+	lc            *light.Client
+	chainID       string
+	version       tmstate.Version
+	initialHeight int64
+	trustOptions  light.TrustOptions
+	logger        log.Logger
+	peers         func() []p2p.Peer
+	dispatcher    *dispatcher
+}
+
+// NewP2PStateProvider creates a new StateProvider using a light client, fetching light blocks
+// and consensus params from the reactor's peers. The light client is set up once at least 2
+// peers serving light blocks are connected.
+func (r *Reactor) NewP2PStateProvider(
+	chainID string,
+	version tmstate.Version,
+	initialHeight int64,
+	trustOptions light.TrustOptions,
+	logger log.Logger,
+) (StateProvider, error) {
+	if err := trustOptions.ValidateBasic(); err != nil {
+		return nil, fmt.Errorf("invalid TrustOptions: %w", err)
 	}
-	primaryRPC, err := rpcClient(primaryURL)
+	return &p2pStateProvider{
+		chainID:       chainID,
+		version:       version,
+		initialHeight: initialHeight,
+		trustOptions:  trustOptions,
+		logger:        logger,
+		peers:         r.lightBlockPeers,
+		dispatcher:    r.dispatcher,
+	}, nil
+}
+
+// client returns the light client, setting it up with the connected peers on first use.
+// CONTRACT: s must be locked.
+func (s *p2pStateProvider) client() (*light.Client, error) {
+	if s.lc != nil {
+		return s.lc, nil
+	}
+
+	peers := s.peers()
+	for deadline := time.Now().Add(peerWaitTimeout); len(peers) < 2; peers = s.peers() {
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("at least 2 peers serving light blocks are required, got %v", len(peers))
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	providers := make([]lightprovider.Provider, 0, len(peers))
+	for _, peer := range peers {
+		providers = append(providers, &blockProvider{chainID: s.chainID, peer: peer, dispatcher: s.dispatcher})
+	}
+	lightStore, err := lightdb.New(dbm.NewMemDB(), "")
 	if err != nil {
-		return sm.State{}, fmt.Errorf("unable to create RPC client: %w", err)
+		return nil, err
 	}
-	rpcclient := lightrpc.NewClient(primaryRPC, s.lc)
-	result, err := rpcclient.ConsensusParams(&nextLightBlock.Height)
+	lc, err := light.NewClient(s.chainID, s.trustOptions, providers[0], providers[1:],
+		lightStore, light.Logger(s.logger), light.MaxRetryAttempts(5))
 	if err != nil {
-		return sm.State{}, fmt.Errorf("unable to fetch consensus parameters for height %v: %w",
-			nextLightBlock.Height, err)
+		return nil, err
 	}
-	state.ConsensusParams = result.ConsensusParams
+	s.lc = lc
+	return lc, nil
+}
 
-	return state, nil
+// AppHash implements StateProvider.
+func (s *p2pStateProvider) AppHash(height uint64) ([]byte, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	lc, err := s.client()
+	if err != nil {
+		return nil, err
+	}
+	// We have to fetch the next height, which contains the app hash for the previous height.
+	header, err := lc.VerifyLightBlockAtHeight(int64(height+1), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return header.AppHash, nil
+}
+
+// Commit implements StateProvider.
+func (s *p2pStateProvider) Commit(height uint64) (*types.Commit, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	lc, err := s.client()
+	if err != nil {
+		return nil, err
+	}
+	header, err := lc.VerifyLightBlockAtHeight(int64(height), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return header.Commit, nil
+}
+
+// State implements StateProvider.
+func (s *p2pStateProvider) State(height uint64) (sm.State, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	lc, err := s.client()
+	if err != nil {
+		return sm.State{}, err
+	}
+	state, nextLightBlock, err := lightClientState(lc, s.version, s.initialHeight, height)
+	if err != nil {
+		return sm.State{}, err
+	}
+
+	// Fetch the consensus params from the providers, starting with the primary, and verify
+	// them against the consensus hash of the light block.
+	providers := append([]lightprovider.Provider{lc.Primary()}, lc.Witnesses()...)
+	for _, provider := range providers {
+		peer := provider.(*blockProvider).peer
+		params, err := s.dispatcher.consensusParams(peer, uint64(nextLightBlock.Height))
+		if err != nil {
+			s.logger.Info("Failed to fetch consensus params", "height", nextLightBlock.Height,
+				"peer", peer.ID(), "err", err)
+			continue
+		}
+		if params == nil {
+			continue
+		}
+		if hash := types.HashConsensusParams(*params); !bytes.Equal(hash, nextLightBlock.ConsensusHash) {
+			s.logger.Info("Peer sent consensus params not matching the trusted hash",
+				"height", nextLightBlock.Height, "peer", peer.ID(), "hash", hash)
+			continue
+		}
+		state.ConsensusParams = *params
+		return state, nil
+	}
+	return sm.State{}, fmt.Errorf("unable to fetch consensus parameters for height %v from any peer",
+		nextLightBlock.Height)
 }
 
 // rpcClient sets up a new RPC client
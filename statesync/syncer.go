@@ -20,12 +20,14 @@ import (
 const (
 	// defaultDiscoveryTime is the time to spend discovering snapshots.
 	defaultDiscoveryTime = 20 * time.Second
-	// chunkFetchers is the number of concurrent chunk fetchers to run.
-	chunkFetchers = 4
 	// chunkTimeout is the timeout while waiting for the next chunk from the chunk queue.
 	chunkTimeout = 2 * time.Minute
-	// requestTimeout is the timeout before rerequesting a chunk, possibly from a different peer.
+	// chunkRequestTimeout is the minimum timeout before rerequesting a chunk, possibly from a
+	// different peer. It is scaled with the chunk size.
 	chunkRequestTimeout = 10 * time.Second
+	// chunkRetryInterval is how long to wait before requesting a chunk again, when no peer is
+	// available or a peer doesn't have it.
+	chunkRetryInterval = time.Second
 )
 
 var (
@@ -56,22 +58,27 @@ type syncer struct {
 	conn          proxy.AppConnSnapshot
 	connQuery     proxy.AppConnQuery
 	snapshots     *snapshotPool
+	fetcher       *chunkFetcher
 	tempDir       string
+	stopPeer      func(p2p.Peer, interface{})
 
 	mtx    tmsync.RWMutex
 	chunks *chunkQueue
 }
 
-// newSyncer creates a new syncer.
+// newSyncer creates a new syncer. Peers repeatedly sending bad chunks or not responding are
+// stopped with stopPeer, if given.
 func newSyncer(logger log.Logger, conn proxy.AppConnSnapshot, connQuery proxy.AppConnQuery,
-	stateProvider StateProvider, tempDir string) *syncer {
+	stateProvider StateProvider, tempDir string, metrics *Metrics, stopPeer func(p2p.Peer, interface{})) *syncer {
 	return &syncer{
 		logger:        logger,
 		stateProvider: stateProvider,
 		conn:          conn,
 		connQuery:     connQuery,
 		snapshots:     newSnapshotPool(stateProvider),
+		fetcher:       newChunkFetcher(metrics),
 		tempDir:       tempDir,
+		stopPeer:      stopPeer,
 	}
 }
 
@@ -88,6 +95,7 @@ func (s *syncer) AddChunk(chunk *chunk) (bool, error) {
 		return false, err
 	}
 	if added {
+		s.fetcher.received(chunk)
 		s.logger.Debug("Added chunk to queue", "height", chunk.Height, "format", chunk.Format,
 			"chunk", chunk.Index)
 	} else {
@@ -97,6 +105,14 @@ func (s *syncer) AddChunk(chunk *chunk) (bool, error) {
 	return added, nil
 }
 
+// MissingChunk records that a peer doesn't have a chunk it was asked for, so that it is
+// requested from another peer.
+func (s *syncer) MissingChunk(peerID p2p.ID, height uint64, format uint32, index uint32) {
+	s.logger.Debug("Peer does not have snapshot chunk", "height", height, "format", format,
+		"chunk", index, "peer", peerID)
+	s.fetcher.missing(peerID, height, format, index)
+}
+
 // AddSnapshot adds a snapshot to the snapshot pool. It returns true if a new, previously unseen
 // snapshot was accepted and added.
 func (s *syncer) AddSnapshot(peer p2p.Peer, snapshot *snapshot) (bool, error) {
@@ -122,6 +138,16 @@ func (s *syncer) AddPeer(peer p2p.Peer) {
 func (s *syncer) RemovePeer(peer p2p.Peer) {
 	s.logger.Debug("Removing peer from sync", "peer", peer.ID())
 	s.snapshots.RemovePeer(peer.ID())
+	s.fetcher.removePeer(peer.ID())
+}
+
+// banPeer rejects a peer repeatedly sending bad chunks or not responding, and stops it.
+func (s *syncer) banPeer(peer p2p.Peer, reason error) {
+	s.logger.Info("Banning snapshot peer", "peer", peer.ID(), "reason", reason)
+	s.snapshots.RejectPeer(peer.ID())
+	if s.stopPeer != nil {
+		s.stopPeer(peer, reason)
+	}
 }
 
 // SyncAny tries to sync any of the snapshots in the snapshot pool, waiting to discover further
@@ -160,6 +186,10 @@ func (s *syncer) SyncAny(discoveryTime time.Duration) (sm.State, *types.Commit,
 				return sm.State{}, nil, fmt.Errorf("failed to create chunk queue: %w", err)
 			}
 			defer chunks.Close() // in case we forget to close it elsewhere
+			if received := chunks.Received(); received > 0 {
+				s.logger.Info("Resuming snapshot restoration", "height", snapshot.Height,
+					"format", snapshot.Format, "chunks", received, "total", chunks.Size())
+			}
 		}
 
 		newState, commit, err := s.Sync(snapshot, chunks)
@@ -235,9 +265,10 @@ func (s *syncer) Sync(snapshot *snapshot, chunks *chunkQueue) (sm.State, *types.
 	}
 
 	// Spawn chunk fetchers. They will terminate when the chunk queue is closed or context cancelled.
+	// The number of concurrent requests is limited by the number of peers holding the snapshot.
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	for i := int32(0); i < chunkFetchers; i++ {
+	for i := int32(0); i < maxChunkFetchers; i++ {
 		go s.fetchChunks(ctx, snapshot, chunks)
 	}
 
@@ -329,8 +360,13 @@ func (s *syncer) applyChunks(chunks *chunkQueue) error {
 		s.logger.Info("Applied snapshot chunk to ABCI app", "height", chunk.Height,
 			"format", chunk.Format, "chunk", chunk.Index, "total", chunks.Size())
 
-		// Discard and refetch any chunks as requested by the app
+		// Discard and refetch any chunks as requested by the app, counting bad chunks against
+		// their senders
+		badSenders := make(map[p2p.ID]bool)
 		for _, index := range resp.RefetchChunks {
+			if sender := chunks.GetSender(index); sender != "" {
+				badSenders[sender] = true
+			}
 			err := chunks.Discard(index)
 			if err != nil {
 				return fmt.Errorf("failed to discard chunk %v: %w", index, err)
@@ -340,6 +376,7 @@ func (s *syncer) applyChunks(chunks *chunkQueue) error {
 		// Reject any senders as requested by the app
 		for _, sender := range resp.RejectSenders {
 			if sender != "" {
+				badSenders[p2p.ID(sender)] = true
 				s.snapshots.RejectPeer(p2p.ID(sender))
 				err := chunks.DiscardSender(p2p.ID(sender))
 				if err != nil {
@@ -347,6 +384,11 @@ func (s *syncer) applyChunks(chunks *chunkQueue) error {
 				}
 			}
 		}
+		for sender := range badSenders {
+			if peer := s.fetcher.badChunk(sender); peer != nil {
+				s.banPeer(peer, errors.New("sent too many bad snapshot chunks"))
+			}
+		}
 
 		switch resp.Result {
 		case abci.ResponseApplySnapshotChunk_ACCEPT:
@@ -386,36 +428,65 @@ func (s *syncer) fetchChunks(ctx context.Context, snapshot *snapshot, chunks *ch
 		}
 		s.logger.Info("Fetching snapshot chunk", "height", snapshot.Height,
 			"format", snapshot.Format, "chunk", index, "total", chunks.Size())
-
-		ticker := time.NewTicker(chunkRequestTimeout)
-		defer ticker.Stop()
-		s.requestChunk(snapshot, index)
-		select {
-		case <-chunks.WaitFor(index):
-		case <-ticker.C:
-			s.requestChunk(snapshot, index)
-		case <-ctx.Done():
+		if !s.fetchChunk(ctx, snapshot, chunks, index) {
 			return
 		}
-		ticker.Stop()
 	}
 }
 
-// requestChunk requests a chunk from a peer.
-func (s *syncer) requestChunk(snapshot *snapshot, chunk uint32) {
-	peer := s.snapshots.GetPeer(snapshot)
-	if peer == nil {
-		s.logger.Error("No valid peers found for snapshot", "height", snapshot.Height,
-			"format", snapshot.Format, "hash", snapshot.Hash)
-		return
+// fetchChunk fetches a chunk, requesting it from the best available peer until it arrives.
+// It returns false if the chunk queue was closed or the context cancelled.
+func (s *syncer) fetchChunk(ctx context.Context, snapshot *snapshot, chunks *chunkQueue, index uint32) bool {
+	arrived := chunks.WaitFor(index)
+	for {
+		peer := s.fetcher.selectPeer(s.snapshots.GetPeers(snapshot))
+		if peer == nil {
+			s.logger.Debug("No peer available for snapshot chunk", "height", snapshot.Height,
+				"format", snapshot.Format, "chunk", index)
+			select {
+			case _, ok := <-arrived:
+				return ok
+			case <-time.After(chunkRetryInterval):
+				continue
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		req := s.fetcher.request(peer, snapshot, index)
+		s.logger.Debug("Requesting snapshot chunk", "height", snapshot.Height,
+			"format", snapshot.Format, "chunk", index, "peer", peer.ID())
+		peer.Send(ChunkChannel, mustEncodeMsg(&ssproto.ChunkRequest{
+			Height: snapshot.Height,
+			Format: snapshot.Format,
+			Index:  index,
+		}))
+
+		timer := time.NewTimer(req.timeout)
+		select {
+		case _, ok := <-arrived:
+			timer.Stop()
+			return ok
+		case <-req.missing:
+			timer.Stop()
+			select {
+			case _, ok := <-arrived:
+				return ok
+			case <-time.After(chunkRetryInterval):
+			case <-ctx.Done():
+				return false
+			}
+		case <-timer.C:
+			s.logger.Debug("Timed out waiting for snapshot chunk", "height", snapshot.Height,
+				"format", snapshot.Format, "chunk", index, "peer", peer.ID())
+			if bad := s.fetcher.timedOut(req); bad != nil {
+				s.banPeer(bad, errors.New("timed out responding to snapshot chunk requests"))
+			}
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		}
 	}
-	s.logger.Debug("Requesting snapshot chunk", "height", snapshot.Height,
-		"format", snapshot.Format, "chunk", chunk, "peer", peer.ID())
-	peer.Send(ChunkChannel, mustEncodeMsg(&ssproto.ChunkRequest{
-		Height: snapshot.Height,
-		Format: snapshot.Format,
-		Index:  chunk,
-	}))
 }
 
 // verifyApp verifies the sync, checking the app hash and last block height. It returns the
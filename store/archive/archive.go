@@ -0,0 +1,370 @@
+// Package archive exports a range of heights of the block store and state
+// store into a portable archive, and imports it into other stores after
+// verifying it, e.g. to seed an archive node or to switch the database backend.
+//
+// An archive is a sequence of length-delimited protobuf messages: an
+// ArchiveHeader, an ArchiveHeight for each height of the range, and the state
+// after the last height.
+package archive
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/tendermint/tendermint/libs/protoio"
+	tmstate "github.com/tendermint/tendermint/proto/tendermint/state"
+	tmstore "github.com/tendermint/tendermint/proto/tendermint/store"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	sm "github.com/tendermint/tendermint/state"
+	"github.com/tendermint/tendermint/types"
+)
+
+// maxMsgSize is the maximum size of an archive message, which is dominated by
+// the block and its ABCI responses.
+const maxMsgSize = 4 * types.MaxBlockSizeBytes
+
+// Export writes the blocks, commits, validator sets, consensus params and ABCI
+// responses of the heights from startHeight to endHeight (inclusive) to w,
+// followed by the state after endHeight.
+func Export(w io.Writer, blockStore sm.BlockStore, stateStore sm.Store, startHeight, endHeight int64) error {
+	state, err := stateStore.Load()
+	if err != nil {
+		return err
+	}
+	if state.IsEmpty() {
+		return errors.New("no state found")
+	}
+	if startHeight < blockStore.Base() || endHeight > state.LastBlockHeight || startHeight > endHeight {
+		return fmt.Errorf("invalid height range %v-%v, heights %v-%v are available",
+			startHeight, endHeight, blockStore.Base(), state.LastBlockHeight)
+	}
+
+	pw := protoio.NewDelimitedWriter(w)
+	if _, err := pw.WriteMsg(&tmstore.ArchiveHeader{
+		ChainID:     state.ChainID,
+		StartHeight: startHeight,
+		EndHeight:   endHeight,
+	}); err != nil {
+		return err
+	}
+	for height := startHeight; height <= endHeight; height++ {
+		msg, err := loadHeight(blockStore, stateStore, height)
+		if err != nil {
+			return fmt.Errorf("failed to load height %v: %w", height, err)
+		}
+		if _, err := pw.WriteMsg(msg); err != nil {
+			return err
+		}
+	}
+
+	if endHeight < state.LastBlockHeight {
+		state, err = sm.RebuildState(stateStore, blockStore, endHeight)
+		if err != nil {
+			return fmt.Errorf("failed to load state at height %v: %w", endHeight, err)
+		}
+	}
+	pbState, err := state.ToProto()
+	if err != nil {
+		return err
+	}
+	_, err = pw.WriteMsg(pbState)
+	return err
+}
+
+func loadHeight(blockStore sm.BlockStore, stateStore sm.Store, height int64) (*tmstore.ArchiveHeight, error) {
+	block := blockStore.LoadBlock(height)
+	if block == nil {
+		return nil, errors.New("block not found")
+	}
+	commit := blockStore.LoadBlockCommit(height)
+	if commit == nil {
+		commit = blockStore.LoadSeenCommit(height)
+	}
+	if commit == nil {
+		return nil, errors.New("commit not found")
+	}
+	vals, err := stateStore.LoadValidators(height)
+	if err != nil {
+		return nil, err
+	}
+	params, err := stateStore.LoadConsensusParams(height)
+	if err != nil {
+		return nil, err
+	}
+	abciResponses, err := stateStore.LoadABCIResponses(height)
+	if err != nil {
+		return nil, err
+	}
+
+	pbBlock, err := block.ToProto()
+	if err != nil {
+		return nil, err
+	}
+	pbVals, err := vals.ToProto()
+	if err != nil {
+		return nil, err
+	}
+	return &tmstore.ArchiveHeight{
+		Block:           pbBlock,
+		Commit:          commit.ToProto(),
+		Validators:      pbVals,
+		ConsensusParams: params,
+		AbciResponses:   abciResponses,
+	}, nil
+}
+
+// Import reads an archive from r and saves it to the stores, verifying the
+// hash-linking of the blocks, the commits, and the validator sets, consensus
+// params and ABCI responses against the headers. The archive must continue the
+// stores, or start at the initial height of the genesis if they're empty, in
+// which case the validator set is checked against the genesis validators if
+// there are any. It returns the last height imported.
+//
+// The stores are only consistent once the import succeeded: after an error, the
+// imported heights are stored but the state isn't, so the stores should be
+// discarded.
+func Import(r io.Reader, genDoc *types.GenesisDoc, blockStore sm.BlockStore, stateStore sm.Store) (int64, error) {
+	pr := protoio.NewDelimitedReader(r, maxMsgSize)
+	var header tmstore.ArchiveHeader
+	if err := pr.ReadMsg(&header); err != nil {
+		return 0, fmt.Errorf("failed to read archive header: %w", err)
+	}
+	if header.ChainID != genDoc.ChainID {
+		return 0, fmt.Errorf("archive has chain ID %q, expected %q", header.ChainID, genDoc.ChainID)
+	}
+
+	im, err := newImporter(genDoc, blockStore, stateStore, header.StartHeight)
+	if err != nil {
+		return 0, err
+	}
+	for height := header.StartHeight; height <= header.EndHeight; height++ {
+		var msg tmstore.ArchiveHeight
+		if err := pr.ReadMsg(&msg); err != nil {
+			return im.lastHeight(), fmt.Errorf("failed to read height %v: %w", height, err)
+		}
+		if err := im.importHeight(height, &msg); err != nil {
+			return im.lastHeight(), fmt.Errorf("height %v: %w", height, err)
+		}
+	}
+
+	var pbState tmstate.State
+	if err := pr.ReadMsg(&pbState); err != nil {
+		return im.lastHeight(), fmt.Errorf("failed to read state: %w", err)
+	}
+	state, err := sm.StateFromProto(&pbState)
+	if err != nil {
+		return im.lastHeight(), err
+	}
+	if err := im.importState(*state); err != nil {
+		return im.lastHeight(), fmt.Errorf("state: %w", err)
+	}
+	return im.lastHeight(), nil
+}
+
+// importer verifies and saves the heights of an archive in order.
+type importer struct {
+	chainID    string
+	blockStore sm.BlockStore
+	stateStore sm.Store
+
+	// the last height imported, or the last stored height before it
+	last          *types.BlockMeta
+	lastResponses *tmstate.ABCIResponses
+	// the genesis validators, to check the first height against
+	genesisVals *types.ValidatorSet
+
+	// the ranges of heights with the same validator set and consensus params,
+	// saved when they change
+	valsFrom, paramsFrom int64
+	vals                 *types.ValidatorSet
+	params               tmproto.ConsensusParams
+}
+
+func newImporter(genDoc *types.GenesisDoc, blockStore sm.BlockStore, stateStore sm.Store,
+	startHeight int64) (*importer, error) {
+	im := &importer{
+		chainID:    genDoc.ChainID,
+		blockStore: blockStore,
+		stateStore: stateStore,
+	}
+
+	if blockStore.Height() == 0 {
+		if startHeight != genDoc.InitialHeight {
+			return nil, fmt.Errorf("archive starts at height %v, but the stores are empty and the initial height is %v",
+				startHeight, genDoc.InitialHeight)
+		}
+		if len(genDoc.Validators) > 0 {
+			state, err := sm.MakeGenesisState(genDoc)
+			if err != nil {
+				return nil, err
+			}
+			im.genesisVals = state.Validators
+		}
+		return im, nil
+	}
+
+	if startHeight != blockStore.Height()+1 {
+		return nil, fmt.Errorf("archive starts at height %v, but the stores end at height %v",
+			startHeight, blockStore.Height())
+	}
+	state, err := stateStore.Load()
+	if err != nil {
+		return nil, err
+	}
+	if state.LastBlockHeight != blockStore.Height() {
+		return nil, fmt.Errorf("state height %v does not match block store height %v",
+			state.LastBlockHeight, blockStore.Height())
+	}
+	im.last = blockStore.LoadBlockMeta(blockStore.Height())
+	im.lastResponses, err = stateStore.LoadABCIResponses(blockStore.Height())
+	if err != nil {
+		return nil, err
+	}
+	return im, nil
+}
+
+func (im *importer) lastHeight() int64 {
+	if im.last == nil {
+		return 0
+	}
+	return im.last.Header.Height
+}
+
+func (im *importer) importHeight(height int64, msg *tmstore.ArchiveHeight) error {
+	block, err := types.BlockFromProto(msg.Block)
+	if err != nil {
+		return fmt.Errorf("invalid block: %w", err)
+	}
+	commit, err := types.CommitFromProto(msg.Commit)
+	if err != nil {
+		return fmt.Errorf("invalid commit: %w", err)
+	}
+	vals, err := types.ValidatorSetFromProto(msg.Validators)
+	if err != nil {
+		return fmt.Errorf("invalid validator set: %w", err)
+	}
+	if msg.AbciResponses == nil {
+		return errors.New("missing ABCI responses")
+	}
+	if err := types.ValidateConsensusParams(msg.ConsensusParams); err != nil {
+		return fmt.Errorf("invalid consensus params: %w", err)
+	}
+
+	// Verify the block and its commit.
+	if err := block.ValidateBasic(); err != nil {
+		return fmt.Errorf("invalid block: %w", err)
+	}
+	if block.ChainID != im.chainID || block.Height != height {
+		return fmt.Errorf("unexpected block at height %v of chain %v", block.Height, block.ChainID)
+	}
+	parts := block.MakePartSet(types.BlockPartSizeBytes)
+	blockID := types.BlockID{Hash: block.Hash(), PartSetHeader: parts.Header()}
+	if !bytes.Equal(block.ValidatorsHash, vals.Hash()) {
+		return fmt.Errorf("validators hash %X does not match the validator set hash %X", block.ValidatorsHash, vals.Hash())
+	}
+	if !bytes.Equal(block.ConsensusHash, types.HashConsensusParams(msg.ConsensusParams)) {
+		return fmt.Errorf("consensus hash %X does not match the consensus params hash %X",
+			block.ConsensusHash, types.HashConsensusParams(msg.ConsensusParams))
+	}
+	if err := vals.VerifyCommit(im.chainID, blockID, height, commit); err != nil {
+		return fmt.Errorf("invalid commit: %w", err)
+	}
+
+	// Verify the links to the previous height.
+	if im.last == nil {
+		if im.genesisVals != nil && !bytes.Equal(vals.Hash(), im.genesisVals.Hash()) {
+			return errors.New("validator set does not match the genesis validators")
+		}
+	} else {
+		if !block.LastBlockID.Equals(im.last.BlockID) {
+			return fmt.Errorf("last block ID %v does not match block ID %v of the previous height",
+				block.LastBlockID, im.last.BlockID)
+		}
+		if !bytes.Equal(im.last.Header.NextValidatorsHash, vals.Hash()) {
+			return fmt.Errorf("validator set hash %X does not match next validators hash %X of the previous height",
+				vals.Hash(), im.last.Header.NextValidatorsHash)
+		}
+		if resultsHash := sm.ABCIResponsesResultsHash(im.lastResponses); !bytes.Equal(block.LastResultsHash, resultsHash) {
+			return fmt.Errorf("last results hash %X does not match results hash %X of the previous height",
+				block.LastResultsHash, resultsHash)
+		}
+	}
+
+	im.blockStore.SaveBlock(block, parts, commit)
+	if err := im.stateStore.SaveABCIResponses(height, msg.AbciResponses); err != nil {
+		return err
+	}
+	if err := im.saveValsAndParams(height, vals, msg.ConsensusParams); err != nil {
+		return err
+	}
+	im.last = im.blockStore.LoadBlockMeta(height)
+	im.lastResponses = msg.AbciResponses
+	return nil
+}
+
+// saveValsAndParams saves the validator set and consensus params of the
+// previous heights when they change at the given height.
+func (im *importer) saveValsAndParams(height int64, vals *types.ValidatorSet, params tmproto.ConsensusParams) error {
+	if im.vals == nil || !bytes.Equal(im.vals.Hash(), vals.Hash()) {
+		if err := im.flushVals(height - 1); err != nil {
+			return err
+		}
+		im.valsFrom, im.vals = height, vals
+	}
+	if im.paramsFrom == 0 || !im.params.Equal(&params) {
+		if err := im.flushParams(height - 1); err != nil {
+			return err
+		}
+		im.paramsFrom, im.params = height, params
+	}
+	return nil
+}
+
+func (im *importer) flushVals(toHeight int64) error {
+	if im.vals == nil || toHeight < im.valsFrom {
+		return nil
+	}
+	return im.stateStore.SaveValidatorSets(im.valsFrom, toHeight, im.vals)
+}
+
+func (im *importer) flushParams(toHeight int64) error {
+	if im.paramsFrom == 0 || toHeight < im.paramsFrom {
+		return nil
+	}
+	return im.stateStore.SaveConsensusParams(im.paramsFrom, toHeight, im.params)
+}
+
+// importState verifies the state after the last height against it, and saves
+// it along with the validator sets and consensus params not saved yet.
+func (im *importer) importState(state sm.State) error {
+	if im.last == nil {
+		return errors.New("no heights imported")
+	}
+	if state.ChainID != im.chainID || state.LastBlockHeight != im.last.Header.Height {
+		return fmt.Errorf("unexpected state at height %v of chain %v", state.LastBlockHeight, state.ChainID)
+	}
+	if !state.LastBlockID.Equals(im.last.BlockID) {
+		return fmt.Errorf("last block ID %v does not match block ID %v of the last height",
+			state.LastBlockID, im.last.BlockID)
+	}
+	if !bytes.Equal(state.LastValidators.Hash(), im.last.Header.ValidatorsHash) {
+		return errors.New("last validator set does not match the validators hash of the last height")
+	}
+	if !bytes.Equal(state.Validators.Hash(), im.last.Header.NextValidatorsHash) {
+		return errors.New("validator set does not match the next validators hash of the last height")
+	}
+	if resultsHash := sm.ABCIResponsesResultsHash(im.lastResponses); !bytes.Equal(state.LastResultsHash, resultsHash) {
+		return fmt.Errorf("last results hash %X does not match results hash %X of the last height",
+			state.LastResultsHash, resultsHash)
+	}
+
+	if err := im.flushVals(state.LastBlockHeight); err != nil {
+		return err
+	}
+	if err := im.flushParams(state.LastBlockHeight); err != nil {
+		return err
+	}
+	return im.stateStore.Bootstrap(state)
+}
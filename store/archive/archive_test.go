@@ -0,0 +1,166 @@
+package archive
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	dbm "github.com/tendermint/tm-db"
+
+	"github.com/tendermint/tendermint/abci/example/kvstore"
+	"github.com/tendermint/tendermint/libs/log"
+	mpmock "github.com/tendermint/tendermint/mempool/mock"
+	"github.com/tendermint/tendermint/proxy"
+	sm "github.com/tendermint/tendermint/state"
+	"github.com/tendermint/tendermint/store"
+	"github.com/tendermint/tendermint/types"
+	tmtime "github.com/tendermint/tendermint/types/time"
+)
+
+// makeChain executes the given number of blocks with a transaction each
+// against the kvstore application, and returns the genesis and the stores.
+func makeChain(t *testing.T, height int64) (*types.GenesisDoc, *store.BlockStore, sm.Store) {
+	privVal := types.NewMockPV()
+	pubKey, err := privVal.GetPubKey()
+	require.NoError(t, err)
+	genDoc := &types.GenesisDoc{
+		ChainID:     "archive-test",
+		GenesisTime: tmtime.Now(),
+		Validators:  []types.GenesisValidator{{PubKey: pubKey, Power: 10}},
+	}
+	require.NoError(t, genDoc.ValidateAndComplete())
+
+	proxyApp := proxy.NewAppConns(proxy.NewLocalClientCreator(kvstore.NewApplication()), proxy.NopMetrics())
+	require.NoError(t, proxyApp.Start())
+	t.Cleanup(func() { require.NoError(t, proxyApp.Stop()) })
+
+	blockStore := store.NewBlockStore(dbm.NewMemDB())
+	stateStore := sm.NewStore(dbm.NewMemDB())
+	state, err := stateStore.LoadFromDBOrGenesisDoc(genDoc)
+	require.NoError(t, err)
+	require.NoError(t, stateStore.Save(state))
+	blockExec := sm.NewBlockExecutor(stateStore, log.TestingLogger(), proxyApp.Consensus(),
+		mpmock.Mempool{}, sm.MockEvidencePool{})
+
+	lastCommit := types.NewCommit(0, 0, types.BlockID{}, nil)
+	for h := int64(1); h <= height; h++ {
+		txs := []types.Tx{types.Tx(fmt.Sprintf("key%d=value", h))}
+		block, parts := state.MakeBlock(h, txs, lastCommit, nil, state.Validators.Proposer.Address)
+		blockID := types.BlockID{Hash: block.Hash(), PartSetHeader: parts.Header()}
+
+		vote, err := types.MakeVote(h, blockID, state.Validators, privVal, genDoc.ChainID, time.Now())
+		require.NoError(t, err)
+		commit := types.NewCommit(h, 0, blockID, []types.CommitSig{vote.CommitSig()})
+		blockStore.SaveBlock(block, parts, commit)
+
+		state, _, err = blockExec.ApplyBlock(state, blockID, block)
+		require.NoError(t, err)
+		lastCommit = commit
+	}
+	return genDoc, blockStore, stateStore
+}
+
+func TestExportImport(t *testing.T) {
+	genDoc, blockStore, stateStore := makeChain(t, 10)
+	buf := &bytes.Buffer{}
+	require.NoError(t, Export(buf, blockStore, stateStore, 1, 10))
+
+	newBlockStore := store.NewBlockStore(dbm.NewMemDB())
+	newStateStore := sm.NewStore(dbm.NewMemDB())
+	height, err := Import(buf, genDoc, newBlockStore, newStateStore)
+	require.NoError(t, err)
+	assert.EqualValues(t, 10, height)
+
+	assertStoresEqual(t, blockStore, stateStore, newBlockStore, newStateStore, 10)
+}
+
+func TestExportImport_Continue(t *testing.T) {
+	genDoc, blockStore, stateStore := makeChain(t, 10)
+	newBlockStore := store.NewBlockStore(dbm.NewMemDB())
+	newStateStore := sm.NewStore(dbm.NewMemDB())
+
+	// An archive must start at the initial height or continue the stores.
+	buf := &bytes.Buffer{}
+	require.NoError(t, Export(buf, blockStore, stateStore, 6, 10))
+	_, err := Import(buf, genDoc, newBlockStore, newStateStore)
+	require.Error(t, err)
+
+	buf.Reset()
+	require.NoError(t, Export(buf, blockStore, stateStore, 1, 5))
+	height, err := Import(buf, genDoc, newBlockStore, newStateStore)
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, height)
+
+	// The state after height 5 is rebuilt from the stores.
+	state, err := newStateStore.Load()
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, state.LastBlockHeight)
+	assert.EqualValues(t, blockStore.LoadBlockMeta(6).Header.AppHash, state.AppHash)
+
+	buf.Reset()
+	require.NoError(t, Export(buf, blockStore, stateStore, 6, 10))
+	height, err = Import(buf, genDoc, newBlockStore, newStateStore)
+	require.NoError(t, err)
+	assert.EqualValues(t, 10, height)
+
+	assertStoresEqual(t, blockStore, stateStore, newBlockStore, newStateStore, 10)
+}
+
+func TestImport_Invalid(t *testing.T) {
+	genDoc, blockStore, stateStore := makeChain(t, 10)
+
+	// ABCI responses not matching the results hash of the next block.
+	abciResponses, err := stateStore.LoadABCIResponses(3)
+	require.NoError(t, err)
+	abciResponses.DeliverTxs[0].Code = 1
+	require.NoError(t, stateStore.SaveABCIResponses(3, abciResponses))
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, Export(buf, blockStore, stateStore, 1, 10))
+	height, err := Import(buf, genDoc, store.NewBlockStore(dbm.NewMemDB()), sm.NewStore(dbm.NewMemDB()))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "height 4: last results hash")
+	assert.EqualValues(t, 3, height)
+
+	// An archive of another chain.
+	otherGenDoc := *genDoc
+	otherGenDoc.ChainID = "other"
+	buf.Reset()
+	require.NoError(t, Export(buf, blockStore, stateStore, 1, 2))
+	_, err = Import(buf, &otherGenDoc, store.NewBlockStore(dbm.NewMemDB()), sm.NewStore(dbm.NewMemDB()))
+	require.Error(t, err)
+}
+
+func assertStoresEqual(t *testing.T, expectBlockStore *store.BlockStore, expectStateStore sm.Store,
+	blockStore *store.BlockStore, stateStore sm.Store, height int64) {
+	for h := int64(1); h <= height; h++ {
+		assert.Equal(t, expectBlockStore.LoadBlockMeta(h).BlockID, blockStore.LoadBlockMeta(h).BlockID, h)
+		assert.Equal(t, expectBlockStore.LoadSeenCommit(h).Hash(), blockStore.LoadSeenCommit(h).Hash(), h)
+
+		expectVals, err := expectStateStore.LoadValidators(h)
+		require.NoError(t, err)
+		vals, err := stateStore.LoadValidators(h)
+		require.NoError(t, err)
+		assert.Equal(t, expectVals, vals, h)
+
+		expectParams, err := expectStateStore.LoadConsensusParams(h)
+		require.NoError(t, err)
+		params, err := stateStore.LoadConsensusParams(h)
+		require.NoError(t, err)
+		assert.Equal(t, expectParams, params, h)
+
+		expectResponses, err := expectStateStore.LoadABCIResponses(h)
+		require.NoError(t, err)
+		responses, err := stateStore.LoadABCIResponses(h)
+		require.NoError(t, err)
+		assert.Equal(t, expectResponses, responses, h)
+	}
+	expectState, err := expectStateStore.Load()
+	require.NoError(t, err)
+	state, err := stateStore.Load()
+	require.NoError(t, err)
+	assert.Equal(t, expectState, state)
+}
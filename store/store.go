@@ -36,6 +36,11 @@ type BlockStore struct {
 	mtx    tmsync.RWMutex
 	base   int64
 	height int64
+	// serializes the pruning, e.g. by the pruner and on commit
+	pruneMtx tmsync.Mutex
+	// lowest height of the headers saved below the base without their block (see
+	// SaveSignedHeader), or 0 if there are none
+	headerBase int64
 }
 
 // NewBlockStore returns a new BlockStore with the given DB,
@@ -43,9 +48,10 @@ type BlockStore struct {
 func NewBlockStore(db dbm.DB) *BlockStore {
 	bs := LoadBlockStoreState(db)
 	return &BlockStore{
-		base:   bs.Base,
-		height: bs.Height,
-		db:     db,
+		base:       bs.Base,
+		height:     bs.Height,
+		headerBase: loadHeaderBase(db),
+		db:         db,
 	}
 }
 
@@ -85,6 +91,10 @@ func (bs *BlockStore) LoadBlock(height int64) *types.Block {
 	buf := []byte{}
 	for i := 0; i < int(blockMeta.BlockID.PartSetHeader.Total); i++ {
 		part := bs.LoadBlockPart(height, i)
+		// If the part is missing (e.g. since only the header was backfilled), the block is missing
+		if part == nil {
+			return nil
+		}
 		buf = append(buf, part.Bytes...)
 	}
 	err := proto.Unmarshal(buf, pbb)
@@ -226,29 +236,46 @@ func (bs *BlockStore) LoadSeenCommit(height int64) *types.Commit {
 
 // PruneBlocks removes block up to (but not including) a height. It returns number of blocks pruned.
 func (bs *BlockStore) PruneBlocks(height int64) (uint64, error) {
+	pruned, _, err := bs.PruneBlocksKeepEvery(height, 0)
+	return pruned, err
+}
+
+// PruneBlocksKeepEvery removes blocks up to (but not including) a height, except for the blocks at
+// heights which are multiples of keepEvery (if positive). The kept blocks remain loadable below the
+// base, e.g. as checkpoints. The headers saved without their block below the height are removed
+// too. It returns the number of blocks pruned and their total size.
+func (bs *BlockStore) PruneBlocksKeepEvery(height int64, keepEvery int64) (uint64, int64, error) {
 	if height <= 0 {
-		return 0, fmt.Errorf("height must be greater than 0")
+		return 0, 0, fmt.Errorf("height must be greater than 0")
 	}
+	bs.pruneMtx.Lock()
+	defer bs.pruneMtx.Unlock()
 	bs.mtx.RLock()
 	if height > bs.height {
 		bs.mtx.RUnlock()
-		return 0, fmt.Errorf("cannot prune beyond the latest height %v", bs.height)
+		return 0, 0, fmt.Errorf("cannot prune beyond the latest height %v", bs.height)
 	}
 	base := bs.base
 	bs.mtx.RUnlock()
 	if height < base {
-		return 0, fmt.Errorf("cannot prune to height %v, it is lower than base height %v",
+		return 0, 0, fmt.Errorf("cannot prune to height %v, it is lower than base height %v",
 			height, base)
 	}
 
-	pruned := uint64(0)
+	var (
+		pruned  = uint64(0)
+		headers = uint64(0)
+		size    = int64(0)
+	)
 	batch := bs.db.NewBatch()
 	defer batch.Close()
 	flush := func(batch dbm.Batch, base int64) error {
 		// We can't trust batches to be atomic, so update base first to make sure noone
-		// tries to access missing blocks.
+		// tries to access missing blocks. The base never goes back to deleted blocks.
 		bs.mtx.Lock()
-		bs.base = base
+		if base > bs.base {
+			bs.base = base
+		}
 		bs.mtx.Unlock()
 		bs.saveState()
 
@@ -260,35 +287,61 @@ func (bs *BlockStore) PruneBlocks(height int64) (uint64, error) {
 		return nil
 	}
 
+	// Headers saved without their block lie below the base, under the blocks kept as checkpoints,
+	// so they're all pruned.
+	bs.mtx.RLock()
+	headerBase := bs.headerBase
+	bs.mtx.RUnlock()
+	if headerBase > 0 {
+		for h := headerBase; h < base; h++ {
+			meta := bs.LoadBlockMeta(h)
+			if meta == nil || meta.BlockSize >= 0 { // already deleted, or a full block
+				continue
+			}
+			if err := deleteBlock(batch, h, meta); err != nil {
+				return 0, 0, err
+			}
+			headers++
+			if headers%1000 == 0 {
+				err := flush(batch, base)
+				if err != nil {
+					return 0, 0, err
+				}
+				batch = bs.db.NewBatch()
+				defer batch.Close()
+			}
+		}
+		// Unless lower headers were saved meanwhile, there are none left.
+		bs.mtx.Lock()
+		if bs.headerBase == headerBase {
+			bs.headerBase = 0
+			if err := saveHeaderBase(batch, 0); err != nil {
+				bs.mtx.Unlock()
+				return 0, 0, err
+			}
+		}
+		bs.mtx.Unlock()
+	}
+
 	for h := base; h < height; h++ {
 		meta := bs.LoadBlockMeta(h)
 		if meta == nil { // assume already deleted
 			continue
 		}
-		if err := batch.Delete(calcBlockMetaKey(h)); err != nil {
-			return 0, err
-		}
-		if err := batch.Delete(calcBlockHashKey(meta.BlockID.Hash)); err != nil {
-			return 0, err
-		}
-		if err := batch.Delete(calcBlockCommitKey(h)); err != nil {
-			return 0, err
-		}
-		if err := batch.Delete(calcSeenCommitKey(h)); err != nil {
-			return 0, err
+		if keepEvery > 0 && h%keepEvery == 0 {
+			continue
 		}
-		for p := 0; p < int(meta.BlockID.PartSetHeader.Total); p++ {
-			if err := batch.Delete(calcBlockPartKey(h, p)); err != nil {
-				return 0, err
-			}
+		if err := deleteBlock(batch, h, meta); err != nil {
+			return 0, 0, err
 		}
 		pruned++
+		size += int64(meta.BlockSize)
 
 		// flush every 1000 blocks to avoid batches becoming too large
 		if pruned%1000 == 0 && pruned > 0 {
 			err := flush(batch, h)
 			if err != nil {
-				return 0, err
+				return 0, 0, err
 			}
 			batch = bs.db.NewBatch()
 			defer batch.Close()
@@ -297,9 +350,31 @@ func (bs *BlockStore) PruneBlocks(height int64) (uint64, error) {
 
 	err := flush(batch, height)
 	if err != nil {
-		return 0, err
+		return 0, 0, err
+	}
+	return pruned, size, nil
+}
+
+// deleteBlock adds the deletion of the block at the height, with the given meta, to the batch.
+func deleteBlock(batch dbm.Batch, height int64, meta *types.BlockMeta) error {
+	if err := batch.Delete(calcBlockMetaKey(height)); err != nil {
+		return err
+	}
+	if err := batch.Delete(calcBlockHashKey(meta.BlockID.Hash)); err != nil {
+		return err
 	}
-	return pruned, nil
+	if err := batch.Delete(calcBlockCommitKey(height)); err != nil {
+		return err
+	}
+	if err := batch.Delete(calcSeenCommitKey(height)); err != nil {
+		return err
+	}
+	for p := 0; p < int(meta.BlockID.PartSetHeader.Total); p++ {
+		if err := batch.Delete(calcBlockPartKey(height, p)); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // SaveBlock persists the given block, blockParts, and seenCommit to the underlying db.
@@ -391,6 +466,56 @@ func (bs *BlockStore) saveState() {
 	SaveBlockStoreState(&bss, bs.db)
 }
 
+// SaveSignedHeader saves the header and commit of a block without its contents, used by e.g. the
+// state sync reactor when backfilling. The base and height of the store only cover full blocks, so
+// they are left unchanged. Block metas saved this way have an unknown (-1) size and number of txs,
+// and are pruned with the blocks above them. It does nothing if the block meta already exists.
+func (bs *BlockStore) SaveSignedHeader(sh *types.SignedHeader, blockID types.BlockID) error {
+	if bs.LoadBlockMeta(sh.Height) != nil {
+		return nil
+	}
+	blockMeta := &types.BlockMeta{
+		BlockID:   blockID,
+		BlockSize: -1,
+		Header:    *sh.Header,
+		NumTxs:    -1,
+	}
+	metaBytes, err := proto.Marshal(blockMeta.ToProto())
+	if err != nil {
+		return fmt.Errorf("unable to marshal block meta: %w", err)
+	}
+	commitBytes, err := proto.Marshal(sh.Commit.ToProto())
+	if err != nil {
+		return fmt.Errorf("unable to marshal commit: %w", err)
+	}
+
+	batch := bs.db.NewBatch()
+	defer batch.Close()
+	if err := batch.Set(calcBlockMetaKey(sh.Height), metaBytes); err != nil {
+		return err
+	}
+	if err := batch.Set(calcBlockHashKey(blockID.Hash), []byte(fmt.Sprintf("%d", sh.Height))); err != nil {
+		return err
+	}
+	if err := batch.Set(calcBlockCommitKey(sh.Height), commitBytes); err != nil {
+		return err
+	}
+
+	bs.mtx.Lock()
+	defer bs.mtx.Unlock()
+	if bs.headerBase == 0 || sh.Height < bs.headerBase {
+		if err := saveHeaderBase(batch, sh.Height); err != nil {
+			return err
+		}
+		if err := batch.WriteSync(); err != nil {
+			return err
+		}
+		bs.headerBase = sh.Height
+		return nil
+	}
+	return batch.WriteSync()
+}
+
 // SaveSeenCommit saves a seen commit, used by e.g. the state sync reactor when bootstrapping node.
 func (bs *BlockStore) SaveSeenCommit(height int64, seenCommit *types.Commit) error {
 	pbc := seenCommit.ToProto()
@@ -425,7 +550,35 @@ func calcBlockHashKey(hash []byte) []byte {
 
 //-----------------------------------------------------------------------------
 
-var blockStoreKey = []byte("blockStore")
+var (
+	blockStoreKey = []byte("blockStore")
+	headerBaseKey = []byte("blockStoreHeaderBase")
+)
+
+// saveHeaderBase adds the lowest height of the headers saved without their block to the batch,
+// deleting it if 0.
+func saveHeaderBase(batch dbm.Batch, height int64) error {
+	if height == 0 {
+		return batch.Delete(headerBaseKey)
+	}
+	return batch.Set(headerBaseKey, []byte(strconv.FormatInt(height, 10)))
+}
+
+// loadHeaderBase loads the lowest height of the headers saved without their block, or 0 if none.
+func loadHeaderBase(db dbm.DB) int64 {
+	bz, err := db.Get(headerBaseKey)
+	if err != nil {
+		panic(err)
+	}
+	if len(bz) == 0 {
+		return 0
+	}
+	height, err := strconv.ParseInt(string(bz), 10, 64)
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse header base %q: %v", bz, err))
+	}
+	return height
+}
 
 // SaveBlockStoreState persists the blockStore state to the database.
 func SaveBlockStoreState(bsj *tmstore.BlockStoreState, db dbm.DB) {
@@ -366,6 +366,62 @@ func TestBlockStoreSaveLoadBlock(t *testing.T) {
 	}
 }
 
+func TestSaveSignedHeader(t *testing.T) {
+	state, bs, cleanup := makeStateAndBlockStore(log.NewTMLogger(new(bytes.Buffer)))
+	defer cleanup()
+
+	block := makeBlock(5, state, new(types.Commit))
+	blockID := types.BlockID{Hash: block.Hash(), PartSetHeader: types.PartSetHeader{Total: 1, Hash: tmrand.Bytes(32)}}
+	commit := makeTestCommit(5, tmtime.Now())
+	sh := &types.SignedHeader{Header: &block.Header, Commit: commit}
+	require.NoError(t, bs.SaveSignedHeader(sh, blockID))
+
+	// the header and commit are stored, without the block
+	meta := bs.LoadBlockMeta(5)
+	require.NotNil(t, meta)
+	assert.Equal(t, blockID, meta.BlockID)
+	assert.Equal(t, block.Hash(), meta.Header.Hash())
+	assert.EqualValues(t, -1, meta.NumTxs)
+	assert.Equal(t, commit.Hash(), bs.LoadBlockCommit(5).Hash())
+	assert.Nil(t, bs.LoadBlock(5))
+
+	// the base and height only cover full blocks
+	assert.EqualValues(t, 0, bs.Base())
+	assert.EqualValues(t, 0, bs.Height())
+}
+
+func TestPruneBlocksBackfilledHeaders(t *testing.T) {
+	state, bs, cleanup := makeStateAndBlockStore(log.NewNopLogger())
+	defer cleanup()
+
+	// blocks 11 to 20, as after state sync, with the headers 5 to 10 backfilled below them
+	for h := int64(11); h <= 20; h++ {
+		block := makeBlock(h, state, new(types.Commit))
+		bs.SaveBlock(block, block.MakePartSet(2), makeTestCommit(h, tmtime.Now()))
+	}
+	for h := int64(10); h >= 5; h-- {
+		block := makeBlock(h, state, new(types.Commit))
+		blockID := types.BlockID{Hash: block.Hash(), PartSetHeader: types.PartSetHeader{Total: 1}}
+		sh := &types.SignedHeader{Header: &block.Header, Commit: makeTestCommit(h, tmtime.Now())}
+		require.NoError(t, bs.SaveSignedHeader(sh, blockID))
+	}
+	assert.EqualValues(t, 5, bs.headerBase)
+	assert.EqualValues(t, 5, NewBlockStore(bs.db).headerBase)
+	assert.EqualValues(t, 11, bs.Base())
+
+	// the headers are pruned with the blocks, but don't count as pruned blocks
+	pruned, err := bs.PruneBlocks(15)
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, pruned)
+	for h := int64(5); h < 15; h++ {
+		require.Nil(t, bs.LoadBlockMeta(h), h)
+		require.Nil(t, bs.LoadBlockCommit(h), h)
+	}
+	assert.NotNil(t, bs.LoadBlock(15))
+	assert.Zero(t, bs.headerBase)
+	assert.Zero(t, NewBlockStore(bs.db).headerBase)
+}
+
 func TestLoadBlockPart(t *testing.T) {
 	bs, db := freshBlockStore()
 	height, index := int64(10), 1
@@ -486,6 +542,40 @@ func TestPruneBlocks(t *testing.T) {
 	assert.Nil(t, bs.LoadBlock(1501))
 }
 
+func TestPruneBlocksKeepEvery(t *testing.T) {
+	state, bs, cleanup := makeStateAndBlockStore(log.NewNopLogger())
+	defer cleanup()
+
+	for h := int64(1); h <= 100; h++ {
+		block := makeBlock(h, state, new(types.Commit))
+		partSet := block.MakePartSet(2)
+		bs.SaveBlock(block, partSet, makeTestCommit(h, tmtime.Now()))
+	}
+
+	pruned, size, err := bs.PruneBlocksKeepEvery(50, 10)
+	require.NoError(t, err)
+	assert.EqualValues(t, 45, pruned)
+	assert.True(t, size > 0)
+	assert.EqualValues(t, 50, bs.Base())
+
+	// every 10th block is kept below the base
+	for h := int64(1); h < 50; h++ {
+		if h%10 == 0 {
+			require.NotNil(t, bs.LoadBlock(h), h)
+			require.NotNil(t, bs.LoadBlockCommit(h), h)
+		} else {
+			require.Nil(t, bs.LoadBlockMeta(h), h)
+		}
+	}
+
+	// pruning further doesn't touch the kept blocks
+	pruned, _, err = bs.PruneBlocksKeepEvery(70, 0)
+	require.NoError(t, err)
+	assert.EqualValues(t, 20, pruned)
+	assert.NotNil(t, bs.LoadBlock(40))
+	assert.Nil(t, bs.LoadBlock(60))
+}
+
 func TestLoadBlockMeta(t *testing.T) {
 	bs, db := freshBlockStore()
 	height := int64(10)
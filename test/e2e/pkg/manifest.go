@@ -0,0 +1,84 @@
+package e2e
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/pelletier/go-toml"
+)
+
+// Manifest represents a TOML testnet manifest.
+type Manifest struct {
+	// InitialHeight specifies the initial block height, set in genesis.
+	// Defaults to 1.
+	InitialHeight int64 `toml:"initial_height"`
+
+	// BasePort is the first port used by the testnet. Every node uses three
+	// consecutive ports (p2p, rpc and prometheus) starting at
+	// BasePort+10*index. Defaults to 30000.
+	BasePort int `toml:"base_port"`
+
+	// Validators maps validator names to their power in genesis. If not
+	// given, every node in validator mode gets power 100.
+	Validators map[string]int64 `toml:"validators"`
+
+	// Evidence is the number of pieces of duplicate vote evidence the runner
+	// injects into the network. The test asserts that all of it is
+	// committed.
+	Evidence int `toml:"evidence"`
+
+	// Nodes specifies the network nodes. At least one must be given.
+	Nodes map[string]*ManifestNode `toml:"node"`
+}
+
+// ManifestNode represents a node in a testnet manifest.
+type ManifestNode struct {
+	// Mode specifies the type of node: "validator" (default) or "full".
+	Mode string `toml:"mode"`
+
+	// StartAt is the height at which to start the node. 0 (default) starts
+	// it with the initial network, otherwise it joins once the network has
+	// reached this height.
+	StartAt int64 `toml:"start_at"`
+
+	// FastSync specifies the fast sync version: "v0" (default), "v1", "v2"
+	// or "disabled".
+	FastSync string `toml:"fast_sync"`
+
+	// StateSync enables state sync. It requires StartAt > 0 and an
+	// application that serves snapshots.
+	StateSync bool `toml:"state_sync"`
+
+	// Perturb lists perturbations to apply to the node once the network has
+	// reached the given height.
+	Perturb []ManifestPerturbation `toml:"perturb"`
+
+	// Misbehaviors makes a validator misbehave, mapping heights to the
+	// names of consensus misbehaviors (e.g. 1012 = "double-prevote" in a
+	// [node.<name>.misbehaviors] table).
+	// Nodes with misbehaviors are run with the maverick binary.
+	Misbehaviors map[string]string `toml:"misbehaviors"`
+}
+
+// ManifestPerturbation is a perturbation applied to a node at a height.
+type ManifestPerturbation struct {
+	// Action is one of "kill", "pause", "disconnect" or "restart".
+	Action string `toml:"action"`
+
+	// Height is the network height at which to apply the perturbation.
+	Height int64 `toml:"height"`
+}
+
+// LoadManifest loads a testnet manifest from a file.
+func LoadManifest(file string) (Manifest, error) {
+	manifest := Manifest{}
+	bz, err := ioutil.ReadFile(file)
+	if err != nil {
+		return manifest, fmt.Errorf("failed to read testnet manifest %q: %w", file, err)
+	}
+	err = toml.Unmarshal(bz, &manifest)
+	if err != nil {
+		return manifest, fmt.Errorf("failed to parse testnet manifest %q: %w", file, err)
+	}
+	return manifest, nil
+}
@@ -0,0 +1,329 @@
+package e2e
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tendermint/tendermint/consensus"
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	"github.com/tendermint/tendermint/p2p"
+	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
+)
+
+const (
+	defaultBasePort = 30000
+	portsPerNode    = 10
+)
+
+// Mode is the mode of a node.
+type Mode string
+
+// Perturbation is an action applied to a running node.
+type Perturbation string
+
+// Node modes and perturbations.
+const (
+	ModeValidator Mode = "validator"
+	ModeFull      Mode = "full"
+
+	PerturbationKill       Perturbation = "kill"
+	PerturbationPause      Perturbation = "pause"
+	PerturbationDisconnect Perturbation = "disconnect"
+	PerturbationRestart    Perturbation = "restart"
+)
+
+// Testnet represents a single testnet, built from a manifest.
+type Testnet struct {
+	Name          string
+	File          string
+	Dir           string
+	InitialHeight int64
+	Validators    map[*Node]int64
+	Evidence      int
+	Nodes         []*Node
+}
+
+// Node represents a Tendermint node in a testnet.
+type Node struct {
+	Name           string
+	Testnet        *Testnet
+	Mode           Mode
+	Key            crypto.PrivKey
+	PrivvalKey     crypto.PrivKey
+	P2PPort        int
+	RPCPort        int
+	PrometheusPort int
+	StartAt        int64
+	FastSync       string
+	StateSync      bool
+	Perturbations  []NodePerturbation
+	Misbehaviors   map[int64]string
+}
+
+// NodePerturbation is a perturbation scheduled at a network height.
+type NodePerturbation struct {
+	Action Perturbation
+	Height int64
+}
+
+// LoadTestnet loads a testnet from a manifest file, using the filename to
+// determine the testnet name and directory (from the basename of the file).
+// Node keys are derived from the node names, so loading the same manifest
+// twice gives the same testnet.
+func LoadTestnet(file string) (*Testnet, error) {
+	manifest, err := LoadManifest(file)
+	if err != nil {
+		return nil, err
+	}
+	return NewTestnet(manifest, file)
+}
+
+// NewTestnet builds a testnet from a manifest.
+func NewTestnet(manifest Manifest, file string) (*Testnet, error) {
+	dir := strings.TrimSuffix(file, filepath.Ext(file))
+
+	testnet := &Testnet{
+		Name:          filepath.Base(dir),
+		File:          file,
+		Dir:           dir,
+		InitialHeight: 1,
+		Validators:    map[*Node]int64{},
+		Evidence:      manifest.Evidence,
+	}
+	if manifest.InitialHeight > 0 {
+		testnet.InitialHeight = manifest.InitialHeight
+	}
+	basePort := defaultBasePort
+	if manifest.BasePort > 0 {
+		basePort = manifest.BasePort
+	}
+
+	// Set up nodes, in alphabetical order (IPs and ports get same order).
+	nodeNames := []string{}
+	for name := range manifest.Nodes {
+		nodeNames = append(nodeNames, name)
+	}
+	sort.Strings(nodeNames)
+
+	for i, name := range nodeNames {
+		nodeManifest := manifest.Nodes[name]
+		port := basePort + i*portsPerNode
+		node := &Node{
+			Name:           name,
+			Testnet:        testnet,
+			Key:            ed25519.GenPrivKeyFromSecret([]byte("node:" + name)),
+			PrivvalKey:     ed25519.GenPrivKeyFromSecret([]byte("privval:" + name)),
+			Mode:           ModeValidator,
+			P2PPort:        port,
+			RPCPort:        port + 1,
+			PrometheusPort: port + 2,
+			StartAt:        nodeManifest.StartAt,
+			FastSync:       "v0",
+			StateSync:      nodeManifest.StateSync,
+			Misbehaviors:   map[int64]string{},
+		}
+		if nodeManifest.Mode != "" {
+			node.Mode = Mode(nodeManifest.Mode)
+		}
+		switch nodeManifest.FastSync {
+		case "":
+		case "disabled":
+			node.FastSync = ""
+		default:
+			node.FastSync = nodeManifest.FastSync
+		}
+		for _, p := range nodeManifest.Perturb {
+			node.Perturbations = append(node.Perturbations, NodePerturbation{
+				Action: Perturbation(p.Action),
+				Height: p.Height,
+			})
+		}
+		for heightString, misbehavior := range nodeManifest.Misbehaviors {
+			height, err := strconv.ParseInt(heightString, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid misbehavior height %q for node %q", heightString, name)
+			}
+			node.Misbehaviors[height] = misbehavior
+		}
+		testnet.Nodes = append(testnet.Nodes, node)
+	}
+
+	// Set up genesis validators. If not specified explicitly, use all
+	// validator nodes.
+	if manifest.Validators != nil {
+		for validatorName, power := range manifest.Validators {
+			validator := testnet.LookupNode(validatorName)
+			if validator == nil {
+				return nil, fmt.Errorf("unknown validator %q", validatorName)
+			}
+			testnet.Validators[validator] = power
+		}
+	} else {
+		for _, node := range testnet.Nodes {
+			if node.Mode == ModeValidator {
+				testnet.Validators[node] = 100
+			}
+		}
+	}
+
+	return testnet, testnet.Validate()
+}
+
+// Validate validates a testnet.
+func (t Testnet) Validate() error {
+	if t.Name == "" {
+		return errors.New("network has no name")
+	}
+	if t.InitialHeight < 1 {
+		return errors.New("initial height must be at least 1")
+	}
+	if len(t.Nodes) == 0 {
+		return errors.New("network has no nodes")
+	}
+	if len(t.Validators) == 0 {
+		return errors.New("network has no validators")
+	}
+	if t.Evidence < 0 {
+		return errors.New("evidence can't be negative")
+	}
+	for _, node := range t.Nodes {
+		if err := node.Validate(); err != nil {
+			return fmt.Errorf("invalid node %q: %w", node.Name, err)
+		}
+	}
+	for node, power := range t.Validators {
+		if node.StartAt > t.InitialHeight {
+			return fmt.Errorf("genesis validator %q can't start after the initial height", node.Name)
+		}
+		if power <= 0 {
+			return fmt.Errorf("validator %q must have positive power", node.Name)
+		}
+	}
+	return nil
+}
+
+// Validate validates a node.
+func (n Node) Validate() error {
+	if n.Name == "" {
+		return errors.New("node has no name")
+	}
+	switch n.Mode {
+	case ModeValidator, ModeFull:
+	default:
+		return fmt.Errorf("invalid mode %q", n.Mode)
+	}
+	switch n.FastSync {
+	case "", "v0", "v1", "v2":
+	default:
+		return fmt.Errorf("invalid fast sync setting %q", n.FastSync)
+	}
+	if n.StateSync && n.StartAt == 0 {
+		return errors.New("state synced nodes cannot start at the initial height")
+	}
+	for _, p := range n.Perturbations {
+		switch p.Action {
+		case PerturbationKill, PerturbationPause, PerturbationDisconnect, PerturbationRestart:
+		default:
+			return fmt.Errorf("invalid perturbation %q", p.Action)
+		}
+		if p.Height <= n.StartAt {
+			return fmt.Errorf("perturbation %q at height %d before the node starts", p.Action, p.Height)
+		}
+	}
+	if len(n.Misbehaviors) > 0 && n.Mode != ModeValidator {
+		return errors.New("only validators can misbehave")
+	}
+	for height, misbehavior := range n.Misbehaviors {
+		if _, ok := consensus.Misbehaviors[misbehavior]; !ok {
+			return fmt.Errorf("invalid misbehavior %q", misbehavior)
+		}
+		if height <= n.StartAt || height < n.Testnet.InitialHeight {
+			return fmt.Errorf("misbehavior %q at height %d before the node starts", misbehavior, height)
+		}
+	}
+	return nil
+}
+
+// MisbehaviorsFlag returns the node's misbehaviors as taken by the
+// --misbehaviors flag of the maverick binary, ordered by height.
+func (n Node) MisbehaviorsFlag() string {
+	heights := make([]int64, 0, len(n.Misbehaviors))
+	for height := range n.Misbehaviors {
+		heights = append(heights, height)
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	items := make([]string, 0, len(heights))
+	for _, height := range heights {
+		items = append(items, fmt.Sprintf("%d:%s", height, n.Misbehaviors[height]))
+	}
+	return strings.Join(items, ",")
+}
+
+// LookupNode looks up a node by name. For now, simply do a linear search.
+func (t Testnet) LookupNode(name string) *Node {
+	for _, node := range t.Nodes {
+		if node.Name == name {
+			return node
+		}
+	}
+	return nil
+}
+
+// MaxPerturbationHeight returns the height of the last scheduled
+// perturbation, or 0 if there are none.
+func (t Testnet) MaxPerturbationHeight() int64 {
+	var height int64
+	for _, node := range t.Nodes {
+		for _, p := range node.Perturbations {
+			if p.Height > height {
+				height = p.Height
+			}
+		}
+	}
+	return height
+}
+
+// MaxMisbehaviorHeight returns the height of the last scheduled
+// misbehavior, or 0 if there are none.
+func (t Testnet) MaxMisbehaviorHeight() int64 {
+	var height int64
+	for _, node := range t.Nodes {
+		for h := range node.Misbehaviors {
+			if h > height {
+				height = h
+			}
+		}
+	}
+	return height
+}
+
+// ID returns the node's p2p ID.
+func (n Node) ID() p2p.ID {
+	return p2p.PubKeyToID(n.Key.PubKey())
+}
+
+// Dir returns the node's home directory.
+func (n Node) Dir() string {
+	return filepath.Join(n.Testnet.Dir, n.Name)
+}
+
+// AddressP2P returns the node's P2P address, including its ID.
+func (n Node) AddressP2P() string {
+	return p2p.IDAddressString(n.ID(), fmt.Sprintf("127.0.0.1:%d", n.P2PPort))
+}
+
+// AddressRPC returns the node's RPC address.
+func (n Node) AddressRPC() string {
+	return fmt.Sprintf("127.0.0.1:%d", n.RPCPort)
+}
+
+// Client returns an RPC client for the node, with a request timeout of a
+// few seconds so that stopped nodes don't block the caller.
+func (n Node) Client() (*rpchttp.HTTP, error) {
+	return rpchttp.NewWithTimeout(fmt.Sprintf("http://%s", n.AddressRPC()), "/websocket", 5)
+}
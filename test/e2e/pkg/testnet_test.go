@@ -0,0 +1,99 @@
+package e2e
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTestnet(t *testing.T) {
+	testnet, err := LoadTestnet("../networks/ci.toml")
+	require.NoError(t, err)
+
+	assert.Equal(t, "ci", testnet.Name)
+	assert.EqualValues(t, 1000, testnet.InitialHeight)
+	assert.Equal(t, 2, testnet.Evidence)
+	require.Len(t, testnet.Nodes, 6)
+
+	// nodes are sorted by name, and get ports in that order
+	full := testnet.Nodes[0]
+	assert.Equal(t, "full01", full.Name)
+	assert.Equal(t, ModeFull, full.Mode)
+	assert.EqualValues(t, 1005, full.StartAt)
+	assert.Equal(t, 30000, full.P2PPort)
+	assert.Equal(t, 30001, full.RPCPort)
+	assert.False(t, full.StateSync)
+
+	// full02 state syncs from the snapshots of the persistent kvstore
+	synced := testnet.Nodes[1]
+	assert.Equal(t, "full02", synced.Name)
+	assert.True(t, synced.StateSync)
+	assert.EqualValues(t, 1030, synced.StartAt)
+	assert.Equal(t, 30010, synced.P2PPort)
+
+	val2 := testnet.LookupNode("validator02")
+	require.NotNil(t, val2)
+	assert.Equal(t, "v1", val2.FastSync)
+	assert.Equal(t, []NodePerturbation{{Action: PerturbationRestart, Height: 1010}}, val2.Perturbations)
+	assert.EqualValues(t, 50, testnet.Validators[val2])
+	val4 := testnet.LookupNode("validator04")
+	require.NotNil(t, val4)
+	assert.Equal(t, "", val4.FastSync)
+	assert.Equal(t, map[int64]string{1012: "double-prevote", 1014: "double-precommit"}, val4.Misbehaviors)
+	assert.Equal(t, "1012:double-prevote,1014:double-precommit", val4.MisbehaviorsFlag())
+	assert.EqualValues(t, 1014, testnet.MaxMisbehaviorHeight())
+	assert.EqualValues(t, 1025, testnet.MaxPerturbationHeight())
+
+	// keys are derived from the node names
+	again, err := LoadTestnet("../networks/ci.toml")
+	require.NoError(t, err)
+	assert.Equal(t, val2.ID(), again.LookupNode("validator02").ID())
+}
+
+func TestNewTestnetInvalid(t *testing.T) {
+	testcases := map[string]Manifest{
+		"no nodes": {},
+		"unknown validator": {
+			Validators: map[string]int64{"foo": 1},
+			Nodes:      map[string]*ManifestNode{"bar": {}},
+		},
+		"no validators": {
+			Nodes: map[string]*ManifestNode{"full": {Mode: "full"}},
+		},
+		"invalid mode": {
+			Nodes: map[string]*ManifestNode{"node": {Mode: "seed"}},
+		},
+		"state sync at genesis": {
+			Nodes: map[string]*ManifestNode{"node": {}, "full": {Mode: "full", StateSync: true}},
+		},
+		"invalid perturbation": {
+			Nodes: map[string]*ManifestNode{"node": {Perturb: []ManifestPerturbation{{Action: "explode", Height: 3}}}},
+		},
+		"perturbation before start": {
+			Nodes: map[string]*ManifestNode{
+				"node": {},
+				"full": {Mode: "full", StartAt: 5, Perturb: []ManifestPerturbation{{Action: "kill", Height: 3}}},
+			},
+		},
+		"invalid misbehavior": {
+			Nodes: map[string]*ManifestNode{"node": {Misbehaviors: map[string]string{"3": "explode"}}},
+		},
+		"invalid misbehavior height": {
+			Nodes: map[string]*ManifestNode{"node": {Misbehaviors: map[string]string{"three": "amnesia"}}},
+		},
+		"misbehaving full node": {
+			Nodes: map[string]*ManifestNode{
+				"node": {},
+				"full": {Mode: "full", Misbehaviors: map[string]string{"3": "amnesia"}},
+			},
+		},
+	}
+	for name, manifest := range testcases {
+		manifest := manifest
+		t.Run(name, func(t *testing.T) {
+			_, err := NewTestnet(manifest, "networks/test.toml")
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tendermint/tendermint/crypto/tmhash"
+	tmrand "github.com/tendermint/tendermint/libs/rand"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	e2e "github.com/tendermint/tendermint/test/e2e/pkg"
+	"github.com/tendermint/tendermint/types"
+)
+
+// InjectEvidence creates duplicate vote evidence for the genesis validators
+// at recent heights and broadcasts it through a running node. The validator
+// keys are known to the runner, so the evidence is valid.
+func InjectEvidence(testnet *e2e.Testnet, network *Network, amount int) error {
+	nodes := network.Running()
+	if len(nodes) == 0 {
+		return errors.New("no running nodes to inject evidence through")
+	}
+	node := nodes[0]
+	client, err := node.Client()
+	if err != nil {
+		return err
+	}
+	height, err := networkHeight([]*e2e.Node{node})
+	if err != nil {
+		return err
+	}
+	if height-int64(amount) < testnet.InitialHeight {
+		return fmt.Errorf("network height %d too low to inject %d pieces of evidence", height, amount)
+	}
+
+	validators := []*e2e.Node{}
+	for _, n := range testnet.Nodes {
+		if _, ok := testnet.Validators[n]; ok {
+			validators = append(validators, n)
+		}
+	}
+
+	for i := 0; i < amount; i++ {
+		// use a different height for each piece of evidence
+		evHeight := height - 1 - int64(i)
+		block, err := client.Block(&evHeight)
+		if err != nil {
+			return err
+		}
+		valSet, err := client.Validators(&evHeight, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		validator := validators[i%len(validators)]
+		addr := validator.PrivvalKey.PubKey().Address()
+		index := int32(-1)
+		for j, v := range valSet.Validators {
+			if bytes.Equal(v.Address, addr) {
+				index = int32(j)
+			}
+		}
+		if index < 0 {
+			return fmt.Errorf("validator %q not in the validator set at height %d", validator.Name, evHeight)
+		}
+
+		pv := types.NewMockPVWithParams(validator.PrivvalKey, false, false)
+		voteA, err := signedVote(pv, testnet.Name, evHeight, index, block.Block.Time)
+		if err != nil {
+			return err
+		}
+		voteB, err := signedVote(pv, testnet.Name, evHeight, index, block.Block.Time)
+		if err != nil {
+			return err
+		}
+		ev := types.NewDuplicateVoteEvidence(voteA, voteB, block.Block.Time)
+		if _, err := client.BroadcastEvidence(ev); err != nil {
+			return fmt.Errorf("failed to broadcast evidence: %w", err)
+		}
+		logger.Info(fmt.Sprintf("Injected duplicate vote evidence for %v at height %v", validator.Name, evHeight))
+	}
+	return nil
+}
+
+// signedVote signs a precommit for a random block.
+func signedVote(pv types.PrivValidator, chainID string, height int64, index int32,
+	time time.Time) (*types.Vote, error) {
+	pubKey, err := pv.GetPubKey()
+	if err != nil {
+		return nil, err
+	}
+	vote := &types.Vote{
+		Type:   tmproto.PrecommitType,
+		Height: height,
+		BlockID: types.BlockID{
+			Hash:          tmrand.Bytes(tmhash.Size),
+			PartSetHeader: types.PartSetHeader{Total: 1, Hash: tmrand.Bytes(tmhash.Size)},
+		},
+		Timestamp:        time,
+		ValidatorAddress: pubKey.Address(),
+		ValidatorIndex:   index,
+	}
+	pbVote := vote.ToProto()
+	if err := pv.SignVote(chainID, pbVote); err != nil {
+		return nil, err
+	}
+	vote.Signature = pbVote.Signature
+	return vote, nil
+}
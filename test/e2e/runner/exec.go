@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	e2e "github.com/tendermint/tendermint/test/e2e/pkg"
+)
+
+// Process is a running node process.
+type Process struct {
+	node *e2e.Node
+	cmd  *exec.Cmd
+	log  *os.File
+	done chan struct{}
+	err  error
+}
+
+// Network keeps track of the node processes of a testnet.
+type Network struct {
+	binary   string
+	maverick string
+
+	mtx       sync.Mutex
+	processes map[*e2e.Node]*Process
+}
+
+// NewNetwork creates a network which runs nodes with the given binary, and
+// misbehaving nodes with the given maverick binary.
+func NewNetwork(binary, maverick string) *Network {
+	return &Network{
+		binary:    binary,
+		maverick:  maverick,
+		processes: map[*e2e.Node]*Process{},
+	}
+}
+
+// Start starts a node process, appending its output to tendermint.log in
+// the node's directory.
+func (n *Network) Start(node *e2e.Node) error {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	if p, ok := n.processes[node]; ok && p.Running() {
+		return fmt.Errorf("node %q is already running", node.Name)
+	}
+
+	log, err := os.OpenFile(filepath.Join(node.Dir(), "tendermint.log"),
+		os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(n.binary, "node", "--home", node.Dir())
+	if len(node.Misbehaviors) > 0 {
+		cmd = exec.Command(n.maverick, "node", "--home", node.Dir(), "--misbehaviors", node.MisbehaviorsFlag())
+	}
+	cmd.Stdout = log
+	cmd.Stderr = log
+	if err := cmd.Start(); err != nil {
+		log.Close()
+		return fmt.Errorf("failed to start node %q: %w", node.Name, err)
+	}
+
+	p := &Process{node: node, cmd: cmd, log: log, done: make(chan struct{})}
+	go func() {
+		p.err = cmd.Wait()
+		log.Close()
+		close(p.done)
+	}()
+	n.processes[node] = p
+	return nil
+}
+
+// Running returns true if the process has not exited.
+func (p *Process) Running() bool {
+	select {
+	case <-p.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Signal sends a signal to a running node.
+func (n *Network) Signal(node *e2e.Node, sig syscall.Signal) error {
+	p, err := n.process(node)
+	if err != nil {
+		return err
+	}
+	return p.cmd.Process.Signal(sig)
+}
+
+// Stop sends the signal to the node and waits for it to exit, killing it
+// if it takes longer than the timeout.
+func (n *Network) Stop(node *e2e.Node, sig syscall.Signal, timeout time.Duration) error {
+	p, err := n.process(node)
+	if err != nil {
+		return err
+	}
+	if err := p.cmd.Process.Signal(sig); err != nil {
+		return err
+	}
+	select {
+	case <-p.done:
+		return nil
+	case <-time.After(timeout):
+		logger.Error(fmt.Sprintf("Node %q did not stop in %v, killing it", node.Name, timeout))
+		_ = p.cmd.Process.Kill()
+		<-p.done
+		return nil
+	}
+}
+
+// StopAll stops every running node.
+func (n *Network) StopAll() {
+	n.mtx.Lock()
+	nodes := make([]*e2e.Node, 0, len(n.processes))
+	for node, p := range n.processes {
+		if p.Running() {
+			nodes = append(nodes, node)
+		}
+	}
+	n.mtx.Unlock()
+
+	for _, node := range nodes {
+		// resume paused nodes, they would never handle SIGTERM otherwise
+		_ = n.Signal(node, syscall.SIGCONT)
+		if err := n.Stop(node, syscall.SIGTERM, 10*time.Second); err != nil {
+			logger.Error(fmt.Sprintf("Failed to stop node %q: %v", node.Name, err))
+		}
+	}
+}
+
+// Running returns the nodes that are currently running.
+func (n *Network) Running() []*e2e.Node {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	nodes := []*e2e.Node{}
+	for node, p := range n.processes {
+		if p.Running() {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+func (n *Network) process(node *e2e.Node) (*Process, error) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	p, ok := n.processes[node]
+	if !ok || !p.Running() {
+		return nil, fmt.Errorf("node %q is not running", node.Name)
+	}
+	return p, nil
+}
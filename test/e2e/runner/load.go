@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tmrand "github.com/tendermint/tendermint/libs/rand"
+	"github.com/tendermint/tendermint/types"
+)
+
+// Load generates transactions against the running nodes, round-robin, at
+// the given rate until the context is cancelled. It returns the number of
+// transactions accepted by a node.
+func Load(ctx context.Context, network *Network, rate int) int {
+	if rate <= 0 {
+		return 0
+	}
+	ticker := time.NewTicker(time.Second / time.Duration(rate))
+	defer ticker.Stop()
+
+	sent, failed := 0, 0
+	for i := 0; ; i++ {
+		select {
+		case <-ctx.Done():
+			logger.Info(fmt.Sprintf("Ending transaction load after %v txs (%v failed)", sent, failed))
+			return sent
+		case <-ticker.C:
+		}
+
+		nodes := network.Running()
+		if len(nodes) == 0 {
+			continue
+		}
+		client, err := nodes[i%len(nodes)].Client()
+		if err != nil {
+			failed++
+			continue
+		}
+		_, err = client.BroadcastTxAsync(loadTx())
+		if err != nil {
+			failed++
+			continue
+		}
+		sent++
+	}
+}
+
+// loadTx returns a random kvstore key=value transaction.
+func loadTx() types.Tx {
+	return types.Tx(fmt.Sprintf("load-%X=%X", tmrand.Bytes(8), tmrand.Bytes(16)))
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tendermint/tendermint/libs/log"
+	e2e "github.com/tendermint/tendermint/test/e2e/pkg"
+)
+
+var logger = log.NewTMLogger(log.NewSyncWriter(os.Stdout))
+
+func main() {
+	NewCLI().Run()
+}
+
+// CLI is the Cobra-based command-line interface.
+type CLI struct {
+	root     *cobra.Command
+	testnet  *e2e.Testnet
+	binary   string
+	maverick string
+	rate     int
+}
+
+// NewCLI sets up the CLI.
+func NewCLI() *CLI {
+	cli := &CLI{}
+	cli.root = &cobra.Command{
+		Use:           "runner",
+		Short:         "End-to-end test runner",
+		SilenceUsage:  true,
+		SilenceErrors: true, // we'll output them ourselves in Run()
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			file, err := cmd.Flags().GetString("file")
+			if err != nil {
+				return err
+			}
+			testnet, err := e2e.LoadTestnet(file)
+			if err != nil {
+				return err
+			}
+			cli.testnet = testnet
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := Cleanup(cli.testnet); err != nil {
+				return err
+			}
+			if err := Setup(cli.testnet); err != nil {
+				return err
+			}
+			return Run(cli.testnet, NewNetwork(cli.binary, cli.maverick), cli.rate)
+		},
+	}
+
+	cli.root.PersistentFlags().StringP("file", "f", "", "Testnet TOML manifest")
+	_ = cli.root.MarkPersistentFlagRequired("file")
+	cli.root.Flags().StringVar(&cli.binary, "binary", "tendermint", "Tendermint binary to run the nodes with")
+	cli.root.Flags().StringVar(&cli.maverick, "maverick-binary", "maverick",
+		"Maverick binary to run the misbehaving nodes with")
+	cli.root.Flags().IntVar(&cli.rate, "load-rate", 10, "Transactions per second to send to the network")
+
+	cli.root.AddCommand(&cobra.Command{
+		Use:   "setup",
+		Short: "Generates the testnet directory and configuration",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return Setup(cli.testnet)
+		},
+	})
+
+	cli.root.AddCommand(&cobra.Command{
+		Use:   "cleanup",
+		Short: "Removes the testnet directory",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return Cleanup(cli.testnet)
+		},
+	})
+
+	return cli
+}
+
+// Run runs the CLI.
+func (cli *CLI) Run() {
+	if err := cli.root.Execute(); err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+}
+
+// Cleanup removes the testnet directory.
+func Cleanup(testnet *e2e.Testnet) error {
+	logger.Info(fmt.Sprintf("Removing testnet directory %q", testnet.Dir))
+	return os.RemoveAll(testnet.Dir)
+}
+
+// event is a node start or perturbation scheduled at a height.
+type event struct {
+	height       int64
+	node         *e2e.Node
+	perturbation e2e.Perturbation // empty for node starts
+}
+
+// Run starts the testnet, applies the scheduled node starts and
+// perturbations under transaction load, injects evidence and finally checks
+// the invariants. All nodes are stopped when it returns.
+func Run(testnet *e2e.Testnet, network *Network, rate int) error {
+	defer network.StopAll()
+
+	logger.Info("Starting initial network nodes...")
+	events := []event{}
+	for _, node := range testnet.Nodes {
+		if node.StartAt == 0 {
+			if err := network.Start(node); err != nil {
+				return err
+			}
+		} else {
+			events = append(events, event{height: node.StartAt, node: node})
+		}
+		for _, p := range node.Perturbations {
+			events = append(events, event{height: p.Height, node: node, perturbation: p.Action})
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].height < events[j].height })
+
+	if _, err := waitForHeight(network.Running(), testnet.InitialHeight, time.Minute); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sent := 0
+	loadDone := make(chan struct{})
+	go func() {
+		sent = Load(ctx, network, rate)
+		close(loadDone)
+	}()
+	stopLoad := func() {
+		cancel()
+		<-loadDone
+	}
+	defer stopLoad()
+
+	for _, ev := range events {
+		block, err := waitForHeight(network.Running(), ev.height, time.Minute)
+		if err != nil {
+			return err
+		}
+		if ev.perturbation != "" {
+			if err := Perturb(network, ev.node, ev.perturbation); err != nil {
+				return err
+			}
+			continue
+		}
+
+		logger.Info(fmt.Sprintf("Starting node %v at height %v...", ev.node.Name, ev.height))
+		if ev.node.StateSync {
+			if err := ConfigureStateSync(ev.node, block.Height, block.Hash()); err != nil {
+				return err
+			}
+		}
+		if err := network.Start(ev.node); err != nil {
+			return err
+		}
+		status, err := waitForNode(ev.node, ev.height, 2*time.Minute)
+		if err != nil {
+			return err
+		}
+		logger.Info(fmt.Sprintf("Node %v caught up at height %v", ev.node.Name, status.SyncInfo.LatestBlockHeight))
+	}
+
+	if testnet.Evidence > 0 {
+		if _, err := waitForHeight(network.Running(), testnet.InitialHeight+int64(testnet.Evidence)+1,
+			time.Minute); err != nil {
+			return err
+		}
+		if err := InjectEvidence(testnet, network, testnet.Evidence); err != nil {
+			return err
+		}
+	}
+
+	// let the network process the load and evidence for a few more blocks,
+	// after the last misbehavior
+	height, err := networkHeight(network.Running())
+	if err != nil {
+		return err
+	}
+	if h := testnet.MaxMisbehaviorHeight(); h > height {
+		height = h
+	}
+	if _, err := waitForHeight(network.Running(), height+5, time.Minute); err != nil {
+		return err
+	}
+	stopLoad()
+	if sent == 0 && rate > 0 {
+		return errors.New("no transactions were accepted by the network")
+	}
+
+	if err := Test(testnet, network); err != nil {
+		return err
+	}
+	logger.Info("All invariants hold")
+	return nil
+}
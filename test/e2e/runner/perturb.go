@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+
+	e2e "github.com/tendermint/tendermint/test/e2e/pkg"
+)
+
+const (
+	// pauseDuration is how long a paused node stays paused.
+	pauseDuration = 10 * time.Second
+
+	// disconnectDuration must exceed the p2p pong timeout so that peers
+	// drop their connections to the node.
+	disconnectDuration = 50 * time.Second
+)
+
+// Perturb applies a perturbation to a node and waits for it to catch up
+// with the network again.
+func Perturb(network *Network, node *e2e.Node, perturbation e2e.Perturbation) error {
+	switch perturbation {
+	case e2e.PerturbationKill:
+		logger.Info(fmt.Sprintf("Killing node %v...", node.Name))
+		if err := network.Stop(node, syscall.SIGKILL, 10*time.Second); err != nil {
+			return err
+		}
+		if err := network.Start(node); err != nil {
+			return err
+		}
+
+	case e2e.PerturbationPause:
+		logger.Info(fmt.Sprintf("Pausing node %v...", node.Name))
+		if err := pause(network, node, pauseDuration); err != nil {
+			return err
+		}
+
+	case e2e.PerturbationDisconnect:
+		// Without network namespaces we can't cut the node's connections, so
+		// we stop the process long enough for its peers to give up on it.
+		logger.Info(fmt.Sprintf("Disconnecting node %v...", node.Name))
+		if err := pause(network, node, disconnectDuration); err != nil {
+			return err
+		}
+
+	case e2e.PerturbationRestart:
+		logger.Info(fmt.Sprintf("Restarting node %v...", node.Name))
+		if err := network.Stop(node, syscall.SIGTERM, 10*time.Second); err != nil {
+			return err
+		}
+		if err := network.Start(node); err != nil {
+			return err
+		}
+
+	default:
+		return fmt.Errorf("unexpected perturbation %q", perturbation)
+	}
+
+	height, err := networkHeight(network.Running())
+	if err != nil {
+		return err
+	}
+	status, err := waitForNode(node, height, time.Minute)
+	if err != nil {
+		return err
+	}
+	logger.Info(fmt.Sprintf("Node %v recovered at height %v", node.Name, status.SyncInfo.LatestBlockHeight))
+	return nil
+}
+
+func pause(network *Network, node *e2e.Node, d time.Duration) error {
+	if err := network.Signal(node, syscall.SIGSTOP); err != nil {
+		return err
+	}
+	time.Sleep(d)
+	return network.Signal(node, syscall.SIGCONT)
+}
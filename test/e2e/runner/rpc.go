@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	rpctypes "github.com/tendermint/tendermint/rpc/core/types"
+	e2e "github.com/tendermint/tendermint/test/e2e/pkg"
+	"github.com/tendermint/tendermint/types"
+)
+
+// waitForHeight waits for any of the given nodes to reach the height,
+// returning the block at that height. It fails if no progress is made within
+// the timeout.
+func waitForHeight(nodes []*e2e.Node, height int64, timeout time.Duration) (*types.Block, error) {
+	var (
+		maxHeight    int64
+		lastProgress = time.Now()
+	)
+	for {
+		for _, node := range nodes {
+			client, err := node.Client()
+			if err != nil {
+				continue
+			}
+			status, err := client.Status()
+			if err != nil {
+				continue
+			}
+			if status.SyncInfo.LatestBlockHeight > maxHeight {
+				maxHeight = status.SyncInfo.LatestBlockHeight
+				lastProgress = time.Now()
+			}
+			if status.SyncInfo.LatestBlockHeight >= height {
+				result, err := fetchBlock(node, height)
+				if err == nil {
+					return result.Block, nil
+				}
+			}
+		}
+
+		if time.Since(lastProgress) > timeout {
+			return nil, fmt.Errorf("network stalled at height %d while waiting for %d", maxHeight, height)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// waitForNode waits for a node to reach the height and finish catching up.
+func waitForNode(node *e2e.Node, height int64, timeout time.Duration) (*rpctypes.ResultStatus, error) {
+	client, err := node.Client()
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		status, err := client.Status()
+		if err == nil && !status.SyncInfo.CatchingUp && status.SyncInfo.LatestBlockHeight >= height {
+			return status, nil
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	return nil, fmt.Errorf("timed out waiting for node %q to reach height %d", node.Name, height)
+}
+
+// networkHeight returns the highest height reported by the given nodes.
+func networkHeight(nodes []*e2e.Node) (int64, error) {
+	var height int64
+	for _, node := range nodes {
+		client, err := node.Client()
+		if err != nil {
+			continue
+		}
+		status, err := client.Status()
+		if err == nil && status.SyncInfo.LatestBlockHeight > height {
+			height = status.SyncInfo.LatestBlockHeight
+		}
+	}
+	if height == 0 {
+		return 0, errors.New("no node reported a height")
+	}
+	return height, nil
+}
+
+func fetchBlock(node *e2e.Node, height int64) (*rpctypes.ResultBlock, error) {
+	client, err := node.Client()
+	if err != nil {
+		return nil, err
+	}
+	return client.Block(&height)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tendermint/tendermint/config"
+	"github.com/tendermint/tendermint/p2p"
+	"github.com/tendermint/tendermint/privval"
+	e2e "github.com/tendermint/tendermint/test/e2e/pkg"
+	"github.com/tendermint/tendermint/types"
+)
+
+const (
+	// AppProxy is the built-in application run by every node.
+	AppProxy = "persistent_kvstore"
+)
+
+// Setup sets up the testnet configuration in its directory.
+func Setup(testnet *e2e.Testnet) error {
+	logger.Info(fmt.Sprintf("Generating testnet files in %q", testnet.Dir))
+
+	if err := os.MkdirAll(testnet.Dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	genesis, err := MakeGenesis(testnet)
+	if err != nil {
+		return err
+	}
+
+	for _, node := range testnet.Nodes {
+		nodeDir := node.Dir()
+		dirs := []string{
+			filepath.Join(nodeDir, "config"),
+			filepath.Join(nodeDir, "data"),
+		}
+		for _, dir := range dirs {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+		}
+
+		cfg := MakeConfig(node)
+		config.WriteConfigFile(filepath.Join(nodeDir, "config", "config.toml"), cfg)
+
+		if err := genesis.SaveAs(cfg.GenesisFile()); err != nil {
+			return err
+		}
+		if err := (&p2p.NodeKey{PrivKey: node.Key}).SaveAs(cfg.NodeKeyFile()); err != nil {
+			return err
+		}
+		privval.NewFilePV(node.PrivvalKey, cfg.PrivValidatorKeyFile(), cfg.PrivValidatorStateFile()).Save()
+	}
+
+	return nil
+}
+
+// MakeGenesis generates a genesis document.
+func MakeGenesis(testnet *e2e.Testnet) (types.GenesisDoc, error) {
+	genesis := types.GenesisDoc{
+		GenesisTime:     time.Now(),
+		ChainID:         testnet.Name,
+		ConsensusParams: types.DefaultConsensusParams(),
+		InitialHeight:   testnet.InitialHeight,
+	}
+	for validator, power := range testnet.Validators {
+		genesis.Validators = append(genesis.Validators, types.GenesisValidator{
+			Name:    validator.Name,
+			Address: validator.PrivvalKey.PubKey().Address(),
+			PubKey:  validator.PrivvalKey.PubKey(),
+			Power:   power,
+		})
+	}
+	// The validator set will be sorted internally by Tendermint ranked by power,
+	// but we sort it here as well so that all genesis files are identical.
+	sort.Slice(genesis.Validators, func(i, j int) bool {
+		return strings.Compare(genesis.Validators[i].Name, genesis.Validators[j].Name) == -1
+	})
+	return genesis, genesis.ValidateAndComplete()
+}
+
+// MakeConfig generates a Tendermint config for a node.
+func MakeConfig(node *e2e.Node) *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.SetRoot(node.Dir())
+	cfg.Moniker = node.Name
+	cfg.ProxyApp = AppProxy
+	cfg.RPC.ListenAddress = fmt.Sprintf("tcp://%s", node.AddressRPC())
+	cfg.P2P.ListenAddress = fmt.Sprintf("tcp://127.0.0.1:%d", node.P2PPort)
+	cfg.P2P.AddrBookStrict = false
+	cfg.P2P.AllowDuplicateIP = true
+	cfg.Instrumentation.Prometheus = true
+	cfg.Instrumentation.PrometheusListenAddr = fmt.Sprintf("127.0.0.1:%d", node.PrometheusPort)
+	cfg.Consensus.TimeoutCommit = 500 * time.Millisecond
+
+	cfg.FastSyncMode = node.FastSync != ""
+	if node.FastSync != "" {
+		cfg.FastSync.Version = node.FastSync
+	}
+
+	// Nodes only peer with the nodes started before them, since the others
+	// aren't listening yet.
+	peers := []string{}
+	for _, peer := range node.Testnet.Nodes {
+		if peer.Name == node.Name || peer.StartAt > node.StartAt {
+			continue
+		}
+		peers = append(peers, peer.AddressP2P())
+	}
+	cfg.P2P.PersistentPeers = strings.Join(peers, ",")
+
+	return cfg
+}
+
+// ConfigureStateSync enables state sync for a node, trusting the block at
+// the given height as reported by the other running nodes.
+func ConfigureStateSync(node *e2e.Node, trustHeight int64, trustHash []byte) error {
+	cfg := MakeConfig(node)
+	servers := []string{}
+	for _, peer := range node.Testnet.Nodes {
+		if peer.Name != node.Name && peer.StartAt <= trustHeight {
+			servers = append(servers, peer.AddressRPC())
+		}
+	}
+	if len(servers) < 2 {
+		return errors.New("state sync needs at least two running nodes to use as RPC servers")
+	}
+	cfg.StateSync.Enable = true
+	cfg.StateSync.RPCServers = servers[:2]
+	cfg.StateSync.TrustHeight = trustHeight
+	cfg.StateSync.TrustHash = fmt.Sprintf("%X", trustHash)
+	cfg.StateSync.TrustPeriod = time.Hour
+	config.WriteConfigFile(filepath.Join(node.Dir(), "config", "config.toml"), cfg)
+	return nil
+}
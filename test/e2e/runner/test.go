@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/tendermint/tendermint/consensus"
+	e2e "github.com/tendermint/tendermint/test/e2e/pkg"
+)
+
+// Test checks the testnet invariants against the running nodes:
+//
+// - blocks keep being produced
+// - all nodes agree on the block and app hashes at every height they have
+// - all injected evidence has been committed
+// - validators that double signed have been punished
+func Test(testnet *e2e.Testnet, network *Network) error {
+	nodes := network.Running()
+	height, err := networkHeight(nodes)
+	if err != nil {
+		return err
+	}
+	logger.Info(fmt.Sprintf("Checking that blocks are produced after height %v", height))
+	if _, err := waitForHeight(nodes, height+3, 30*time.Second); err != nil {
+		return fmt.Errorf("blocks are no longer produced: %w", err)
+	}
+
+	if err := testAppHashes(nodes); err != nil {
+		return err
+	}
+	return testEvidence(testnet, nodes)
+}
+
+// fullNode returns a running node that has stored the whole chain.
+func fullNode(nodes []*e2e.Node) (*e2e.Node, error) {
+	for _, n := range nodes {
+		if n.StartAt == 0 {
+			return n, nil
+		}
+	}
+	return nil, fmt.Errorf("no node with the full chain to check evidence against")
+}
+
+// testAppHashes compares the block of every height between all nodes. Since
+// the app hash of height h is stored in the header of h+1, comparing headers
+// also compares app hashes.
+func testAppHashes(nodes []*e2e.Node) error {
+	var maxBase, minHeight int64 = 0, -1
+	for _, node := range nodes {
+		client, err := node.Client()
+		if err != nil {
+			return err
+		}
+		status, err := client.Status()
+		if err != nil {
+			return fmt.Errorf("node %q: %w", node.Name, err)
+		}
+		base, height := status.SyncInfo.EarliestBlockHeight, status.SyncInfo.LatestBlockHeight
+		if base > maxBase {
+			maxBase = base
+		}
+		if minHeight < 0 || height < minHeight {
+			minHeight = height
+		}
+	}
+	logger.Info(fmt.Sprintf("Comparing blocks %v-%v across %v nodes", maxBase, minHeight, len(nodes)))
+
+	for h := maxBase; h <= minHeight; h++ {
+		var (
+			expectHash, expectAppHash []byte
+			expectNode                *e2e.Node
+		)
+		for _, node := range nodes {
+			result, err := fetchBlock(node, h)
+			if err != nil {
+				return fmt.Errorf("node %q failed to return block %v: %w", node.Name, h, err)
+			}
+			hash, appHash := result.Block.Hash(), result.Block.AppHash
+			if expectNode == nil {
+				expectHash, expectAppHash, expectNode = hash, appHash, node
+				continue
+			}
+			if !bytes.Equal(hash, expectHash) {
+				return fmt.Errorf("block hash mismatch at height %v: %v has %X, %v has %X",
+					h, expectNode.Name, expectHash, node.Name, hash)
+			}
+			if !bytes.Equal(appHash, expectAppHash) {
+				return fmt.Errorf("app hash mismatch at height %v: %v has %X, %v has %X",
+					h, expectNode.Name, expectAppHash, node.Name, appHash)
+			}
+		}
+	}
+	return nil
+}
+
+// testEvidence checks that the injected evidence and evidence of double
+// signing misbehaviors has been committed, and that the app punished the
+// double signing validators.
+func testEvidence(testnet *e2e.Testnet, nodes []*e2e.Node) error {
+	doubleSigners := map[*e2e.Node]bool{}
+	for _, n := range testnet.Nodes {
+		for _, misbehavior := range n.Misbehaviors {
+			if misbehavior == consensus.MisbehaviorDoublePrevote ||
+				misbehavior == consensus.MisbehaviorDoublePrecommit {
+				doubleSigners[n] = true
+			}
+		}
+	}
+	if testnet.Evidence == 0 && len(doubleSigners) == 0 {
+		return nil
+	}
+	node, err := fullNode(nodes)
+	if err != nil {
+		return err
+	}
+	height, err := networkHeight([]*e2e.Node{node})
+	if err != nil {
+		return err
+	}
+
+	found := 0
+	evidenceAgainst := map[string]bool{}
+	for h := testnet.InitialHeight; h <= height; h++ {
+		result, err := fetchBlock(node, h)
+		if err != nil {
+			return err
+		}
+		for _, ev := range result.Block.Evidence.Evidence {
+			evidenceAgainst[string(ev.Address())] = true
+		}
+		found += len(result.Block.Evidence.Evidence)
+	}
+	if found < testnet.Evidence {
+		return fmt.Errorf("only %v of %v pieces of injected evidence were committed", found, testnet.Evidence)
+	}
+	logger.Info(fmt.Sprintf("Found %v pieces of committed evidence", found))
+
+	client, err := node.Client()
+	if err != nil {
+		return err
+	}
+	validators, err := client.Validators(&height, nil, nil)
+	if err != nil {
+		return err
+	}
+	for doubleSigner := range doubleSigners {
+		addr := doubleSigner.PrivvalKey.PubKey().Address()
+		if !evidenceAgainst[string(addr)] {
+			return fmt.Errorf("no evidence against misbehaving validator %q was committed", doubleSigner.Name)
+		}
+		for _, v := range validators.Validators {
+			if bytes.Equal(v.Address, addr) && v.VotingPower >= testnet.Validators[doubleSigner] {
+				return fmt.Errorf("misbehaving validator %q was not punished, it has power %v",
+					doubleSigner.Name, v.VotingPower)
+			}
+		}
+		logger.Info(fmt.Sprintf("Misbehaving validator %v was punished", doubleSigner.Name))
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+// Maverick is a Tendermint node build for testing, whose validator can be
+// made to misbehave at given heights, e.g.:
+//
+//	maverick node --misbehaviors 10:double-prevote,15:amnesia
+//
+// The misbehaviors can also be set with a top-level "misbehaviors" entry in
+// config.toml. See consensus.Misbehaviors for the available misbehaviors.
+//
+// The validator signs with its file key but without double signing
+// protection. Never run it with the key of a production validator.
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	cmd "github.com/tendermint/tendermint/cmd/tendermint/commands"
+	cfg "github.com/tendermint/tendermint/config"
+	cs "github.com/tendermint/tendermint/consensus"
+	"github.com/tendermint/tendermint/libs/cli"
+	"github.com/tendermint/tendermint/libs/log"
+	nm "github.com/tendermint/tendermint/node"
+	"github.com/tendermint/tendermint/p2p"
+	"github.com/tendermint/tendermint/privval"
+	"github.com/tendermint/tendermint/proxy"
+	"github.com/tendermint/tendermint/types"
+)
+
+const misbehaviorsFlag = "misbehaviors"
+
+func main() {
+	rootCmd := cmd.RootCmd
+	rootCmd.AddCommand(
+		cmd.InitFilesCmd,
+		cmd.ResetAllCmd,
+		cmd.ShowValidatorCmd,
+		cmd.ShowNodeIDCmd,
+		cmd.VersionCmd,
+	)
+
+	nodeCmd := cmd.NewRunNodeCmd(newMaverickNode)
+	nodeCmd.Flags().String(misbehaviorsFlag, "",
+		fmt.Sprintf("Comma-separated height:misbehavior pairs, with misbehaviors %s",
+			strings.Join(cs.MisbehaviorNames(), "|")))
+	rootCmd.AddCommand(nodeCmd)
+
+	cmd := cli.PrepareBaseCmd(rootCmd, "TM", os.ExpandEnv(filepath.Join("$HOME", cfg.DefaultTendermintDir)))
+	if err := cmd.Execute(); err != nil {
+		panic(err)
+	}
+}
+
+// newMaverickNode creates a node like nm.DefaultNewNode, but whose validator
+// misbehaves at the configured heights.
+func newMaverickNode(config *cfg.Config, logger log.Logger) (*nm.Node, error) {
+	misbehaviors, err := cs.ParseMisbehaviors(viper.GetString(misbehaviorsFlag))
+	if err != nil {
+		return nil, err
+	}
+
+	nodeKey, err := p2p.LoadOrGenNodeKey(config.NodeKeyFile())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load or gen node key %s: %w", config.NodeKeyFile(), err)
+	}
+
+	// sign with the file key, but without the double signing protection of
+	// the file signer
+	filePV := privval.LoadOrGenFilePV(config.PrivValidatorKeyFile(), config.PrivValidatorStateFile())
+	pv := types.NewMockPVWithParams(filePV.Key.PrivKey, false, false)
+
+	n, err := nm.NewNode(config,
+		pv,
+		nodeKey,
+		proxy.DefaultClientCreator(config.ProxyApp, config.ABCI, config.DBDir()),
+		nm.DefaultGenesisDocProviderFunc(config),
+		nm.DefaultDBProvider,
+		nm.DefaultMetricsProvider(config.Instrumentation),
+		logger,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(misbehaviors) > 0 {
+		logger.Info("Enabling misbehaviors", "misbehaviors", viper.GetString(misbehaviorsFlag))
+		n.ConsensusReactor().SetMisbehaviors(misbehaviors)
+	}
+	return n, nil
+}
@@ -166,6 +166,10 @@ func (b *EventBus) PublishEventVote(data EventDataVote) error {
 	return b.Publish(EventVote, data)
 }
 
+func (b *EventBus) PublishEventRoundFailed(data EventDataRoundFailed) error {
+	return b.Publish(EventRoundFailed, data)
+}
+
 func (b *EventBus) PublishEventValidBlock(data EventDataRoundState) error {
 	return b.Publish(EventValidBlock, data)
 }
@@ -227,6 +231,10 @@ func (b *EventBus) PublishEventValidatorSetUpdates(data EventDataValidatorSetUpd
 	return b.Publish(EventValidatorSetUpdates, data)
 }
 
+func (b *EventBus) PublishEventValidatorMissedBlocks(data EventDataValidatorMissedBlocks) error {
+	return b.Publish(EventValidatorMissedBlocks, data)
+}
+
 //-----------------------------------------------------------------------------
 type NopEventBus struct{}
 
@@ -263,6 +271,10 @@ func (NopEventBus) PublishEventVote(data EventDataVote) error {
 	return nil
 }
 
+func (NopEventBus) PublishEventRoundFailed(data EventDataRoundFailed) error {
+	return nil
+}
+
 func (NopEventBus) PublishEventTx(data EventDataTx) error {
 	return nil
 }
@@ -306,3 +318,7 @@ func (NopEventBus) PublishEventLock(data EventDataRoundState) error {
 func (NopEventBus) PublishEventValidatorSetUpdates(data EventDataValidatorSetUpdates) error {
 	return nil
 }
+
+func (NopEventBus) PublishEventValidatorMissedBlocks(data EventDataValidatorMissedBlocks) error {
+	return nil
+}
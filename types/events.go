@@ -16,11 +16,12 @@ const (
 	// after a block has been committed.
 	// These are also used by the tx indexer for async indexing.
 	// All of this data can be fetched through the rpc.
-	EventNewBlock            = "NewBlock"
-	EventNewBlockHeader      = "NewBlockHeader"
-	EventNewEvidence         = "NewEvidence"
-	EventTx                  = "Tx"
-	EventValidatorSetUpdates = "ValidatorSetUpdates"
+	EventNewBlock              = "NewBlock"
+	EventNewBlockHeader        = "NewBlockHeader"
+	EventNewEvidence           = "NewEvidence"
+	EventTx                    = "Tx"
+	EventValidatorMissedBlocks = "ValidatorMissedBlocks"
+	EventValidatorSetUpdates   = "ValidatorSetUpdates"
 
 	// Internal consensus events.
 	// These are used for testing the consensus state machine.
@@ -31,6 +32,7 @@ const (
 	EventNewRoundStep     = "NewRoundStep"
 	EventPolka            = "Polka"
 	EventRelock           = "Relock"
+	EventRoundFailed      = "RoundFailed"
 	EventTimeoutPropose   = "TimeoutPropose"
 	EventTimeoutWait      = "TimeoutWait"
 	EventUnlock           = "Unlock"
@@ -56,7 +58,9 @@ func init() {
 	tmjson.RegisterType(EventDataNewRound{}, "tendermint/event/NewRound")
 	tmjson.RegisterType(EventDataCompleteProposal{}, "tendermint/event/CompleteProposal")
 	tmjson.RegisterType(EventDataVote{}, "tendermint/event/Vote")
+	tmjson.RegisterType(EventDataRoundFailed{}, "tendermint/event/RoundFailed")
 	tmjson.RegisterType(EventDataValidatorSetUpdates{}, "tendermint/event/ValidatorSetUpdates")
+	tmjson.RegisterType(EventDataValidatorMissedBlocks{}, "tendermint/event/ValidatorMissedBlocks")
 	tmjson.RegisterType(EventDataString(""), "tendermint/event/ProposalString")
 }
 
@@ -121,12 +125,35 @@ type EventDataVote struct {
 	Vote *Vote
 }
 
+// EventDataRoundFailed is fired when a round of consensus ends without a
+// commit.
+type EventDataRoundFailed struct {
+	Height      int64        `json:"height"`
+	Round       int32        `json:"round"`
+	Reason      string       `json:"reason"` // timeout, nil_precommits or round_skip
+	MissedVotes []MissedVote `json:"missed_votes"`
+}
+
+// MissedVote is a vote of a validator missed in a round.
+type MissedVote struct {
+	ValidatorAddress Address `json:"validator_address"`
+	Type             string  `json:"type"` // prevote or precommit
+}
+
 type EventDataString string
 
 type EventDataValidatorSetUpdates struct {
 	ValidatorUpdates []*Validator `json:"validator_updates"`
 }
 
+// EventDataValidatorMissedBlocks is fired when the local validator missed the
+// configured number of blocks in a row.
+type EventDataValidatorMissedBlocks struct {
+	Address      Address `json:"address"`
+	Height       int64   `json:"height"`        // Height of the last block missed.
+	MissedBlocks int64   `json:"missed_blocks"` // Number of blocks missed in a row.
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // PUBSUB
 ///////////////////////////////////////////////////////////////////////////////
@@ -143,22 +170,24 @@ const (
 )
 
 var (
-	EventQueryCompleteProposal    = QueryForEvent(EventCompleteProposal)
-	EventQueryLock                = QueryForEvent(EventLock)
-	EventQueryNewBlock            = QueryForEvent(EventNewBlock)
-	EventQueryNewBlockHeader      = QueryForEvent(EventNewBlockHeader)
-	EventQueryNewEvidence         = QueryForEvent(EventNewEvidence)
-	EventQueryNewRound            = QueryForEvent(EventNewRound)
-	EventQueryNewRoundStep        = QueryForEvent(EventNewRoundStep)
-	EventQueryPolka               = QueryForEvent(EventPolka)
-	EventQueryRelock              = QueryForEvent(EventRelock)
-	EventQueryTimeoutPropose      = QueryForEvent(EventTimeoutPropose)
-	EventQueryTimeoutWait         = QueryForEvent(EventTimeoutWait)
-	EventQueryTx                  = QueryForEvent(EventTx)
-	EventQueryUnlock              = QueryForEvent(EventUnlock)
-	EventQueryValidatorSetUpdates = QueryForEvent(EventValidatorSetUpdates)
-	EventQueryValidBlock          = QueryForEvent(EventValidBlock)
-	EventQueryVote                = QueryForEvent(EventVote)
+	EventQueryCompleteProposal      = QueryForEvent(EventCompleteProposal)
+	EventQueryLock                  = QueryForEvent(EventLock)
+	EventQueryNewBlock              = QueryForEvent(EventNewBlock)
+	EventQueryNewBlockHeader        = QueryForEvent(EventNewBlockHeader)
+	EventQueryNewEvidence           = QueryForEvent(EventNewEvidence)
+	EventQueryNewRound              = QueryForEvent(EventNewRound)
+	EventQueryNewRoundStep          = QueryForEvent(EventNewRoundStep)
+	EventQueryPolka                 = QueryForEvent(EventPolka)
+	EventQueryRelock                = QueryForEvent(EventRelock)
+	EventQueryRoundFailed           = QueryForEvent(EventRoundFailed)
+	EventQueryTimeoutPropose        = QueryForEvent(EventTimeoutPropose)
+	EventQueryTimeoutWait           = QueryForEvent(EventTimeoutWait)
+	EventQueryTx                    = QueryForEvent(EventTx)
+	EventQueryUnlock                = QueryForEvent(EventUnlock)
+	EventQueryValidatorMissedBlocks = QueryForEvent(EventValidatorMissedBlocks)
+	EventQueryValidatorSetUpdates   = QueryForEvent(EventValidatorSetUpdates)
+	EventQueryValidBlock            = QueryForEvent(EventValidBlock)
+	EventQueryVote                  = QueryForEvent(EventVote)
 )
 
 func EventQueryTxFor(tx Tx) tmpubsub.Query {
@@ -176,6 +205,7 @@ type BlockEventPublisher interface {
 	PublishEventNewEvidence(evidence EventDataNewEvidence) error
 	PublishEventTx(EventDataTx) error
 	PublishEventValidatorSetUpdates(EventDataValidatorSetUpdates) error
+	PublishEventValidatorMissedBlocks(EventDataValidatorMissedBlocks) error
 }
 
 type TxEventPublisher interface {